@@ -10,6 +10,7 @@
 package blob
 
 import (
+	"bytes"
 	"log"
 	"net/http"
 	"runtime"
@@ -99,6 +100,12 @@ func (b *Blob) Get(bucket, key string) ([]byte, error) {
 	return content, nil
 }
 
+// Put uploads content to the bucket under key
+func (b *Blob) Put(bucket, key string, content []byte) error {
+	_, err := b.Uploader.Upload(bytes.NewReader(content), bucket, key)
+	return err
+}
+
 func (b *Blob) GC() {
 	pool := worker.New[struct{}](worker.Config{
 		Concurrency:   runtime.NumCPU(),
@@ -34,7 +34,7 @@ func Unarchive(filename string, filebytes []byte) (files []ArchiveResult, err er
 		if err != nil {
 			return nil, fmt.Errorf("unable to unarchive tarfile; file=%s, err=%s", filename, err.Error())
 		}
-	case ".gz", ".gzip":
+	case ".gz", ".gzip", ".tgz":
 		files, err = Untar(filebytes, true)
 		if err != nil {
 			return nil, fmt.Errorf("unable to unarchive tarfile; file=%s, err=%s", filename, err.Error())
@@ -50,3 +50,12 @@ func Unarchive(filename string, filebytes []byte) (files []ArchiveResult, err er
 
 	return
 }
+
+// IsArchive reports whether the file extension is a supported archive format
+func IsArchive(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".tar", ".gz", ".gzip", ".tgz", ".zip":
+		return true
+	}
+	return false
+}
@@ -32,10 +32,11 @@ func (fw FakeWriterAt) WriteAt(p []byte, offset int64) (n int, err error) {
 }
 
 type ObjectInput struct {
-	Bucket *string
-	Key    *string
+	Bucket   *string
+	Key      *string
+	Filename string // name of the downloaded file within the archive; defaults to the base of Key
 
-	RawBytes map[string][]byte // filename->file bytes
+	RawBytes map[string][]byte // filename->file bytes; filenames may include directories
 }
 
 type ObjectOutput struct {
@@ -106,7 +107,7 @@ func (z *ZipWriter) ZipS3Files(in <-chan *ObjectInput, out *ObjectOutput) error
 					for filename, fileBytes := range file.RawBytes {
 						log.Printf("reading file: %s", filename)
 
-						w, err := zipWriter.Create(path.Base(filename))
+						w, err := zipWriter.Create(filename)
 						if err != nil {
 							return err
 						}
@@ -122,7 +123,11 @@ func (z *ZipWriter) ZipS3Files(in <-chan *ObjectInput, out *ObjectOutput) error
 					log.Printf("downloading file: %s", *file.Key)
 
 					// Sequentially download each file to writer from zip.Writer
-					w, err := zipWriter.Create(path.Base(*file.Key))
+					filename := path.Base(*file.Key)
+					if file.Filename != "" {
+						filename = file.Filename
+					}
+					w, err := zipWriter.Create(filename)
 					if err != nil {
 						return err
 					}
@@ -0,0 +1,442 @@
+/*
+ * File: augment.go
+ * Project: image
+ * File Created: Tuesday, 20th February 2024 10:14:38 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 10:14:38 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"net/http"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	ResizeStretch = "stretch" // scale to the size, ignoring the aspect ratio.
+	ResizeFit     = "fit"     // scale to fit within the size, keeping the aspect ratio.
+	ResizeFill    = "fill"    // scale to cover the size, keeping the aspect ratio, and crop the center.
+
+	MaxBlurSigma       = 3.0  // gaussian blur sigma at full intensity.
+	MaxNoiseStdDev     = 64.0 // standard deviation of the pixel noise at full intensity.
+	MinJPEGQuality     = 10   // lowest quality of the jpeg compression augmentation.
+	MaxJPEGQuality     = 90   // highest quality of the jpeg compression augmentation.
+	MinRandomCropScale = 0.5  // smallest side of a random crop relative to the image.
+	MinErasingArea     = 0.02 // smallest erased rectangle relative to the image.
+	MaxErasingArea     = 0.2  // largest erased rectangle relative to the image.
+)
+
+// Transform is an image transformation. Bounding boxes are moved along with the pixels; boxes left outside the
+// image are dropped. Random transformations draw their parameters from r.
+type Transform interface {
+	Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox)
+}
+
+// Geometric is implemented by deterministic transforms that move pixels. Transforms not implementing it keep
+// pixels in place or are random.
+type Geometric interface {
+	// Mapping returns where the points of a width x height image are moved to
+	Mapping(width, height int) Affine
+}
+
+// Affine maps a point (x, y) of an image to (x*ScaleX + OffsetX, y*ScaleY + OffsetY)
+type Affine struct {
+	ScaleX  float64
+	ScaleY  float64
+	OffsetX float64
+	OffsetY float64
+}
+
+// Identity leaves points in place
+var Identity = Affine{ScaleX: 1, ScaleY: 1}
+
+// Then returns the mapping of a followed by b
+func (a Affine) Then(b Affine) Affine {
+	return Affine{
+		ScaleX:  a.ScaleX * b.ScaleX,
+		ScaleY:  a.ScaleY * b.ScaleY,
+		OffsetX: a.OffsetX*b.ScaleX + b.OffsetX,
+		OffsetY: a.OffsetY*b.ScaleY + b.OffsetY,
+	}
+}
+
+// Invert maps a point moved by a back to where it came from
+func (a Affine) Invert(x, y float64) (float64, float64) {
+	return (x - a.OffsetX) / a.ScaleX, (y - a.OffsetY) / a.ScaleY
+}
+
+// TransformImage applies the transforms to the image in order and encodes the result in the format of the image.
+// It returns the encoded image, its width and height, and the moved bounding boxes.
+func TransformImage(imgBytes []byte, boxes []BoundingBox, transforms []Transform, r *rand.Rand) ([]byte, int, int, []BoundingBox, error) {
+	orig, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+
+	img := imaging.Clone(orig)
+	for _, transform := range transforms {
+		img, boxes = transform.Apply(img, boxes, r)
+	}
+
+	encodedImg, err := encodeLike(img, imgBytes)
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	return encodedImg, img.Bounds().Dx(), img.Bounds().Dy(), boxes, nil
+}
+
+// PreprocessImage applies deterministic transforms to the image in order and encodes the result in the format of
+// the image. It returns the encoded image along with the mapping of coordinates relative to the size of the image
+// to coordinates relative to the size of the result, so positions found on the result can be mapped back.
+func PreprocessImage(imgBytes []byte, transforms []Transform) ([]byte, Affine, error) {
+	orig, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return nil, Affine{}, err
+	}
+
+	img := imaging.Clone(orig)
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	mapping := Affine{ScaleX: w, ScaleY: h}
+	for _, transform := range transforms {
+		if geometric, ok := transform.(Geometric); ok {
+			mapping = mapping.Then(geometric.Mapping(img.Bounds().Dx(), img.Bounds().Dy()))
+		}
+		img, _ = transform.Apply(img, nil, nil)
+	}
+	w, h = float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	mapping = mapping.Then(Affine{ScaleX: 1 / w, ScaleY: 1 / h})
+
+	encodedImg, err := encodeLike(img, imgBytes)
+	if err != nil {
+		return nil, Affine{}, err
+	}
+	return encodedImg, mapping, nil
+}
+
+// encodeLike encodes the image in the format of the encoded image
+func encodeLike(img image.Image, imgBytes []byte) ([]byte, error) {
+	var encodedImg *bytes.Buffer
+	var err error
+
+	contentType := http.DetectContentType(imgBytes)
+	switch contentType {
+	case ContentTypeJPEG:
+		encodedImg, err = encodeImageToJPEG(img)
+	case ContentTypePNG:
+		encodedImg, err = encodeImageToPNG(img)
+	default:
+		return nil, fmt.Errorf("unsupported MIME type '%s'", contentType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return encodedImg.Bytes(), nil
+}
+
+// Resize scales images to the size according to the mode
+type Resize struct {
+	Mode   string
+	Width  int
+	Height int
+}
+
+func (t Resize) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	width, height := t.scaled(img.Bounds().Dx(), img.Bounds().Dy())
+
+	dst := imaging.Resize(img, width, height, imaging.Lanczos)
+	boxes = moveBoxes(boxes, width, height, func(x, y float64) (float64, float64) {
+		return x * float64(width) / w, y * float64(height) / h
+	})
+	if t.Mode != ResizeFill {
+		return dst, boxes
+	}
+
+	// Crop the overflowing side, as imaging.CropCenter does
+	offsetX, offsetY := (width-t.Width)/2, (height-t.Height)/2
+	dst = imaging.CropCenter(dst, t.Width, t.Height)
+	return dst, moveBoxes(boxes, t.Width, t.Height, func(x, y float64) (float64, float64) {
+		return x - float64(offsetX), y - float64(offsetY)
+	})
+}
+
+func (t Resize) Mapping(width, height int) Affine {
+	scaledWidth, scaledHeight := t.scaled(width, height)
+	mapping := Affine{ScaleX: float64(scaledWidth) / float64(width), ScaleY: float64(scaledHeight) / float64(height)}
+	if t.Mode == ResizeFill {
+		mapping.OffsetX, mapping.OffsetY = -float64((scaledWidth-t.Width)/2), -float64((scaledHeight-t.Height)/2)
+	}
+	return mapping
+}
+
+// scaled returns the size a width x height image is scaled to, before cropping
+func (t Resize) scaled(width, height int) (int, int) {
+	w, h := float64(width), float64(height)
+
+	scaleX, scaleY := float64(t.Width)/w, float64(t.Height)/h
+	switch t.Mode {
+	case ResizeFit:
+		scaleX = math.Min(scaleX, scaleY)
+		scaleY = scaleX
+	case ResizeFill:
+		scaleX = math.Max(scaleX, scaleY)
+		scaleY = scaleX
+	}
+	return int(math.Max(math.Round(w*scaleX), 1)), int(math.Max(math.Round(h*scaleY), 1))
+}
+
+// PercentCrop keeps the center of images; width and height are the fractions of the image kept
+type PercentCrop struct {
+	Width  float64
+	Height float64
+}
+
+func (t PercentCrop) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	width, height, offsetX, offsetY := t.cropped(img.Bounds().Dx(), img.Bounds().Dy())
+
+	dst := imaging.CropCenter(img, width, height)
+	return dst, moveBoxes(boxes, width, height, func(x, y float64) (float64, float64) {
+		return x - float64(offsetX), y - float64(offsetY)
+	})
+}
+
+func (t PercentCrop) Mapping(width, height int) Affine {
+	_, _, offsetX, offsetY := t.cropped(width, height)
+	return Affine{ScaleX: 1, ScaleY: 1, OffsetX: -float64(offsetX), OffsetY: -float64(offsetY)}
+}
+
+// cropped returns the size of the crop of a width x height image and the offset of its top left corner
+func (t PercentCrop) cropped(width, height int) (int, int, int, int) {
+	w, h := clamp(int(math.Round(float64(width)*t.Width)), 1, width), clamp(int(math.Round(float64(height)*t.Height)), 1, height)
+	return w, h, (width - w) / 2, (height - h) / 2
+}
+
+// Greyscale converts images to greyscale
+type Greyscale struct{}
+
+func (t Greyscale) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	return imaging.Grayscale(img), boxes
+}
+
+// Flip randomly flips images along each enabled axis, half of the time
+type Flip struct {
+	Horizontal bool
+	Vertical   bool
+}
+
+func (t Flip) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	if t.Horizontal && r.Intn(2) == 0 {
+		img = imaging.FlipH(img)
+		boxes = moveBoxes(boxes, w, h, func(x, y float64) (float64, float64) {
+			return float64(w) - x, y
+		})
+	}
+	if t.Vertical && r.Intn(2) == 0 {
+		img = imaging.FlipV(img)
+		boxes = moveBoxes(boxes, w, h, func(x, y float64) (float64, float64) {
+			return x, float64(h) - y
+		})
+	}
+	return img, boxes
+}
+
+// Rotate rotates images about their center by a random angle of up to the degree either way. Images keep their
+// size; the uncovered corners are black. Boxes become the boxes enclosing their rotated corners.
+type Rotate struct {
+	Degree int
+}
+
+func (t Rotate) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	angle := uniform(r, float64(t.Degree))
+	if angle == 0 {
+		return img, boxes
+	}
+
+	// imaging.Rotate enlarges the image to fit the rotated image, counter-clockwise
+	dst := imaging.CropCenter(imaging.Rotate(img, angle, color.Black), w, h)
+
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(w)/2, float64(h)/2
+	return dst, moveBoxes(boxes, w, h, func(x, y float64) (float64, float64) {
+		dx, dy := x-cx, y-cy
+		return cx + dx*cos + dy*sin, cy - dx*sin + dy*cos
+	})
+}
+
+// RandomCrop crops a random part of images and scales it back to the image size
+type RandomCrop struct{}
+
+func (t RandomCrop) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	scale := func() float64 { return MinRandomCropScale + r.Float64()*(1-MinRandomCropScale) }
+	width, height := clamp(int(math.Round(float64(w)*scale())), 1, w), clamp(int(math.Round(float64(h)*scale())), 1, h)
+	x0, y0 := r.Intn(w-width+1), r.Intn(h-height+1)
+
+	dst := imaging.Resize(imaging.Crop(img, image.Rect(x0, y0, x0+width, y0+height)), w, h, imaging.Lanczos)
+	return dst, moveBoxes(boxes, w, h, func(x, y float64) (float64, float64) {
+		return (x - float64(x0)) * float64(w) / float64(width), (y - float64(y0)) * float64(h) / float64(height)
+	})
+}
+
+// ColorJitter randomly changes the brightness, contrast and saturation of images by up to the given fraction
+// either way, and shifts their hue by up to the given fraction of a half turn either way
+type ColorJitter struct {
+	Brightness float64
+	Contrast   float64
+	Saturation float64
+	Hue        float64
+}
+
+func (t ColorJitter) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	if t.Brightness > 0 {
+		img = imaging.AdjustBrightness(img, uniform(r, t.Brightness)*100)
+	}
+	if t.Contrast > 0 {
+		img = imaging.AdjustContrast(img, uniform(r, t.Contrast)*100)
+	}
+	if t.Saturation > 0 {
+		img = imaging.AdjustSaturation(img, uniform(r, t.Saturation)*100)
+	}
+	if t.Hue > 0 {
+		img = shiftHue(img, uniform(r, t.Hue)*180)
+	}
+	return img, boxes
+}
+
+// GaussianBlur blurs images with a random sigma of up to the intensity times MaxBlurSigma
+type GaussianBlur struct {
+	Intensity float64
+}
+
+func (t GaussianBlur) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	sigma := r.Float64() * t.Intensity * MaxBlurSigma
+	if sigma <= 0 {
+		return img, boxes
+	}
+	return imaging.Blur(img, sigma), boxes
+}
+
+// RandomErasing fills a random rectangle of images with a random shade of grey
+type RandomErasing struct{}
+
+func (t RandomErasing) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	area := (MinErasingArea + r.Float64()*(MaxErasingArea-MinErasingArea)) * float64(w*h)
+	aspect := math.Exp(uniform(r, math.Log(3)))
+	width := clamp(int(math.Round(math.Sqrt(area*aspect))), 1, w)
+	height := clamp(int(math.Round(math.Sqrt(area/aspect))), 1, h)
+	x0, y0 := r.Intn(w-width+1), r.Intn(h-height+1)
+
+	grey := uint8(r.Intn(256))
+	draw.Draw(img, image.Rect(x0, y0, x0+width, y0+height), image.NewUniform(color.NRGBA{grey, grey, grey, 0xFF}), image.Point{}, draw.Src)
+	return img, boxes
+}
+
+// Noise adds gaussian noise to each color channel of images with a standard deviation of the intensity times
+// MaxNoiseStdDev
+type Noise struct {
+	Intensity float64
+}
+
+func (t Noise) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	stddev := t.Intensity * MaxNoiseStdDev
+	for i := range img.Pix {
+		if i%4 == 3 { // alpha
+			continue
+		}
+		img.Pix[i] = uint8(clamp(int(math.Round(float64(img.Pix[i])+r.NormFloat64()*stddev)), 0, 0xFF))
+	}
+	return img, boxes
+}
+
+// JPEGCompression compresses images as JPEG with a random quality between MinJPEGQuality and MaxJPEGQuality
+type JPEGCompression struct{}
+
+func (t JPEGCompression) Apply(img *image.NRGBA, boxes []BoundingBox, r *rand.Rand) (*image.NRGBA, []BoundingBox) {
+	quality := MinJPEGQuality + r.Intn(MaxJPEGQuality-MinJPEGQuality+1)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return img, boxes
+	}
+	compressed, err := jpeg.Decode(buf)
+	if err != nil {
+		return img, boxes
+	}
+	return imaging.Clone(compressed), boxes
+}
+
+// moveBoxes maps the corners of the boxes to a width x height image and keeps the part of the boxes enclosing
+// them inside the image. Boxes left without area are dropped.
+func moveBoxes(boxes []BoundingBox, width, height int, move func(x, y float64) (float64, float64)) []BoundingBox {
+	moved := make([]BoundingBox, 0, len(boxes))
+	for _, box := range boxes {
+		xmin, ymin, xmax, ymax := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, corner := range [][2]int{{box.Xmin, box.Ymin}, {box.Xmax, box.Ymin}, {box.Xmin, box.Ymax}, {box.Xmax, box.Ymax}} {
+			x, y := move(float64(corner[0]), float64(corner[1]))
+			xmin, ymin, xmax, ymax = math.Min(xmin, x), math.Min(ymin, y), math.Max(xmax, x), math.Max(ymax, y)
+		}
+
+		movedBox := BoundingBox{
+			Xmin:      clamp(int(math.Round(xmin)), 0, width),
+			Ymin:      clamp(int(math.Round(ymin)), 0, height),
+			Xmax:      clamp(int(math.Round(xmax)), 0, width),
+			Ymax:      clamp(int(math.Round(ymax)), 0, height),
+			ClassName: box.ClassName,
+		}
+		if movedBox.Xmax > movedBox.Xmin && movedBox.Ymax > movedBox.Ymin {
+			moved = append(moved, movedBox)
+		}
+	}
+	return moved
+}
+
+// shiftHue rotates the hue of the image by the angle in degrees, in the YIQ color space
+func shiftHue(img *image.NRGBA, angle float64) *image.NRGBA {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		r, g, b := float64(c.R), float64(c.G), float64(c.B)
+		y := 0.299*r + 0.587*g + 0.114*b
+		i := 0.596*r - 0.274*g - 0.322*b
+		q := 0.211*r - 0.523*g + 0.312*b
+		i, q = i*cos-q*sin, i*sin+q*cos
+		return color.NRGBA{
+			R: uint8(clamp(int(math.Round(y+0.956*i+0.621*q)), 0, 0xFF)),
+			G: uint8(clamp(int(math.Round(y-0.272*i-0.647*q)), 0, 0xFF)),
+			B: uint8(clamp(int(math.Round(y-1.106*i+1.703*q)), 0, 0xFF)),
+			A: c.A,
+		}
+	})
+}
+
+// uniform returns a random number between -limit and limit
+func uniform(r *rand.Rand, limit float64) float64 {
+	return (2*r.Float64() - 1) * limit
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+/*
+ * File: augment_test.go
+ * Project: image
+ * File Created: Tuesday, 20th February 2024 11:02:19 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 11:02:19 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package image
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// bright returns the rectangle enclosing the bright pixels of the image
+func bright(img *image.NRGBA) image.Rectangle {
+	rect := image.Rectangle{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.NRGBAAt(x, y).R > 0x80 {
+				rect = rect.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return rect
+}
+
+func TestTransforms(t *testing.T) {
+	img := imaging.New(80, 40, color.Black)
+	box := BoundingBox{Xmin: 10, Ymin: 5, Xmax: 30, Ymax: 15, ClassName: "cat"}
+	for y := box.Ymin; y < box.Ymax; y++ {
+		for x := box.Xmin; x < box.Xmax; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for name, tc := range map[string]struct {
+		transform Transform
+		size      image.Point
+	}{
+		"stretch": {Resize{Mode: ResizeStretch, Width: 40, Height: 40}, image.Pt(40, 40)},
+		"fit":     {Resize{Mode: ResizeFit, Width: 40, Height: 40}, image.Pt(40, 20)},
+		"fill":    {Resize{Mode: ResizeFill, Width: 40, Height: 40}, image.Pt(40, 40)},
+		"crop":    {PercentCrop{Width: 0.5, Height: 0.5}, image.Pt(40, 20)},
+		"flip":    {Flip{Horizontal: true, Vertical: true}, image.Pt(80, 40)},
+		"rotate":  {Rotate{Degree: 30}, image.Pt(80, 40)},
+		"random":  {RandomCrop{}, image.Pt(80, 40)},
+	} {
+		for i := 0; i < 5; i++ {
+			dst, boxes := tc.transform.Apply(imaging.Clone(img), []BoundingBox{box}, r)
+			assert.Equal(t, tc.size, dst.Bounds().Size(), name)
+
+			// The box follows the pixels, within resampling error
+			rect := bright(dst)
+			if rect.Empty() {
+				assert.Empty(t, boxes, name)
+				continue
+			}
+			if assert.Len(t, boxes, 1, name) {
+				assert.Equal(t, "cat", boxes[0].ClassName)
+				assert.InDelta(t, rect.Min.X, boxes[0].Xmin, 2, name)
+				assert.InDelta(t, rect.Min.Y, boxes[0].Ymin, 2, name)
+				assert.InDelta(t, rect.Max.X, boxes[0].Xmax, 2, name)
+				assert.InDelta(t, rect.Max.Y, boxes[0].Ymax, 2, name)
+			}
+		}
+	}
+
+	// Boxes cropped out of the image are dropped
+	_, boxes := PercentCrop{Width: 0.2, Height: 0.2}.Apply(imaging.Clone(img), []BoundingBox{box}, r)
+	assert.Empty(t, boxes)
+
+	pngImg, _ := newImage("image/png", 40, 20)
+	transformed, width, height, boxes, err := TransformImage(pngImg, []BoundingBox{{Xmin: 0, Ymin: 0, Xmax: 20, Ymax: 20}},
+		[]Transform{Resize{Mode: ResizeStretch, Width: 20, Height: 20}, Greyscale{}, ColorJitter{Brightness: 0.5, Hue: 0.5},
+			GaussianBlur{Intensity: 0.5}, Noise{Intensity: 0.5}, RandomErasing{}, JPEGCompression{}}, r)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, transformed)
+	assert.Equal(t, 20, width)
+	assert.Equal(t, 20, height)
+	assert.Equal(t, []BoundingBox{{Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 20}}, boxes)
+}
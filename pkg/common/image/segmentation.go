@@ -0,0 +1,164 @@
+/*
+ * File: segmentation.go
+ * Project: image
+ * File Created: Monday, 19th February 2024 9:41:12 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 9:41:12 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const SegmentationOpacity = 0.5 // opacity of filled segmentation regions.
+
+// Polygon is a closed polygon with vertices given as consecutive x, y pixel coordinates
+type Polygon struct {
+	Points    []float64
+	ClassName string
+}
+
+// Mask is an uncompressed COCO run-length encoded mask; runs alternate between background and region pixels,
+// starting with background, over the pixels in column-major order
+type Mask struct {
+	Height    int
+	Width     int
+	Counts    []int
+	ClassName string
+}
+
+// ThumbnailSegmentation fills the polygons and masks over the image, one color per class, and returns a base64
+// encoded thumbnail of the result.
+func ThumbnailSegmentation(imgBytes []byte, width, height int, polygons []Polygon, masks []Mask) (string, int, error) {
+	orig, _, err := image.Decode(bytes.NewReader(imgBytes))
+	if err != nil {
+		return "", 0, err
+	}
+	// convert as usable image
+	b := orig.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), orig, b.Min, draw.Src)
+
+	// Regions of a class are drawn into a single alpha mask so overlapping regions do not darken
+	regions := make(map[string]*image.Alpha)
+	region := func(className string) *image.Alpha {
+		if _, ok := regions[className]; !ok {
+			regions[className] = image.NewAlpha(img.Bounds())
+		}
+		return regions[className]
+	}
+	for _, polygon := range polygons {
+		fillPolygon(region(polygon.ClassName), polygon.Points)
+	}
+	for _, mask := range masks {
+		fillMask(region(mask.ClassName), mask)
+	}
+	segmentationImage := addRegionsToFace(img, regions)
+
+	// Resize the image
+	dstImg := imaging.Thumbnail(segmentationImage, width, height, imaging.Lanczos)
+
+	// Encode back to original format
+	var encodedImg *bytes.Buffer
+
+	contentType := http.DetectContentType(imgBytes)
+	switch contentType {
+	case ContentTypeJPEG:
+		encodedImg, err = encodeImageToJPEG(dstImg)
+	case ContentTypePNG:
+		encodedImg, err = encodeImageToPNG(dstImg)
+	default:
+		return "", 0, fmt.Errorf("unsupported MIME type '%s'", contentType)
+	}
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	sEnc := base64.StdEncoding.EncodeToString(encodedImg.Bytes())
+
+	return sEnc, len([]byte(sEnc)), nil
+}
+
+// fillPolygon sets the pixels whose centers lie inside the polygon (even-odd rule)
+func fillPolygon(dst *image.Alpha, points []float64) {
+	n := len(points) / 2
+	if n < 3 {
+		return
+	}
+
+	b := dst.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		cy := float64(y) + 0.5
+
+		// Crossings of the scanline with the polygon edges
+		xs := []float64{}
+		for i := 0; i < n; i++ {
+			j := (i + 1) % n
+			x1, y1, x2, y2 := points[2*i], points[2*i+1], points[2*j], points[2*j+1]
+			if (y1 <= cy) == (y2 <= cy) {
+				continue
+			}
+			xs = append(xs, x1+(cy-y1)*(x2-x1)/(y2-y1))
+		}
+		sort.Float64s(xs)
+
+		for i := 0; i+1 < len(xs); i += 2 {
+			start := int(math.Max(math.Ceil(xs[i]-0.5), float64(b.Min.X)))
+			end := int(math.Min(math.Ceil(xs[i+1]-0.5), float64(b.Max.X)))
+			for x := start; x < end; x++ {
+				dst.SetAlpha(x, y, color.Alpha{A: 0xFF})
+			}
+		}
+	}
+}
+
+// fillMask sets the pixels of a run-length encoded mask. Masks are expected to have the dimensions of the image;
+// pixels outside the image are ignored.
+func fillMask(dst *image.Alpha, mask Mask) {
+	if mask.Height <= 0 {
+		return
+	}
+
+	pos := 0
+	for i, count := range mask.Counts {
+		if i%2 == 1 {
+			for p := pos; p < pos+count; p++ {
+				dst.SetAlpha(p/mask.Height, p%mask.Height, color.Alpha{A: 0xFF})
+			}
+		}
+		pos += count
+	}
+}
+
+// addRegionsToFace blends each class's regions over the image in the class color
+func addRegionsToFace(img draw.Image, regions map[string]*image.Alpha) draw.Image {
+	classNames := make([]string, 0, len(regions))
+	for className := range regions {
+		classNames = append(classNames, className)
+	}
+	colors := classColor(classNames)
+
+	for className, mask := range regions {
+		for j := range mask.Pix {
+			mask.Pix[j] = uint8(float64(mask.Pix[j]) * SegmentationOpacity)
+		}
+		fill := image.NewUniform(colors[className])
+		draw.DrawMask(img, img.Bounds(), fill, image.Point{}, mask, image.Point{}, draw.Over)
+	}
+
+	return img
+}
@@ -0,0 +1,51 @@
+/*
+ * File: segmentation_test.go
+ * Project: image
+ * File Created: Monday, 19th February 2024 9:41:12 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 9:41:12 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package image
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func count(mask *image.Alpha) int {
+	n := 0
+	for _, a := range mask.Pix {
+		if a > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSegmentation(t *testing.T) {
+	// Polygon covering x=2..5, y=1..3
+	polygon := image.NewAlpha(image.Rect(0, 0, 10, 10))
+	fillPolygon(polygon, []float64{2, 1, 6, 1, 6, 4, 2, 4})
+	assert.Equal(t, 12, count(polygon))
+	assert.NotZero(t, polygon.AlphaAt(2, 1).A)
+	assert.Zero(t, polygon.AlphaAt(6, 1).A)
+
+	// Mask covering x=1, y=1..2 in column-major order
+	mask := image.NewAlpha(image.Rect(0, 0, 4, 3))
+	fillMask(mask, Mask{Height: 3, Width: 4, Counts: []int{4, 2, 6}})
+	assert.Equal(t, 2, count(mask))
+	assert.NotZero(t, mask.AlphaAt(1, 1).A)
+	assert.NotZero(t, mask.AlphaAt(1, 2).A)
+
+	pngImg, _ := newImage("image/png", 40, 20)
+	thumb, size, err := ThumbnailSegmentation(pngImg, 10, 10,
+		[]Polygon{{Points: []float64{2, 1, 30, 1, 30, 15}, ClassName: "scratch"}},
+		[]Mask{{Height: 20, Width: 40, Counts: []int{20, 20, 760}, ClassName: "dent"}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, thumb)
+	assert.Equal(t, len(thumb), size)
+}
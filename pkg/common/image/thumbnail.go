@@ -17,7 +17,9 @@ import (
 	"image/color"
 	"image/draw"
 	_ "image/jpeg"
+	"math"
 	"net/http"
+	"sort"
 
 	"github.com/disintegration/imaging"
 )
@@ -25,6 +27,8 @@ import (
 const (
 	BoundingBoxWidth                = 5 // pixel width of bounding boxes.
 	BoundingBoxDefaultThumbnailSize = 100
+	KeypointRadius                  = 6 // pixel radius of keypoints.
+	SkeletonWidth                   = 3 // pixel width of skeleton edges.
 )
 
 type BoundingBox struct {
@@ -33,6 +37,17 @@ type BoundingBox struct {
 	Ymin      int
 	Ymax      int
 	ClassName string
+	// Keypoints of the object, if any, drawn over the box
+	Keypoints []Keypoint
+	// Pairs of keypoint indices joined by a line
+	Edges [][2]int
+}
+
+// Keypoint is a point of an object; keypoints that are not labelled are not drawn
+type Keypoint struct {
+	X        int
+	Y        int
+	Labelled bool
 }
 
 // Thumbnail is a function that scales the image up or down using the specified resample filter,
@@ -90,6 +105,7 @@ func ThumbnailBoundingBox(imgBytes []byte, width, height int, boundingBoxes []Bo
 		myRectangles[box.ClassName] = append(myRectangles[box.ClassName], myRectangle)
 	}
 	boundingBoxImage := addRectanglesToFace(img, myRectangles)
+	boundingBoxImage = addKeypointsToFace(boundingBoxImage, boundingBoxes)
 
 	// Resize the image
 	dstImg := imaging.Thumbnail(boundingBoxImage, width, height, imaging.Lanczos)
@@ -141,28 +157,102 @@ func drawRectangle(img draw.Image, color color.Color, x1, y1, x2, y2, width int)
 	}
 }
 
-func addRectanglesToFace(img draw.Image, rectangles map[string][]image.Rectangle) draw.Image {
-	// TODO add option to pass in desired colors
-	colorOptions := []color.RGBA{
-		{0x00, 0xFF, 0x00, 0xFF}, // light green
-		{245, 86, 39, 0xFF},      // orange
-		{39, 245, 245, 0xFF},     // teal
-		{245, 245, 39, 0xFF},     // yellow
-		{245, 39, 86, 0xFF},      // red
+// classColors are the colors annotations are drawn in, one per class
+// TODO add option to pass in desired colors
+var classColors = []color.RGBA{
+	{0x00, 0xFF, 0x00, 0xFF}, // light green
+	{245, 86, 39, 0xFF},      // orange
+	{39, 245, 245, 0xFF},     // teal
+	{245, 245, 39, 0xFF},     // yellow
+	{245, 39, 86, 0xFF},      // red
+}
 
-	}
+// classColor returns the color of each class; classes are colored in name order so that boxes and keypoints of
+// a class share a color
+func classColor(classNames []string) map[string]color.RGBA {
+	sorted := append([]string{}, classNames...)
+	sort.Strings(sorted)
 
-	i := 0
-	for _, rectangles := range rectangles {
-		if i > len(colorOptions)-1 {
-			i = 0
+	colors := make(map[string]color.RGBA, len(sorted))
+	for _, className := range sorted {
+		if _, ok := colors[className]; !ok {
+			colors[className] = classColors[len(colors)%len(classColors)]
 		}
+	}
+	return colors
+}
+
+func addRectanglesToFace(img draw.Image, rectangles map[string][]image.Rectangle) draw.Image {
+	classNames := make([]string, 0, len(rectangles))
+	for className := range rectangles {
+		classNames = append(classNames, className)
+	}
+	colors := classColor(classNames)
+
+	for className, rectangles := range rectangles {
 		for _, rectangle := range rectangles {
 			min := rectangle.Min
 			max := rectangle.Max
-			drawRectangle(img, colorOptions[i], min.X, min.Y, max.X, max.Y, BoundingBoxWidth)
+			drawRectangle(img, colors[className], min.X, min.Y, max.X, max.Y, BoundingBoxWidth)
+		}
+	}
+
+	return img
+}
+
+// drawLine draws a line of the given width between two points
+func drawLine(img draw.Image, color color.Color, x1, y1, x2, y2, width int) {
+	steps := int(math.Max(math.Abs(float64(x2-x1)), math.Abs(float64(y2-y1))))
+	for s := 0; s <= steps; s++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(s) / float64(steps)
+		}
+		x := x1 + int(math.Round(t*float64(x2-x1)))
+		y := y1 + int(math.Round(t*float64(y2-y1)))
+		for i := -width / 2; i <= width/2; i++ {
+			for j := -width / 2; j <= width/2; j++ {
+				img.Set(x+i, y+j, color)
+			}
+		}
+	}
+}
+
+// drawPoint draws a filled circle
+func drawPoint(img draw.Image, color color.Color, x, y, radius int) {
+	for i := -radius; i <= radius; i++ {
+		for j := -radius; j <= radius; j++ {
+			if i*i+j*j <= radius*radius {
+				img.Set(x+i, y+j, color)
+			}
+		}
+	}
+}
+
+// addKeypointsToFace draws the skeleton edges and keypoints of each box in the color of its class. Edges are only
+// drawn between labelled keypoints.
+func addKeypointsToFace(img draw.Image, boxes []BoundingBox) draw.Image {
+	classNames := make([]string, 0, len(boxes))
+	for _, box := range boxes {
+		classNames = append(classNames, box.ClassName)
+	}
+	colors := classColor(classNames)
+
+	for _, box := range boxes {
+		for _, edge := range box.Edges {
+			if edge[0] >= len(box.Keypoints) || edge[1] >= len(box.Keypoints) {
+				continue
+			}
+			from, to := box.Keypoints[edge[0]], box.Keypoints[edge[1]]
+			if from.Labelled && to.Labelled {
+				drawLine(img, colors[box.ClassName], from.X, from.Y, to.X, to.Y, SkeletonWidth)
+			}
+		}
+		for _, keypoint := range box.Keypoints {
+			if keypoint.Labelled {
+				drawPoint(img, colors[box.ClassName], keypoint.X, keypoint.Y, KeypointRadius)
+			}
 		}
-		i += 1
 	}
 
 	return img
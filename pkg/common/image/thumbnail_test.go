@@ -0,0 +1,43 @@
+/*
+ * File: thumbnail_test.go
+ * Project: image
+ * File Created: Monday, 19th February 2024 2:27:51 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 2:27:51 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeypoints(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	box := BoundingBox{
+		Xmin: 10, Ymin: 10, Xmax: 90, Ymax: 90,
+		ClassName: "person",
+		Keypoints: []Keypoint{{X: 20, Y: 50, Labelled: true}, {X: 80, Y: 50, Labelled: true}, {X: 50, Y: 20}},
+		Edges:     [][2]int{{0, 1}, {1, 2}},
+	}
+	addKeypointsToFace(img, []BoundingBox{box})
+
+	colored := func(x, y int) bool { return img.RGBAAt(x, y) != color.RGBA{} }
+	assert.True(t, colored(20, 50))
+	assert.True(t, colored(80, 50))
+	// Along the edge between labelled keypoints
+	assert.True(t, colored(50, 50))
+	// Unlabelled keypoints and their edges are not drawn
+	assert.False(t, colored(50, 20))
+	assert.False(t, colored(65, 35))
+
+	pngImg, _ := newImage("image/png", 100, 100)
+	thumb, _, err := ThumbnailBoundingBox(pngImg, 10, 10, []BoundingBox{box})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, thumb)
+}
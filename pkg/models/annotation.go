@@ -11,6 +11,7 @@ package models
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -32,6 +33,53 @@ func (s Split) String() string {
 	return [...]string{"UNDEFINED", "TRAIN", "VALIDATION", "TEST"}[s]
 }
 
+// AnnotationStatus is an enum for the review state of an annotation
+type AnnotationStatus int
+
+const (
+	// Being labelled; annotations created before reviews were introduced have no status and are drafts
+	AnnotationStatusDraft AnnotationStatus = iota
+	// Awaiting review
+	AnnotationStatusSubmitted
+	AnnotationStatusApproved
+	AnnotationStatusRejected
+)
+
+func (s AnnotationStatus) String() string {
+	return [...]string{"DRAFT", "SUBMITTED", "APPROVED", "REJECTED"}[s]
+}
+
+// AnnotationStatusFromString returns the annotation status for a string
+func AnnotationStatusFromString(str string) (AnnotationStatus, error) {
+	switch strings.ToUpper(str) {
+	case "", "DRAFT":
+		return AnnotationStatusDraft, nil
+	case "SUBMITTED":
+		return AnnotationStatusSubmitted, nil
+	case "APPROVED":
+		return AnnotationStatusApproved, nil
+	case "REJECTED":
+		return AnnotationStatusRejected, nil
+	default:
+		return AnnotationStatusDraft, ErrInvalidAnnotationStatus
+	}
+}
+
+// ErrInvalidAnnotationStatus is an error returned when an unknown annotation status is specified
+var ErrInvalidAnnotationStatus = fmt.Errorf("annotation status must be one of draft, submitted, approved or rejected")
+
+// AnnotationReview is the outcome of the last review of an annotation
+type AnnotationReview struct {
+	// User who reviewed the annotation
+	//
+	ReviewerID string `json:"reviewerid" bson:"reviewerid"`
+	// Reviewer comment; required when rejecting
+	//
+	Comment string `json:"comment,omitempty" bson:"comment,omitempty"`
+
+	ReviewedAt time.Time `json:"reviewed_at" bson:"reviewed_at"`
+}
+
 type AnnotationDataBoundingBox struct {
 	TagID string `json:"name,omitempty" bson:"tagid,omitempty"`
 	Xmin  int    `json:"xmin,omitempty" bson:"xmin,omitempty"`
@@ -51,8 +99,271 @@ func (a AnnotationDataBoundingBox) ToTopLeftWidthHeightFormat() (left int, top i
 	return
 }
 
+// AnnotationDataPolygon is a region of a segmentation annotation outlined by a closed polygon
+type AnnotationDataPolygon struct {
+	TagID string `json:"name,omitempty" bson:"tagid,omitempty"`
+	// Vertices as consecutive x, y pixel coordinates, as in COCO i.e. [x1, y1, x2, y2, ...]
+	Points []float64 `json:"points" bson:"points"`
+	// Area in pixels; computed when the annotation is saved
+	Area float64 `json:"area,omitempty" bson:"area,omitempty"`
+}
+
+// PixelArea returns the area enclosed by the polygon (shoelace formula)
+func (p AnnotationDataPolygon) PixelArea() float64 {
+	n := len(p.Points) / 2
+	area := 0.0
+	for i := 0; i < n; i++ {
+		j := (i + 1) % n
+		area += p.Points[2*i]*p.Points[2*j+1] - p.Points[2*j]*p.Points[2*i+1]
+	}
+	return math.Abs(area) / 2
+}
+
+// Bounds returns the bounding box of the polygon
+func (p AnnotationDataPolygon) Bounds() (xmin, ymin, xmax, ymax float64) {
+	if len(p.Points) < 2 {
+		return
+	}
+	xmin, ymin, xmax, ymax = p.Points[0], p.Points[1], p.Points[0], p.Points[1]
+	for i := 2; i+1 < len(p.Points); i += 2 {
+		xmin, xmax = math.Min(xmin, p.Points[i]), math.Max(xmax, p.Points[i])
+		ymin, ymax = math.Min(ymin, p.Points[i+1]), math.Max(ymax, p.Points[i+1])
+	}
+	return
+}
+
+func (p AnnotationDataPolygon) valid() error {
+	if len(p.Points) < 6 || len(p.Points)%2 != 0 {
+		return fmt.Errorf("annotation polygon must have at least 3 vertices given as x, y pairs")
+	}
+	for _, v := range p.Points {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("annotation polygon contains invalid coordinates")
+		}
+	}
+	return nil
+}
+
+// AnnotationDataMask is a region of a segmentation annotation given as a run-length encoded pixel mask
+type AnnotationDataMask struct {
+	TagID  string `json:"name,omitempty" bson:"tagid,omitempty"`
+	Height int    `json:"height" bson:"height"`
+	Width  int    `json:"width" bson:"width"`
+	// Uncompressed COCO run-length encoding: lengths of alternating runs of background and region pixels,
+	// starting with background, over the pixels in column-major order
+	Counts []int `json:"counts" bson:"counts"`
+	// Area in pixels; computed when the annotation is saved
+	Area float64 `json:"area,omitempty" bson:"area,omitempty"`
+}
+
+// PixelArea returns the number of pixels in the mask
+func (m AnnotationDataMask) PixelArea() float64 {
+	area := 0
+	for i := 1; i < len(m.Counts); i += 2 {
+		area += m.Counts[i]
+	}
+	return float64(area)
+}
+
+// Bounds returns the bounding box of the mask, with exclusive maximums. All values are zero for an empty mask.
+func (m AnnotationDataMask) Bounds() (xmin, ymin, xmax, ymax int) {
+	if m.Height == 0 {
+		return
+	}
+	xmin, ymin = m.Width, m.Height
+	pos := 0
+	for i, count := range m.Counts {
+		if i%2 == 1 && count > 0 {
+			first, last := pos, pos+count-1
+			firstCol, lastCol := first/m.Height, last/m.Height
+			if firstCol < xmin {
+				xmin = firstCol
+			}
+			if lastCol+1 > xmax {
+				xmax = lastCol + 1
+			}
+			if firstCol != lastCol {
+				// The run wraps from the bottom of a column to the top of the next
+				ymin, ymax = 0, m.Height
+			} else {
+				if first%m.Height < ymin {
+					ymin = first % m.Height
+				}
+				if last%m.Height+1 > ymax {
+					ymax = last%m.Height + 1
+				}
+			}
+		}
+		pos += count
+	}
+	if xmax == 0 {
+		return 0, 0, 0, 0
+	}
+	return
+}
+
+// Resized returns the mask resampled to the given dimensions with nearest neighbour sampling
+func (m AnnotationDataMask) Resized(width, height int) AnnotationDataMask {
+	resized := AnnotationDataMask{TagID: m.TagID, Height: height, Width: width, Counts: []int{}}
+	if m.Height <= 0 || m.Width <= 0 || width <= 0 || height <= 0 {
+		return resized
+	}
+
+	// Decode the source pixels
+	pixels := make([]bool, m.Height*m.Width)
+	pos := 0
+	for i, count := range m.Counts {
+		for j := 0; j < count && pos < len(pixels); j++ {
+			pixels[pos] = i%2 == 1
+			pos++
+		}
+	}
+
+	// Nearest source index of a target index, sampling at pixel centres
+	nearest := func(i, from, to int) int {
+		if n := int((float64(i) + 0.5) * float64(from) / float64(to)); n < from {
+			return n
+		}
+		return from - 1
+	}
+	rows := make([]int, height)
+	for y := range rows {
+		rows[y] = nearest(y, m.Height, height)
+	}
+
+	// Encode the target pixels, starting with a background run
+	run, region := 0, false
+	for x := 0; x < width; x++ {
+		column := nearest(x, m.Width, width) * m.Height
+		for y := 0; y < height; y++ {
+			if pixels[column+rows[y]] != region {
+				resized.Counts = append(resized.Counts, run)
+				run, region = 0, !region
+			}
+			run++
+		}
+	}
+	resized.Counts = append(resized.Counts, run)
+
+	return resized
+}
+
+func (m AnnotationDataMask) valid() error {
+	if m.Height <= 0 || m.Width <= 0 {
+		return fmt.Errorf("annotation mask has invalid dimensions; height=%d width=%d", m.Height, m.Width)
+	}
+	total := 0
+	for _, count := range m.Counts {
+		if count < 0 {
+			return fmt.Errorf("annotation mask contains a negative run length")
+		}
+		total += count
+	}
+	if total != m.Height*m.Width {
+		return fmt.Errorf("annotation mask run lengths cover %d pixels; expected %d", total, m.Height*m.Width)
+	}
+	return nil
+}
+
+// KeypointVisibility is the COCO visibility flag of a keypoint
+type KeypointVisibility int
+
+const (
+	// Not labelled; the coordinates are meaningless
+	KeypointNotLabelled KeypointVisibility = iota
+	// Labelled but occluded
+	KeypointOccluded
+	KeypointVisible
+)
+
+// AnnotationDataKeypoint is a keypoint of an object; which keypoint it is follows from its position in the object
+type AnnotationDataKeypoint struct {
+	X float64 `json:"x" bson:"x"`
+	Y float64 `json:"y" bson:"y"`
+	// 0 not labelled, 1 labelled but occluded, 2 visible
+	Visibility KeypointVisibility `json:"visibility" bson:"visibility"`
+}
+
+// AnnotationDataKeypointObject is an object of a keypoint annotation: a bounding box with the keypoints of the
+// skeleton of its tag, in the order of the skeleton
+type AnnotationDataKeypointObject struct {
+	AnnotationDataBoundingBox `bson:",inline"`
+	Keypoints                 []AnnotationDataKeypoint `json:"keypoints" bson:"keypoints"`
+}
+
+// Labelled returns the number of labelled keypoints of the object
+func (o AnnotationDataKeypointObject) Labelled() int {
+	n := 0
+	for _, keypoint := range o.Keypoints {
+		if keypoint.Visibility != KeypointNotLabelled {
+			n++
+		}
+	}
+	return n
+}
+
+func (o AnnotationDataKeypointObject) valid() error {
+	if o.TagID == "" {
+		return fmt.Errorf("annotation keypoint object has nil tagid")
+	}
+	if o.Xmax == 0 && o.Xmin == 0 && o.Ymax == 0 && o.Ymin == 0 {
+		return fmt.Errorf("annotation keypoint object bounding box contains all zero values")
+	}
+	for _, keypoint := range o.Keypoints {
+		if keypoint.Visibility < KeypointNotLabelled || keypoint.Visibility > KeypointVisible {
+			return fmt.Errorf("annotation keypoint visibility must be 0, 1 or 2; visibility=%d", keypoint.Visibility)
+		}
+		if keypoint.Visibility != KeypointNotLabelled && (keypoint.X < 0 || keypoint.Y < 0) {
+			return fmt.Errorf("annotation keypoint contains negative coordinates")
+		}
+	}
+	return nil
+}
+
 type AnnotationMetadata struct {
-	BoundingBoxes []AnnotationDataBoundingBox `json:"bounding_boxes,omitempty" bson:"bounding_boxes,omitempty"`
+	BoundingBoxes   []AnnotationDataBoundingBox    `json:"bounding_boxes,omitempty" bson:"bounding_boxes,omitempty"`
+	Polygons        []AnnotationDataPolygon        `json:"polygons,omitempty" bson:"polygons,omitempty"`
+	Masks           []AnnotationDataMask           `json:"masks,omitempty" bson:"masks,omitempty"`
+	KeypointObjects []AnnotationDataKeypointObject `json:"keypoint_objects,omitempty" bson:"keypoint_objects,omitempty"`
+}
+
+// Empty reports whether the metadata holds no labels of any kind
+func (m AnnotationMetadata) Empty() bool {
+	return len(m.BoundingBoxes) == 0 && len(m.Polygons) == 0 && len(m.Masks) == 0 && len(m.KeypointObjects) == 0
+}
+
+// SetAreas computes the area of each segmentation region
+func (m *AnnotationMetadata) SetAreas() {
+	for i := range m.Polygons {
+		m.Polygons[i].Area = m.Polygons[i].PixelArea()
+	}
+	for i := range m.Masks {
+		m.Masks[i].Area = m.Masks[i].PixelArea()
+	}
+}
+
+// segmentTagIDs returns the tags of the polygons and masks, checking each region is well formed
+func (m AnnotationMetadata) segmentTagIDs() (map[string]struct{}, error) {
+	tagids := make(map[string]struct{})
+	for _, polygon := range m.Polygons {
+		if polygon.TagID == "" {
+			return nil, fmt.Errorf("annotation polygon has nil tagid")
+		}
+		if err := polygon.valid(); err != nil {
+			return nil, err
+		}
+		tagids[polygon.TagID] = struct{}{}
+	}
+	for _, mask := range m.Masks {
+		if mask.TagID == "" {
+			return nil, fmt.Errorf("annotation mask has nil tagid")
+		}
+		if err := mask.valid(); err != nil {
+			return nil, err
+		}
+		tagids[mask.TagID] = struct{}{}
+	}
+	return tagids, nil
 }
 
 type ContentMetadata struct {
@@ -87,6 +398,12 @@ type Annotation struct {
 	// Train, validation, or test label
 	//
 	Split string `json:"split" bson:"split"`
+	// One of DRAFT, SUBMITTED, APPROVED or REJECTED
+	//
+	Status string `json:"status" bson:"status,omitempty"`
+	// Last review of the annotation
+	//
+	Review *AnnotationReview `json:"review,omitempty" bson:"review,omitempty"`
 	// Content b64 string
 	//
 	Base64Image string `json:"b64_image" bson:"b64_image"`
@@ -113,6 +430,7 @@ func NewAnnotation(userid, projectid, datasetid, contentid string, tagids []stri
 	if len(tagids) == 0 {
 		isNullAnnotation = true
 	}
+	metadata.SetAreas()
 
 	return &Annotation{
 		ID:               primitive.NewObjectID(),
@@ -122,6 +440,7 @@ func NewAnnotation(userid, projectid, datasetid, contentid string, tagids []stri
 		TagIDs:           tagids,
 		ContentID:        contentid,
 		Split:            SplitUndefined.String(),
+		Status:           AnnotationStatusDraft.String(),
 		Base64Image:      base64Image,
 		Metadata:         metadata,
 		ContentMetadata:  contentMetadata,
@@ -137,6 +456,14 @@ func (a *Annotation) Valid(annotationType string) error {
 		return err
 	}
 
+	segmented := len(a.Metadata.Polygons) > 0 || len(a.Metadata.Masks) > 0
+	if segmented && projectAnnotationType != ProjectAnnotationTypeSegmentation {
+		return ErrAnnotationMismatch
+	}
+	if len(a.Metadata.KeypointObjects) > 0 && projectAnnotationType != ProjectAnnotationTypeKeypoint {
+		return ErrAnnotationMismatch
+	}
+
 	switch projectAnnotationType {
 	case ProjectAnnotationTypeBoundingBox:
 
@@ -163,9 +490,83 @@ func (a *Annotation) Valid(annotationType string) error {
 		}
 	case ProjectAnnotationTypeClassification:
 		// Noop
+	case ProjectAnnotationTypeSegmentation:
+		if len(a.Metadata.BoundingBoxes) > 0 {
+			return ErrAnnotationMismatch
+		}
+
+		if segmented {
+			segmentTagIdSet, err := a.Metadata.segmentTagIDs()
+			if err != nil {
+				return err
+			}
+
+			// Masks cover the whole content
+			for _, mask := range a.Metadata.Masks {
+				if a.ContentMetadata.Height > 0 && (mask.Height != a.ContentMetadata.Height || mask.Width != a.ContentMetadata.Width) {
+					return fmt.Errorf("annotation mask dimensions do not match the content; mask=%dx%d content=%dx%d",
+						mask.Width, mask.Height, a.ContentMetadata.Width, a.ContentMetadata.Height)
+				}
+			}
+
+			if len(segmentTagIdSet) != len(a.TagIDs) {
+				return fmt.Errorf(
+					"annotation tagids do not match tagids found in segmentation metadata; annotation-tagids=%s, segmentation-tagids=%s",
+					strings.Join(a.TagIDs, ","),
+					strings.Join(common.MapStringStructToSlice(segmentTagIdSet), ","),
+				)
+			}
+			for _, tagid := range a.TagIDs {
+				if _, ok := segmentTagIdSet[tagid]; !ok {
+					return fmt.Errorf("annotation tagid not found in segmentation metadata; tagid=%s", tagid)
+				}
+			}
+		}
+	case ProjectAnnotationTypeKeypoint:
+		if len(a.Metadata.BoundingBoxes) > 0 {
+			return ErrAnnotationMismatch
+		}
+
+		if len(a.Metadata.KeypointObjects) > 0 {
+			objectTagIdSet := make(map[string]struct{})
+			for _, object := range a.Metadata.KeypointObjects {
+				if err := object.valid(); err != nil {
+					return err
+				}
+				objectTagIdSet[object.TagID] = struct{}{}
+			}
+
+			if len(objectTagIdSet) != len(a.TagIDs) {
+				return fmt.Errorf(
+					"annotation tagids do not match tagids found in keypoint metadata; annotation-tagids=%s, keypoint-tagids=%s",
+					strings.Join(a.TagIDs, ","),
+					strings.Join(common.MapStringStructToSlice(objectTagIdSet), ","),
+				)
+			}
+			for _, tagid := range a.TagIDs {
+				if _, ok := objectTagIdSet[tagid]; !ok {
+					return fmt.Errorf("annotation tagid not found in keypoint metadata; tagid=%s", tagid)
+				}
+			}
+		}
 	case ProjectAnnotationTypeUnknown:
 		return ErrInvalidProjectAnnotation
 	}
 
 	return nil
 }
+
+// ValidSkeletons checks each keypoint object has the keypoints of the skeleton of its tag. Tags are keyed by id.
+func (a *Annotation) ValidSkeletons(tags map[string]Tag) error {
+	for _, object := range a.Metadata.KeypointObjects {
+		tag, ok := tags[object.TagID]
+		if !ok {
+			return fmt.Errorf("annotation keypoint object tag not found; tagid=%s", object.TagID)
+		}
+		if len(object.Keypoints) != tag.Skeleton.Size() {
+			return fmt.Errorf("annotation keypoint object has %d keypoints; the skeleton of tag %s has %d",
+				len(object.Keypoints), tag.Name, tag.Skeleton.Size())
+		}
+	}
+	return nil
+}
@@ -11,13 +11,15 @@ package models
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // Test conversion from Pascal VOC to CoCo
 // https://albumentations.ai/docs/getting_started/bounding_boxes_augmentation
 func TestAnnotationConversion(t *testing.T) {
 	metatdata := AnnotationMetadata{
-		[]AnnotationDataBoundingBox{
+		BoundingBoxes: []AnnotationDataBoundingBox{
 			{
 				TagID: "",
 				Xmin:  98,
@@ -37,3 +39,84 @@ func TestAnnotationConversion(t *testing.T) {
 			98, 345, 322, 117, left, top, width, height)
 	}
 }
+
+func TestSegmentationAnnotation(t *testing.T) {
+	// 4x3 mask with a 2x2 region at x=1..2, y=1..2 (column-major)
+	mask := AnnotationDataMask{TagID: "b", Height: 3, Width: 4, Counts: []int{4, 2, 1, 2, 3}}
+	xmin, ymin, xmax, ymax := mask.Bounds()
+	assert.Equal(t, []int{1, 1, 3, 3}, []int{xmin, ymin, xmax, ymax})
+	assert.Equal(t, 4.0, mask.PixelArea())
+
+	polygon := AnnotationDataPolygon{TagID: "a", Points: []float64{0, 0, 4, 0, 4, 2, 0, 2}}
+	assert.Equal(t, 8.0, polygon.PixelArea())
+
+	metadata := AnnotationMetadata{Polygons: []AnnotationDataPolygon{polygon}, Masks: []AnnotationDataMask{mask}}
+	contentMetadata := ContentMetadata{Height: 3, Width: 4}
+	annotation := NewAnnotation("testid", "testid", "testid", "testid", []string{"a", "b"}, "", metadata, contentMetadata)
+	assert.NoError(t, annotation.Valid(ProjectAnnotationTypeSegmentation.String()))
+	assert.Equal(t, 8.0, annotation.Metadata.Polygons[0].Area)
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeBoundingBox.String()))
+
+	// Tags have to match the regions
+	annotation.TagIDs = []string{"a", "c"}
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeSegmentation.String()))
+	annotation.TagIDs = []string{"a", "b"}
+
+	// Masks have to cover the content
+	annotation.ContentMetadata = ContentMetadata{Height: 6, Width: 8}
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeSegmentation.String()))
+	annotation.ContentMetadata = contentMetadata
+
+	annotation.Metadata.Polygons[0].Points = []float64{0, 0, 4, 0, 4}
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeSegmentation.String()))
+}
+
+func TestMaskResized(t *testing.T) {
+	// 4x3 mask with a 2x2 region at x=1..2, y=1..2 (column-major)
+	mask := AnnotationDataMask{TagID: "b", Height: 3, Width: 4, Counts: []int{4, 2, 1, 2, 3}}
+
+	// Doubling the size doubles the region to x=2..5, y=2..5
+	resized := mask.Resized(8, 6)
+	assert.Equal(t, AnnotationDataMask{TagID: "b", Height: 6, Width: 8, Counts: []int{14, 4, 2, 4, 2, 4, 2, 4, 12}}, resized)
+	assert.NoError(t, resized.valid())
+	assert.Equal(t, 16.0, resized.PixelArea())
+	xmin, ymin, xmax, ymax := resized.Bounds()
+	assert.Equal(t, []int{2, 2, 6, 6}, []int{xmin, ymin, xmax, ymax})
+
+	assert.Equal(t, mask, resized.Resized(4, 3))
+
+	// Imported masks match the stored image
+	label := Label{Width: 8, Height: 6, Masks: []AnnotationDataMask{resized}}
+	assert.Equal(t, []AnnotationDataMask{mask}, label.ScaledMasks(4, 3))
+	assert.Equal(t, []AnnotationDataMask{resized}, label.ScaledMasks(8, 6))
+}
+
+func TestKeypointAnnotation(t *testing.T) {
+	skeleton := &TagSkeleton{Keypoints: []string{"head", "left_hand", "right_hand"}, Edges: [][2]int{{0, 1}, {0, 2}}}
+	assert.NoError(t, skeleton.Valid())
+	assert.Error(t, TagSkeleton{Keypoints: []string{"head", "head"}}.Valid())
+	assert.Error(t, TagSkeleton{Keypoints: []string{"head"}, Edges: [][2]int{{0, 1}}}.Valid())
+
+	person := Tag{Name: "person", Skeleton: skeleton}
+	object := AnnotationDataKeypointObject{
+		AnnotationDataBoundingBox: AnnotationDataBoundingBox{TagID: "person", Xmin: 10, Ymin: 10, Xmax: 50, Ymax: 90},
+		Keypoints: []AnnotationDataKeypoint{
+			{X: 30, Y: 15, Visibility: KeypointVisible},
+			{X: 12, Y: 50, Visibility: KeypointOccluded},
+			{},
+		},
+	}
+	assert.Equal(t, 2, object.Labelled())
+
+	metadata := AnnotationMetadata{KeypointObjects: []AnnotationDataKeypointObject{object}}
+	annotation := NewAnnotation("testid", "testid", "testid", "testid", []string{"person"}, "", metadata, ContentMetadata{})
+	assert.NoError(t, annotation.Valid(ProjectAnnotationTypeKeypoint.String()))
+	assert.NoError(t, annotation.ValidSkeletons(map[string]Tag{"person": person}))
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeBoundingBox.String()))
+
+	// Keypoints follow the skeleton of the tag
+	assert.Error(t, annotation.ValidSkeletons(map[string]Tag{"person": {Name: "person"}}))
+
+	annotation.Metadata.KeypointObjects[0].Keypoints[2].Visibility = 3
+	assert.Error(t, annotation.Valid(ProjectAnnotationTypeKeypoint.String()))
+}
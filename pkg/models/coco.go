@@ -0,0 +1,248 @@
+/*
+ * File: coco.go
+ * Project: models
+ * File Created: Tuesday, 6th February 2024 10:12:44 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 6th February 2024 10:12:44 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+)
+
+var ErrNotCOCO = errors.New("file is not in COCO format")
+
+/* Example COCO instances.json
+{
+  "images": [{"id": 1, "file_name": "image1.jpeg", "width": 640, "height": 480}],
+  "categories": [{"id": 1, "name": "cat"}],
+  "annotations": [{"id": 1, "image_id": 1, "category_id": 1, "bbox": [33, 33, 33, 33]}]
+}
+*/
+
+// COCO represents the subset of the COCO object detection format used for label import and export
+type COCO struct {
+	Images      []COCOImage      `json:"images"`
+	Categories  []COCOCategory   `json:"categories"`
+	Annotations []COCOAnnotation `json:"annotations"`
+}
+
+type COCOImage struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type COCOCategory struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Supercategory string `json:"supercategory,omitempty"`
+	// Keypoint names of keypoint categories
+	Keypoints []string `json:"keypoints,omitempty"`
+	// Pairs of keypoints, indexed from 1, joined in the skeleton
+	Skeleton [][2]int `json:"skeleton,omitempty"`
+}
+
+type COCOAnnotation struct {
+	ID         int `json:"id"`
+	ImageID    int `json:"image_id"`
+	CategoryID int `json:"category_id"`
+	// [x, y, width, height] in absolute pixels
+	BBox         []float64         `json:"bbox,omitempty"`
+	Segmentation *COCOSegmentation `json:"segmentation,omitempty"`
+	Area         float64           `json:"area,omitempty"`
+	IsCrowd      int               `json:"iscrowd"`
+	// Keypoints as consecutive x, y, visibility triples in the order of the category keypoints
+	Keypoints    []float64 `json:"keypoints,omitempty"`
+	NumKeypoints int       `json:"num_keypoints,omitempty"`
+}
+
+// COCOSegmentation is the region of a COCO annotation, either polygons or a run-length encoded mask
+type COCOSegmentation struct {
+	// Polygons as consecutive x, y pixel coordinates
+	Polygons [][]float64
+	// Mask of crowd annotations (iscrowd=1)
+	RLE *COCORLE
+}
+
+// COCORLE is a COCO run-length encoded mask. Masks are always exported uncompressed; compressed masks are
+// decoded on import.
+type COCORLE struct {
+	Counts []int `json:"counts"`
+	// [height, width]
+	Size []int `json:"size"`
+}
+
+func (s COCOSegmentation) MarshalJSON() ([]byte, error) {
+	if s.RLE != nil {
+		return json.Marshal(s.RLE)
+	}
+	return json.Marshal(s.Polygons)
+}
+
+func (s *COCOSegmentation) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		return json.Unmarshal(data, &s.Polygons)
+	}
+
+	var rle struct {
+		Counts json.RawMessage `json:"counts"`
+		Size   []int           `json:"size"`
+	}
+	if err := json.Unmarshal(data, &rle); err != nil {
+		return err
+	}
+	s.RLE = &COCORLE{Size: rle.Size}
+	if err := json.Unmarshal(rle.Counts, &s.RLE.Counts); err == nil {
+		return nil
+	}
+
+	var compressed string
+	if err := json.Unmarshal(rle.Counts, &compressed); err != nil {
+		return err
+	}
+	counts, err := decodeCOCORLE(compressed)
+	if err != nil {
+		return err
+	}
+	s.RLE.Counts = counts
+	return nil
+}
+
+// decodeCOCORLE decodes the counts of a compressed COCO mask, as encoded by the COCO API: each count is a
+// variable length sequence of 5-bit chunks offset by '0' and, from the fourth on, relative to the count two
+// places before it.
+func decodeCOCORLE(s string) ([]int, error) {
+	counts := []int{}
+	for p := 0; p < len(s); {
+		x, k, more := 0, 0, true
+		for more {
+			if p >= len(s) {
+				return nil, fmt.Errorf("truncated compressed RLE counts")
+			}
+			c := int(s[p]) - 48
+			x |= (c & 0x1f) << (5 * k)
+			more = c&0x20 != 0
+			p++
+			k++
+			if !more && c&0x10 != 0 {
+				x |= -1 << (5 * k)
+			}
+		}
+		if len(counts) > 2 {
+			x += counts[len(counts)-2]
+		}
+		counts = append(counts, x)
+	}
+	return counts, nil
+}
+
+// ParseCOCO parses a COCO annotations file. ErrNotCOCO is returned if the file is valid JSON but
+// does not describe any COCO images.
+func ParseCOCO(fileBytes []byte) (COCO, error) {
+	var coco COCO
+	if err := json.Unmarshal(fileBytes, &coco); err != nil {
+		return COCO{}, err
+	}
+	if len(coco.Images) == 0 {
+		return COCO{}, ErrNotCOCO
+	}
+	return coco, nil
+}
+
+// Labels converts COCO images and annotations into labels. Bounding boxes are only included for
+// bounding box projects, segmentation regions for segmentation projects and keypoint objects for keypoint
+// projects; classification projects are tagged with the categories found in each image.
+func (c COCO) Labels(annotationType string) Labels {
+	categories := make(map[int]string, len(c.Categories))
+	for _, category := range c.Categories {
+		categories[category.ID] = strings.ToLower(category.Name)
+	}
+
+	annotations := make(map[int][]COCOAnnotation)
+	for _, annotation := range c.Annotations {
+		annotations[annotation.ImageID] = append(annotations[annotation.ImageID], annotation)
+	}
+
+	labels := Labels{}
+	for _, image := range c.Images {
+		label := Label{
+			Tags:       []string{},
+			ExternalID: path.Base(image.FileName),
+			Width:      image.Width,
+			Height:     image.Height,
+		}
+
+		seen := make(map[string]bool)
+		for _, annotation := range annotations[image.ID] {
+			name, ok := categories[annotation.CategoryID]
+			if !ok {
+				continue
+			}
+			if !seen[name] {
+				seen[name] = true
+				label.Tags = append(label.Tags, name)
+			}
+
+			if annotationType == ProjectAnnotationTypeSegmentation.String() && annotation.Segmentation != nil {
+				for _, points := range annotation.Segmentation.Polygons {
+					label.Polygons = append(label.Polygons, AnnotationDataPolygon{TagID: name, Points: points})
+				}
+				if rle := annotation.Segmentation.RLE; rle != nil && len(rle.Size) == 2 {
+					label.Masks = append(label.Masks, AnnotationDataMask{
+						TagID:  name,
+						Height: rle.Size[0],
+						Width:  rle.Size[1],
+						Counts: rle.Counts,
+					})
+				}
+				continue
+			}
+
+			if len(annotation.BBox) != 4 {
+				continue
+			}
+			x, y, w, h := annotation.BBox[0], annotation.BBox[1], annotation.BBox[2], annotation.BBox[3]
+			box := AnnotationDataBoundingBox{
+				TagID: name,
+				Xmin:  int(math.Round(x)),
+				Ymin:  int(math.Round(y)),
+				Xmax:  int(math.Round(x + w)),
+				Ymax:  int(math.Round(y + h)),
+			}
+
+			switch annotationType {
+			case ProjectAnnotationTypeBoundingBox.String():
+				label.Metadata = append(label.Metadata, box)
+			case ProjectAnnotationTypeKeypoint.String():
+				object := AnnotationDataKeypointObject{AnnotationDataBoundingBox: box, Keypoints: []AnnotationDataKeypoint{}}
+				for i := 0; i+2 < len(annotation.Keypoints); i += 3 {
+					object.Keypoints = append(object.Keypoints, AnnotationDataKeypoint{
+						X:          annotation.Keypoints[i],
+						Y:          annotation.Keypoints[i+1],
+						Visibility: KeypointVisibility(annotation.Keypoints[i+2]),
+					})
+				}
+				label.KeypointObjects = append(label.KeypointObjects, object)
+			}
+		}
+
+		// Images without annotations are left unlabeled
+		if len(label.Tags) == 0 {
+			continue
+		}
+		labels = append(labels, label)
+	}
+
+	return labels
+}
@@ -0,0 +1,95 @@
+/*
+ * File: coco_test.go
+ * Project: models
+ * File Created: Tuesday, 6th February 2024 10:48:02 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 6th February 2024 10:48:02 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCOCOLabels(t *testing.T) {
+	fileBytes := []byte(`{
+		"images": [
+			{"id": 1, "file_name": "train/image1.jpeg", "width": 640, "height": 480},
+			{"id": 2, "file_name": "image2.jpeg", "width": 640, "height": 480}
+		],
+		"categories": [{"id": 1, "name": "Cat"}, {"id": 2, "name": "dog"}],
+		"annotations": [
+			{"id": 1, "image_id": 1, "category_id": 1, "bbox": [98, 345, 322, 117.4]},
+			{"id": 2, "image_id": 1, "category_id": 2, "bbox": [10, 10, 20, 20]},
+			{"id": 3, "image_id": 1, "category_id": 1, "bbox": [0, 0, 5, 5]}
+		]
+	}`)
+
+	labels, err := ParseLabels(fileBytes, ProjectAnnotationTypeBoundingBox.String())
+	assert.NoError(t, err)
+
+	// Images without annotations are skipped
+	assert.Len(t, labels, 1)
+	assert.Equal(t, "image1.jpeg", labels[0].ExternalID)
+	assert.Equal(t, []string{"cat", "dog"}, labels[0].Tags)
+	assert.Equal(t, AnnotationDataBoundingBox{TagID: "cat", Xmin: 98, Ymin: 345, Xmax: 420, Ymax: 462}, labels[0].Metadata[0])
+	assert.Len(t, labels[0].Metadata, 3)
+
+	// Classification projects only receive tags
+	labels, err = ParseLabels(fileBytes, ProjectAnnotationTypeClassification.String())
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+	assert.Nil(t, labels[0].Metadata)
+}
+
+func TestCOCOSegmentation(t *testing.T) {
+	fileBytes := []byte(`{
+		"images": [{"id": 1, "file_name": "image1.jpeg", "width": 4, "height": 3}],
+		"categories": [{"id": 1, "name": "scratch"}, {"id": 2, "name": "dent"}],
+		"annotations": [
+			{"id": 1, "image_id": 1, "category_id": 1, "segmentation": [[0, 0, 2, 0, 2, 2]], "bbox": [0, 0, 2, 2]},
+			{"id": 2, "image_id": 1, "category_id": 2, "segmentation": {"counts": [4, 2, 6], "size": [3, 4]}, "iscrowd": 1},
+			{"id": 3, "image_id": 1, "category_id": 2, "segmentation": {"counts": "426", "size": [3, 4]}, "iscrowd": 1}
+		]
+	}`)
+
+	labels, err := ParseLabels(fileBytes, ProjectAnnotationTypeSegmentation.String())
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+	assert.Equal(t, []string{"scratch", "dent"}, labels[0].Tags)
+	assert.Nil(t, labels[0].Metadata)
+	assert.Equal(t, []AnnotationDataPolygon{{TagID: "scratch", Points: []float64{0, 0, 2, 0, 2, 2}}}, labels[0].Polygons)
+	assert.Len(t, labels[0].Masks, 2)
+	for _, mask := range labels[0].Masks {
+		assert.Equal(t, AnnotationDataMask{TagID: "dent", Height: 3, Width: 4, Counts: []int{4, 2, 6}}, mask)
+	}
+
+	// Masks are written uncompressed
+	segmentation, err := json.Marshal(COCOSegmentation{RLE: &COCORLE{Counts: []int{4, 2, 6}, Size: []int{3, 4}}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"counts": [4, 2, 6], "size": [3, 4]}`, string(segmentation))
+}
+
+func TestCOCOKeypoints(t *testing.T) {
+	fileBytes := []byte(`{
+		"images": [{"id": 1, "file_name": "image1.jpeg", "width": 640, "height": 480}],
+		"categories": [{"id": 1, "name": "person", "keypoints": ["head", "hand"], "skeleton": [[1, 2]]}],
+		"annotations": [
+			{"id": 1, "image_id": 1, "category_id": 1, "bbox": [10, 10, 40, 80], "keypoints": [30, 15, 2, 0, 0, 0], "num_keypoints": 1}
+		]
+	}`)
+
+	labels, err := ParseLabels(fileBytes, ProjectAnnotationTypeKeypoint.String())
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+	assert.Nil(t, labels[0].Metadata)
+	assert.Equal(t, []AnnotationDataKeypointObject{{
+		AnnotationDataBoundingBox: AnnotationDataBoundingBox{TagID: "person", Xmin: 10, Ymin: 10, Xmax: 50, Ymax: 90},
+		Keypoints:                 []AnnotationDataKeypoint{{X: 30, Y: 15, Visibility: KeypointVisible}, {}},
+	}}, labels[0].KeypointObjects)
+}
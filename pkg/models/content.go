@@ -43,6 +43,12 @@ type Content struct {
 	// Content width
 	//
 	Width int `json:"width" bson:"width"`
+	// Height of the uploaded image before it was resized for storage
+	//
+	OriginalHeight int `json:"original_height,omitempty" bson:"original_height,omitempty"`
+	// Width of the uploaded image before it was resized for storage
+	//
+	OriginalWidth int `json:"original_width,omitempty" bson:"original_width,omitempty"`
 	// Content b64 string
 	//
 	Base64Image string `json:"b64_image" bson:"b64_image"`
@@ -80,3 +86,10 @@ func NewContentID(hash, userid string) string {
 	hmd5 := md5.Sum([]byte(userid + hash))
 	return hex.EncodeToString(hmd5[:])
 }
+
+// SetOriginalDimensions records the dimensions of the uploaded image before it was resized for storage.
+// Labels imported alongside the image are rescaled from them; annotations and exports use the stored size.
+func (c *Content) SetOriginalDimensions(height, width int) {
+	c.OriginalHeight = height
+	c.OriginalWidth = width
+}
@@ -12,6 +12,8 @@ package models
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,9 +25,61 @@ const (
 	DefaultTestSplit       = 0.00
 )
 
+// DatasetCopyState is an enum for the state of a dataset version while it is copied or removed
+type DatasetCopyState int
+
+const (
+	DatasetCopyStateComplete DatasetCopyState = iota
+	DatasetCopyStatePending
+	DatasetCopyStateDeleting
+	// Labels of a restored version are copied; its tags are being merged into the editable dataset and annotations
+	// of content it does not label removed from it
+	DatasetCopyStateRestoring
+	// The copied annotations of a restored version are being applied to the editable dataset
+	DatasetCopyStateSwapping
+)
+
+func (s DatasetCopyState) String() string {
+	return [...]string{"", "PENDING", "DELETING", "RESTORING", "SWAPPING"}[s]
+}
+
+// SplitStrategy is an enum for how annotations are assigned to train, validation and test splits
+type SplitStrategy int
+
+const (
+	// Shuffled with the dataset's seed and split by count
+	SplitStrategyRandom SplitStrategy = iota
+	// Split by count within each tag so that rare tags appear in every split
+	SplitStrategyStratified
+	// Splits set on annotations are kept; remaining annotations are split randomly
+	SplitStrategyManual
+)
+
+func (s SplitStrategy) String() string {
+	return [...]string{"RANDOM", "STRATIFIED", "MANUAL"}[s]
+}
+
+// SplitStrategyFromString returns the split strategy for a string; datasets created before split strategies
+// were introduced have none and are split randomly.
+func SplitStrategyFromString(str string) (SplitStrategy, error) {
+	switch strings.ToUpper(str) {
+	case "", "RANDOM":
+		return SplitStrategyRandom, nil
+	case "STRATIFIED":
+		return SplitStrategyStratified, nil
+	case "MANUAL":
+		return SplitStrategyManual, nil
+	default:
+		return SplitStrategyRandom, ErrInvalidSplitStrategy
+	}
+}
+
 // ErrInvalidDatasetSplit is an error return when an invalid train, validation, test split is specified
 var ErrInvalidDatasetSplit = fmt.Errorf("train, validation, and test dataset split must sum to 1.0; train and validation splits cannot be 0")
 
+// ErrInvalidSplitStrategy is an error returned when an unknown split strategy is specified
+var ErrInvalidSplitStrategy = fmt.Errorf("split strategy must be one of random, stratified or manual")
+
 // Dataset represents dataset domain model
 //
 // swagger:model Dataset
@@ -47,6 +101,12 @@ type Dataset struct {
 		Validation float64 `json:"validation" bson:"validation"`
 		Test       float64 `json:"test" bson:"test"`
 	} `json:"split" bson:"split"`
+	// Strategy used to assign annotations to splits when training
+	//
+	SplitStrategy string `json:"split_strategy" bson:"split_strategy,omitempty"`
+	// Seed used to shuffle annotations, so that trainings of the same dataset see the same splits
+	//
+	SplitSeed int64 `json:"split_seed" bson:"split_seed,omitempty"`
 	// Dataset lock status - datasets created and attached to models are locked.
 	// Datasets that are Locked cannot have tags or annotation deleted.
 	//
@@ -54,6 +114,20 @@ type Dataset struct {
 	// Version of dataset
 	//
 	Version int `json:"version" bson:"version"`
+	// Copy state of a version whose tags and annotations are being copied or removed; empty once complete.
+	// Versions left behind by a crash are periodically removed, or their restore finished, by the model service.
+	//
+	CopyState string `json:"-" bson:"copy_state,omitempty"`
+	// Dataset this version was copied from
+	//
+	CopiedFrom string `json:"-" bson:"copied_from,omitempty"`
+	// Editable dataset whose labels are replaced by this copy when a version is restored
+	//
+	RestoreTo string `json:"-" bson:"restore_to,omitempty"`
+	// Copy of a version being restored into this editable dataset; its tags, annotations and split settings
+	// cannot be edited until the restore is finished
+	//
+	Restoring string `json:"-" bson:"restoring,omitempty"`
 
 	CreatedAt time.Time `json:"created_at" bson:"created_at" export:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
@@ -72,10 +146,12 @@ func NewDataset(userid, projectid string) *Dataset {
 			Validation float64 "json:\"validation\" bson:\"validation\""
 			Test       float64 "json:\"test\" bson:\"test\""
 		}{DefaultTrainSplit, DefaultValidationSplit, DefaultTestSplit},
-		Locked:    false,
-		Version:   0,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		SplitStrategy: SplitStrategyRandom.String(),
+		SplitSeed:     rand.Int63(),
+		Locked:        false,
+		Version:       0,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 }
 
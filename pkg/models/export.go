@@ -40,6 +40,17 @@ const (
 	ExportTypeModel   ExportType = "MODEL"
 )
 
+// ExportFormat is the annotation format of a dataset export
+type ExportFormat string
+
+const (
+	ExportFormatEmerald  ExportFormat = "EMERALD"
+	ExportFormatCOCO     ExportFormat = "COCO"
+	ExportFormatVOC      ExportFormat = "VOC"
+	ExportFormatYOLO     ExportFormat = "YOLO"
+	ExportFormatManifest ExportFormat = "MANIFEST"
+)
+
 // Export represents export domain model
 //
 // swagger:model Export
@@ -54,6 +65,12 @@ type Export struct {
 	// Type of export -> maps the type to the id
 	//
 	Type ExportType `json:"type" bson:"type" export:"type"`
+	// Annotation format of a dataset export
+	//
+	Format ExportFormat `json:"format,omitempty" bson:"format,omitempty" export:"format"`
+	// Partition a dataset export into train, validation and test folders
+	//
+	Split bool `json:"split,omitempty" bson:"split,omitempty" export:"split"`
 	// UserID associated with Export
 	//
 	UserID string `json:"userid" bson:"userid" export:"userid"`
@@ -66,6 +83,9 @@ type Export struct {
 	// Last error associated with this Export
 	//
 	LastError string `json:"error" bson:"error"`
+	// Labels left out of a dataset export because their tag is not a class of the dataset
+	//
+	SkippedLabels int `json:"skipped_labels,omitempty" bson:"skipped_labels,omitempty"`
 	// Content keys
 	//
 	ContentKeys []string `json:"content_keys" bson:"content_keys" export:"content_keys"`
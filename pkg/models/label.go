@@ -10,10 +10,12 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"strings"
 
@@ -52,6 +54,29 @@ type Label struct {
 	//
 	//
 	Metadata []AnnotationDataBoundingBox `json:"bounding_boxes"`
+	// Segmentation regions; only used in segmentation projects
+	//
+	Polygons []AnnotationDataPolygon `json:"polygons,omitempty"`
+	Masks    []AnnotationDataMask    `json:"masks,omitempty"`
+	// Keypoint objects; only used in keypoint projects
+	//
+	KeypointObjects []AnnotationDataKeypointObject `json:"keypoint_objects,omitempty"`
+	// Dimensions of the image the bounding boxes were drawn on. When set, boxes are rescaled to the
+	// dimensions of the stored content.
+	//
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Bounding boxes relative to the image dimensions e.g. imported from YOLO
+	NormalizedBoxes []NormalizedBoundingBox `json:"-"`
+}
+
+// NormalizedBoundingBox represents a bounding box with coordinates in the range [0, 1]
+type NormalizedBoundingBox struct {
+	TagID string
+	Xmin  float64
+	Ymin  float64
+	Xmax  float64
+	Ymax  float64
 }
 
 type Labels []Label
@@ -78,6 +103,108 @@ func (l *Label) Normalize() *Label {
 	return l
 }
 
+// BoundingBoxes returns the bounding boxes of the label in absolute pixels for an image of the given dimensions
+func (l Label) BoundingBoxes(width, height int) []AnnotationDataBoundingBox {
+	boxes := []AnnotationDataBoundingBox{}
+
+	scaleX, scaleY := 1.0, 1.0
+	if l.Width > 0 && l.Height > 0 {
+		scaleX, scaleY = float64(width)/float64(l.Width), float64(height)/float64(l.Height)
+	}
+	for _, bbox := range l.Metadata {
+		boxes = append(boxes, AnnotationDataBoundingBox{
+			TagID: bbox.TagID,
+			Xmin:  int(math.Round(float64(bbox.Xmin) * scaleX)),
+			Ymin:  int(math.Round(float64(bbox.Ymin) * scaleY)),
+			Xmax:  int(math.Round(float64(bbox.Xmax) * scaleX)),
+			Ymax:  int(math.Round(float64(bbox.Ymax) * scaleY)),
+		})
+	}
+
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(1, v)) }
+	for _, bbox := range l.NormalizedBoxes {
+		boxes = append(boxes, AnnotationDataBoundingBox{
+			TagID: bbox.TagID,
+			Xmin:  int(math.Round(clamp(bbox.Xmin) * float64(width))),
+			Ymin:  int(math.Round(clamp(bbox.Ymin) * float64(height))),
+			Xmax:  int(math.Round(clamp(bbox.Xmax) * float64(width))),
+			Ymax:  int(math.Round(clamp(bbox.Ymax) * float64(height))),
+		})
+	}
+
+	return boxes
+}
+
+// ScaledPolygons returns the polygons of the label in absolute pixels for an image of the given dimensions
+func (l Label) ScaledPolygons(width, height int) []AnnotationDataPolygon {
+	polygons := []AnnotationDataPolygon{}
+
+	scaleX, scaleY := 1.0, 1.0
+	if l.Width > 0 && l.Height > 0 {
+		scaleX, scaleY = float64(width)/float64(l.Width), float64(height)/float64(l.Height)
+	}
+	for _, polygon := range l.Polygons {
+		points := make([]float64, len(polygon.Points))
+		for i, v := range polygon.Points {
+			if i%2 == 0 {
+				points[i] = v * scaleX
+			} else {
+				points[i] = v * scaleY
+			}
+		}
+		polygons = append(polygons, AnnotationDataPolygon{TagID: polygon.TagID, Points: points})
+	}
+
+	return polygons
+}
+
+// ScaledMasks returns the masks of the label resampled to the given image dimensions
+func (l Label) ScaledMasks(width, height int) []AnnotationDataMask {
+	masks := []AnnotationDataMask{}
+
+	for _, mask := range l.Masks {
+		if mask.Width != width || mask.Height != height {
+			mask = mask.Resized(width, height)
+		}
+		masks = append(masks, mask)
+	}
+
+	return masks
+}
+
+// ScaledKeypointObjects returns the keypoint objects of the label in absolute pixels for an image of the given
+// dimensions
+func (l Label) ScaledKeypointObjects(width, height int) []AnnotationDataKeypointObject {
+	objects := []AnnotationDataKeypointObject{}
+
+	scaleX, scaleY := 1.0, 1.0
+	if l.Width > 0 && l.Height > 0 {
+		scaleX, scaleY = float64(width)/float64(l.Width), float64(height)/float64(l.Height)
+	}
+	for _, object := range l.KeypointObjects {
+		scaled := AnnotationDataKeypointObject{
+			AnnotationDataBoundingBox: AnnotationDataBoundingBox{
+				TagID: object.TagID,
+				Xmin:  int(math.Round(float64(object.Xmin) * scaleX)),
+				Ymin:  int(math.Round(float64(object.Ymin) * scaleY)),
+				Xmax:  int(math.Round(float64(object.Xmax) * scaleX)),
+				Ymax:  int(math.Round(float64(object.Ymax) * scaleY)),
+			},
+			Keypoints: []AnnotationDataKeypoint{},
+		}
+		for _, keypoint := range object.Keypoints {
+			scaled.Keypoints = append(scaled.Keypoints, AnnotationDataKeypoint{
+				X:          keypoint.X * scaleX,
+				Y:          keypoint.Y * scaleY,
+				Visibility: keypoint.Visibility,
+			})
+		}
+		objects = append(objects, scaled)
+	}
+
+	return objects
+}
+
 // Check for labels file and create label-map if found
 func ParseLabelsFromFile(labelsFile string, files []*multipart.FileHeader) (Labels, error) {
 	labelSlice := []Label{}
@@ -105,6 +232,24 @@ func ParseLabelsFromFile(labelsFile string, files []*multipart.FileHeader) (Labe
 	return labelSlice, nil
 }
 
+// ParseLabels parses the contents of a labels file. Both the Emerald labels format and COCO are supported.
+func ParseLabels(fileBytes []byte, annotationType string) (Labels, error) {
+	// COCO files are a single JSON object, Emerald labels files are a list
+	if trimmed := bytes.TrimSpace(fileBytes); len(trimmed) > 0 && trimmed[0] == '{' {
+		coco, err := ParseCOCO(fileBytes)
+		if err != nil {
+			return nil, err
+		}
+		return coco.Labels(annotationType), nil
+	}
+
+	labelSlice := []Label{}
+	if err := json.Unmarshal(fileBytes, &labelSlice); err != nil {
+		return nil, err
+	}
+	return labelSlice, nil
+}
+
 // Validate labels and create label-map
 func (l Labels) Validate(annotationType string) LabelMap {
 	labelMap := make(map[string]Label)
@@ -133,20 +278,36 @@ func (l Labels) Validate(annotationType string) LabelMap {
 }
 
 func (l Label) validateLabelType(t string) error {
+	if t != ProjectAnnotationTypeSegmentation.String() && (len(l.Polygons) > 0 || len(l.Masks) > 0) {
+		log.Errorf("Unexpected format for %s project; label=%+v", t, l)
+		return errors.New("invalid label format; segmentation metadata found in non-segmentation project")
+	}
+	if t != ProjectAnnotationTypeKeypoint.String() && len(l.KeypointObjects) > 0 {
+		log.Errorf("Unexpected format for %s project; label=%+v", t, l)
+		return errors.New("invalid label format; keypoint metadata found in non-keypoint project")
+	}
+
 	if t == ProjectAnnotationTypeBoundingBox.String() {
-		if l.Metadata == nil {
+		if l.Metadata == nil && l.NormalizedBoxes == nil {
 			log.Errorf("Unexpected format for bounding box project; label=%+v", l)
 			return errors.New("invalid label format; label does not contain bounding box metadata")
 		} else {
 			return nil
 		}
 	} else if t == ProjectAnnotationTypeClassification.String() {
-		if len(l.Metadata) > 0 {
+		if len(l.Metadata) > 0 || len(l.NormalizedBoxes) > 0 {
 			log.Errorf("Unexpected format for classification project; label=%+v", l)
 			return errors.New("invalid label format; bounding box metadata found in classification project")
 		} else {
 			return nil
 		}
+	} else if t == ProjectAnnotationTypeSegmentation.String() || t == ProjectAnnotationTypeKeypoint.String() {
+		if len(l.Metadata) > 0 || len(l.NormalizedBoxes) > 0 {
+			log.Errorf("Unexpected format for %s project; label=%+v", t, l)
+			return fmt.Errorf("invalid label format; bounding box metadata found in %s project", t)
+		} else {
+			return nil
+		}
 	} else {
 		return errors.New("invalid annotation type; unknown annotation type")
 	}
@@ -0,0 +1,54 @@
+/*
+ * File: label_test.go
+ * Project: models
+ * File Created: Wednesday, 7th February 2024 11:20:40 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 7th February 2024 11:20:40 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVOCLabel(t *testing.T) {
+	fileBytes := []byte(`<annotation>
+		<filename>image1.jpeg</filename>
+		<size><width>2560</width><height>1920</height><depth>3</depth></size>
+		<object><name>Cat</name><bndbox><xmin>100</xmin><ymin>200</ymin><xmax>300.4</xmax><ymax>400</ymax></bndbox></object>
+	</annotation>`)
+
+	label, err := ParseVOC("annotations/image1.xml", fileBytes, ProjectAnnotationTypeBoundingBox.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "image1.jpeg", label.ExternalID)
+	assert.Equal(t, "image1", label.InternalID)
+	assert.Equal(t, []string{"cat"}, label.Tags)
+
+	// Content stored at half the source resolution
+	boxes := label.BoundingBoxes(1280, 960)
+	assert.Equal(t, []AnnotationDataBoundingBox{{TagID: "cat", Xmin: 50, Ymin: 100, Xmax: 150, Ymax: 200}}, boxes)
+}
+
+func TestYOLOLabel(t *testing.T) {
+	classes := ParseYOLOClasses([]byte("cat\nDog\n"))
+	assert.Equal(t, []string{"cat", "dog"}, classes)
+
+	label, err := ParseYOLO("labels/image1.txt", []byte("1 0.5 0.5 0.25 0.5\n\n0 0.1 0.1 0.4 0.4\n"), classes, ProjectAnnotationTypeBoundingBox.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "image1", label.ExternalID)
+	assert.Equal(t, []string{"dog", "cat"}, label.Tags)
+
+	// Boxes extending past the image are clamped
+	boxes := label.BoundingBoxes(640, 480)
+	assert.Equal(t, []AnnotationDataBoundingBox{
+		{TagID: "dog", Xmin: 240, Ymin: 120, Xmax: 400, Ymax: 360},
+		{TagID: "cat", Xmin: 0, Ymin: 0, Xmax: 192, Ymax: 144},
+	}, boxes)
+
+	_, err = ParseYOLO("labels/image2.txt", []byte("0 0.5 0.5\n"), classes, ProjectAnnotationTypeBoundingBox.String())
+	assert.Error(t, err)
+}
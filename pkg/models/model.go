@@ -26,10 +26,11 @@ const (
 	ModelStateTrained
 	ModelStateTraining
 	ModelStateErr
+	ModelStateCancelled
 )
 
 func (s ModelState) String() string {
-	return [...]string{"UNKNOWN", "INITIALIZED", "TRAINED", "TRAINING", "ERR"}[s]
+	return [...]string{"UNKNOWN", "INITIALIZED", "TRAINED", "TRAINING", "ERR", "CANCELLED"}[s]
 }
 
 // Model represents model domain model
@@ -46,6 +47,9 @@ type Model struct {
 	// Training job name - internal name associated with Sagemaker job
 	//
 	TrainingJobName string `json:"-" bson:"training_job_name"`
+	// Backend the model was trained on i.e. "sagemaker" or "local"; empty for models trained before backends
+	//
+	TrainingBackend string `json:"-" bson:"training_backend,omitempty"`
 	// UserID associated with Model
 	//
 	UserID string `json:"userid" bson:"userid"`
@@ -58,12 +62,21 @@ type Model struct {
 	// Model filepath
 	//
 	Path string `json:"-" bson:"path"`
+	// Location of the trained model artifact i.e. model.tar.gz
+	//
+	ArtifactPath string `json:"-" bson:"artifact_path,omitempty"`
+	// Hyperparameters of the selected training job
+	//
+	HyperParameters map[string]string `json:"-" bson:"hyperparameters,omitempty"`
 	// Model metrics
 	//
 	Metrics map[string]interface{} `json:"metrics" bson:"metrics"`
 	// State of the current model
 	//
 	State string `json:"state" bson:"state"`
+	// Training run the state belongs to; renewed whenever training is queued
+	//
+	TrainRunID string `json:"-" bson:"train_run_id,omitempty"`
 	// Last error (if any) associated with the model
 	//
 	LastError *string `json:"error" bson:"error"`
@@ -73,12 +86,19 @@ type Model struct {
 	// Optional Train Parameters
 	//
 	Parameters TrainParameters `json:"parameters" bson:"parameters"`
+	// Train parameters of the last training run, with the defaults filled in; training with them as the model's
+	// parameters reproduces the run
+	//
+	TrainedParameters *TrainParameters `json:"trained_parameters,omitempty" bson:"trained_parameters,omitempty"`
 	// Preprocessing
 	//
 	Preprocessing Preprocessors `json:"preprocessing" bson:"preprocessing"`
 	// Augmentation
 	//
 	Augmentation Augmentations `json:"augmentation" bson:"augmentation"`
+	// Train only on approved annotations
+	//
+	ApprovedOnly *bool `json:"approved_only,omitempty" bson:"approved_only,omitempty"`
 	// Deployment Info
 	//
 	Deployment Deployment `json:"deployment" bson:"deployment"`
@@ -135,6 +155,9 @@ type TrainParameters struct {
 	// "epochs":               	<int>			// The number of training epochs.
 	// "lr_scheduler_step":    	<int>			// The epochs at which to reduce the learning rate. The learning rate is reduced by lr_scheduler_factor at epochs listed in a comma-delimited string: "epoch1, epoch2, ...". For example, if the value is set to "10, 20" and the lr_scheduler_factor is set to 1/2, then the learning rate is halved after 10th epoch and then halved again after 20th epoch.
 	// "lr_scheduler_factor":  	<float(0:1)>	// The ratio to reduce learning rate. Used in conjunction with the lr_scheduler_step parameter defined as lr_new = lr_old * lr_scheduler_factor.
+	// =========================================
+	// A value pins a parameter. The tuned parameters "learning_rate", "weight_decay", "momentum" and "mini_batch_size"
+	// also take a [min, max] range and "optimizer" a list of values, narrowing the values tried.
 	HyperParameters map[string]interface{} `json:"hyperparameters,omitempty"`
 }
 
@@ -178,3 +201,14 @@ func (m *Model) Key() string {
 	u, _ := url.Parse(m.Path)
 	return strings.TrimPrefix(u.Path, "/")
 }
+
+// Artifact returns the bucket and key of the trained model artifact. Models trained before the artifact
+// path was recorded fall back to the location SageMaker writes the artifact to.
+func (m *Model) Artifact() (bucket, key string) {
+	artifactPath := m.ArtifactPath
+	if artifactPath == "" {
+		artifactPath = m.Path + "/" + path.Join(m.TrainingJobName, "output", "model.tar.gz")
+	}
+	u, _ := url.Parse(artifactPath)
+	return u.Host, strings.TrimPrefix(u.Path, "/")
+}
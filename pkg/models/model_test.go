@@ -0,0 +1,29 @@
+/*
+ * File: model_test.go
+ * Project: models
+ * File Created: Friday, 9th February 2024 11:20:35 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 9th February 2024 11:20:35 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelArtifact(t *testing.T) {
+	model := Model{Path: "s3://bucket/user/models/123", TrainingJobName: "job-1"}
+
+	bucket, key := model.Artifact()
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "user/models/123/job-1/output/model.tar.gz", key)
+
+	model.ArtifactPath = "s3://other/path/model.tar.gz"
+	bucket, key = model.Artifact()
+	assert.Equal(t, "other", bucket)
+	assert.Equal(t, "path/model.tar.gz", key)
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 )
 
+// DefaultAugmentationCopies is the number of augmented copies of each training image when augmentations are set
+const DefaultAugmentationCopies = 1
+
 var (
 	ErrInvalidResizeMode = errors.New("invalid preprocessor 'resize'; mode must be one of stretch, fit, fill")
 )
@@ -35,6 +38,11 @@ type Augmentations struct {
 	RandomErasing   *RandomErasing   `json:"random_erasing,omitempty" bson:"random_erasing,omitempty"`
 	Noise           *Noise           `json:"noise,omitempty" bson:"noise,omitempty"`
 	JpegCompression *JpegCompression `json:"jpeg_compression,omitempty" bson:"jpeg_compression,omitempty"`
+	// Number of augmented copies trained on alongside each training image; defaults to DefaultAugmentationCopies
+	// example: 3
+	// min: 1
+	// max: 10
+	Copies int `json:"copies,omitempty" bson:"copies,omitempty" validate:"omitempty,min=1,max=10"`
 }
 
 func (a *Augmentations) ToJSON() ([]byte, error) {
@@ -18,7 +18,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var ErrInvalidProjectAnnotation = echo.NewHTTPError(http.StatusBadRequest, "Invalid project annotation type. Acceptable options include: classification or bounding_box.")
+var ErrInvalidProjectAnnotation = echo.NewHTTPError(http.StatusBadRequest, "Invalid project annotation type. Acceptable options include: classification, bounding_box, segmentation or keypoint.")
 var ErrAnnotationMismatch = echo.NewHTTPError(http.StatusBadRequest, "Annotation type does not match project annotation type")
 
 type ProjectAnnotationType int
@@ -27,10 +27,12 @@ const (
 	ProjectAnnotationTypeUnknown ProjectAnnotationType = iota
 	ProjectAnnotationTypeClassification
 	ProjectAnnotationTypeBoundingBox
+	ProjectAnnotationTypeSegmentation
+	ProjectAnnotationTypeKeypoint
 )
 
 func (a ProjectAnnotationType) String() string {
-	return [...]string{"unknown", "classification", "bounding_box"}[a]
+	return [...]string{"unknown", "classification", "bounding_box", "segmentation", "keypoint"}[a]
 }
 
 func ProjectAnnotationTypeFromString(str string) (ProjectAnnotationType, error) {
@@ -40,6 +42,10 @@ func ProjectAnnotationTypeFromString(str string) (ProjectAnnotationType, error)
 		return ProjectAnnotationTypeClassification, nil
 	case "bounding_box":
 		return ProjectAnnotationTypeBoundingBox, nil
+	case "segmentation":
+		return ProjectAnnotationTypeSegmentation, nil
+	case "keypoint":
+		return ProjectAnnotationTypeKeypoint, nil
 	default:
 		return ProjectAnnotationTypeUnknown, ErrInvalidProjectAnnotation
 	}
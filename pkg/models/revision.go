@@ -0,0 +1,167 @@
+/*
+ * File: revision.go
+ * Project: models
+ * File Created: Thursday, 15th February 2024 2:12:47 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 15th February 2024 2:12:47 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RevisionAction is an enum for the change recorded by an annotation revision
+type RevisionAction int
+
+const (
+	RevisionActionCreate RevisionAction = iota
+	RevisionActionUpdate
+	RevisionActionDelete
+	RevisionActionRevert
+)
+
+func (r RevisionAction) String() string {
+	return [...]string{"CREATE", "UPDATE", "DELETE", "REVERT"}[r]
+}
+
+// AnnotationState is the labelling of an annotation at a point in time
+type AnnotationState struct {
+	// Tag IDs
+	//
+	TagIDs []string `json:"tagids" bson:"tagids"`
+	// Annotation metadata
+	//
+	Metadata AnnotationMetadata `json:"metadata" bson:"metadata"`
+}
+
+// Equal reports whether two states have the same tags, bounding boxes, segmentation regions and keypoint objects
+func (s *AnnotationState) Equal(o *AnnotationState) bool {
+	if s == nil || o == nil {
+		return s == o
+	}
+	if len(s.TagIDs) != len(o.TagIDs) || len(s.Metadata.BoundingBoxes) != len(o.Metadata.BoundingBoxes) ||
+		len(s.Metadata.Polygons) != len(o.Metadata.Polygons) || len(s.Metadata.Masks) != len(o.Metadata.Masks) ||
+		len(s.Metadata.KeypointObjects) != len(o.Metadata.KeypointObjects) {
+		return false
+	}
+	return (len(s.TagIDs) == 0 || reflect.DeepEqual(s.TagIDs, o.TagIDs)) &&
+		(len(s.Metadata.BoundingBoxes) == 0 || reflect.DeepEqual(s.Metadata.BoundingBoxes, o.Metadata.BoundingBoxes)) &&
+		(len(s.Metadata.Polygons) == 0 || reflect.DeepEqual(s.Metadata.Polygons, o.Metadata.Polygons)) &&
+		(len(s.Metadata.Masks) == 0 || reflect.DeepEqual(s.Metadata.Masks, o.Metadata.Masks)) &&
+		(len(s.Metadata.KeypointObjects) == 0 || reflect.DeepEqual(s.Metadata.KeypointObjects, o.Metadata.KeypointObjects))
+}
+
+// State returns the labelling of the annotation
+func (a *Annotation) State() *AnnotationState {
+	if a == nil {
+		return nil
+	}
+	return &AnnotationState{
+		TagIDs: append([]string{}, a.TagIDs...),
+		Metadata: AnnotationMetadata{
+			BoundingBoxes:   append([]AnnotationDataBoundingBox{}, a.Metadata.BoundingBoxes...),
+			Polygons:        append([]AnnotationDataPolygon{}, a.Metadata.Polygons...),
+			Masks:           append([]AnnotationDataMask{}, a.Metadata.Masks...),
+			KeypointObjects: append([]AnnotationDataKeypointObject{}, a.Metadata.KeypointObjects...),
+		},
+	}
+}
+
+// AnnotationRevision is an immutable record of a change to an annotation
+//
+// swagger:model AnnotationRevision
+type AnnotationRevision struct {
+	// ID of the revision
+	//
+	// swagger:strfmt bsonobjectid
+	ID primitive.ObjectID `json:"id" bson:"_id"`
+	// Owner of the annotation
+	//
+	UserID string `json:"userid" bson:"userid"`
+	// User who made the change, e.g. the labeller or reviewer of a labelling task of the owner
+	//
+	AuthorID string `json:"authorid" bson:"authorid"`
+	// Annotation changed
+	//
+	AnnotationID string `json:"annotationid" bson:"annotationid"`
+	// Project of the annotation
+	//
+	ProjectID string `json:"projectid" bson:"projectid"`
+	// Dataset of the annotation
+	//
+	DatasetID string `json:"datasetid" bson:"datasetid"`
+	// Content of the annotation
+	//
+	ContentID string `json:"contentid" bson:"contentid"`
+	// One of CREATE, UPDATE, DELETE or REVERT
+	//
+	Action string `json:"action" bson:"action"`
+	// Labelling before the change; empty when the annotation was created
+	//
+	Previous *AnnotationState `json:"previous" bson:"previous"`
+	// Labelling after the change; empty when the annotation was deleted
+	//
+	Current *AnnotationState `json:"current" bson:"current"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// NewAnnotationRevision records a change of an annotation from previous to current. Previous is nil for a
+// created annotation and current is nil for a deleted one.
+func NewAnnotationRevision(userid, authorid string, action RevisionAction, previous, current *Annotation) AnnotationRevision {
+	annotation := current
+	if annotation == nil {
+		annotation = previous
+	}
+
+	return AnnotationRevision{
+		ID:           primitive.NewObjectID(),
+		UserID:       userid,
+		AuthorID:     authorid,
+		AnnotationID: annotation.ID.Hex(),
+		ProjectID:    annotation.ProjectID,
+		DatasetID:    annotation.DatasetID,
+		ContentID:    annotation.ContentID,
+		Action:       action.String(),
+		Previous:     previous.State(),
+		Current:      current.State(),
+		CreatedAt:    time.Now(),
+	}
+}
+
+// AnnotationRevisions records the changes between annotations before and after a bulk change. Annotations only
+// in previous were deleted, annotations only in current were created and unchanged annotations are skipped.
+func AnnotationRevisions(userid string, previous, current []Annotation) []AnnotationRevision {
+	revisions := []AnnotationRevision{}
+
+	after := make(map[primitive.ObjectID]*Annotation, len(current))
+	for i := range current {
+		after[current[i].ID] = &current[i]
+	}
+
+	for i := range previous {
+		before := &previous[i]
+		annotation, ok := after[before.ID]
+		switch {
+		case !ok:
+			revisions = append(revisions, NewAnnotationRevision(userid, userid, RevisionActionDelete, before, nil))
+		case !before.State().Equal(annotation.State()):
+			revisions = append(revisions, NewAnnotationRevision(userid, userid, RevisionActionUpdate, before, annotation))
+		}
+		delete(after, before.ID)
+	}
+
+	for i := range current {
+		if annotation, ok := after[current[i].ID]; ok {
+			revisions = append(revisions, NewAnnotationRevision(userid, userid, RevisionActionCreate, nil, annotation))
+		}
+	}
+
+	return revisions
+}
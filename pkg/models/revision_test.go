@@ -0,0 +1,63 @@
+/*
+ * File: revision_test.go
+ * Project: models
+ * File Created: Thursday, 15th February 2024 2:40:05 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 15th February 2024 2:40:05 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotationRevisions(t *testing.T) {
+	box := AnnotationMetadata{BoundingBoxes: []AnnotationDataBoundingBox{{TagID: "a", Xmin: 1, Ymin: 1, Xmax: 5, Ymax: 5}}}
+	unchanged := NewAnnotation("user", "project", "dataset", "content1", []string{"a"}, "", box, ContentMetadata{})
+	relabelled := NewAnnotation("user", "project", "dataset", "content2", []string{"a"}, "", box, ContentMetadata{})
+	deleted := NewAnnotation("user", "project", "dataset", "content3", []string{"a"}, "", AnnotationMetadata{}, ContentMetadata{})
+	created := NewAnnotation("user", "project", "dataset", "content4", []string{"b"}, "", AnnotationMetadata{}, ContentMetadata{})
+
+	after := *relabelled
+	after.TagIDs = []string{"b"}
+	after.Metadata = AnnotationMetadata{BoundingBoxes: []AnnotationDataBoundingBox{{TagID: "b", Xmin: 1, Ymin: 1, Xmax: 5, Ymax: 5}}}
+
+	// Annotations read back from the database are compared by value
+	reloaded := *unchanged
+	reloaded.Metadata = AnnotationMetadata{BoundingBoxes: append([]AnnotationDataBoundingBox{}, box.BoundingBoxes...)}
+
+	revisions := AnnotationRevisions("user",
+		[]Annotation{*unchanged, *relabelled, *deleted},
+		[]Annotation{reloaded, after, *created},
+	)
+
+	assert.Len(t, revisions, 3)
+
+	assert.Equal(t, relabelled.ID.Hex(), revisions[0].AnnotationID)
+	assert.Equal(t, RevisionActionUpdate.String(), revisions[0].Action)
+	assert.Equal(t, []string{"a"}, revisions[0].Previous.TagIDs)
+	assert.Equal(t, "b", revisions[0].Current.Metadata.BoundingBoxes[0].TagID)
+
+	assert.Equal(t, deleted.ID.Hex(), revisions[1].AnnotationID)
+	assert.Equal(t, RevisionActionDelete.String(), revisions[1].Action)
+	assert.Nil(t, revisions[1].Current)
+
+	assert.Equal(t, "user", revisions[1].AuthorID)
+
+	assert.Equal(t, created.ID.Hex(), revisions[2].AnnotationID)
+	assert.Equal(t, RevisionActionCreate.String(), revisions[2].Action)
+	assert.Nil(t, revisions[2].Previous)
+}
+
+func TestNewAnnotationRevisionAuthor(t *testing.T) {
+	annotation := NewAnnotation("owner", "project", "dataset", "content", []string{"a"}, "", AnnotationMetadata{}, ContentMetadata{})
+
+	revision := NewAnnotationRevision("owner", "labeller", RevisionActionCreate, nil, annotation)
+
+	assert.Equal(t, "owner", revision.UserID)
+	assert.Equal(t, "labeller", revision.AuthorID)
+}
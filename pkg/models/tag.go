@@ -10,6 +10,7 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -51,6 +52,12 @@ type Tag struct {
 	// Tag properties
 	//
 	Property []string `json:"property" bson:"property"`
+	// Parent tag, for taxonomies such as truck under vehicle; empty for top level tags
+	//
+	ParentID string `json:"parentid,omitempty" bson:"parentid,omitempty"`
+	// Keypoints of objects of the tag; keypoint projects only
+	//
+	Skeleton *TagSkeleton `json:"skeleton,omitempty" bson:"skeleton,omitempty"`
 
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
@@ -70,3 +77,44 @@ func NewTag(userid, projectid, datasetid, name string, properties []string) Tag
 		UpdatedAt: time.Now(),
 	}
 }
+
+// TagSkeleton defines the keypoints of objects of a tag e.g. the joints of a person
+type TagSkeleton struct {
+	// Ordered keypoint names; keypoints of annotated objects are given in this order
+	//
+	Keypoints []string `json:"keypoints" bson:"keypoints"`
+	// Pairs of keypoint indices joined by a line when drawn
+	//
+	Edges [][2]int `json:"edges,omitempty" bson:"edges,omitempty"`
+}
+
+// Valid checks the keypoint names are unique and the edges join two different keypoints of the skeleton
+func (s TagSkeleton) Valid() error {
+	if len(s.Keypoints) == 0 {
+		return fmt.Errorf("skeleton must have at least one keypoint")
+	}
+	names := make(map[string]struct{}, len(s.Keypoints))
+	for _, name := range s.Keypoints {
+		if name == "" {
+			return fmt.Errorf("skeleton keypoint names cannot be empty")
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("skeleton keypoint names must be unique; name=%s", name)
+		}
+		names[name] = struct{}{}
+	}
+	for _, edge := range s.Edges {
+		if edge[0] == edge[1] || edge[0] < 0 || edge[1] < 0 || edge[0] >= len(s.Keypoints) || edge[1] >= len(s.Keypoints) {
+			return fmt.Errorf("skeleton edge must join two keypoints of the skeleton; edge=%v", edge)
+		}
+	}
+	return nil
+}
+
+// Size returns the number of keypoints of the skeleton
+func (s *TagSkeleton) Size() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.Keypoints)
+}
@@ -0,0 +1,244 @@
+/*
+ * File: task.go
+ * Project: models
+ * File Created: Friday, 16th February 2024 9:14:38 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 16th February 2024 9:14:38 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// How long an item served to a labeller is held for them before it can be served again
+	TaskItemLockTimeout = 30 * time.Minute
+	// Number of items per batch when none is specified
+	DefaultTaskBatchSize = 100
+)
+
+// TaskItemState is an enum for the state of a content item of a labelling task
+type TaskItemState int
+
+const (
+	TaskItemStatePending TaskItemState = iota
+	TaskItemStateDone
+	TaskItemStateSkipped
+)
+
+func (s TaskItemState) String() string {
+	return [...]string{"PENDING", "DONE", "SKIPPED"}[s]
+}
+
+// TaskBatch is a share of the items of a labelling task assigned to a single labeller
+type TaskBatch struct {
+	// ID of the batch
+	//
+	// swagger:strfmt bsonobjectid
+	ID primitive.ObjectID `json:"id" bson:"_id"`
+	// User labelling the batch
+	//
+	AssigneeID string `json:"assigneeid" bson:"assigneeid"`
+	// Number of items in the batch
+	//
+	Size int `json:"size" bson:"size"`
+	// Batches of the same group hold the same content for different labellers
+	//
+	Group int `json:"group" bson:"group"`
+}
+
+// Task represents a labelling task distributing the unannotated content of a dataset to labellers
+//
+// swagger:model Task
+type Task struct {
+	// ID of the Task
+	//
+	// swagger:strfmt bsonobjectid
+	ID primitive.ObjectID `json:"id" bson:"_id"`
+	// Owner of the project
+	//
+	UserID string `json:"userid" bson:"userid"`
+	// ProjectID associated with Task
+	//
+	ProjectID string `json:"projectid" bson:"projectid"`
+	// Dataset annotations are created in
+	//
+	DatasetID string `json:"datasetid" bson:"datasetid"`
+	// Name of Task
+	//
+	Name string `json:"name" bson:"name"`
+	// Content filters the task was created with
+	//
+	Filters []Filter `json:"filters,omitempty" bson:"filters,omitempty"`
+	// Logical operator of the filters
+	//
+	Operator string `json:"operator,omitempty" bson:"operator,omitempty"`
+	// Batches of the task
+	//
+	Batches []TaskBatch `json:"batches" bson:"batches"`
+	// Number of items in the task
+	//
+	Size int `json:"size" bson:"size"`
+	// Number of labellers each content is sent to. With more than one, labels compete and are merged into the
+	// dataset's annotation.
+	//
+	Overlap int `json:"overlap" bson:"overlap"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func NewTask(userid, projectid, datasetid, name string) Task {
+	return Task{
+		ID:        primitive.NewObjectID(),
+		UserID:    userid,
+		ProjectID: projectid,
+		DatasetID: datasetid,
+		Name:      name,
+		Batches:   []TaskBatch{},
+		Overlap:   1,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// TaskItem is a single content item of a labelling task
+//
+// swagger:model TaskItem
+type TaskItem struct {
+	// ID of the item
+	//
+	// swagger:strfmt bsonobjectid
+	ID primitive.ObjectID `json:"id" bson:"_id"`
+	// Owner of the project
+	//
+	UserID string `json:"userid" bson:"userid"`
+	// Task of the item
+	//
+	TaskID string `json:"taskid" bson:"taskid"`
+	// Batch of the item
+	//
+	BatchID string `json:"batchid" bson:"batchid"`
+	// User the item is assigned to
+	//
+	AssigneeID string `json:"assigneeid" bson:"assigneeid"`
+	// Content to label
+	//
+	ContentID string `json:"contentid" bson:"contentid"`
+	// One of PENDING, DONE or SKIPPED
+	//
+	State string `json:"state" bson:"state"`
+	// Labeller currently holding the item
+	//
+	LockedBy string `json:"lockedby,omitempty" bson:"lockedby,omitempty"`
+	// Time the lock expires
+	//
+	LockedUntil *time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	// Labeller who completed or skipped the item
+	//
+	CompletedBy string `json:"completedby,omitempty" bson:"completedby,omitempty"`
+	// Annotation created for the item
+	//
+	AnnotationID string `json:"annotationid,omitempty" bson:"annotationid,omitempty"`
+	// Competing label created for the item in a task with overlap
+	//
+	LabelID string `json:"labelid,omitempty" bson:"labelid,omitempty"`
+
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// Held reports whether the labeller holds a lock on the pending item that has not expired at the given time
+func (i TaskItem) Held(assigneeid string, now time.Time) bool {
+	return i.State == TaskItemStatePending.String() && i.LockedBy == assigneeid && i.LockedUntil != nil && !i.LockedUntil.Before(now)
+}
+
+func NewTaskItem(task Task, batch TaskBatch, contentid string) TaskItem {
+	return TaskItem{
+		ID:         primitive.NewObjectID(),
+		UserID:     task.UserID,
+		TaskID:     task.ID.Hex(),
+		BatchID:    batch.ID.Hex(),
+		AssigneeID: batch.AssigneeID,
+		ContentID:  contentid,
+		State:      TaskItemStatePending.String(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// Throughput is the labelling progress of a single labeller on a task
+//
+// swagger:model Throughput
+type Throughput struct {
+	// Labeller
+	//
+	UserID string `json:"userid" bson:"_id"`
+	// Items assigned
+	//
+	Assigned int `json:"assigned" bson:"assigned"`
+	// Items labelled
+	//
+	Done int `json:"done" bson:"done"`
+	// Items skipped
+	//
+	Skipped int `json:"skipped" bson:"skipped"`
+	// Labelled items per hour between the first and last labelled item
+	//
+	PerHour float64 `json:"per_hour" bson:"-"`
+
+	FirstCompletedAt *time.Time `json:"first_completed_at,omitempty" bson:"first_completed_at,omitempty"`
+	LastCompletedAt  *time.Time `json:"last_completed_at,omitempty" bson:"last_completed_at,omitempty"`
+}
+
+// TaskLabel is a single labeller's labelling of content in a task with overlap. Labels of the same content compete
+// with each other and are kept apart from the dataset's annotations until they are merged into one.
+//
+// swagger:model TaskLabel
+type TaskLabel struct {
+	// ID of the label
+	//
+	// swagger:strfmt bsonobjectid
+	ID primitive.ObjectID `json:"id" bson:"_id"`
+	// Owner of the project
+	//
+	UserID string `json:"userid" bson:"userid"`
+	// Task the label was made in
+	//
+	TaskID string `json:"taskid" bson:"taskid"`
+	// Content labelled
+	//
+	ContentID string `json:"contentid" bson:"contentid"`
+	// User who made the label
+	//
+	LabellerID string `json:"labellerid" bson:"labellerid"`
+	// Tag IDs
+	//
+	TagIDs []string `json:"tagids" bson:"tagids"`
+	// Annotation metadata
+	//
+	Metadata AnnotationMetadata `json:"metadata" bson:"metadata"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func NewTaskLabel(item TaskItem, labellerid string, tagids []string, metadata AnnotationMetadata) TaskLabel {
+	return TaskLabel{
+		ID:         primitive.NewObjectID(),
+		UserID:     item.UserID,
+		TaskID:     item.TaskID,
+		ContentID:  item.ContentID,
+		LabellerID: labellerid,
+		TagIDs:     tagids,
+		Metadata:   metadata,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * File: task_test.go
+ * Project: models
+ * File Created: Monday, 19th February 2024 10:40:17 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 10:40:17 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskItemHeld(t *testing.T) {
+	now := time.Now()
+	locked := func(state TaskItemState, lockedBy string, until *time.Time) TaskItem {
+		return TaskItem{State: state.String(), LockedBy: lockedBy, LockedUntil: until}
+	}
+	future, past := now.Add(TaskItemLockTimeout), now.Add(-time.Second)
+
+	tests := []struct {
+		name string
+		item TaskItem
+		held bool
+	}{
+		{"locked", locked(TaskItemStatePending, "labeller", &future), true},
+		{"lock expiring now", locked(TaskItemStatePending, "labeller", &now), true},
+		{"lock expired", locked(TaskItemStatePending, "labeller", &past), false},
+		{"locked by another labeller", locked(TaskItemStatePending, "other", &future), false},
+		{"not locked", locked(TaskItemStatePending, "", nil), false},
+		{"lock without expiry", locked(TaskItemStatePending, "labeller", nil), false},
+		{"completed", locked(TaskItemStateDone, "labeller", &future), false},
+		{"skipped", locked(TaskItemStateSkipped, "labeller", &future), false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.held, test.item.Held("labeller", now), test.name)
+	}
+}
@@ -11,6 +11,7 @@ package models
 
 import (
 	"fmt"
+	"path"
 	"time"
 
 	"github.com/google/uuid"
@@ -77,6 +78,9 @@ type Upload struct {
 	// Labels file associated with Upload
 	//
 	LabelsFile string `json:"labels_file" bson:"labels_file"`
+	// Staged files pending processing; internal to the upload pipeline and not returned to clients
+	//
+	Files []string `json:"-" bson:"files"`
 	// Files that failed to upload
 	//
 	Failed map[string]interface{} `json:"failed" bson:"failed"`
@@ -126,6 +130,7 @@ func NewUpload(userid, projectid, bucket, labelsFile string) Upload {
 		Key:        fmt.Sprintf("%s/content/staging/%s/%s", userid, projectid, uuid.New()),
 		LabelsFile: labelsFile,
 		Bucket:     bucket,
+		Files:      []string{},
 		Failed:     make(map[string]interface{}),
 
 		CreatedAt: time.Now(),
@@ -134,3 +139,33 @@ func NewUpload(userid, projectid, bucket, labelsFile string) Upload {
 		Metadata: make(map[string]interface{}),
 	}
 }
+
+// StagedKey returns the blob key used to stage the given file for this upload
+func (u Upload) StagedKey(idx int, filename string) string {
+	return fmt.Sprintf("%s/%d/%s", u.Key, idx, path.Base(filename))
+}
+
+// StagedArchiveKey returns the blob key used to stage a file extracted from a staged archive
+func (u Upload) StagedArchiveKey(archiveKey string, idx int, filename string) string {
+	return fmt.Sprintf("%s.d/%d/%s", archiveKey, idx, path.Base(filename))
+}
+
+// LabelsKey returns the blob key used to stage the labels file for this upload
+func (u Upload) LabelsKey() string {
+	return fmt.Sprintf("%s/%s", u.Key, path.Base(u.LabelsFile))
+}
+
+// UploadProgress represents the outcome of processing a single staged file
+type UploadProgress struct {
+	// Staged key of the processed file
+	File string
+	// Processed bytes
+	Bytes int64
+	// Image counts; labels files are not processed as images
+	Processed int
+	Succeeded int
+	Failed    int
+	Duplicate int
+	// Failures keyed by filename
+	Errors map[string]interface{}
+}
@@ -0,0 +1,106 @@
+/*
+ * File: voc.go
+ * Project: models
+ * File Created: Wednesday, 7th February 2024 9:31:15 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 7th February 2024 9:31:15 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"encoding/xml"
+	"math"
+	"path"
+	"strings"
+
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+)
+
+/* Example Pascal VOC image1.xml
+<annotation>
+  <filename>image1.jpeg</filename>
+  <size><width>640</width><height>480</height><depth>3</depth></size>
+  <object>
+    <name>cat</name>
+    <bndbox><xmin>33</xmin><ymin>33</ymin><xmax>66</xmax><ymax>66</ymax></bndbox>
+  </object>
+</annotation>
+*/
+
+// VOC represents a Pascal VOC annotation for a single image
+type VOC struct {
+	XMLName  xml.Name    `xml:"annotation"`
+	Folder   string      `xml:"folder,omitempty"`
+	Filename string      `xml:"filename"`
+	Size     VOCSize     `xml:"size"`
+	Objects  []VOCObject `xml:"object"`
+}
+
+type VOCSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+type VOCObject struct {
+	Name      string `xml:"name"`
+	Pose      string `xml:"pose,omitempty"`
+	Truncated int    `xml:"truncated"`
+	Difficult int    `xml:"difficult"`
+	// Omitted for classification
+	BndBox *VOCBndBox `xml:"bndbox,omitempty"`
+}
+
+// VOCBndBox coordinates are absolute pixels; some tools write them as floats
+type VOCBndBox struct {
+	Xmin float64 `xml:"xmin"`
+	Ymin float64 `xml:"ymin"`
+	Xmax float64 `xml:"xmax"`
+	Ymax float64 `xml:"ymax"`
+}
+
+// ParseVOC parses a Pascal VOC annotation file. The label is identified by the image filename found in
+// the annotation, falling back to the name of the annotation file itself.
+func ParseVOC(filename string, fileBytes []byte, annotationType string) (Label, error) {
+	var voc VOC
+	if err := xml.Unmarshal(fileBytes, &voc); err != nil {
+		return Label{}, err
+	}
+
+	stem := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	label := Label{
+		Tags:       []string{},
+		ExternalID: stem,
+		InternalID: stem,
+		Width:      voc.Size.Width,
+		Height:     voc.Size.Height,
+	}
+	if voc.Filename != "" {
+		label.ExternalID = path.Base(voc.Filename)
+	}
+
+	for _, object := range voc.Objects {
+		name := strings.ToLower(strings.TrimSpace(object.Name))
+		if name == "" {
+			continue
+		}
+		if !common.SliceContains(label.Tags, name) {
+			label.Tags = append(label.Tags, name)
+		}
+
+		if annotationType != ProjectAnnotationTypeBoundingBox.String() || object.BndBox == nil {
+			continue
+		}
+		label.Metadata = append(label.Metadata, AnnotationDataBoundingBox{
+			TagID: name,
+			Xmin:  int(math.Round(object.BndBox.Xmin)),
+			Ymin:  int(math.Round(object.BndBox.Ymin)),
+			Xmax:  int(math.Round(object.BndBox.Xmax)),
+			Ymax:  int(math.Round(object.BndBox.Ymax)),
+		})
+	}
+
+	return label, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * File: yolo.go
+ * Project: models
+ * File Created: Wednesday, 7th February 2024 10:05:52 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 7th February 2024 10:05:52 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+)
+
+// YOLOClassesFile lists the class names of a YOLO dataset, one per line, indexed by line number
+const YOLOClassesFile = "classes.txt"
+
+/* Example YOLO image1.txt, one object per line as normalized `class cx cy w h`
+0 0.0773 0.1031 0.0516 0.0688
+1 0.2836 0.3094 0.0516 0.0688
+*/
+
+// ParseYOLOClasses parses a YOLO classes.txt file
+func ParseYOLOClasses(fileBytes []byte) []string {
+	classes := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(fileBytes))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			classes = append(classes, strings.ToLower(name))
+		}
+	}
+	return classes
+}
+
+// ParseYOLO parses a YOLO annotation file. The label is identified by the name of the annotation file without
+// its extension; boxes remain normalized until the dimensions of the image are known. Class indices without a
+// matching entry in classes are named by their index.
+func ParseYOLO(filename string, fileBytes []byte, classes []string, annotationType string) (Label, error) {
+	stem := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	label := Label{
+		Tags:       []string{},
+		ExternalID: stem,
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(fileBytes))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 5 {
+			return Label{}, fmt.Errorf("invalid yolo annotation; file=%s line=%d", filename, line)
+		}
+
+		class, err := strconv.Atoi(fields[0])
+		if err != nil || class < 0 {
+			return Label{}, fmt.Errorf("invalid yolo class; file=%s line=%d", filename, line)
+		}
+		values := make([]float64, 4)
+		for i := range values {
+			if values[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+				return Label{}, fmt.Errorf("invalid yolo coordinate; file=%s line=%d", filename, line)
+			}
+		}
+
+		name := strconv.Itoa(class)
+		if class < len(classes) {
+			name = classes[class]
+		}
+		if !common.SliceContains(label.Tags, name) {
+			label.Tags = append(label.Tags, name)
+		}
+
+		if annotationType != ProjectAnnotationTypeBoundingBox.String() {
+			continue
+		}
+		cx, cy, w, h := values[0], values[1], values[2], values[3]
+		label.NormalizedBoxes = append(label.NormalizedBoxes, NormalizedBoundingBox{
+			TagID: name,
+			Xmin:  cx - w/2,
+			Ymin:  cy - h/2,
+			Xmax:  cx + w/2,
+			Ymax:  cy + h/2,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Label{}, err
+	}
+
+	return label, nil
+}
@@ -47,12 +47,18 @@ type AnnotationDB interface {
 	List(*db.DB, string, string, string, models.Pagination) ([]models.Annotation, int64, error)
 	Query(*db.DB, string, models.Query) ([]models.Annotation, int64, error)
 	Update(*db.DB, *models.Annotation) error
+	Review(*db.DB, string, models.AnnotationStatus, models.AnnotationReview, []primitive.ObjectID) (int64, error)
+	ListStatus(*db.DB, string, string, string, models.AnnotationStatus, models.Pagination) ([]models.Annotation, int64, error)
+	CountStatus(*db.DB, string, string, models.AnnotationStatus) (*int64, error)
 	DeleteTagID(*db.DB, string, string, string, string) error
+	ReplaceTagID(*db.DB, string, string, string, string, ...primitive.ObjectID) error
 
 	AnnotationsAssociatedWithContent(*db.DB, string, string, string) ([]string, error)
 	TotalAnnotations(*db.DB, string, string, string, interface{}) error
 	AverageAnnotationsPerImage(*db.DB, string, string, string, interface{}) error
 	AnnotationsPerClass(*db.DB, string, string, string, interface{}) error
+	SegmentsPerClass(*db.DB, string, string, string, interface{}) error
+	KeypointsPerClass(*db.DB, string, string, string, interface{}) error
 	AnnotationsImageInsights(*db.DB, string, string, string, interface{}) error
 	AnnotationsImageStat(*db.DB, string, string, string, string, interface{}) error
 	CountNullAnnotations(*db.DB, string, string, string) (*int64, error)
@@ -60,6 +66,8 @@ type AnnotationDB interface {
 	CountUnannotated(*db.DB, string, string, string) (*int64, error)
 	FindContentAnnotation(*db.DB, string, string, string, string) (*models.Annotation, error)
 	FindDatasetAnnotations(*db.DB, string, string, ...*options.FindOptions) (*mongo.Cursor, error)
+	FindAnnotations(*db.DB, string, []primitive.ObjectID) ([]models.Annotation, error)
+	FindTagAnnotations(*db.DB, string, string, string, ...primitive.ObjectID) ([]models.Annotation, error)
 	DeleteUserAnnotations(*db.DB, string, []primitive.ObjectID) error
 	DeleteProjectAnnotations(*db.DB, string, string) error
 	DeleteDatasetAnnotations(*db.DB, string, string, string) error
@@ -85,6 +93,46 @@ func (a Annotation) FindDatasetAnnotations(db *db.DB, userid, datasetid string,
 	return cursor, nil
 }
 
+// FindAnnotations returns annotations by ID without their images
+func (a Annotation) FindAnnotations(db *db.DB, userid string, annotationids []primitive.ObjectID) ([]models.Annotation, error) {
+	return a.find(db, bson.M{
+		"userid": userid,
+		"_id":    bson.M{"$in": annotationids},
+	})
+}
+
+// FindTagAnnotations returns the annotations of a dataset labelled with a tag without their images. When
+// annotation ids are given only those annotations are returned.
+func (a Annotation) FindTagAnnotations(db *db.DB, userid, datasetid, tagid string, annotationids ...primitive.ObjectID) ([]models.Annotation, error) {
+	filter := bson.M{
+		"userid":    userid,
+		"datasetid": datasetid,
+		"tagids":    tagid,
+	}
+	if len(annotationids) > 0 {
+		filter["_id"] = bson.M{"$in": annotationids}
+	}
+	return a.find(db, filter)
+}
+
+func (a Annotation) find(db *db.DB, filter bson.M) ([]models.Annotation, error) {
+	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	annotations := []models.Annotation{}
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find().SetProjection(bson.M{"b64_image": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &annotations); err != nil {
+		return nil, err
+	}
+
+	return annotations, nil
+}
+
 func (a Annotation) Index(db *db.DB) error {
 	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
 
@@ -117,6 +165,10 @@ func (a Annotation) Index(db *db.DB) error {
 			Keys:    bson.D{{Key: "contentid", Value: 1}, {Key: "projectid", Value: 1}},
 			Options: &options.IndexOptions{Background: common.Ptr(true)},
 		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "datasetid", Value: 1}, {Key: "status", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
 	}
 
 	if _, err := collection.Indexes().CreateMany(context.TODO(), models); err != nil {
@@ -185,6 +237,22 @@ func (a Annotation) View(db *db.DB, userid, id string) (*models.Annotation, erro
 
 // List returns list of all annotations.
 func (a Annotation) List(db *db.DB, userid, projectid, datasetid string, p models.Pagination) ([]models.Annotation, int64, error) {
+	return a.list(db, bson.M{
+		"userid":    userid,
+		"datasetid": datasetid,
+		"projectid": projectid}, p)
+}
+
+// ListStatus returns list of the annotations of a dataset in a review status
+func (a Annotation) ListStatus(db *db.DB, userid, projectid, datasetid string, status models.AnnotationStatus, p models.Pagination) ([]models.Annotation, int64, error) {
+	return a.list(db, bson.M{
+		"userid":    userid,
+		"datasetid": datasetid,
+		"projectid": projectid,
+		"status":    statusFilter(status)}, p)
+}
+
+func (a Annotation) list(db *db.DB, filter bson.M, p models.Pagination) ([]models.Annotation, int64, error) {
 	var annotations = []models.Annotation{}
 
 	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
@@ -196,11 +264,6 @@ func (a Annotation) List(db *db.DB, userid, projectid, datasetid string, p model
 	options.SetLimit(int64(p.Limit))
 	options.SetSkip(int64(p.Offset))
 
-	filter := bson.M{
-		"userid":    userid,
-		"datasetid": datasetid,
-		"projectid": projectid}
-
 	cursor, err := collection.Find(context.TODO(), filter, options)
 	if err != nil {
 		return nil, 0, err
@@ -267,14 +330,30 @@ func (a Annotation) Update(db *db.DB, annotation *models.Annotation) error {
 		},
 	}
 
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{
+			"$set": annotationUpdate(annotation),
+		})
+
+	return err
+}
+
+// annotationUpdate returns the fields of an annotation update; metadata is written along with tags or whenever any
+// kind of label is set
+func annotationUpdate(annotation *models.Annotation) bson.M {
 	var update = make(map[string]interface{})
 	update["updated_at"] = time.Now()
 
 	if annotation.Split != "" {
 		update["split"] = annotation.Split
 	}
+	if annotation.Status != "" {
+		update["status"] = annotation.Status
+	}
 
-	if len(annotation.Metadata.BoundingBoxes) != 0 {
+	if annotation.TagIDs != nil || !annotation.Metadata.Empty() {
 		update["metadata"] = annotation.Metadata
 	}
 	if annotation.Base64Image != "" {
@@ -296,14 +375,34 @@ func (a Annotation) Update(db *db.DB, annotation *models.Annotation) error {
 		update["tagids"] = annotation.TagIDs
 	}
 
-	_, err := collection.UpdateOne(
-		context.TODO(),
-		filter,
-		bson.M{
-			"$set": update,
-		})
+	return update
+}
 
-	return err
+// Review sets the status of submitted annotations to approved or rejected and returns the number of annotations
+// reviewed. Annotations that are not awaiting review are left unchanged.
+func (a Annotation) Review(db *db.DB, userid string, status models.AnnotationStatus, review models.AnnotationReview, annotationids []primitive.ObjectID) (int64, error) {
+	if status != models.AnnotationStatusApproved && status != models.AnnotationStatusRejected {
+		return 0, models.ErrInvalidAnnotationStatus
+	}
+
+	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	result, err := collection.UpdateMany(context.TODO(), bson.M{
+		"_id":    bson.M{"$in": annotationids},
+		"userid": userid,
+		"status": models.AnnotationStatusSubmitted.String(),
+	}, bson.M{
+		"$set": bson.M{
+			"status":     status.String(),
+			"review":     review,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
 }
 
 func (a Annotation) DeleteTagID(db *db.DB, userid, projectid, datasetid, tagid string) error {
@@ -318,8 +417,11 @@ func (a Annotation) DeleteTagID(db *db.DB, userid, projectid, datasetid, tagid s
 		},
 	}).SetUpdate(bson.M{
 		"$pull": bson.M{
-			"metadata.bounding_boxes": bson.M{"tagid": tagid},
-			"tagids":                  tagid,
+			"metadata.bounding_boxes":   bson.M{"tagid": tagid},
+			"metadata.polygons":         bson.M{"tagid": tagid},
+			"metadata.masks":            bson.M{"tagid": tagid},
+			"metadata.keypoint_objects": bson.M{"tagid": tagid},
+			"tagids":                    tagid,
 		},
 	})
 
@@ -341,6 +443,52 @@ func (a Annotation) DeleteTagID(db *db.DB, userid, projectid, datasetid, tagid s
 	return err
 }
 
+// ReplaceTagID replaces a tagid with another in the annotations of a dataset, including their bounding boxes,
+// segmentation regions and keypoint objects.
+// When annotation ids are given only those annotations are updated.
+func (a Annotation) ReplaceTagID(db *db.DB, userid, datasetid, from, to string, annotationids ...primitive.ObjectID) error {
+	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	filter := func(extra bson.M) bson.M {
+		f := bson.M{"userid": userid, "datasetid": datasetid, "tagids": from}
+		if len(annotationids) > 0 {
+			f["_id"] = bson.M{"$in": annotationids}
+		}
+		for k, v := range extra {
+			f[k] = v
+		}
+		return f
+	}
+
+	// Relabel bounding boxes; array filters require the boxes to exist
+	boxModel := mongo.NewUpdateManyModel().
+		SetFilter(filter(bson.M{"metadata.bounding_boxes.tagid": from})).
+		SetUpdate(bson.M{"$set": bson.M{"metadata.bounding_boxes.$[box].tagid": to}}).
+		SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"box.tagid": from}}})
+
+	regionModel := func(field string) mongo.WriteModel {
+		return mongo.NewUpdateManyModel().
+			SetFilter(filter(bson.M{"metadata." + field + ".tagid": from})).
+			SetUpdate(bson.M{"$set": bson.M{"metadata." + field + ".$[region].tagid": to}}).
+			SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"region.tagid": from}}})
+	}
+
+	// A single update cannot both add to and pull from tagids
+	addModel := mongo.NewUpdateManyModel().
+		SetFilter(filter(nil)).
+		SetUpdate(bson.M{"$addToSet": bson.M{"tagids": to}, "$set": bson.M{"updated_at": time.Now()}})
+	pullModel := mongo.NewUpdateManyModel().
+		SetFilter(filter(nil)).
+		SetUpdate(bson.M{"$pull": bson.M{"tagids": from}})
+
+	models := []mongo.WriteModel{boxModel, regionModel("polygons"), regionModel("masks"), regionModel("keypoint_objects"), addModel, pullModel}
+	opts := options.BulkWrite().SetOrdered(true)
+
+	_, err := collection.BulkWrite(context.TODO(), models, opts)
+
+	return err
+}
+
 func (a Annotation) AnnotationsAssociatedWithContent(db *db.DB, userid, projectid, contentid string) (annotationIDs []string, err error) {
 	lookup := bson.M{
 		"from": "dataset",
@@ -459,6 +607,62 @@ func (a Annotation) AnnotationsPerClass(db *db.DB, userid, projectid, datasetid
 	return a.aggregate(db, pipeline, results)
 }
 
+// SegmentsPerClass counts the polygons and masks of each tag in the annotations of a dataset, along with their
+// average area in pixels
+func (a Annotation) SegmentsPerClass(db *db.DB, userid, projectid, datasetid string, results interface{}) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"userid":    userid,
+			"datasetid": datasetid,
+			"projectid": projectid,
+		}},
+		{"$project": bson.M{
+			"segments": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$metadata.polygons", bson.A{}}},
+				bson.M{"$ifNull": bson.A{"$metadata.masks", bson.A{}}},
+			}},
+		}},
+		{"$unwind": "$segments"},
+		{"$group": bson.M{
+			"_id":          "$segments.tagid",
+			"count":        bson.M{"$sum": 1},
+			"average_area": bson.M{"$avg": "$segments.area"},
+		}},
+	}
+
+	return a.aggregate(db, pipeline, results)
+}
+
+// KeypointsPerClass counts the keypoint objects of each tag in the annotations of a dataset by keypoint, along with
+// how many of each keypoint are labelled and visible. Objects of tags without a skeleton are counted with a nil index.
+func (a Annotation) KeypointsPerClass(db *db.DB, userid, projectid, datasetid string, results interface{}) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"userid":    userid,
+			"datasetid": datasetid,
+			"projectid": projectid,
+		}},
+		{"$unwind": "$metadata.keypoint_objects"},
+		{"$unwind": bson.M{
+			"path":                       "$metadata.keypoint_objects.keypoints",
+			"includeArrayIndex":          "index",
+			"preserveNullAndEmptyArrays": true,
+		}},
+		{"$group": bson.M{
+			"_id":   bson.M{"tagid": "$metadata.keypoint_objects.tagid", "index": "$index"},
+			"count": bson.M{"$sum": 1},
+			"labelled": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$metadata.keypoint_objects.keypoints.visibility", models.KeypointNotLabelled}}, 1, 0,
+			}}},
+			"visible": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$metadata.keypoint_objects.keypoints.visibility", models.KeypointVisible}}, 1, 0,
+			}}},
+		}},
+	}
+
+	return a.aggregate(db, pipeline, results)
+}
+
 func (a Annotation) AnnotationsImageInsights(db *db.DB, userid, projectid, datasetid string, results interface{}) error {
 	// Dimensions
 	lookup := bson.M{
@@ -535,6 +739,26 @@ func (a Annotation) CountAnnotations(db *db.DB, userid, datasetid string) (*int6
 	return common.Ptr(count), err
 }
 
+// CountStatus counts the annotations of a dataset in a review status
+func (a Annotation) CountStatus(db *db.DB, userid, datasetid string, status models.AnnotationStatus) (*int64, error) {
+	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{
+		"userid":    userid,
+		"datasetid": datasetid,
+		"status":    statusFilter(status),
+	})
+	return common.Ptr(count), err
+}
+
+// statusFilter matches a review status; annotations without a status are drafts
+func statusFilter(status models.AnnotationStatus) interface{} {
+	if status == models.AnnotationStatusDraft {
+		return bson.M{"$in": bson.A{status.String(), nil}}
+	}
+	return status.String()
+}
+
 func (a Annotation) CountUnannotated(db *db.DB, userid, datasetid, projectid string) (*int64, error) {
 	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
 
@@ -0,0 +1,63 @@
+/*
+ * File: annotation_test.go
+ * Project: platform
+ * File Created: Tuesday, 20th February 2024 9:41:17 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 9:41:17 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestAnnotationUpdate(t *testing.T) {
+	polygon := models.AnnotationDataPolygon{TagID: "tag", Points: []float64{0, 0, 4, 0, 4, 4}}
+	mask := models.AnnotationDataMask{TagID: "tag", Height: 2, Width: 2, Counts: []int{1, 3}}
+	object := models.AnnotationDataKeypointObject{
+		AnnotationDataBoundingBox: models.AnnotationDataBoundingBox{TagID: "tag", Xmax: 4, Ymax: 4},
+		Keypoints:                 []models.AnnotationDataKeypoint{{X: 1, Y: 2, Visibility: models.KeypointVisible}},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		metadata models.AnnotationMetadata
+	}{
+		{"bounding boxes", models.AnnotationMetadata{BoundingBoxes: []models.AnnotationDataBoundingBox{{TagID: "tag", Xmax: 4, Ymax: 4}}}},
+		{"polygons", models.AnnotationMetadata{Polygons: []models.AnnotationDataPolygon{polygon}}},
+		{"masks", models.AnnotationMetadata{Masks: []models.AnnotationDataMask{mask}}},
+		{"keypoint objects", models.AnnotationMetadata{KeypointObjects: []models.AnnotationDataKeypointObject{object}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			update := annotationUpdate(&models.Annotation{Metadata: tc.metadata})
+			assert.Equal(t, tc.metadata, update["metadata"])
+			assert.NotContains(t, update, "tagids")
+		})
+	}
+
+	t.Run("tags without labels clear the metadata", func(t *testing.T) {
+		update := annotationUpdate(&models.Annotation{TagIDs: []string{"tag"}})
+		assert.Equal(t, models.AnnotationMetadata{}, update["metadata"])
+		assert.Equal(t, []string{"tag"}, update["tagids"])
+		assert.Equal(t, false, update["null_annotation"])
+	})
+
+	t.Run("null annotation", func(t *testing.T) {
+		update := annotationUpdate(&models.Annotation{TagIDs: []string{}, Metadata: models.AnnotationMetadata{Polygons: []models.AnnotationDataPolygon{polygon}}})
+		assert.Equal(t, models.AnnotationMetadata{}, update["metadata"])
+		assert.Equal(t, true, update["null_annotation"])
+	})
+
+	t.Run("no label update", func(t *testing.T) {
+		update := annotationUpdate(&models.Annotation{Status: models.AnnotationStatusSubmitted.String()})
+		assert.NotContains(t, update, "metadata")
+		assert.NotContains(t, update, "tagids")
+		assert.Equal(t, models.AnnotationStatusSubmitted.String(), update["status"])
+	})
+}
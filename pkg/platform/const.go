@@ -22,4 +22,8 @@ const (
 	DATASET_COLLECTION    = "dataset"
 	ANNOTATION_COLLECTION = "annotation"
 	PREDICTION_COLLECTION = "prediction"
+	REVISION_COLLECTION   = "annotation_revision"
+	TASK_COLLECTION       = "labelling_task"
+	TASK_ITEM_COLLECTION  = "labelling_item"
+	TASK_LABEL_COLLECTION = "labelling_label"
 )
@@ -55,6 +55,7 @@ type ContentDB interface {
 	FindOrphanedContent(*db.DB, string, ...*options.FindOptions) (*mongo.Cursor, error)
 	FindProjectContent(*db.DB, string, string, ...*options.FindOptions) (*mongo.Cursor, error)
 	FindAnnotated(*db.DB, string, string, string, string, models.Pagination, ...string) ([]models.Content, int, error)
+	FindUnannotatedIDs(*db.DB, string, string, string, interface{}) ([]string, error)
 
 	aggregate(*db.DB, interface{}, interface{}, ...*options.AggregateOptions) error
 }
@@ -94,16 +95,26 @@ func (c Content) FindAnnotated(db *db.DB, userid, projectid, datasetid, operator
 	return c.findUnannotated(db, userid, projectid, p)
 }
 
-func (c Content) findUnannotated(db *db.DB, userid, projectid string, p models.Pagination) ([]models.Content, int, error) {
-	lookup := bson.M{
+// unannotatedLookup joins the annotations of a project, or of a single dataset when given, with content
+func unannotatedLookup(projectid, datasetid string) bson.M {
+	match := bson.M{"projectid": projectid}
+	if datasetid != "" {
+		match["datasetid"] = datasetid
+	}
+
+	return bson.M{
 		"from":         ANNOTATION_COLLECTION,
 		"localField":   "_id",
 		"foreignField": "contentid",
 		"as":           "matched_annotation",
 		"pipeline": bson.A{
-			bson.M{"$match": bson.M{"projectid": projectid}},
+			bson.M{"$match": match},
 		},
 	}
+}
+
+func (c Content) findUnannotated(db *db.DB, userid, projectid string, p models.Pagination) ([]models.Content, int, error) {
+	lookup := unannotatedLookup(projectid, "")
 
 	pipeline := []bson.M{
 		{"$match": bson.M{
@@ -148,6 +159,41 @@ func (c Content) findUnannotated(db *db.DB, userid, projectid string, p models.P
 	return []models.Content{}, 0, nil
 }
 
+// FindUnannotatedIDs returns the ids of project content without an annotation in a dataset. An optional filter,
+// such as a query filter, further restricts the content.
+func (c Content) FindUnannotatedIDs(db *db.DB, userid, projectid, datasetid string, filter interface{}) ([]string, error) {
+	match := []bson.M{{"$match": bson.M{
+		"userid":   userid,
+		"projects": projectid,
+	}}}
+	if filter != nil {
+		match = append(match, bson.M{"$match": filter})
+	}
+
+	pipeline := append(match,
+		bson.M{"$lookup": unannotatedLookup(projectid, datasetid)},
+		bson.M{"$match": bson.M{"matched_annotation": bson.M{"$eq": bson.A{}}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$project": bson.M{"_id": 1}},
+	)
+
+	var results []struct {
+		ID string `bson:"_id"`
+	}
+
+	opts := options.AggregateOptions{AllowDiskUse: common.Ptr(true)}
+	if err := c.aggregate(db, pipeline, &results, &opts); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+
+	return ids, nil
+}
+
 func (c Content) findAnnotatedContent(db *db.DB, userid, datasetid, operator string, p models.Pagination, tagids ...string) ([]models.Content, int, error) {
 	collection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
 
@@ -32,6 +32,16 @@ var (
 	ErrDatasetDoesNotExist = echo.NewHTTPError(http.StatusNotFound, "Dataset does not exists.")
 	ErrDatasetCorruptState = echo.NewHTTPError(http.StatusInternalServerError, "Project dataset's in corrupt state")
 	ErrDatasetLocked       = echo.NewHTTPError(http.StatusConflict, "Dataset locked and not modifiable.")
+	ErrDatasetRestoring    = echo.NewHTTPError(http.StatusConflict, "Dataset is being restored and not modifiable.")
+)
+
+const (
+	// Dataset versions being copied refresh their updated_at at this interval, so that copies still in progress
+	// are not taken for interrupted ones
+	DatasetCopyHeartbeat = time.Minute
+
+	// Annotations are copied in batches of this size
+	datasetCopyBatchSize = 1000
 )
 
 // Dataset represents the client for dataset table
@@ -49,6 +59,12 @@ type DatasetDB interface {
 	Update(*db.DB, *models.Dataset) error
 	Copy(*db.DB, *models.Dataset, bool, *primitive.ObjectID) (*models.Dataset, error)
 	FindVersion(*db.DB, string, string, int, ...*options.FindOptions) (*mongo.Cursor, error)
+	Versions(*db.DB, string, string) ([]models.Dataset, error)
+	Restore(*db.DB, *models.Dataset, *models.Dataset) error
+	FinishRestore(*db.DB, *models.Dataset) error
+	DeleteVersion(*db.DB, string, primitive.ObjectID) error
+	FindIncomplete(*db.DB, time.Time) ([]models.Dataset, error)
+	ClaimIncomplete(*db.DB, models.Dataset) (bool, error)
 	Delete(*db.DB, primitive.ObjectID) error
 	DeleteProjectDatasets(*db.DB, string, string) error
 }
@@ -77,16 +93,18 @@ func (d Dataset) Index(db *db.DB) error {
 	return nil
 }
 
+// Copy creates a new version of a dataset with copies of its tags and annotations. The version is created in a
+// pending state and only marked complete once everything has been copied; on failure it is removed again.
 func (d Dataset) Copy(db *db.DB, datasetToCopy *models.Dataset, lockDataset bool, newDatasetID *primitive.ObjectID) (*models.Dataset, error) {
 	datasetCollection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
-	tagCollection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
-	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
 	contentCollection := db.Client.Database(DATABASE).Collection(CONTENT_COLLECTION)
 
-	options := options.FindOptions{AllowDiskUse: aws.Bool(true)}
-
-	// Version count
-	count, err := datasetCollection.CountDocuments(context.TODO(), bson.M{"userid": datasetToCopy.UserID, "projectid": datasetToCopy.ProjectID})
+	// Version count; copies made by a restore are not versions
+	count, err := datasetCollection.CountDocuments(context.TODO(), bson.M{
+		"userid":     datasetToCopy.UserID,
+		"projectid":  datasetToCopy.ProjectID,
+		"restore_to": bson.M{"$exists": false},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -101,21 +119,212 @@ func (d Dataset) Copy(db *db.DB, datasetToCopy *models.Dataset, lockDataset bool
 		newDataset.Locked = true
 	}
 	newDataset.Split = datasetToCopy.Split
+	newDataset.SplitStrategy = datasetToCopy.SplitStrategy
+	newDataset.SplitSeed = datasetToCopy.SplitSeed
+	newDataset.CopyState = models.DatasetCopyStatePending.String()
+	newDataset.CopiedFrom = datasetToCopy.ID.Hex()
 	if _, err := datasetCollection.InsertOne(context.TODO(), newDataset); err != nil {
 		return nil, err
 	}
 
+	copyVersion := func() error {
+		if err := d.copyLabels(db, datasetToCopy, newDataset, nil); err != nil {
+			return err
+		}
+
+		// Copy over dataset associations
+		if _, err := contentCollection.UpdateMany(context.TODO(),
+			bson.M{"userid": newDataset.UserID, "projects": newDataset.ProjectID},
+			bson.M{"$addToSet": bson.M{"datasets": newDataset.ID.Hex()}}); err != nil {
+			return err
+		}
+
+		// Mark complete
+		_, err := datasetCollection.UpdateOne(context.TODO(),
+			bson.M{"_id": newDataset.ID},
+			bson.M{"$unset": bson.M{"copy_state": ""}})
+		return err
+	}
+
+	if err := copyVersion(); err != nil {
+		if errDelete := d.DeleteVersion(db, newDataset.UserID, newDataset.ID); errDelete != nil {
+			return nil, fmt.Errorf("error removing dataset=%s after failed copy; err=%s; copy err=%s", newDataset.ID.Hex(), errDelete.Error(), err.Error())
+		}
+		return nil, err
+	}
+	newDataset.CopyState = models.DatasetCopyStateComplete.String()
+
+	return newDataset, nil
+}
+
+// DeleteVersion removes a dataset version along with its tags and annotations. The version is first marked as
+// deleting and the dataset itself removed last, so that an interrupted removal can be finished later.
+func (d Dataset) DeleteVersion(db *db.DB, userid string, id primitive.ObjectID) error {
+	datasetCollection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+	tagCollection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+	contentCollection := db.Client.Database(DATABASE).Collection(CONTENT_COLLECTION)
+
+	if _, err := datasetCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": id, "userid": userid},
+		bson.M{"$set": bson.M{"copy_state": models.DatasetCopyStateDeleting.String(), "updated_at": time.Now()}}); err != nil {
+		return err
+	}
+
+	filter := bson.M{"userid": userid, "datasetid": id.Hex()}
+	if _, err := annotationCollection.DeleteMany(context.TODO(), filter); err != nil {
+		return err
+	}
+	if _, err := tagCollection.DeleteMany(context.TODO(), filter); err != nil {
+		return err
+	}
+	if _, err := contentCollection.UpdateMany(context.TODO(),
+		bson.M{"userid": userid, "datasets": id.Hex()},
+		bson.M{"$pull": bson.M{"datasets": id.Hex()}}); err != nil {
+		return err
+	}
+	// A restore abandoned before its labels were copied no longer holds up edits of the editable dataset
+	if _, err := datasetCollection.UpdateMany(context.TODO(),
+		bson.M{"userid": userid, "restoring": id.Hex()},
+		bson.M{"$unset": bson.M{"restoring": ""}}); err != nil {
+		return err
+	}
+
+	_, err := datasetCollection.DeleteOne(context.TODO(), bson.M{"_id": id, "userid": userid})
+	return err
+}
+
+// FindIncomplete returns dataset versions left pending, deleting or restoring since before the given time.
+func (d Dataset) FindIncomplete(db *db.DB, before time.Time) ([]models.Dataset, error) {
+	var datasets []models.Dataset
+
+	collection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{
+		"copy_state": bson.M{"$in": []string{
+			models.DatasetCopyStatePending.String(),
+			models.DatasetCopyStateDeleting.String(),
+			models.DatasetCopyStateRestoring.String(),
+			models.DatasetCopyStateSwapping.String(),
+		}},
+		"updated_at": bson.M{"$lt": before},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &datasets); err != nil {
+		return nil, err
+	}
+
+	return datasets, nil
+}
+
+// ClaimIncomplete takes ownership of an incomplete dataset version. The update only succeeds if the version has
+// not been modified since it was read, so only one service instance finishes or removes a given version.
+func (d Dataset) ClaimIncomplete(db *db.DB, dataset models.Dataset) (bool, error) {
+	collection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"_id": dataset.ID},
+			bson.M{"updated_at": dataset.UpdatedAt},
+		},
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// heartbeat refreshes the updated_at of a dataset version being copied, at most once per DatasetCopyHeartbeat
+type heartbeat struct {
+	db   *db.DB
+	id   primitive.ObjectID
+	last time.Time
+}
+
+func (h *heartbeat) beat() error {
+	if time.Since(h.last) < DatasetCopyHeartbeat {
+		return nil
+	}
+	h.last = time.Now()
+
+	collection := h.db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": h.id}, bson.M{"$set": bson.M{"updated_at": h.last}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dataset removed while being copied; dataset=%s", h.id.Hex())
+	}
+	return nil
+}
+
+// mapMetadataTags returns a copy of the metadata with the tag of every label mapped through tagMap (old -> new)
+func mapMetadataTags(metadata models.AnnotationMetadata, tagMap map[string]string) (models.AnnotationMetadata, error) {
+	mapTag := func(tag string) (string, error) {
+		if t, ok := tagMap[tag]; ok {
+			return t, nil
+		}
+		return "", fmt.Errorf("tag=%s", tag)
+	}
+
+	var err error
+	mapped := models.AnnotationMetadata{BoundingBoxes: []models.AnnotationDataBoundingBox{}}
+	for _, box := range metadata.BoundingBoxes {
+		if box.TagID, err = mapTag(box.TagID); err != nil {
+			return mapped, err
+		}
+		mapped.BoundingBoxes = append(mapped.BoundingBoxes, box)
+	}
+	for _, polygon := range metadata.Polygons {
+		if polygon.TagID, err = mapTag(polygon.TagID); err != nil {
+			return mapped, err
+		}
+		mapped.Polygons = append(mapped.Polygons, polygon)
+	}
+	for _, mask := range metadata.Masks {
+		if mask.TagID, err = mapTag(mask.TagID); err != nil {
+			return mapped, err
+		}
+		mapped.Masks = append(mapped.Masks, mask)
+	}
+	for _, object := range metadata.KeypointObjects {
+		if object.TagID, err = mapTag(object.TagID); err != nil {
+			return mapped, err
+		}
+		mapped.KeypointObjects = append(mapped.KeypointObjects, object)
+	}
+
+	return mapped, nil
+}
+
+// copyLabels copies the tags and annotations of one dataset to another, mapping tag ids to the new tags.
+// Only annotations for which keep returns true are copied; a nil keep copies all annotations.
+func (d Dataset) copyLabels(db *db.DB, from, to *models.Dataset, keep func(models.Annotation) bool) error {
+	tagCollection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	options := options.FindOptions{AllowDiskUse: aws.Bool(true)}
+	heartbeat := &heartbeat{db: db, id: to.ID, last: time.Now()}
+
 	// Copy over tags
 	cursor, err := tagCollection.Find(context.TODO(), bson.M{
-		"userid":    datasetToCopy.UserID,
-		"datasetid": datasetToCopy.ID.Hex(),
+		"userid":    from.UserID,
+		"datasetid": from.ID.Hex(),
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer cursor.Close(context.TODO())
 
 	var (
+		tags    []models.Tag
 		newTags []interface{}
 		tagMap  = make(map[string]string) // old -> new
 	)
@@ -123,74 +332,460 @@ func (d Dataset) Copy(db *db.DB, datasetToCopy *models.Dataset, lockDataset bool
 	for cursor.Next(context.TODO()) {
 		var tag models.Tag
 		if err = cursor.Decode(&tag); err != nil {
-			return nil, fmt.Errorf("error retrieving tag from database; user=%s dataset=%s err=%s", datasetToCopy.UserID, datasetToCopy.ID.Hex(), err.Error())
+			return fmt.Errorf("error retrieving tag from database; user=%s dataset=%s err=%s", from.UserID, from.ID.Hex(), err.Error())
 
 		}
-		t := models.NewTag(tag.UserID, tag.ProjectID, newDataset.ID.Hex(), tag.Name, tag.Property)
-		newTags = append(newTags, t)
+		t := models.NewTag(tag.UserID, tag.ProjectID, to.ID.Hex(), tag.Name, tag.Property)
+		t.ParentID = tag.ParentID
+		t.Skeleton = tag.Skeleton
+		tags = append(tags, t)
 		tagMap[tag.ID.Hex()] = t.ID.Hex()
 	}
 
-	_, err = tagCollection.InsertMany(context.TODO(), newTags)
-	if err != nil {
-		return nil, err
+	// Parents are mapped once all new tag ids are known
+	for _, t := range tags {
+		t.ParentID = tagMap[t.ParentID]
+		newTags = append(newTags, t)
+	}
+
+	if len(newTags) > 0 {
+		if _, err = tagCollection.InsertMany(context.TODO(), newTags); err != nil {
+			return err
+		}
 	}
 
 	// Copy over annotations
 	cursor, err = annotationCollection.Find(context.TODO(), bson.M{
-		"userid":    datasetToCopy.UserID,
-		"datasetid": datasetToCopy.ID.Hex(),
+		"userid":    from.UserID,
+		"datasetid": from.ID.Hex(),
 	}, &options)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer cursor.Close(context.TODO())
 
 	var newAnnotations []interface{}
+	insert := func() error {
+		if len(newAnnotations) == 0 {
+			return nil
+		}
+		if _, err := annotationCollection.InsertMany(context.TODO(), newAnnotations); err != nil {
+			return err
+		}
+		newAnnotations = newAnnotations[:0]
+		return heartbeat.beat()
+	}
+
 	for cursor.Next(context.TODO()) {
 		var annotation models.Annotation
 		if err = cursor.Decode(&annotation); err != nil {
-			return nil, fmt.Errorf("error retrieving annotation from database; user=%s dataset=%s err=%s", datasetToCopy.UserID, datasetToCopy.ID.Hex(), err.Error())
+			return fmt.Errorf("error retrieving annotation from database; user=%s dataset=%s err=%s", from.UserID, from.ID.Hex(), err.Error())
 
 		}
+		if keep != nil && !keep(annotation) {
+			continue
+		}
 
 		mappedTagIDs := []string{}
 		for _, tag := range annotation.TagIDs {
 			if t, ok := tagMap[tag]; ok {
 				mappedTagIDs = append(mappedTagIDs, t)
 			} else {
-				return nil, fmt.Errorf("error mapping new tags ids; user=%s dataset=%s err=%s", datasetToCopy.UserID, datasetToCopy.ID.Hex(), err.Error())
+				return fmt.Errorf("error mapping new tags ids; user=%s dataset=%s tag=%s", from.UserID, from.ID.Hex(), tag)
 			}
 		}
-		mappedMetadata := models.AnnotationMetadata{BoundingBoxes: []models.AnnotationDataBoundingBox{}}
-		for _, box := range annotation.Metadata.BoundingBoxes {
-			if t, ok := tagMap[box.TagID]; ok {
-				newBox := box
-				newBox.TagID = t
-				mappedMetadata.BoundingBoxes = append(mappedMetadata.BoundingBoxes, newBox)
-			} else {
-				return nil, fmt.Errorf("error mapping new tags ids; user=%s dataset=%s err=%s", datasetToCopy.UserID, datasetToCopy.ID.Hex(), err.Error())
-			}
+		mappedMetadata, err := mapMetadataTags(annotation.Metadata, tagMap)
+		if err != nil {
+			return fmt.Errorf("error mapping new tags ids; user=%s dataset=%s %s", from.UserID, from.ID.Hex(), err.Error())
 		}
 
-		t := models.NewAnnotation(annotation.UserID, annotation.ProjectID, newDataset.ID.Hex(), annotation.ContentID, mappedTagIDs, annotation.Base64Image, mappedMetadata, annotation.ContentMetadata)
+		t := models.NewAnnotation(annotation.UserID, annotation.ProjectID, to.ID.Hex(), annotation.ContentID, mappedTagIDs, annotation.Base64Image, mappedMetadata, annotation.ContentMetadata)
+		if annotation.Split != "" {
+			t.Split = annotation.Split // keep splits pinned by the user or assigned by training
+		}
+		if annotation.Status != "" {
+			t.Status = annotation.Status
+		}
+		t.Review = annotation.Review
 
 		newAnnotations = append(newAnnotations, t)
+		if len(newAnnotations) == datasetCopyBatchSize {
+			if err := insert(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return insert()
+}
+
+// Restore restores the tags, annotations and split settings of another version into an editable dataset. The labels
+// of the version are first copied into a pending dataset, so a failed copy leaves the editable dataset untouched,
+// and then applied to the editable dataset in place by FinishRestore. The editable dataset cannot be edited until
+// the restore is finished. Annotations of content that is no longer part of the project are not restored.
+func (d Dataset) Restore(db *db.DB, head *models.Dataset, version *models.Dataset) error {
+	datasetCollection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+	contentCollection := db.Client.Database(DATABASE).Collection(CONTENT_COLLECTION)
+
+	if head.Locked {
+		return ErrDatasetLocked
+	}
+	if head.Restoring != "" {
+		return ErrDatasetRestoring
 	}
 
-	_, err = annotationCollection.InsertMany(context.TODO(), newAnnotations)
+	contentIDs, err := contentCollection.Distinct(context.TODO(), "_id", bson.M{"userid": head.UserID, "projects": head.ProjectID})
 	if err != nil {
-		return nil, err
+		return err
+	}
+	content := make(map[string]struct{}, len(contentIDs))
+	for _, id := range contentIDs {
+		content[fmt.Sprint(id)] = struct{}{}
 	}
 
-	// Copy over dataset associations
-	if _, err := contentCollection.UpdateMany(context.TODO(),
-		bson.M{"userid": newDataset.UserID, "projects": newDataset.ProjectID},
-		bson.M{"$addToSet": bson.M{"datasets": newDataset.ID.Hex()}}); err != nil {
+	// The copy is never listed or found as a version of the project
+	restored := models.NewDataset(head.UserID, head.ProjectID)
+	restored.Version = -1
+	restored.Locked = true
+	restored.Split = version.Split
+	restored.SplitStrategy = version.SplitStrategy
+	restored.SplitSeed = version.SplitSeed
+	restored.CopyState = models.DatasetCopyStatePending.String()
+	restored.CopiedFrom = version.ID.Hex()
+	restored.RestoreTo = head.ID.Hex()
+	if _, err := datasetCollection.InsertOne(context.TODO(), restored); err != nil {
+		return err
+	}
+
+	// Only one restore at a time; the mark is removed along with the copy if the restore is abandoned
+	result, err := datasetCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": head.ID, "userid": head.UserID, "restoring": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"restoring": restored.ID.Hex()}})
+	if err == nil && result.MatchedCount == 0 {
+		err = ErrDatasetRestoring
+	}
+	if err == nil {
+		head.Restoring = restored.ID.Hex()
+		err = d.copyLabels(db, version, restored, func(annotation models.Annotation) bool {
+			_, ok := content[annotation.ContentID]
+			return ok
+		})
+	}
+	if err != nil {
+		if errDelete := d.DeleteVersion(db, restored.UserID, restored.ID); errDelete != nil {
+			return fmt.Errorf("error removing dataset=%s after failed restore; err=%s; restore err=%s", restored.ID.Hex(), errDelete.Error(), err.Error())
+		}
+		return err
+	}
+
+	// From here on the restore is finished rather than undone
+	restored.CopyState = models.DatasetCopyStateRestoring.String()
+	if _, err := datasetCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": restored.ID},
+		bson.M{"$set": bson.M{"copy_state": restored.CopyState, "updated_at": time.Now()}}); err != nil {
+		return err
+	}
+
+	return d.FinishRestore(db, restored)
+}
+
+// restoreTags merges the tags copied by a restore into the editable dataset by name. Tags of the editable dataset
+// keep their id and take the properties, parent and skeleton of the restored tag; missing tags are created. The
+// ids of the editable dataset's tags are returned keyed by those of the copies.
+func (d Dataset) restoreTags(db *db.DB, restored *models.Dataset, headID string) (map[string]string, error) {
+	tagCollection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+
+	list := func(datasetid string) ([]models.Tag, error) {
+		var tags []models.Tag
+		cursor, err := tagCollection.Find(context.TODO(), bson.M{"userid": restored.UserID, "datasetid": datasetid})
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(context.TODO())
+		return tags, cursor.All(context.TODO(), &tags)
+	}
+
+	copies, err := list(restored.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	tags, err := list(headID)
+	if err != nil {
 		return nil, err
 	}
+	byName := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag.ID.Hex()
+	}
 
-	return newDataset, nil
+	tagMap := make(map[string]string, len(copies)) // copy -> editable dataset
+	for _, tag := range copies {
+		if id, ok := byName[tag.Name]; ok {
+			tagMap[tag.ID.Hex()] = id
+			continue
+		}
+		t := models.NewTag(tag.UserID, tag.ProjectID, headID, tag.Name, tag.Property)
+		if _, err := tagCollection.InsertOne(context.TODO(), t); err != nil {
+			return nil, err
+		}
+		tagMap[tag.ID.Hex()] = t.ID.Hex()
+	}
+
+	// Parents are mapped once all tags exist
+	for _, tag := range copies {
+		id, err := primitive.ObjectIDFromHex(tagMap[tag.ID.Hex()])
+		if err != nil {
+			return nil, err
+		}
+		set := bson.M{"property": tag.Property, "updated_at": time.Now()}
+		unset := bson.M{}
+		if parentid := tagMap[tag.ParentID]; parentid != "" {
+			set["parentid"] = parentid
+		} else {
+			unset["parentid"] = ""
+		}
+		if tag.Skeleton != nil {
+			set["skeleton"] = tag.Skeleton
+		} else {
+			unset["skeleton"] = ""
+		}
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		if _, err := tagCollection.UpdateOne(context.TODO(), bson.M{"_id": id, "userid": restored.UserID}, update); err != nil {
+			return nil, err
+		}
+	}
+
+	return tagMap, nil
+}
+
+// FinishRestore applies the labels copied by a restore to the editable dataset in place, so that annotation and
+// tag ids, and everything referencing them, are kept. Tags are merged by name and annotations matched on their
+// content: annotations of content the restored version does not label are removed, those it labels relabelled
+// and missing ones added, each change recorded in the history of the annotation. Tags the restored version does not
+// have are removed last. Each step can be repeated, so an interrupted restore is finished by calling it again.
+func (d Dataset) FinishRestore(db *db.DB, restored *models.Dataset) error {
+	datasetCollection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+	tagCollection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	headID, err := primitive.ObjectIDFromHex(restored.RestoreTo)
+	if err != nil {
+		return err
+	}
+	copies := bson.M{"userid": restored.UserID, "datasetid": restored.ID.Hex()}
+	heartbeat := &heartbeat{db: db, id: restored.ID, last: time.Now()}
+
+	tagMap, err := d.restoreTags(db, restored, headID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if restored.CopyState == models.DatasetCopyStateRestoring.String() {
+		contentIDs, err := annotationCollection.Distinct(context.TODO(), "contentid", copies)
+		if err != nil {
+			return err
+		}
+		if err := d.removeRestoredAnnotations(db, restored.UserID, bson.M{
+			"userid":    restored.UserID,
+			"datasetid": headID.Hex(),
+			"contentid": bson.M{"$nin": contentIDs},
+		}); err != nil {
+			return err
+		}
+
+		restored.CopyState = models.DatasetCopyStateSwapping.String()
+		if _, err := datasetCollection.UpdateOne(context.TODO(),
+			bson.M{"_id": restored.ID},
+			bson.M{"$set": bson.M{"copy_state": restored.CopyState, "updated_at": time.Now()}}); err != nil {
+			return err
+		}
+	}
+	if restored.CopyState != models.DatasetCopyStateSwapping.String() {
+		return fmt.Errorf("dataset is not being restored; dataset=%s state=%s", restored.ID.Hex(), restored.CopyState)
+	}
+
+	// Annotations already applied are no longer part of the copy
+	cursor, err := annotationCollection.Find(context.TODO(), copies, &options.FindOptions{AllowDiskUse: aws.Bool(true)})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var annotation models.Annotation
+		if err := cursor.Decode(&annotation); err != nil {
+			return fmt.Errorf("error retrieving annotation from database; user=%s dataset=%s err=%s", restored.UserID, restored.ID.Hex(), err.Error())
+		}
+		if err := d.restoreAnnotation(db, annotation, headID.Hex(), tagMap); err != nil {
+			return err
+		}
+		if err := heartbeat.beat(); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	kept := make([]primitive.ObjectID, 0, len(tagMap))
+	for _, id := range tagMap {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return err
+		}
+		kept = append(kept, objID)
+	}
+	if _, err := tagCollection.DeleteMany(context.TODO(), bson.M{
+		"userid":    restored.UserID,
+		"datasetid": headID.Hex(),
+		"_id":       bson.M{"$nin": kept},
+	}); err != nil {
+		return err
+	}
+
+	if _, err := datasetCollection.UpdateOne(context.TODO(),
+		bson.M{"_id": headID, "userid": restored.UserID},
+		bson.M{
+			"$set": bson.M{
+				"split":          restored.Split,
+				"split_strategy": restored.SplitStrategy,
+				"split_seed":     restored.SplitSeed,
+				"updated_at":     time.Now(),
+			},
+			"$unset": bson.M{"restoring": ""},
+		}); err != nil {
+		return err
+	}
+
+	if _, err := tagCollection.DeleteMany(context.TODO(), copies); err != nil {
+		return err
+	}
+	_, err = datasetCollection.DeleteOne(context.TODO(), bson.M{"_id": restored.ID, "userid": restored.UserID})
+	return err
+}
+
+// removeRestoredAnnotations removes the annotations of an editable dataset that a restored version does not have,
+// recording their deletion in their history first
+func (d Dataset) removeRestoredAnnotations(db *db.DB, userid string, filter bson.M) error {
+	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	cursor, err := annotationCollection.Find(context.TODO(), filter, &options.FindOptions{
+		Projection:   bson.M{"b64_image": 0},
+		AllowDiskUse: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	var (
+		revisions []models.AnnotationRevision
+		ids       []primitive.ObjectID
+	)
+	remove := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := (Revision{}).Create(db, revisions...); err != nil {
+			return err
+		}
+		if _, err := annotationCollection.DeleteMany(context.TODO(), bson.M{"userid": userid, "_id": bson.M{"$in": ids}}); err != nil {
+			return err
+		}
+		revisions, ids = nil, nil
+		return nil
+	}
+
+	for cursor.Next(context.TODO()) {
+		var annotation models.Annotation
+		if err := cursor.Decode(&annotation); err != nil {
+			return fmt.Errorf("error retrieving annotation from database; user=%s err=%s", userid, err.Error())
+		}
+		revisions = append(revisions, models.NewAnnotationRevision(userid, userid, models.RevisionActionDelete, &annotation, nil))
+		ids = append(ids, annotation.ID)
+		if len(ids) == datasetCopyBatchSize {
+			if err := remove(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return remove()
+}
+
+// restoreAnnotation applies an annotation copied by a restore to the editable dataset. The annotation of the same
+// content is relabelled in place and the copy removed; without one, the copy is moved into the editable dataset. The
+// change is recorded as an update or creation of the annotation, as is done for changes made through the API.
+func (d Dataset) restoreAnnotation(db *db.DB, annotation models.Annotation, headID string, tagMap map[string]string) error {
+	annotationCollection := db.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	tagIDs := []string{}
+	for _, tag := range annotation.TagIDs {
+		t, ok := tagMap[tag]
+		if !ok {
+			return fmt.Errorf("error mapping restored tag ids; user=%s annotation=%s tag=%s", annotation.UserID, annotation.ID.Hex(), tag)
+		}
+		tagIDs = append(tagIDs, t)
+	}
+	metadata, err := mapMetadataTags(annotation.Metadata, tagMap)
+	if err != nil {
+		return fmt.Errorf("error mapping restored tag ids; user=%s annotation=%s %s", annotation.UserID, annotation.ID.Hex(), err.Error())
+	}
+
+	labels := bson.M{
+		"tagids":           tagIDs,
+		"metadata":         metadata,
+		"split":            annotation.Split,
+		"status":           annotation.Status,
+		"review":           annotation.Review,
+		"b64_image":        annotation.Base64Image,
+		"content_metadata": annotation.ContentMetadata,
+		"null_annotation":  annotation.IsNullAnnotation,
+		"updated_at":       time.Now(),
+	}
+
+	var current models.Annotation
+	err = annotationCollection.FindOne(context.TODO(), bson.M{
+		"userid":    annotation.UserID,
+		"datasetid": headID,
+		"contentid": annotation.ContentID,
+	}).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		restored := annotation
+		restored.DatasetID, restored.TagIDs, restored.Metadata = headID, tagIDs, metadata
+		if err := (Revision{}).Create(db, models.NewAnnotationRevision(annotation.UserID, annotation.UserID, models.RevisionActionCreate, nil, &restored)); err != nil {
+			return err
+		}
+
+		labels["datasetid"] = headID
+		_, err = annotationCollection.UpdateOne(context.TODO(), bson.M{"_id": annotation.ID, "userid": annotation.UserID}, bson.M{"$set": labels})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	restored := current
+	restored.TagIDs, restored.Metadata = tagIDs, metadata
+	if !current.State().Equal(restored.State()) {
+		if err := (Revision{}).Create(db, models.NewAnnotationRevision(current.UserID, current.UserID, models.RevisionActionUpdate, &current, &restored)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := annotationCollection.UpdateOne(context.TODO(), bson.M{"_id": current.ID, "userid": current.UserID}, bson.M{"$set": labels}); err != nil {
+		return err
+	}
+	_, err = annotationCollection.DeleteOne(context.TODO(), bson.M{"_id": annotation.ID, "userid": annotation.UserID})
+	return err
 }
 
 // Create creates a new dataset to the db
@@ -254,18 +849,38 @@ func (d Dataset) Update(db *db.DB, dataset *models.Dataset) error {
 		}
 		update["split"] = dataset.Split
 	}
+	if dataset.SplitStrategy != "" {
+		strategy, err := models.SplitStrategyFromString(dataset.SplitStrategy)
+		if err != nil {
+			return err
+		}
+		update["split_strategy"] = strategy.String()
+	}
+	if dataset.SplitSeed != 0 {
+		update["split_seed"] = dataset.SplitSeed
+	}
 
 	filter := bson.M{
 		"$and": []interface{}{
 			bson.M{"_id": dataset.ID},
 			bson.M{"userid": dataset.UserID},
+			bson.M{"restoring": bson.M{"$exists": false}},
 		},
 	}
 
-	_, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": update})
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": update})
 	if err != nil {
 		return err
 	}
+	if result.MatchedCount == 0 {
+		count, err := collection.CountDocuments(context.TODO(), bson.M{"_id": dataset.ID, "userid": dataset.UserID})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDatasetRestoring
+		}
+	}
 
 	return nil
 }
@@ -282,7 +897,8 @@ func (d Dataset) List(db *db.DB, userid string, page models.Pagination) ([]model
 	options.SetLimit(int64(page.Limit))
 	options.SetSkip(int64(page.Offset))
 
-	cursor, err := collection.Find(context.TODO(), bson.M{"userid": userid}, options)
+	// Versions being copied or removed are left out
+	cursor, err := collection.Find(context.TODO(), bson.M{"userid": userid, "copy_state": bson.M{"$exists": false}}, options)
 	if err != nil {
 		return nil, err
 	}
@@ -309,11 +925,12 @@ func (d Dataset) Query(db *db.DB, userid string, q models.Query) ([]models.Datas
 	options.SetLimit(int64(q.Limit))
 	options.SetSkip(int64(q.Offset))
 
-	// Build filter
+	// Build filter; versions being copied or removed are left out
 	filter, err := q.NewQueryFilter(userid)
 	if err != nil {
 		return nil, err
 	}
+	filter = bson.M{"$and": []interface{}{filter, bson.M{"copy_state": bson.M{"$exists": false}}}}
 
 	cursor, err := collection.Find(context.TODO(), filter, options)
 	if err != nil {
@@ -328,6 +945,32 @@ func (d Dataset) Query(db *db.DB, userid string, q models.Query) ([]models.Datas
 	return datasets, nil
 }
 
+// Versions returns all complete versions of a project's dataset ordered by version.
+func (d Dataset) Versions(db *db.DB, userid, projectid string) ([]models.Dataset, error) {
+	var datasets []models.Dataset
+
+	collection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
+
+	options := options.Find()
+	options.SetSort(bson.M{"version": 1})
+
+	cursor, err := collection.Find(context.TODO(), bson.M{
+		"userid":     userid,
+		"projectid":  projectid,
+		"copy_state": bson.M{"$exists": false},
+	}, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &datasets); err != nil {
+		return nil, err
+	}
+
+	return datasets, nil
+}
+
 func (d *Dataset) FindVersion(db *db.DB, userid, projectid string, version int, opts ...*options.FindOptions) (*mongo.Cursor, error) {
 	collection := db.Client.Database(DATABASE).Collection(DATASET_COLLECTION)
 
@@ -0,0 +1,52 @@
+/*
+ * File: dataset_test.go
+ * Project: platform
+ * File Created: Tuesday, 20th February 2024 10:02:36 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 10:02:36 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestMapMetadataTags(t *testing.T) {
+	tagMap := map[string]string{"a": "new-a", "b": "new-b"}
+
+	metadata := models.AnnotationMetadata{
+		BoundingBoxes: []models.AnnotationDataBoundingBox{{TagID: "a", Xmax: 4, Ymax: 4}},
+		Polygons:      []models.AnnotationDataPolygon{{TagID: "b", Points: []float64{0, 0, 4, 0, 4, 4}, Area: 8}},
+		Masks:         []models.AnnotationDataMask{{TagID: "a", Height: 2, Width: 2, Counts: []int{1, 3}, Area: 3}},
+		KeypointObjects: []models.AnnotationDataKeypointObject{{
+			AnnotationDataBoundingBox: models.AnnotationDataBoundingBox{TagID: "b", Xmax: 4, Ymax: 4},
+			Keypoints:                 []models.AnnotationDataKeypoint{{X: 1, Y: 1, Visibility: models.KeypointVisible}},
+		}},
+	}
+
+	mapped, err := mapMetadataTags(metadata, tagMap)
+	require.NoError(t, err)
+	assert.Equal(t, models.AnnotationMetadata{
+		BoundingBoxes: []models.AnnotationDataBoundingBox{{TagID: "new-a", Xmax: 4, Ymax: 4}},
+		Polygons:      []models.AnnotationDataPolygon{{TagID: "new-b", Points: []float64{0, 0, 4, 0, 4, 4}, Area: 8}},
+		Masks:         []models.AnnotationDataMask{{TagID: "new-a", Height: 2, Width: 2, Counts: []int{1, 3}, Area: 3}},
+		KeypointObjects: []models.AnnotationDataKeypointObject{{
+			AnnotationDataBoundingBox: models.AnnotationDataBoundingBox{TagID: "new-b", Xmax: 4, Ymax: 4},
+			Keypoints:                 []models.AnnotationDataKeypoint{{X: 1, Y: 1, Visibility: models.KeypointVisible}},
+		}},
+	}, mapped)
+
+	// The source metadata is left untouched
+	assert.Equal(t, "b", metadata.Polygons[0].TagID)
+
+	// Labels of unknown tags are not copied
+	_, err = mapMetadataTags(models.AnnotationMetadata{Masks: []models.AnnotationDataMask{{TagID: "c"}}}, tagMap)
+	assert.EqualError(t, err, "tag=c")
+}
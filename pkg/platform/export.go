@@ -234,15 +234,21 @@ func (e *Export) Delete(db *db.DB, exportid primitive.ObjectID) error {
 	return err
 }
 
+// RemoveModelFromExports removes the metadata of a deleted model from its exports
 func (e *Export) RemoveModelFromExports(db *db.DB, userid, modelid string) error {
+	objID, err := primitive.ObjectIDFromHex(modelid)
+	if err != nil {
+		return err
+	}
+
 	filter := bson.M{
 		"$and": []interface{}{
 			bson.M{"userid": userid},
-			bson.M{"modelid": modelid},
+			bson.M{"model._id": objID},
 		},
 	}
 
-	update := bson.M{"$set": bson.M{"modelid": ""}}
+	update := bson.M{"$unset": bson.M{"model": ""}}
 
 	return e.UpdateMany(db, filter, update)
 }
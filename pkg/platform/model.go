@@ -31,6 +31,7 @@ var (
 	ErrModelAlreadyExists     = echo.NewHTTPError(http.StatusConflict, "Model already exists.")
 	ErrModelDoesNotExist      = echo.NewHTTPError(http.StatusNotFound, "Model does not exist.")
 	ErrModelNameAlreadyExists = echo.NewHTTPError(http.StatusConflict, "Model name already exists.")
+	ErrModelStateChanged      = echo.NewHTTPError(http.StatusConflict, "Model state changed.")
 )
 
 // Model represents the client for model table
@@ -48,6 +49,7 @@ type ModelDB interface {
 	List(*db.DB, string, string, models.Pagination) ([]models.Model, int64, error)
 	Query(*db.DB, string, models.Query) ([]models.Model, int64, error)
 	Update(*db.DB, models.Model) error
+	Transition(*db.DB, models.Model, string, ...models.ModelState) error
 	DeleteMany(*db.DB, string, primitive.ObjectID) error
 	FindProjectModels(*db.DB, string, string, ...*options.FindOptions) (*mongo.Cursor, error)
 	ProjectCount(*db.DB, string, string) (int64, error)
@@ -143,13 +145,71 @@ func (m Model) Update(db *db.DB, model models.Model) error {
 		},
 	}
 
+	update, err := modelUpdate(collection, model)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{"$set": update},
+	)
+
+	return err
+}
+
+// Transition updates the model as Update does, provided it is still in one of the given states of the given training
+// run; an empty run matches models never queued for training. ErrModelStateChanged is returned otherwise.
+func (m Model) Transition(db *db.DB, model models.Model, runid string, states ...models.ModelState) error {
+	collection := db.Client.Database(DATABASE).Collection(MODEL_COLLECTION)
+
+	from := []string{}
+	for _, state := range states {
+		from = append(from, state.String())
+	}
+	run := bson.M{"train_run_id": runid}
+	if runid == "" {
+		run = bson.M{"train_run_id": bson.M{"$exists": false}}
+	}
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"_id": model.ID},
+			bson.M{"userid": model.UserID},
+			bson.M{"state": bson.M{"$in": from}},
+			run,
+		},
+	}
+
+	update, err := modelUpdate(collection, model)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrModelStateChanged
+	}
+
+	return nil
+}
+
+// modelUpdate returns the fields set on the model
+func modelUpdate(collection *mongo.Collection, model models.Model) (map[string]interface{}, error) {
 	var update = make(map[string]interface{})
 
 	update["updated_at"] = time.Now()
 	if model.Name != "" {
 		// Check that model name is not already being used.
 		if err := modelExists(collection, model); err != nil {
-			return err
+			return nil, err
 		}
 		update["name"] = model.Name
 	}
@@ -162,12 +222,24 @@ func (m Model) Update(db *db.DB, model models.Model) error {
 	if model.Path != "" {
 		update["path"] = model.Path
 	}
+	if model.TrainingBackend != "" {
+		update["training_backend"] = model.TrainingBackend
+	}
+	if model.ArtifactPath != "" {
+		update["artifact_path"] = model.ArtifactPath
+	}
+	if len(model.HyperParameters) != 0 {
+		update["hyperparameters"] = model.HyperParameters
+	}
 	if model.Metrics != nil {
 		update["metrics"] = model.Metrics
 	}
 	if model.State != "" {
 		update["state"] = model.State
 	}
+	if model.TrainRunID != "" {
+		update["train_run_id"] = model.TrainRunID
+	}
 	if !time.Time.IsZero(model.TrainStartedAt) {
 		update["train_started_at"] = model.TrainStartedAt
 	}
@@ -186,12 +258,18 @@ func (m Model) Update(db *db.DB, model models.Model) error {
 	if !cmp.Equal(model.Parameters, models.TrainParameters{}) {
 		update["parameters"] = model.Parameters
 	}
+	if model.TrainedParameters != nil {
+		update["trained_parameters"] = model.TrainedParameters
+	}
 	if !cmp.Equal(model.Augmentation, models.Augmentations{}) {
 		update["augmentation"] = model.Augmentation
 	}
 	if !cmp.Equal(model.Preprocessing, models.Preprocessors{}) {
 		update["preprocessing"] = model.Preprocessing
 	}
+	if model.ApprovedOnly != nil {
+		update["approved_only"] = model.ApprovedOnly
+	}
 	if !cmp.Equal(model.Deployment, models.Deployment{}) {
 		update["deployment"] = model.Deployment
 	}
@@ -199,13 +277,7 @@ func (m Model) Update(db *db.DB, model models.Model) error {
 		update["batch"] = model.Batch
 	}
 
-	_, err := collection.UpdateOne(
-		context.TODO(),
-		filter,
-		bson.M{"$set": update},
-	)
-
-	return err
+	return update, nil
 }
 
 // Delete is a method for deleting a model by ID
@@ -0,0 +1,66 @@
+/*
+ * File: model_test.go
+ * Project: platform
+ * File Created: Wednesday, 21st February 2024 3:18:09 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 21st February 2024 3:18:09 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ *
+ * This is an integration test and requires a local instance of mongo; it is skipped when none is reachable.
+ */
+package platform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestModelTransitionIntegration(t *testing.T) {
+	database := testDB(t)
+	modelDB := NewModel()
+
+	model := models.NewModel(fmt.Sprintf("integration-test-%s", common.ShortUUID(6)), "user", "project", "dataset", "bucket", models.Preprocessors{}, models.Augmentations{})
+	_, err := modelDB.Create(database, model)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = modelDB.Delete(database, model.UserID, model.ID.Hex()) })
+
+	state := func() (string, string) {
+		current, err := modelDB.View(database, model.UserID, model.ID.Hex())
+		require.NoError(t, err)
+		return current.State, current.TrainRunID
+	}
+	transition := func(to models.ModelState, runid, newRunID string, from ...models.ModelState) error {
+		return modelDB.Transition(database, models.Model{ID: model.ID, UserID: model.UserID, State: to.String(), TrainRunID: newRunID}, runid, from...)
+	}
+
+	// Models never queued for training match the empty run
+	require.NoError(t, transition(models.ModelStateInitialized, "", "run-1", models.ModelStateInitialized))
+	s, run := state()
+	assert.Equal(t, models.ModelStateInitialized.String(), s)
+	assert.Equal(t, "run-1", run)
+
+	// Transitions from other states or runs are rejected
+	assert.Equal(t, ErrModelStateChanged, transition(models.ModelStateTraining, "run-1", "", models.ModelStateTrained))
+	assert.Equal(t, ErrModelStateChanged, transition(models.ModelStateTraining, "", "", models.ModelStateInitialized))
+
+	// A cancellation is not overwritten by the worker
+	require.NoError(t, transition(models.ModelStateCancelled, "run-1", "", models.ModelStateInitialized, models.ModelStateTraining))
+	assert.Equal(t, ErrModelStateChanged, transition(models.ModelStateTraining, "run-1", "", models.ModelStateInitialized))
+
+	// A cancelled run does not touch training queued again
+	require.NoError(t, transition(models.ModelStateInitialized, "run-1", "run-2", models.ModelStateCancelled))
+	assert.Equal(t, ErrModelStateChanged, transition(models.ModelStateTraining, "run-1", "", models.ModelStateInitialized))
+	assert.Equal(t, ErrModelStateChanged, transition(models.ModelStateCancelled, "run-1", "", models.ModelStateInitialized, models.ModelStateTraining, models.ModelStateCancelled))
+
+	require.NoError(t, transition(models.ModelStateTraining, "run-2", "", models.ModelStateInitialized))
+	s, run = state()
+	assert.Equal(t, models.ModelStateTraining.String(), s)
+	assert.Equal(t, "run-2", run)
+}
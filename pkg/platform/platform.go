@@ -22,6 +22,9 @@ var (
 	_ UserDB       = (*User)(nil)
 	_ ProjectDB    = (*Project)(nil)
 	_ PredictionDB = (*Prediction)(nil)
+	_ UploadDB     = (*Upload)(nil)
+	_ RevisionDB   = (*Revision)(nil)
+	_ TaskDB       = (*Task)(nil)
 )
 
 type Platform struct {
@@ -34,6 +37,9 @@ type Platform struct {
 	DatasetDB    *Dataset
 	AnnotationDB *Annotation
 	PredictionDB *Prediction
+	UploadDB     *Upload
+	RevisionDB   *Revision
+	TaskDB       *Task
 }
 
 type Configuration struct {
@@ -52,6 +58,9 @@ func NewPlatform() *Platform {
 		DatasetDB:    NewDataset(),
 		AnnotationDB: NewAnnotation(),
 		PredictionDB: NewPrediction(),
+		UploadDB:     NewUpload(),
+		RevisionDB:   NewRevision(),
+		TaskDB:       NewTask(),
 	}
 }
 
@@ -65,5 +74,8 @@ func (p *Platform) Indices() []func(*db.DB) error {
 		p.ExportDB.Index,
 		p.ModelDB.Index,
 		p.PredictionDB.Index,
+		p.UploadDB.Index,
+		p.RevisionDB.Index,
+		p.TaskDB.Index,
 	}
 }
@@ -47,6 +47,7 @@ type PredictionDB interface {
 	Sample(*db.DB, string, string, []string, float64, int) ([]models.Prediction, error)
 	PredictionsPerClass(*db.DB, models.Model, float64, ...string) ([]map[string]interface{}, error)
 	View(*db.DB, string, string) (*models.Prediction, error)
+	FindModelPredictions(*db.DB, string, string, ...*options.FindOptions) (*mongo.Cursor, error)
 }
 
 func (p Prediction) Index(db *db.DB) error {
@@ -232,6 +233,19 @@ func (p Prediction) Sample(db *db.DB, userid, modelid string, tagNames []string,
 	return predictions, nil
 }
 
+// Finds predictions associated with a model.
+// It is up to the caller to close the returned cursor.
+func (p Prediction) FindModelPredictions(db *db.DB, userid, modelid string, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	collection := db.Client.Database(DATABASE).Collection(PREDICTION_COLLECTION)
+
+	filter := bson.M{
+		"userid":  userid,
+		"modelid": modelid,
+	}
+
+	return collection.Find(context.TODO(), filter, opts...)
+}
+
 // Count counts the number of predictions in collection.
 func (p Prediction) Count(db *db.DB, model models.Model) (int64, error) {
 	collection := db.Client.Database(DATABASE).Collection(PREDICTION_COLLECTION)
@@ -0,0 +1,101 @@
+/*
+ * File: review_test.go
+ * Project: platform
+ * File Created: Monday, 4th March 2024 2:15:43 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 4th March 2024 2:15:43 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ *
+ * This is an integration test and requires a local instance of mongo; it is skipped when none is reachable.
+ */
+package platform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestAnnotationReviewIntegration(t *testing.T) {
+	database := testDB(t)
+	annotations := NewAnnotation()
+	collection := database.Client.Database(DATABASE).Collection(ANNOTATION_COLLECTION)
+
+	userid := fmt.Sprintf("integration-test-%s", common.ShortUUID(6))
+	t.Cleanup(func() { _, _ = collection.DeleteMany(context.TODO(), bson.M{"userid": userid}) })
+
+	// An annotation in each state, and one created before reviews were introduced
+	byStatus := make(map[string]*models.Annotation)
+	ids := []primitive.ObjectID{}
+	for _, status := range []string{"", "DRAFT", "SUBMITTED", "APPROVED", "REJECTED"} {
+		annotation := models.NewAnnotation(userid, "project", "dataset", status, []string{}, "", models.AnnotationMetadata{}, models.ContentMetadata{})
+		annotation.Status = status
+		_, err := collection.InsertOne(context.TODO(), annotation)
+		require.NoError(t, err)
+		byStatus[status] = annotation
+		ids = append(ids, annotation.ID)
+	}
+	status := func(annotation *models.Annotation) string {
+		a, err := annotations.View(database, userid, annotation.ID.Hex())
+		require.NoError(t, err)
+		return a.Status
+	}
+
+	// Annotations without a status are drafts
+	drafts, count, err := annotations.ListStatus(database, userid, "project", "dataset", models.AnnotationStatusDraft, models.Pagination{SortKey: "_id", SortVal: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Len(t, drafts, 2)
+
+	queue, _, err := annotations.ListStatus(database, userid, "project", "dataset", models.AnnotationStatusSubmitted, models.Pagination{SortKey: "_id", SortVal: 1})
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, byStatus["SUBMITTED"].ID, queue[0].ID)
+
+	// Only submitted annotations are reviewed
+	review := models.AnnotationReview{ReviewerID: "reviewer", Comment: "box too loose", ReviewedAt: time.Now()}
+	reviewed, err := annotations.Review(database, userid, models.AnnotationStatusRejected, review, ids)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reviewed)
+
+	for previous, annotation := range byStatus {
+		if previous == "SUBMITTED" {
+			continue
+		}
+		assert.Equal(t, previous, status(annotation))
+	}
+	rejected, err := annotations.View(database, userid, byStatus["SUBMITTED"].ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.AnnotationStatusRejected.String(), rejected.Status)
+	require.NotNil(t, rejected.Review)
+	assert.Equal(t, "reviewer", rejected.Review.ReviewerID)
+	assert.Equal(t, "box too loose", rejected.Review.Comment)
+
+	// A reviewed annotation is not reviewed again until it is resubmitted
+	reviewed, err = annotations.Review(database, userid, models.AnnotationStatusApproved, review, ids)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), reviewed)
+
+	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": rejected.ID}, bson.M{"$set": bson.M{"status": models.AnnotationStatusSubmitted.String()}})
+	require.NoError(t, err)
+	reviewed, err = annotations.Review(database, userid, models.AnnotationStatusApproved, review, ids)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reviewed)
+	assert.Equal(t, models.AnnotationStatusApproved.String(), status(rejected))
+
+	// Annotations can only be approved or rejected
+	for _, s := range []models.AnnotationStatus{models.AnnotationStatusDraft, models.AnnotationStatusSubmitted} {
+		_, err = annotations.Review(database, userid, s, review, ids)
+		assert.Equal(t, models.ErrInvalidAnnotationStatus, err)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * File: revision.go
+ * Project: platform
+ * File Created: Thursday, 15th February 2024 2:25:19 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 15th February 2024 2:25:19 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package platform
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// Custom errors
+var (
+	ErrRevisionDoesNotExist = echo.NewHTTPError(http.StatusNotFound, "Revision does not exists.")
+)
+
+// Revision represents the client for annotation revision table
+type Revision struct{}
+
+func NewRevision() *Revision {
+	return &Revision{}
+}
+
+// RevisionDB represents annotation revision repository interface. Revisions are never updated.
+type RevisionDB interface {
+	Index(*db.DB) error
+	Create(*db.DB, ...models.AnnotationRevision) error
+	View(*db.DB, string, string) (*models.AnnotationRevision, error)
+	List(*db.DB, string, string, models.Pagination) ([]models.AnnotationRevision, int64, error)
+	DeleteProjectRevisions(*db.DB, string, string) error
+}
+
+func (r Revision) Index(db *db.DB) error {
+	collection := db.Client.Database(DATABASE).Collection(REVISION_COLLECTION)
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "_id", Value: 1}, {Key: "userid", Value: 1}},
+			Options: &options.IndexOptions{Unique: common.Ptr(true), Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "annotationid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "projectid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(context.TODO(), models); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create appends revisions to the history of their annotations
+func (r Revision) Create(db *db.DB, revisions ...models.AnnotationRevision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	collection := db.Client.Database(DATABASE).Collection(REVISION_COLLECTION)
+
+	documents := make([]interface{}, len(revisions))
+	for i, revision := range revisions {
+		documents[i] = revision
+	}
+
+	_, err := collection.InsertMany(context.TODO(), documents)
+	return err
+}
+
+// View returns single revision by ID
+func (r Revision) View(db *db.DB, userid, id string) (*models.AnnotationRevision, error) {
+	collection := db.Client.Database(DATABASE).Collection(REVISION_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRevisionDoesNotExist
+	}
+
+	revision := models.AnnotationRevision{}
+	if err := collection.FindOne(context.TODO(), bson.M{"_id": objID, "userid": userid}).Decode(&revision); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRevisionDoesNotExist
+		}
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// List returns the history of an annotation, newest first unless sorted otherwise
+func (r Revision) List(db *db.DB, userid, annotationid string, p models.Pagination) ([]models.AnnotationRevision, int64, error) {
+	var revisions = []models.AnnotationRevision{}
+
+	collection := db.Client.Database(DATABASE).Collection(REVISION_COLLECTION)
+
+	options := options.Find()
+	options.SetSort(bson.M{p.SortKey: p.SortVal})
+	options.SetLimit(int64(p.Limit))
+	options.SetSkip(int64(p.Offset))
+
+	filter := bson.M{
+		"userid":       userid,
+		"annotationid": annotationid,
+	}
+
+	cursor, err := collection.Find(context.TODO(), filter, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), &revisions); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, count, nil
+}
+
+// DeleteProjectRevisions deletes the history of all annotations of a project
+func (r Revision) DeleteProjectRevisions(db *db.DB, userid, projectid string) error {
+	collection := db.Client.Database(DATABASE).Collection(REVISION_COLLECTION)
+
+	_, err := collection.DeleteMany(context.TODO(), bson.M{
+		"userid":    userid,
+		"projectid": projectid,
+	})
+
+	return err
+}
@@ -48,6 +48,8 @@ type TagDB interface {
 	DeleteDatasetTags(*db.DB, string, string, string) error
 	Delete(*db.DB, primitive.ObjectID) error
 	CopyToNewDataset(*db.DB, string, string) error
+	SetParent(*db.DB, string, primitive.ObjectID, string) error
+	ReplaceParent(*db.DB, string, string, string, string) error
 	TagIntegerMap(*db.DB, string, string) (map[string]int, error)
 }
 
@@ -221,6 +223,34 @@ func (t Tag) FindByName(db *db.DB, userid, datasetid, name string, opts ...*opti
 	return tag, nil
 }
 
+// SetParent sets the parent of a tag; an empty parentid makes it a top level tag
+func (t Tag) SetParent(db *db.DB, userid string, id primitive.ObjectID, parentid string) error {
+	collection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+
+	update := bson.M{"$set": bson.M{"parentid": parentid, "updated_at": time.Now()}}
+	if parentid == "" {
+		update = bson.M{"$unset": bson.M{"parentid": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"userid": userid, "_id": id}, update)
+
+	return err
+}
+
+// ReplaceParent moves the children of a tag to another parent within a dataset; an empty parent makes them top level tags
+func (t Tag) ReplaceParent(db *db.DB, userid, datasetid, from, to string) error {
+	collection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
+
+	update := bson.M{"$set": bson.M{"parentid": to, "updated_at": time.Now()}}
+	if to == "" {
+		update = bson.M{"$unset": bson.M{"parentid": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+
+	_, err := collection.UpdateMany(context.TODO(), bson.M{"userid": userid, "datasetid": datasetid, "parentid": from}, update)
+
+	return err
+}
+
 // Update updates tag info
 func (t Tag) Update(db *db.DB, tag models.Tag) error {
 	collection := db.Client.Database(DATABASE).Collection(TAG_COLLECTION)
@@ -240,6 +270,9 @@ func (t Tag) Update(db *db.DB, tag models.Tag) error {
 	if len(tag.Property) > 0 {
 		update["property"] = tag.Property
 	}
+	if tag.Skeleton != nil {
+		update["skeleton"] = tag.Skeleton
+	}
 
 	_, err := collection.UpdateOne(
 		context.TODO(),
@@ -0,0 +1,610 @@
+/*
+ * File: task.go
+ * Project: platform
+ * File Created: Friday, 16th February 2024 9:52:10 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 16th February 2024 9:52:10 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package platform
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// Custom errors
+var (
+	ErrTaskDoesNotExist      = echo.NewHTTPError(http.StatusNotFound, "Task does not exists.")
+	ErrTaskBatchDoesNotExist = echo.NewHTTPError(http.StatusNotFound, "Task batch does not exists.")
+	ErrTaskItemDoesNotExist  = echo.NewHTTPError(http.StatusNotFound, "Task item does not exists.")
+	ErrTaskItemNotLocked     = echo.NewHTTPError(http.StatusConflict, "Task item is not held by the labeller.")
+	ErrTaskComplete          = echo.NewHTTPError(http.StatusNotFound, "No items left to label.")
+)
+
+// Task represents the client for labelling task, task item and task label tables
+type Task struct{}
+
+func NewTask() *Task {
+	return &Task{}
+}
+
+// TaskDB represents labelling task repository interface
+type TaskDB interface {
+	Index(*db.DB) error
+	Create(*db.DB, models.Task, []models.TaskItem) error
+	View(*db.DB, string, string) (models.Task, error)
+	ViewAssigned(*db.DB, string, string) (models.Task, error)
+	List(*db.DB, string, string, models.Pagination) ([]models.Task, int64, error)
+	ListAssigned(*db.DB, string, models.Pagination) ([]models.Task, int64, error)
+	Assign(*db.DB, string, string, string, string) error
+	Delete(*db.DB, string, string) error
+	DeleteProjectTasks(*db.DB, string, string) error
+
+	PendingContent(*db.DB, string, string) ([]string, error)
+	Next(*db.DB, string, string) (*models.TaskItem, error)
+	ViewItem(*db.DB, string, string) (*models.TaskItem, error)
+	Complete(*db.DB, string, string, models.TaskItemState, string, string) error
+	Release(*db.DB, string, string) error
+	Throughput(*db.DB, string, string) ([]models.Throughput, error)
+
+	SaveLabel(*db.DB, models.TaskLabel) (*models.TaskLabel, error)
+	ListLabels(*db.DB, string, string, ...string) ([]models.TaskLabel, error)
+}
+
+func (t Task) Index(db *db.DB) error {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "_id", Value: 1}, {Key: "userid", Value: 1}},
+			Options: &options.IndexOptions{Unique: common.Ptr(true), Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "projectid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "batches.assigneeid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(context.TODO(), models); err != nil {
+		return err
+	}
+
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	itemModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "taskid", Value: 1}, {Key: "assigneeid", Value: 1}, {Key: "state", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "taskid", Value: 1}, {Key: "batchid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+	}
+
+	if _, err := itemCollection.Indexes().CreateMany(context.TODO(), itemModels); err != nil {
+		return err
+	}
+
+	labelCollection := db.Client.Database(DATABASE).Collection(TASK_LABEL_COLLECTION)
+
+	labelModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "taskid", Value: 1}, {Key: "contentid", Value: 1}, {Key: "labellerid", Value: 1}},
+			Options: &options.IndexOptions{Unique: common.Ptr(true), Background: common.Ptr(true)},
+		},
+	}
+
+	if _, err := labelCollection.Indexes().CreateMany(context.TODO(), labelModels); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create creates a task along with its items. The task is removed again if its items cannot be created.
+func (t Task) Create(db *db.DB, task models.Task, items []models.TaskItem) error {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	if _, err := collection.InsertOne(context.TODO(), task); err != nil {
+		return err
+	}
+
+	documents := make([]interface{}, len(items))
+	for i, item := range items {
+		documents[i] = item
+	}
+	if len(documents) > 0 {
+		if _, err := itemCollection.InsertMany(context.TODO(), documents); err != nil {
+			if err := t.Delete(db, task.UserID, task.ID.Hex()); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// View returns a task of the project owner
+func (t Task) View(db *db.DB, userid, id string) (models.Task, error) {
+	return t.view(db, id, bson.M{"userid": userid})
+}
+
+// ViewAssigned returns a task with a batch assigned to a labeller
+func (t Task) ViewAssigned(db *db.DB, assigneeid, id string) (models.Task, error) {
+	return t.view(db, id, bson.M{"batches.assigneeid": assigneeid})
+}
+
+func (t Task) view(db *db.DB, id string, filter bson.M) (models.Task, error) {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.Task{}, ErrTaskDoesNotExist
+	}
+	filter["_id"] = objID
+
+	task := models.Task{}
+	if err := collection.FindOne(context.TODO(), filter).Decode(&task); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Task{}, ErrTaskDoesNotExist
+		}
+		return models.Task{}, err
+	}
+
+	return task, nil
+}
+
+// List returns the tasks of a project
+func (t Task) List(db *db.DB, userid, projectid string, p models.Pagination) ([]models.Task, int64, error) {
+	return t.list(db, bson.M{"userid": userid, "projectid": projectid}, p)
+}
+
+// ListAssigned returns the tasks with a batch assigned to a labeller
+func (t Task) ListAssigned(db *db.DB, assigneeid string, p models.Pagination) ([]models.Task, int64, error) {
+	return t.list(db, bson.M{"batches.assigneeid": assigneeid}, p)
+}
+
+func (t Task) list(db *db.DB, filter bson.M, p models.Pagination) ([]models.Task, int64, error) {
+	var tasks = []models.Task{}
+
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+
+	options := options.Find()
+	options.SetSort(bson.M{p.SortKey: p.SortVal})
+	options.SetLimit(int64(p.Limit))
+	options.SetSkip(int64(p.Offset))
+
+	cursor, err := collection.Find(context.TODO(), filter, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err = cursor.All(context.TODO(), &tasks); err != nil {
+		return nil, 0, err
+	}
+
+	count, err := collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, count, nil
+}
+
+// Assign assigns a batch to another labeller. Items that have not been labelled move with the batch; items held
+// by the previous labeller are released.
+func (t Task) Assign(db *db.DB, userid, taskid, batchid, assigneeid string) error {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	taskObjID, err := primitive.ObjectIDFromHex(taskid)
+	if err != nil {
+		return ErrTaskDoesNotExist
+	}
+	batchObjID, err := primitive.ObjectIDFromHex(batchid)
+	if err != nil {
+		return ErrTaskBatchDoesNotExist
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), bson.M{
+		"_id":         taskObjID,
+		"userid":      userid,
+		"batches._id": batchObjID,
+	}, bson.M{
+		"$set": bson.M{"batches.$.assigneeid": assigneeid, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTaskBatchDoesNotExist
+	}
+
+	_, err = itemCollection.UpdateMany(context.TODO(), bson.M{
+		"userid":  userid,
+		"taskid":  taskid,
+		"batchid": batchid,
+		"state":   models.TaskItemStatePending.String(),
+	}, bson.M{
+		"$set":   bson.M{"assigneeid": assigneeid, "updated_at": time.Now()},
+		"$unset": bson.M{"lockedby": "", "locked_until": ""},
+	})
+
+	return err
+}
+
+// Delete deletes a task, its items and labels
+func (t Task) Delete(db *db.DB, userid, id string) error {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+	labelCollection := db.Client.Database(DATABASE).Collection(TASK_LABEL_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrTaskDoesNotExist
+	}
+
+	if _, err := itemCollection.DeleteMany(context.TODO(), bson.M{"userid": userid, "taskid": id}); err != nil {
+		return err
+	}
+	if _, err := labelCollection.DeleteMany(context.TODO(), bson.M{"userid": userid, "taskid": id}); err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": objID, "userid": userid})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTaskDoesNotExist
+	}
+
+	return nil
+}
+
+// DeleteProjectTasks deletes all tasks of a project, their items and labels
+func (t Task) DeleteProjectTasks(db *db.DB, userid, projectid string) error {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+	labelCollection := db.Client.Database(DATABASE).Collection(TASK_LABEL_COLLECTION)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"userid": userid, "projectid": projectid}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	var tasks []models.Task
+	if err := cursor.All(context.TODO(), &tasks); err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskids := make([]string, len(tasks))
+	for i, task := range tasks {
+		taskids[i] = task.ID.Hex()
+	}
+
+	if _, err := itemCollection.DeleteMany(context.TODO(), bson.M{"userid": userid, "taskid": bson.M{"$in": taskids}}); err != nil {
+		return err
+	}
+	if _, err := labelCollection.DeleteMany(context.TODO(), bson.M{"userid": userid, "taskid": bson.M{"$in": taskids}}); err != nil {
+		return err
+	}
+
+	_, err = collection.DeleteMany(context.TODO(), bson.M{"userid": userid, "projectid": projectid})
+	return err
+}
+
+// PendingContent returns the content still to be labelled by the tasks of a dataset
+func (t Task) PendingContent(db *db.DB, userid, datasetid string) ([]string, error) {
+	collection := db.Client.Database(DATABASE).Collection(TASK_COLLECTION)
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"userid": userid, "datasetid": datasetid}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var tasks []models.Task
+	if err := cursor.All(context.TODO(), &tasks); err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return []string{}, nil
+	}
+
+	taskids := make([]string, len(tasks))
+	for i, task := range tasks {
+		taskids[i] = task.ID.Hex()
+	}
+
+	results, err := itemCollection.Distinct(context.TODO(), "contentid", bson.M{
+		"userid": userid,
+		"taskid": bson.M{"$in": taskids},
+		"state":  models.TaskItemStatePending.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentids := make([]string, 0, len(results))
+	for _, result := range results {
+		if contentid, ok := result.(string); ok {
+			contentids = append(contentids, contentid)
+		}
+	}
+
+	return contentids, nil
+}
+
+// Next locks and returns the next item of a task for a labeller. An item the labeller already holds is returned
+// first; otherwise the oldest pending item that is not held by anyone, or whose lock expired, is locked.
+func (t Task) Next(db *db.DB, assigneeid, taskid string) (*models.TaskItem, error) {
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	now := time.Now()
+	lock := bson.M{"$set": bson.M{"lockedby": assigneeid, "locked_until": now.Add(models.TaskItemLockTimeout), "updated_at": now}}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"_id": 1}).SetReturnDocument(options.After)
+
+	pending := func(extra bson.M) bson.M {
+		filter := bson.M{
+			"taskid":     taskid,
+			"assigneeid": assigneeid,
+			"state":      models.TaskItemStatePending.String(),
+		}
+		for k, v := range extra {
+			filter[k] = v
+		}
+		return filter
+	}
+
+	for _, filter := range []bson.M{
+		pending(bson.M{"lockedby": assigneeid}),
+		pending(bson.M{"$or": bson.A{
+			bson.M{"lockedby": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lt": now}},
+		}}),
+	} {
+		item := models.TaskItem{}
+		err := itemCollection.FindOneAndUpdate(context.TODO(), filter, lock, opts).Decode(&item)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &item, nil
+	}
+
+	return nil, ErrTaskComplete
+}
+
+// ViewItem returns an item assigned to a labeller
+func (t Task) ViewItem(db *db.DB, assigneeid, id string) (*models.TaskItem, error) {
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrTaskItemDoesNotExist
+	}
+
+	item := models.TaskItem{}
+	if err := itemCollection.FindOne(context.TODO(), bson.M{"_id": objID, "assigneeid": assigneeid}).Decode(&item); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTaskItemDoesNotExist
+		}
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// Complete marks an item held by a labeller as done or skipped and releases it. A done item records the annotation
+// or, in a task with overlap, the label created for it. An item whose lock expired is not completed.
+func (t Task) Complete(db *db.DB, assigneeid, id string, state models.TaskItemState, annotationid, labelid string) error {
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrTaskItemDoesNotExist
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"state":        state.String(),
+		"completedby":  assigneeid,
+		"completed_at": now,
+		"updated_at":   now,
+	}
+	if annotationid != "" {
+		update["annotationid"] = annotationid
+	}
+	if labelid != "" {
+		update["labelid"] = labelid
+	}
+
+	result, err := itemCollection.UpdateOne(context.TODO(), bson.M{
+		"_id":          objID,
+		"assigneeid":   assigneeid,
+		"lockedby":     assigneeid,
+		"locked_until": bson.M{"$gte": now},
+		"state":        models.TaskItemStatePending.String(),
+	}, bson.M{
+		"$set":   update,
+		"$unset": bson.M{"lockedby": "", "locked_until": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTaskItemNotLocked
+	}
+
+	return nil
+}
+
+// Release gives up a labeller's hold on an item so it can be served again
+func (t Task) Release(db *db.DB, assigneeid, id string) error {
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrTaskItemDoesNotExist
+	}
+
+	result, err := itemCollection.UpdateOne(context.TODO(), bson.M{
+		"_id":      objID,
+		"lockedby": assigneeid,
+		"state":    models.TaskItemStatePending.String(),
+	}, bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"lockedby": "", "locked_until": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTaskItemNotLocked
+	}
+
+	return nil
+}
+
+// Throughput returns the items assigned to, and labelled or skipped by, each labeller of a task
+func (t Task) Throughput(db *db.DB, userid, taskid string) ([]models.Throughput, error) {
+	itemCollection := db.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+
+	var assigned []models.Throughput
+	cursor, err := itemCollection.Aggregate(context.TODO(), []bson.M{
+		{"$match": bson.M{"userid": userid, "taskid": taskid}},
+		{"$group": bson.M{"_id": "$assigneeid", "assigned": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &assigned); err != nil {
+		return nil, err
+	}
+
+	var completed []models.Throughput
+	cursor, err = itemCollection.Aggregate(context.TODO(), []bson.M{
+		{"$match": bson.M{"userid": userid, "taskid": taskid, "completedby": bson.M{"$exists": true}}},
+		{"$group": bson.M{
+			"_id":                "$completedby",
+			"done":               bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$state", models.TaskItemStateDone.String()}}, 1, 0}}},
+			"skipped":            bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$state", models.TaskItemStateSkipped.String()}}, 1, 0}}},
+			"first_completed_at": bson.M{"$min": "$completed_at"},
+			"last_completed_at":  bson.M{"$max": "$completed_at"},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &completed); err != nil {
+		return nil, err
+	}
+
+	throughput := []models.Throughput{}
+	index := make(map[string]int)
+	for _, a := range assigned {
+		index[a.UserID] = len(throughput)
+		throughput = append(throughput, a)
+	}
+	for _, c := range completed {
+		i, ok := index[c.UserID]
+		if !ok {
+			index[c.UserID] = len(throughput)
+			throughput = append(throughput, models.Throughput{UserID: c.UserID})
+			i = len(throughput) - 1
+		}
+		throughput[i].Done = c.Done
+		throughput[i].Skipped = c.Skipped
+		throughput[i].FirstCompletedAt = c.FirstCompletedAt
+		throughput[i].LastCompletedAt = c.LastCompletedAt
+		if c.FirstCompletedAt != nil && c.LastCompletedAt != nil {
+			if hours := c.LastCompletedAt.Sub(*c.FirstCompletedAt).Hours(); hours > 0 {
+				throughput[i].PerHour = float64(c.Done) / hours
+			}
+		}
+	}
+
+	return throughput, nil
+}
+
+// SaveLabel creates or replaces a labeller's label of content in a task
+func (t Task) SaveLabel(db *db.DB, label models.TaskLabel) (*models.TaskLabel, error) {
+	labelCollection := db.Client.Database(DATABASE).Collection(TASK_LABEL_COLLECTION)
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	saved := models.TaskLabel{}
+	err := labelCollection.FindOneAndUpdate(context.TODO(), bson.M{
+		"userid":     label.UserID,
+		"taskid":     label.TaskID,
+		"contentid":  label.ContentID,
+		"labellerid": label.LabellerID,
+	}, bson.M{
+		"$set": bson.M{
+			"tagids":     label.TagIDs,
+			"metadata":   label.Metadata,
+			"updated_at": label.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        label.ID,
+			"created_at": label.CreatedAt,
+		},
+	}, opts).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// ListLabels returns the labels of a task, optionally only those of the specified content
+func (t Task) ListLabels(db *db.DB, userid, taskid string, contentids ...string) ([]models.TaskLabel, error) {
+	var labels = []models.TaskLabel{}
+
+	labelCollection := db.Client.Database(DATABASE).Collection(TASK_LABEL_COLLECTION)
+
+	filter := bson.M{"userid": userid, "taskid": taskid}
+	if len(contentids) > 0 {
+		filter["contentid"] = bson.M{"$in": contentids}
+	}
+
+	cursor, err := labelCollection.Find(context.TODO(), filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &labels); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,136 @@
+/*
+ * File: task_test.go
+ * Project: platform
+ * File Created: Monday, 19th February 2024 11:05:52 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 11:05:52 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ *
+ * This is an integration test and requires a local instance of mongo; it is skipped when none is reachable.
+ */
+package platform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// testTask creates a task of a single batch with an item per content
+func testTask(t *testing.T, database *db.DB, assigneeid string, contentids ...string) (models.Task, []models.TaskItem) {
+	task := models.NewTask(fmt.Sprintf("integration-test-%s", common.ShortUUID(6)), "project", "dataset", "task")
+	batch := models.TaskBatch{ID: task.ID, AssigneeID: assigneeid, Size: len(contentids)}
+	task.Batches = append(task.Batches, batch)
+
+	items := []models.TaskItem{}
+	for _, contentid := range contentids {
+		items = append(items, models.NewTaskItem(task, batch, contentid))
+	}
+	task.Size = len(items)
+
+	require.NoError(t, NewTask().Create(database, task, items))
+	t.Cleanup(func() { _ = NewTask().Delete(database, task.UserID, task.ID.Hex()) })
+
+	return task, items
+}
+
+// setLock locks an item directly
+func setLock(t *testing.T, database *db.DB, item models.TaskItem, lockedBy string, until time.Time) {
+	collection := database.Client.Database(DATABASE).Collection(TASK_ITEM_COLLECTION)
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": item.ID}, bson.M{"$set": bson.M{"lockedby": lockedBy, "locked_until": until}})
+	require.NoError(t, err)
+}
+
+func TestTaskNextIntegration(t *testing.T) {
+	database := testDB(t)
+	tasks := NewTask()
+
+	task, items := testTask(t, database, "labeller", "c1", "c2", "c3")
+
+	// The oldest pending item is locked
+	item, err := tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, items[0].ID, item.ID)
+	assert.Equal(t, "labeller", item.LockedBy)
+	require.NotNil(t, item.LockedUntil)
+	assert.WithinDuration(t, time.Now().Add(models.TaskItemLockTimeout), *item.LockedUntil, time.Minute)
+	assert.True(t, item.Held("labeller", time.Now()))
+
+	// An item the labeller holds is served again
+	item, err = tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, items[0].ID, item.ID)
+
+	// Items of other labellers are never served
+	_, err = tasks.Next(database, "other", task.ID.Hex())
+	assert.Equal(t, ErrTaskComplete, err)
+
+	// Items locked by someone else are skipped until their lock expires
+	require.NoError(t, tasks.Complete(database, "labeller", items[0].ID.Hex(), models.TaskItemStateDone, "annotation", ""))
+	setLock(t, database, items[1], "other", time.Now().Add(time.Hour))
+
+	item, err = tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, items[2].ID, item.ID)
+	require.NoError(t, tasks.Complete(database, "labeller", items[2].ID.Hex(), models.TaskItemStateSkipped, "", ""))
+
+	_, err = tasks.Next(database, "labeller", task.ID.Hex())
+	assert.Equal(t, ErrTaskComplete, err)
+
+	setLock(t, database, items[1], "other", time.Now().Add(-time.Second))
+	item, err = tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, items[1].ID, item.ID)
+	assert.Equal(t, "labeller", item.LockedBy)
+
+	// Completed items are not served again
+	require.NoError(t, tasks.Complete(database, "labeller", items[1].ID.Hex(), models.TaskItemStateDone, "annotation", ""))
+	_, err = tasks.Next(database, "labeller", task.ID.Hex())
+	assert.Equal(t, ErrTaskComplete, err)
+}
+
+func TestTaskCompleteIntegration(t *testing.T) {
+	database := testDB(t)
+	tasks := NewTask()
+
+	task, items := testTask(t, database, "labeller", "c1")
+
+	// Items have to be held to be completed
+	err := tasks.Complete(database, "labeller", items[0].ID.Hex(), models.TaskItemStateDone, "annotation", "")
+	assert.Equal(t, ErrTaskItemNotLocked, err)
+
+	_, err = tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+
+	// An expired lock no longer holds the item
+	setLock(t, database, items[0], "labeller", time.Now().Add(-time.Second))
+	err = tasks.Complete(database, "labeller", items[0].ID.Hex(), models.TaskItemStateDone, "annotation", "")
+	assert.Equal(t, ErrTaskItemNotLocked, err)
+
+	item, err := tasks.ViewItem(database, "labeller", items[0].ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskItemStatePending.String(), item.State)
+
+	// Once locked again the item is completed and released
+	_, err = tasks.Next(database, "labeller", task.ID.Hex())
+	require.NoError(t, err)
+	require.NoError(t, tasks.Complete(database, "labeller", items[0].ID.Hex(), models.TaskItemStateDone, "annotation", ""))
+
+	item, err = tasks.ViewItem(database, "labeller", items[0].ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskItemStateDone.String(), item.State)
+	assert.Equal(t, "annotation", item.AnnotationID)
+	assert.Equal(t, "labeller", item.CompletedBy)
+	assert.Empty(t, item.LockedBy)
+	assert.Nil(t, item.LockedUntil)
+}
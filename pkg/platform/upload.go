@@ -0,0 +1,272 @@
+/*
+ * File: upload.go
+ * Project: platform
+ * File Created: Monday, 16th May 2022 2:41:02 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 2nd February 2024 2:33:11 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// Custom errors
+var (
+	ErrUploadDoesNotExist = echo.NewHTTPError(http.StatusNotFound, "Upload does not exist.")
+)
+
+// Mongo field names may not contain '.' or start with '$'
+var uploadFailedKeyReplacer = strings.NewReplacer(".", "_", "$", "_")
+
+// Upload represents the client for upload table
+type Upload struct{}
+
+func NewUpload() *Upload {
+	return &Upload{}
+}
+
+// UploadDB represents upload repository interface
+type UploadDB interface {
+	Index(*db.DB) error
+	Create(*db.DB, models.Upload) (models.Upload, error)
+	View(*db.DB, string, string) (models.Upload, error)
+	Update(*db.DB, models.Upload) error
+	SetState(*db.DB, primitive.ObjectID, models.UploadState, string) error
+	Progress(*db.DB, primitive.ObjectID, models.UploadProgress) error
+	Pending(*db.DB, primitive.ObjectID, string) (bool, error)
+	Heartbeat(*db.DB, primitive.ObjectID) error
+	Expand(*db.DB, primitive.ObjectID, string, []string, int, int64, string) error
+	FindStale(*db.DB, time.Time) ([]models.Upload, error)
+	Claim(*db.DB, models.Upload) (bool, error)
+}
+
+func (u *Upload) Index(db *db.DB) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "_id", Value: 1}, {Key: "userid", Value: 1}},
+			Options: &options.IndexOptions{Unique: common.Ptr(true), Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "userid", Value: 1}, {Key: "projectid", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+		{
+			Keys:    bson.D{{Key: "state", Value: 1}},
+			Options: &options.IndexOptions{Background: common.Ptr(true)},
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(context.TODO(), models); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create creates a new upload in the db
+func (u *Upload) Create(db *db.DB, upload models.Upload) (models.Upload, error) {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	if _, err := collection.InsertOne(context.TODO(), upload); err != nil {
+		return models.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+func (u *Upload) View(db *db.DB, userid, uploadid string) (models.Upload, error) {
+	var upload models.Upload
+
+	objID, err := primitive.ObjectIDFromHex(uploadid)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+	if err = collection.FindOne(context.TODO(), bson.M{"_id": objID, "userid": userid}).Decode(&upload); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.Upload{}, ErrUploadDoesNotExist
+		}
+		return models.Upload{}, err
+	}
+	return upload, nil
+}
+
+func (u *Upload) Update(db *db.DB, upload models.Upload) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"_id": upload.ID},
+			bson.M{"userid": upload.UserID},
+		},
+	}
+
+	upload.UpdatedAt = time.Now()
+
+	_, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": upload})
+	return err
+}
+
+// SetState updates the state of an upload and optionally records a catastrophic error
+func (u *Upload) SetState(db *db.DB, uploadid primitive.ObjectID, state models.UploadState, uploadErr string) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	set := bson.M{"state": state.String(), "updated_at": time.Now()}
+	if uploadErr != "" {
+		set["error"] = uploadErr
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": uploadid}, bson.M{"$set": set})
+	return err
+}
+
+// Progress atomically records the outcome of a single staged file; the file is removed from
+// the pending list so a resumed upload does not process it again. The outcome of a file that is
+// no longer pending is not recorded, so a file processed twice is only counted once.
+func (u *Upload) Progress(db *db.DB, uploadid primitive.ObjectID, progress models.UploadProgress) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	set := bson.M{"updated_at": time.Now()}
+	for filename, failure := range progress.Errors {
+		set[fmt.Sprintf("failed.%s", uploadFailedKeyReplacer.Replace(filename))] = failure
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"files": progress.File},
+		"$inc": bson.M{
+			"total_bytes_uploaded":   progress.Bytes,
+			"total_images_processed": progress.Processed,
+			"total_images_succeeded": progress.Succeeded,
+			"total_images_failed":    progress.Failed,
+			"total_images_duplicate": progress.Duplicate,
+		},
+		"$set": set,
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": uploadid, "files": progress.File}, update)
+	return err
+}
+
+// Pending reports whether a staged file of an upload has yet to be processed
+func (u *Upload) Pending(db *db.DB, uploadid primitive.ObjectID, file string) (bool, error) {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"_id": uploadid, "files": file})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Heartbeat refreshes the updated_at of an upload that is still being staged or processed, so it is
+// not picked up as stale by Resume while its owner is working on it.
+func (u *Upload) Heartbeat(db *db.DB, uploadid primitive.ObjectID) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"_id": uploadid},
+			bson.M{"state": bson.M{"$in": []string{models.UploadStateInitialized.String(), models.UploadStateRunning.String()}}},
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	return err
+}
+
+// Expand replaces a staged archive with its extracted members and adds the images among them to the image
+// count of the upload. Members are added before the archive is removed so an interrupted expansion is simply
+// repeated on resume.
+func (u *Upload) Expand(db *db.DB, uploadid primitive.ObjectID, archiveKey string, files []string, images int, bytes int64, labelsFile string) error {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": uploadid}, bson.M{
+		"$addToSet": bson.M{"files": bson.M{"$each": files}},
+	}); err != nil {
+		return err
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if labelsFile != "" {
+		set["labels_file"] = labelsFile
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"files": archiveKey},
+		"$inc": bson.M{
+			"total_bytes":         bytes,
+			"total_images":        images - 1,
+			"total_file_uploaded": len(files) - 1,
+		},
+		"$set": set,
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": uploadid, "files": archiveKey}, update)
+	return err
+}
+
+// FindStale returns all uploads that have not reached a terminal state and have not been updated since the given time
+func (u *Upload) FindStale(db *db.DB, before time.Time) ([]models.Upload, error) {
+	var uploads []models.Upload
+
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"state": bson.M{"$in": []string{models.UploadStateInitialized.String(), models.UploadStateRunning.String()}}},
+			bson.M{"updated_at": bson.M{"$lt": before}},
+		},
+	}
+
+	cursor, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if err := cursor.All(context.TODO(), &uploads); err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
+
+// Claim takes ownership of a stale upload. The update only succeeds if the upload has not been
+// modified since it was read, so only one service instance resumes a given upload.
+func (u *Upload) Claim(db *db.DB, upload models.Upload) (bool, error) {
+	collection := db.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+
+	filter := bson.M{
+		"$and": []interface{}{
+			bson.M{"_id": upload.ID},
+			bson.M{"updated_at": upload.UpdatedAt},
+		},
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,115 @@
+/*
+ * File: upload_test.go
+ * Project: platform
+ * File Created: Monday, 4th March 2024 10:21:07 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 4th March 2024 10:21:07 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ *
+ * This is an integration test and requires a local instance of mongo; it is skipped when none is reachable.
+ */
+package platform
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// testUpload creates a running upload with the given staged files
+func testUpload(t *testing.T, database *db.DB, files ...string) models.Upload {
+	upload := models.NewUpload(fmt.Sprintf("integration-test-%s", common.ShortUUID(6)), "project", "bucket", "")
+	upload.State = models.UploadStateRunning.String()
+	upload.Files = files
+
+	_, err := NewUpload().Create(database, upload)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		collection := database.Client.Database(DATABASE).Collection(UPLOAD_COLLECTION)
+		_, _ = collection.DeleteOne(context.TODO(), bson.M{"_id": upload.ID})
+	})
+
+	return upload
+}
+
+func TestUploadProgressIntegration(t *testing.T) {
+	database := testDB(t)
+	uploads := NewUpload()
+
+	upload := testUpload(t, database, "a.jpg", "b.jpg")
+
+	pending, err := uploads.Pending(database, upload.ID, "a.jpg")
+	require.NoError(t, err)
+	assert.True(t, pending)
+
+	progress := models.UploadProgress{File: "a.jpg", Bytes: 10, Processed: 1, Succeeded: 1}
+	require.NoError(t, uploads.Progress(database, upload.ID, progress))
+
+	pending, err = uploads.Pending(database, upload.ID, "a.jpg")
+	require.NoError(t, err)
+	assert.False(t, pending)
+
+	// A file processed twice is only counted once
+	require.NoError(t, uploads.Progress(database, upload.ID, progress))
+
+	result, err := uploads.View(database, upload.UserID, upload.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.jpg"}, result.Files)
+	assert.Equal(t, int64(10), result.TotalBytesUploaded)
+	assert.Equal(t, 1, result.TotalImagesProcessed)
+	assert.Equal(t, 1, result.TotalImagesSucceeded)
+}
+
+func TestUploadHeartbeatIntegration(t *testing.T) {
+	database := testDB(t)
+	uploads := NewUpload()
+
+	upload := testUpload(t, database, "a.jpg")
+
+	read, err := uploads.View(database, upload.UserID, upload.ID.Hex())
+	require.NoError(t, err)
+
+	found, err := uploads.FindStale(database, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Contains(t, uploadIDs(found), upload.ID.Hex())
+
+	// A beating upload is neither stale nor claimable with the state it was read in
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, uploads.Heartbeat(database, upload.ID))
+
+	claimed, err := uploads.Claim(database, read)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	found, err = uploads.FindStale(database, read.UpdatedAt.Add(5*time.Millisecond))
+	require.NoError(t, err)
+	assert.NotContains(t, uploadIDs(found), upload.ID.Hex())
+
+	// Uploads in a terminal state are left untouched
+	require.NoError(t, uploads.SetState(database, upload.ID, models.UploadStateComplete, ""))
+	result, err := uploads.View(database, upload.UserID, upload.ID.Hex())
+	require.NoError(t, err)
+
+	require.NoError(t, uploads.Heartbeat(database, upload.ID))
+	after, err := uploads.View(database, upload.UserID, upload.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, result.UpdatedAt, after.UpdatedAt)
+}
+
+func uploadIDs(uploads []models.Upload) []string {
+	ids := []string{}
+	for _, upload := range uploads {
+		ids = append(ids, upload.ID.Hex())
+	}
+	return ids
+}
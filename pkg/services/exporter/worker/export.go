@@ -11,11 +11,10 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"path"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -78,7 +77,12 @@ func (e *Export) Export(export *models.Export) error {
 			return err
 		}
 	case models.ExportTypeModel:
-		return http.ErrNotSupported
+		if err := e.generateModelExport(
+			e.Platform,
+			export); err != nil {
+			log.Printf("error generating model export; userid=%s exportid=%s err=%s", export.UserID, export.ID.Hex(), err.Error())
+			return err
+		}
 	default:
 		err := errors.New("Export requires project_id, dataset_id or model_id.")
 		log.Printf("error generating export; userid=%s exportid=%s err=%s", export.UserID, export.ID.Hex(), err.Error())
@@ -202,6 +206,7 @@ func (e *Export) generateDatasetExport(
 		ID        primitive.ObjectID        `bson:"_id"`
 		TagIDs    []string                  `bson:"tagids"`
 		ContentID string                    `bson:"contentid"`
+		Split     string                    `bson:"split"`
 		Metadata  models.AnnotationMetadata `bson:"metadata,omitempty"`
 	}
 
@@ -210,7 +215,7 @@ func (e *Export) generateDatasetExport(
 	}
 
 	// Build labels file
-	opts := options.FindOptions{Projection: bson.M{"_id": 1, "tagids": 1, "contentid": 1, "metadata": 1}, AllowDiskUse: common.Ptr(true)}
+	opts := options.FindOptions{Projection: bson.M{"_id": 1, "tagids": 1, "contentid": 1, "split": 1, "metadata": 1}, AllowDiskUse: common.Ptr(true)}
 	cursor, err := plat.AnnotationDB.FindDatasetAnnotations(e.Db, export.UserID, export.Dataset.ID.Hex(), &opts)
 	if err != nil {
 		log.Printf("error retrieving dataset annotations from database; export=%s err=%s", export.ID.Hex(), err.Error())
@@ -224,7 +229,7 @@ func (e *Export) generateDatasetExport(
 		return err
 	}
 
-	var labels = models.Labels{}
+	var items []DatasetItem
 	tagCache := make(map[string]models.Tag) // maps tagid -> tag
 	for _, annotation := range annotations {
 		tags := []string{}
@@ -252,40 +257,111 @@ func (e *Export) generateDatasetExport(
 			id := annotation.Metadata.BoundingBoxes[i].TagID
 			annotation.Metadata.BoundingBoxes[i].TagID = tagCache[id].Name
 		}
+		// Likewise for segmentation regions
+		for i := 0; i < len(annotation.Metadata.Polygons); i++ {
+			id := annotation.Metadata.Polygons[i].TagID
+			annotation.Metadata.Polygons[i].TagID = tagCache[id].Name
+		}
+		for i := 0; i < len(annotation.Metadata.Masks); i++ {
+			id := annotation.Metadata.Masks[i].TagID
+			annotation.Metadata.Masks[i].TagID = tagCache[id].Name
+		}
+		// And keypoint objects
+		for i := 0; i < len(annotation.Metadata.KeypointObjects); i++ {
+			id := annotation.Metadata.KeypointObjects[i].TagID
+			annotation.Metadata.KeypointObjects[i].TagID = tagCache[id].Name
+		}
 
-		// build labels file
-		labels = append(labels, models.Label{
-			Tags:       tags,
-			Metadata:   annotation.Metadata.BoundingBoxes,
-			ExternalID: content.Name,
-			InternalID: path.Base(content.StoredPath),
+		items = append(items, DatasetItem{
+			Content:  *content,
+			Tags:     tags,
+			Boxes:    annotation.Metadata.BoundingBoxes,
+			Polygons: annotation.Metadata.Polygons,
+			Masks:    annotation.Metadata.Masks,
+			Objects:  annotation.Metadata.KeypointObjects,
+			Split:    annotation.Split,
 		})
 	}
 
-	// Marshal file
-	labelsFile, err := json.Marshal(labels)
+	// Classes are indexed the same way as for training
+	project, err := plat.ProjectDB.View(e.Db, export.UserID, export.Dataset.ProjectID)
 	if err != nil {
-		log.Printf("error creating labels file; err=%s", err.Error())
-		return err
+		return errors.Wrapf(err, "error retrieving project from database; project=%s", export.Dataset.ProjectID)
+	}
+	labelIntegerMap, err := plat.TagDB.TagIntegerMap(e.Db, export.UserID, export.Dataset.ID.Hex())
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving dataset tags; dataset=%s", export.Dataset.ID.Hex())
+	}
+	classes := make([]string, len(labelIntegerMap))
+	for name, idx := range labelIntegerMap {
+		classes[idx] = name
+	}
+	// Skeletons of the annotated classes of keypoint projects
+	skeletons := make(map[string]models.TagSkeleton)
+	for _, tag := range tagCache {
+		if tag.Skeleton != nil {
+			skeletons[tag.Name] = *tag.Skeleton
+		}
 	}
 
-	// Upload archive
-	inChan := make(chan *zipwriter.ObjectInput, 10)
-	doneChan := make(chan error, 1)
 	archiveName := path.Join(export.Path, LabelsFile+".zip")
+	if export.Format != "" && export.Format != models.ExportFormatEmerald {
+		archiveName = path.Join(export.Path, strings.ToLower(string(export.Format))+".zip")
+	}
 
-	go func() {
-		err := e.Zipw.ZipS3Files(inChan, &zipwriter.ObjectOutput{Bucket: &e.Bucket, Key: &archiveName})
-		doneChan <- err
-	}()
+	var parts []archivePart
+	if export.Split {
+		// Partition content and labels by the split assigned when the dataset was trained
+		splits := make(map[string][]DatasetItem)
+		for _, item := range items {
+			splits[item.Split] = append(splits[item.Split], item)
+		}
+		if undefined := len(splits[models.SplitUndefined.String()]); undefined > 0 {
+			log.Printf("skipping annotations without a split; export=%s count=%d", export.ID.Hex(), undefined)
+		}
+
+		for _, split := range []models.Split{models.SplitTrain, models.SplitValidation, models.SplitTest} {
+			splitItems, ok := splits[split.String()]
+			if !ok {
+				continue
+			}
 
-	// Upload file
-	inChan <- &zipwriter.ObjectInput{RawBytes: map[string][]byte{LabelsFile: labelsFile}}
+			files, skipped, err := DatasetFiles(export.Format, splitItems, classes, skeletons, project.AnnotationType)
+			if err != nil {
+				log.Printf("error creating export files; format=%s split=%s err=%s", export.Format, split.String(), err.Error())
+				return err
+			}
+			export.SkippedLabels += skipped
 
-	close(inChan)
+			parts = append(parts, archivePart{folder: strings.ToLower(split.String()), files: files, items: splitItems})
+		}
+	} else {
+		// Render files in the requested format
+		files, skipped, err := DatasetFiles(export.Format, items, classes, skeletons, project.AnnotationType)
+		if err != nil {
+			log.Printf("error creating export files; format=%s err=%s", export.Format, err.Error())
+			return err
+		}
+		export.SkippedLabels = skipped
 
+		// The YOLO dataset description points at the images
+		part := archivePart{files: files}
+		if export.Format == models.ExportFormatYOLO {
+			part.items = items
+		}
+		parts = append(parts, part)
+	}
+
+	if err := e.uploadDatasetArchive(archiveName, parts); err != nil {
+		log.Printf("error uploading export archive; export=%s err=%s", export.ID.Hex(), err.Error())
+		return err
+	}
 	export.ContentKeys = append(export.ContentKeys, archiveName)
 
+	if export.SkippedLabels > 0 {
+		log.Printf("skipped labels of tags not found in dataset classes; export=%s count=%d", export.ID.Hex(), export.SkippedLabels)
+	}
+
 	// Attach statistics
 	metadata, err := annotationAPI.Initialize(e.Db, e.Platform, nil).Statistics(nil, export.UserID, export.Dataset.ProjectID, export.Dataset.ID.Hex(), nil)
 	if err != nil {
@@ -301,5 +377,53 @@ func (e *Export) generateDatasetExport(
 
 	export.Metadata = metadataMap
 
+	return nil
+}
+
+// archivePart is a folder of a dataset export archive holding generated label files and, when set, the content
+// of the given items in an images folder. Files of a part without a folder are placed at the archive root.
+type archivePart struct {
+	folder string
+	files  map[string][]byte
+	items  []DatasetItem
+}
+
+// datasetArchiveInputs lists the objects and generated files making up a dataset export archive
+func datasetArchiveInputs(parts []archivePart) []*zipwriter.ObjectInput {
+	inputs := []*zipwriter.ObjectInput{}
+	rawBytes := make(map[string][]byte)
+
+	for _, part := range parts {
+		for _, item := range part.items {
+			bucket := item.Content.StoredDir
+			key := item.Content.StoredPath
+			inputs = append(inputs, &zipwriter.ObjectInput{Bucket: &bucket, Key: &key, Filename: path.Join(part.folder, "images", item.Filename())})
+		}
+		for filename, fileBytes := range part.files {
+			rawBytes[path.Join(part.folder, filename)] = fileBytes
+		}
+	}
+
+	return append(inputs, &zipwriter.ObjectInput{RawBytes: rawBytes})
+}
+
+// uploadDatasetArchive zips the parts of a dataset export into a single archive
+func (e *Export) uploadDatasetArchive(archiveName string, parts []archivePart) error {
+
+	// Upload archive
+	inChan := make(chan *zipwriter.ObjectInput, 10)
+	doneChan := make(chan error, 1)
+
+	go func() {
+		err := e.Zipw.ZipS3Files(inChan, &zipwriter.ObjectOutput{Bucket: &e.Bucket, Key: &archiveName})
+		doneChan <- err
+	}()
+
+	for _, input := range datasetArchiveInputs(parts) {
+		inChan <- input
+	}
+
+	close(inChan)
+
 	return <-doneChan
 }
@@ -0,0 +1,45 @@
+/*
+ * File: export_test.go
+ * Project: worker
+ * File Created: Tuesday, 5th March 2024 1:58:20 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 5th March 2024 1:58:20 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasetArchiveInputs(t *testing.T) {
+	items := testBoxItems()
+	files := map[string][]byte{LabelsFile: []byte("[]")}
+
+	// Splits are folders of a single archive, each with its labels and content
+	inputs := datasetArchiveInputs([]archivePart{
+		{folder: "train", files: files, items: items[:1]},
+		{folder: "validation", files: files, items: items[1:]},
+		{folder: "test", files: files},
+	})
+	require.Len(t, inputs, 3)
+
+	assert.Equal(t, "train/images/image1.jpeg", inputs[0].Filename)
+	assert.Equal(t, "bucket", *inputs[0].Bucket)
+	assert.Equal(t, "user/content/image1.jpeg", *inputs[0].Key)
+	assert.Equal(t, "validation/images/image2.jpeg", inputs[1].Filename)
+	assert.Equal(t, map[string][]byte{
+		"train/labels.json":      []byte("[]"),
+		"validation/labels.json": []byte("[]"),
+		"test/labels.json":       []byte("[]"),
+	}, inputs[2].RawBytes)
+
+	// Unsplit exports are written at the archive root
+	inputs = datasetArchiveInputs([]archivePart{{files: files}})
+	require.Len(t, inputs, 1)
+	assert.Equal(t, map[string][]byte{"labels.json": []byte("[]")}, inputs[0].RawBytes)
+}
@@ -0,0 +1,399 @@
+/*
+ * File: format.go
+ * Project: worker
+ * File Created: Thursday, 8th February 2024 9:14:27 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 8th February 2024 9:14:27 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	train "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
+)
+
+const (
+	// COCO annotations file name
+	COCOFile = "instances.json"
+	// YOLO dataset description file name
+	YOLODataFile = "data.yaml"
+	// SageMaker augmented manifest file name
+	ManifestFile = "dataset.manifest"
+)
+
+// DatasetItem is an annotated content item of a dataset export. Tags, bounding boxes, segmentation regions and
+// keypoint objects reference tags by name.
+type DatasetItem struct {
+	Content  models.Content
+	Tags     []string
+	Boxes    []models.AnnotationDataBoundingBox
+	Polygons []models.AnnotationDataPolygon
+	Masks    []models.AnnotationDataMask
+	Objects  []models.AnnotationDataKeypointObject
+	Split    string
+}
+
+// Filename is the name of the content within a project export
+func (d DatasetItem) Filename() string {
+	return path.Base(d.Content.StoredPath)
+}
+
+// boxes returns the bounding boxes of the item, including those of its keypoint objects
+func (d DatasetItem) boxes() []models.AnnotationDataBoundingBox {
+	boxes := append([]models.AnnotationDataBoundingBox{}, d.Boxes...)
+	for _, object := range d.Objects {
+		boxes = append(boxes, object.AnnotationDataBoundingBox)
+	}
+	return boxes
+}
+
+func (d DatasetItem) stem() string {
+	filename := d.Filename()
+	return strings.TrimSuffix(filename, path.Ext(filename))
+}
+
+// DatasetFiles renders dataset items in the given export format. Classes are the tag names of the dataset,
+// indexed the same way as when the dataset is trained, and skeletons those of the classes of keypoint projects.
+// The returned map is keyed by archive file name. Labels of tags that are not classes of the dataset are left
+// out of formats that index classes, and their number is returned along with the files.
+func DatasetFiles(format models.ExportFormat, items []DatasetItem, classes []string, skeletons map[string]models.TagSkeleton, annotationType string) (map[string][]byte, int, error) {
+	switch format {
+	case models.ExportFormatEmerald, "":
+		files, err := emeraldFiles(items)
+		return files, 0, err
+	case models.ExportFormatCOCO:
+		return cocoFiles(items, classes, skeletons)
+	case models.ExportFormatVOC:
+		files, err := vocFiles(items, annotationType)
+		return files, 0, err
+	case models.ExportFormatYOLO:
+		if annotationType != models.ProjectAnnotationTypeBoundingBox.String() {
+			return nil, 0, fmt.Errorf("yolo export requires a bounding box project; annotation type=%s", annotationType)
+		}
+		return yoloFiles(items, classes)
+	case models.ExportFormatManifest:
+		if annotationType == models.ProjectAnnotationTypeSegmentation.String() || annotationType == models.ProjectAnnotationTypeKeypoint.String() {
+			return nil, 0, fmt.Errorf("manifest export does not support %s projects", annotationType)
+		}
+		return manifestFiles(items, classes, annotationType)
+	default:
+		return nil, 0, fmt.Errorf("unsupported export format; format=%s", format)
+	}
+}
+
+// classIndex looks up the class of a label by its tag name. Labels of tags that are not classes of the dataset
+// are logged and counted in skipped, so they are left out of the export rather than failing it.
+func classIndex(indices map[string]int, item DatasetItem, tag string, skipped *int) (int, bool) {
+	idx, ok := indices[tag]
+	if !ok {
+		log.Printf("skipping label; tag not found in dataset classes; content=%s tag=%s", item.Content.ID, tag)
+		*skipped++
+	}
+	return idx, ok
+}
+
+func emeraldFiles(items []DatasetItem) (map[string][]byte, error) {
+	labels := models.Labels{}
+	for _, item := range items {
+		labels = append(labels, models.Label{
+			Tags:            item.Tags,
+			Metadata:        item.Boxes,
+			Polygons:        item.Polygons,
+			Masks:           item.Masks,
+			KeypointObjects: item.Objects,
+			ExternalID:      item.Content.Name,
+			InternalID:      item.Filename(),
+		})
+	}
+
+	labelsFile, err := json.Marshal(labels)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{LabelsFile: labelsFile}, nil
+}
+
+// cocoFiles writes a single COCO file. Classification tags are written as annotations without a bounding box.
+// Polygons are written as polygon segmentations and masks as uncompressed RLE crowd annotations, both with
+// their bounding box. Keypoint objects are written as COCO keypoint annotations of categories with a skeleton.
+func cocoFiles(items []DatasetItem, classes []string, skeletons map[string]models.TagSkeleton) (map[string][]byte, int, error) {
+	coco := models.COCO{
+		Images:      []models.COCOImage{},
+		Categories:  []models.COCOCategory{},
+		Annotations: []models.COCOAnnotation{},
+	}
+
+	categories := make(map[string]int, len(classes))
+	for idx, class := range classes {
+		categories[class] = idx + 1
+		category := models.COCOCategory{ID: idx + 1, Name: class}
+		if skeleton, ok := skeletons[class]; ok {
+			category.Keypoints = skeleton.Keypoints
+			// COCO skeletons index keypoints from 1
+			for _, edge := range skeleton.Edges {
+				category.Skeleton = append(category.Skeleton, [2]int{edge[0] + 1, edge[1] + 1})
+			}
+		}
+		coco.Categories = append(coco.Categories, category)
+	}
+	skipped := 0
+
+	for idx, item := range items {
+		imageID := idx + 1
+		coco.Images = append(coco.Images, models.COCOImage{
+			ID:       imageID,
+			FileName: item.Filename(),
+			Width:    item.Content.Width,
+			Height:   item.Content.Height,
+		})
+
+		if len(item.Objects) > 0 {
+			for _, object := range item.Objects {
+				left, top, width, height := object.ToTopLeftWidthHeightFormat()
+				category, ok := classIndex(categories, item, object.TagID, &skipped)
+				if !ok {
+					continue
+				}
+				keypoints := make([]float64, 0, 3*len(object.Keypoints))
+				for _, keypoint := range object.Keypoints {
+					keypoints = append(keypoints, keypoint.X, keypoint.Y, float64(keypoint.Visibility))
+				}
+				coco.Annotations = append(coco.Annotations, models.COCOAnnotation{
+					ID:           len(coco.Annotations) + 1,
+					ImageID:      imageID,
+					CategoryID:   category,
+					BBox:         []float64{float64(left), float64(top), float64(width), float64(height)},
+					Area:         float64(width * height),
+					Keypoints:    keypoints,
+					NumKeypoints: object.Labelled(),
+				})
+			}
+			continue
+		}
+
+		if len(item.Polygons) > 0 || len(item.Masks) > 0 {
+			for _, polygon := range item.Polygons {
+				category, ok := classIndex(categories, item, polygon.TagID, &skipped)
+				if !ok {
+					continue
+				}
+				xmin, ymin, xmax, ymax := polygon.Bounds()
+				coco.Annotations = append(coco.Annotations, models.COCOAnnotation{
+					ID:           len(coco.Annotations) + 1,
+					ImageID:      imageID,
+					CategoryID:   category,
+					BBox:         []float64{xmin, ymin, xmax - xmin, ymax - ymin},
+					Segmentation: &models.COCOSegmentation{Polygons: [][]float64{polygon.Points}},
+					Area:         polygon.PixelArea(),
+				})
+			}
+			for _, mask := range item.Masks {
+				category, ok := classIndex(categories, item, mask.TagID, &skipped)
+				if !ok {
+					continue
+				}
+				xmin, ymin, xmax, ymax := mask.Bounds()
+				coco.Annotations = append(coco.Annotations, models.COCOAnnotation{
+					ID:           len(coco.Annotations) + 1,
+					ImageID:      imageID,
+					CategoryID:   category,
+					BBox:         []float64{float64(xmin), float64(ymin), float64(xmax - xmin), float64(ymax - ymin)},
+					Segmentation: &models.COCOSegmentation{RLE: &models.COCORLE{Counts: mask.Counts, Size: []int{mask.Height, mask.Width}}},
+					Area:         mask.PixelArea(),
+					IsCrowd:      1,
+				})
+			}
+			continue
+		}
+
+		if len(item.Boxes) == 0 {
+			for _, tag := range item.Tags {
+				category, ok := classIndex(categories, item, tag, &skipped)
+				if !ok {
+					continue
+				}
+				coco.Annotations = append(coco.Annotations, models.COCOAnnotation{
+					ID:         len(coco.Annotations) + 1,
+					ImageID:    imageID,
+					CategoryID: category,
+				})
+			}
+			continue
+		}
+
+		for _, box := range item.Boxes {
+			category, ok := classIndex(categories, item, box.TagID, &skipped)
+			if !ok {
+				continue
+			}
+			left, top, width, height := box.ToTopLeftWidthHeightFormat()
+			coco.Annotations = append(coco.Annotations, models.COCOAnnotation{
+				ID:         len(coco.Annotations) + 1,
+				ImageID:    imageID,
+				CategoryID: category,
+				BBox:       []float64{float64(left), float64(top), float64(width), float64(height)},
+				Area:       float64(width * height),
+			})
+		}
+	}
+
+	cocoFile, err := json.Marshal(coco)
+	if err != nil {
+		return nil, 0, err
+	}
+	return map[string][]byte{COCOFile: cocoFile}, skipped, nil
+}
+
+// vocFiles writes one Pascal VOC file per content item
+func vocFiles(items []DatasetItem, annotationType string) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(items))
+	for _, item := range items {
+		voc := models.VOC{
+			Folder:   "images",
+			Filename: item.Filename(),
+			Size:     models.VOCSize{Width: item.Content.Width, Height: item.Content.Height, Depth: 3},
+			Objects:  []models.VOCObject{},
+		}
+
+		if annotationType == models.ProjectAnnotationTypeBoundingBox.String() || annotationType == models.ProjectAnnotationTypeKeypoint.String() {
+			for _, box := range item.boxes() {
+				voc.Objects = append(voc.Objects, models.VOCObject{
+					Name: box.TagID,
+					BndBox: &models.VOCBndBox{
+						Xmin: float64(box.Xmin),
+						Ymin: float64(box.Ymin),
+						Xmax: float64(box.Xmax),
+						Ymax: float64(box.Ymax),
+					},
+				})
+			}
+		} else {
+			for _, tag := range item.Tags {
+				voc.Objects = append(voc.Objects, models.VOCObject{Name: tag})
+			}
+		}
+
+		vocFile, err := xml.MarshalIndent(voc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join("Annotations", item.stem()+".xml")] = vocFile
+	}
+	return files, nil
+}
+
+// yoloData describes a YOLO dataset; images are expected alongside the labels directory, so YOLO exports always
+// include their images
+type yoloData struct {
+	Path  string   `yaml:"path"`
+	Train string   `yaml:"train"`
+	Val   string   `yaml:"val"`
+	NC    int      `yaml:"nc"`
+	Names []string `yaml:"names"`
+}
+
+// yoloFiles writes one YOLO file per content item along with the class names and dataset description
+func yoloFiles(items []DatasetItem, classes []string) (map[string][]byte, int, error) {
+	files := make(map[string][]byte, len(items)+2)
+	skipped := 0
+
+	indices := make(map[string]int, len(classes))
+	for idx, class := range classes {
+		indices[class] = idx
+	}
+
+	for _, item := range items {
+		if item.Content.Width == 0 || item.Content.Height == 0 {
+			return nil, 0, fmt.Errorf("content dimensions unknown; content=%s", item.Content.ID)
+		}
+		width, height := float64(item.Content.Width), float64(item.Content.Height)
+
+		var buf bytes.Buffer
+		for _, box := range item.Boxes {
+			class, ok := classIndex(indices, item, box.TagID, &skipped)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&buf, "%d %.6f %.6f %.6f %.6f\n",
+				class,
+				float64(box.Xmin+box.Xmax)/2/width,
+				float64(box.Ymin+box.Ymax)/2/height,
+				float64(box.Xmax-box.Xmin)/width,
+				float64(box.Ymax-box.Ymin)/height,
+			)
+		}
+		files[path.Join("labels", item.stem()+".txt")] = buf.Bytes()
+	}
+
+	files[models.YOLOClassesFile] = []byte(strings.Join(classes, "\n") + "\n")
+
+	data, err := yaml.Marshal(yoloData{Path: ".", Train: "images", Val: "images", NC: len(classes), Names: classes})
+	if err != nil {
+		return nil, 0, err
+	}
+	files[YOLODataFile] = data
+
+	return files, skipped, nil
+}
+
+// manifestFiles writes the same SageMaker augmented manifest lines used for training
+func manifestFiles(items []DatasetItem, classes []string, annotationType string) (map[string][]byte, int, error) {
+	skipped := 0
+	indices := make(map[string]int, len(classes))
+	classMap := make(map[int]string, len(classes))
+	for idx, class := range classes {
+		indices[class] = idx
+		classMap[idx] = class
+	}
+
+	var buf bytes.Buffer
+	for _, item := range items {
+		source := "s3://" + path.Join(item.Content.StoredDir, item.Content.StoredPath)
+
+		var (
+			entry []byte
+			err   error
+		)
+		if annotationType == models.ProjectAnnotationTypeClassification.String() {
+			labels := make(map[int]struct{})
+			for _, tag := range item.Tags {
+				if idx, ok := classIndex(indices, item, tag, &skipped); ok {
+					labels[idx] = struct{}{}
+				}
+			}
+			entry, err = train.NewClassificationManifest(source, train.ClassLabels(len(classes), labels)).ToJSON()
+		} else {
+			boxes := [][]float32{}
+			for _, box := range item.Boxes {
+				idx, ok := classIndex(indices, item, box.TagID, &skipped)
+				if !ok {
+					continue
+				}
+				left, top, width, height := box.ToTopLeftWidthHeightFormat()
+				boxes = append(boxes, []float32{float32(idx), float32(left), float32(top), float32(width), float32(height)})
+			}
+			imageSize := []int{item.Content.Width, item.Content.Height, 3}
+			entry, err = train.NewObjectDetectionManifest(source, classMap, imageSize, boxes).ToJSON()
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		buf.Write(entry)
+		buf.WriteString("\n")
+	}
+
+	return map[string][]byte{ManifestFile: buf.Bytes()}, skipped, nil
+}
@@ -0,0 +1,243 @@
+/*
+ * File: format_test.go
+ * Project: worker
+ * File Created: Tuesday, 5th March 2024 9:32:51 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 5th March 2024 9:32:51 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package worker
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+var (
+	testClasses = []string{"cat", "dog"}
+	bbox        = models.ProjectAnnotationTypeBoundingBox.String()
+)
+
+func testItem(name string, width, height int) DatasetItem {
+	return DatasetItem{Content: models.Content{
+		ID:         name,
+		Name:       name + ".png",
+		StoredDir:  "bucket",
+		StoredPath: "user/content/" + name + ".jpeg",
+		Width:      width,
+		Height:     height,
+	}}
+}
+
+// testBoxItems are two images with a box of a known tag each, one of which also has a box of an unknown tag
+func testBoxItems() []DatasetItem {
+	image1 := testItem("image1", 100, 50)
+	image1.Tags = []string{"cat", "bird"}
+	image1.Boxes = []models.AnnotationDataBoundingBox{
+		{TagID: "cat", Xmin: 10, Ymin: 20, Xmax: 50, Ymax: 40},
+		{TagID: "bird", Xmin: 0, Ymin: 0, Xmax: 5, Ymax: 5},
+	}
+
+	image2 := testItem("image2", 200, 100)
+	image2.Tags = []string{"dog"}
+	image2.Boxes = []models.AnnotationDataBoundingBox{{TagID: "dog", Xmin: 0, Ymin: 0, Xmax: 200, Ymax: 100}}
+
+	return []DatasetItem{image1, image2}
+}
+
+func TestDatasetFilesEmerald(t *testing.T) {
+	items := testBoxItems()
+
+	files, skipped, err := DatasetFiles(models.ExportFormatEmerald, items, testClasses, nil, bbox)
+	require.NoError(t, err)
+	assert.Zero(t, skipped)
+	require.Contains(t, files, LabelsFile)
+
+	labels := models.Labels{}
+	require.NoError(t, json.Unmarshal(files[LabelsFile], &labels))
+	require.Len(t, labels, 2)
+	assert.Equal(t, "image1.png", labels[0].ExternalID)
+	assert.Equal(t, "image1.jpeg", labels[0].InternalID)
+	assert.Equal(t, items[0].Boxes, labels[0].Metadata)
+
+	// The default format is the Emerald labels file
+	defaults, _, err := DatasetFiles("", items, testClasses, nil, bbox)
+	require.NoError(t, err)
+	assert.Equal(t, files, defaults)
+}
+
+func TestDatasetFilesCOCO(t *testing.T) {
+	files, skipped, err := DatasetFiles(models.ExportFormatCOCO, testBoxItems(), testClasses, nil, bbox)
+	require.NoError(t, err)
+	require.Contains(t, files, COCOFile)
+
+	// The box of a tag that is not a class of the dataset is skipped
+	assert.Equal(t, 1, skipped)
+
+	var coco models.COCO
+	require.NoError(t, json.Unmarshal(files[COCOFile], &coco))
+	assert.Equal(t, []models.COCOCategory{{ID: 1, Name: "cat"}, {ID: 2, Name: "dog"}}, coco.Categories)
+	assert.Equal(t, []models.COCOImage{
+		{ID: 1, FileName: "image1.jpeg", Width: 100, Height: 50},
+		{ID: 2, FileName: "image2.jpeg", Width: 200, Height: 100},
+	}, coco.Images)
+	assert.Equal(t, []models.COCOAnnotation{
+		{ID: 1, ImageID: 1, CategoryID: 1, BBox: []float64{10, 20, 40, 20}, Area: 800},
+		{ID: 2, ImageID: 2, CategoryID: 2, BBox: []float64{0, 0, 200, 100}, Area: 20000},
+	}, coco.Annotations)
+
+	// Classification tags are written without a bounding box
+	items := []DatasetItem{testItem("image1", 100, 50)}
+	items[0].Tags = []string{"dog", "bird"}
+
+	files, skipped, err = DatasetFiles(models.ExportFormatCOCO, items, testClasses, nil, models.ProjectAnnotationTypeClassification.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+
+	coco = models.COCO{}
+	require.NoError(t, json.Unmarshal(files[COCOFile], &coco))
+	assert.Equal(t, []models.COCOAnnotation{{ID: 1, ImageID: 1, CategoryID: 2}}, coco.Annotations)
+}
+
+func TestDatasetFilesCOCOSegmentation(t *testing.T) {
+	item := testItem("image1", 4, 4)
+	item.Tags = []string{"cat", "dog"}
+	item.Polygons = []models.AnnotationDataPolygon{
+		{TagID: "cat", Points: []float64{0, 0, 4, 0, 4, 4}},
+		{TagID: "bird", Points: []float64{0, 0, 1, 0, 1, 1}},
+	}
+	item.Masks = []models.AnnotationDataMask{{TagID: "dog", Height: 2, Width: 2, Counts: []int{1, 3}}}
+
+	files, skipped, err := DatasetFiles(models.ExportFormatCOCO, []DatasetItem{item}, testClasses, nil, models.ProjectAnnotationTypeSegmentation.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+
+	var coco models.COCO
+	require.NoError(t, json.Unmarshal(files[COCOFile], &coco))
+	require.Len(t, coco.Annotations, 2)
+
+	polygon := coco.Annotations[0]
+	assert.Equal(t, 1, polygon.CategoryID)
+	assert.Equal(t, []float64{0, 0, 4, 4}, polygon.BBox)
+	require.NotNil(t, polygon.Segmentation)
+	assert.Equal(t, [][]float64{{0, 0, 4, 0, 4, 4}}, polygon.Segmentation.Polygons)
+	assert.Zero(t, polygon.IsCrowd)
+
+	mask := coco.Annotations[1]
+	assert.Equal(t, 2, mask.CategoryID)
+	assert.Equal(t, 1, mask.IsCrowd)
+	require.NotNil(t, mask.Segmentation)
+	require.NotNil(t, mask.Segmentation.RLE)
+	assert.Equal(t, []int{2, 2}, mask.Segmentation.RLE.Size)
+}
+
+func TestDatasetFilesCOCOKeypoints(t *testing.T) {
+	item := testItem("image1", 100, 100)
+	item.Tags = []string{"cat"}
+	item.Objects = []models.AnnotationDataKeypointObject{
+		{
+			AnnotationDataBoundingBox: models.AnnotationDataBoundingBox{TagID: "cat", Xmin: 10, Ymin: 10, Xmax: 30, Ymax: 20},
+			Keypoints: []models.AnnotationDataKeypoint{
+				{X: 12, Y: 14, Visibility: models.KeypointVisible},
+				{Visibility: models.KeypointNotLabelled},
+			},
+		},
+		{AnnotationDataBoundingBox: models.AnnotationDataBoundingBox{TagID: "bird", Xmax: 1, Ymax: 1}},
+	}
+	skeletons := map[string]models.TagSkeleton{"cat": {Keypoints: []string{"nose", "tail"}, Edges: [][2]int{{0, 1}}}}
+
+	files, skipped, err := DatasetFiles(models.ExportFormatCOCO, []DatasetItem{item}, testClasses, skeletons, models.ProjectAnnotationTypeKeypoint.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+
+	var coco models.COCO
+	require.NoError(t, json.Unmarshal(files[COCOFile], &coco))
+
+	// COCO skeletons index keypoints from 1
+	assert.Equal(t, models.COCOCategory{ID: 1, Name: "cat", Keypoints: []string{"nose", "tail"}, Skeleton: [][2]int{{1, 2}}}, coco.Categories[0])
+	assert.Equal(t, []models.COCOAnnotation{{
+		ID:           1,
+		ImageID:      1,
+		CategoryID:   1,
+		BBox:         []float64{10, 10, 20, 10},
+		Area:         200,
+		Keypoints:    []float64{12, 14, float64(models.KeypointVisible), 0, 0, 0},
+		NumKeypoints: 1,
+	}}, coco.Annotations)
+}
+
+func TestDatasetFilesVOC(t *testing.T) {
+	files, skipped, err := DatasetFiles(models.ExportFormatVOC, testBoxItems(), testClasses, nil, bbox)
+	require.NoError(t, err)
+	assert.Zero(t, skipped)
+	require.Len(t, files, 2)
+
+	var voc models.VOC
+	require.NoError(t, xml.Unmarshal(files["Annotations/image1.xml"], &voc))
+	assert.Equal(t, "image1.jpeg", voc.Filename)
+	assert.Equal(t, models.VOCSize{Width: 100, Height: 50, Depth: 3}, voc.Size)
+	require.Len(t, voc.Objects, 2)
+	assert.Equal(t, "cat", voc.Objects[0].Name)
+	assert.Equal(t, &models.VOCBndBox{Xmin: 10, Ymin: 20, Xmax: 50, Ymax: 40}, voc.Objects[0].BndBox)
+
+	// Classification projects are written as objects without a box
+	files, _, err = DatasetFiles(models.ExportFormatVOC, testBoxItems(), testClasses, nil, models.ProjectAnnotationTypeClassification.String())
+	require.NoError(t, err)
+
+	voc = models.VOC{}
+	require.NoError(t, xml.Unmarshal(files["Annotations/image2.xml"], &voc))
+	require.Len(t, voc.Objects, 1)
+	assert.Equal(t, "dog", voc.Objects[0].Name)
+	assert.Nil(t, voc.Objects[0].BndBox)
+}
+
+func TestDatasetFilesYOLO(t *testing.T) {
+	files, skipped, err := DatasetFiles(models.ExportFormatYOLO, testBoxItems(), testClasses, nil, bbox)
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+
+	assert.Equal(t, "0 0.300000 0.600000 0.400000 0.400000\n", string(files["labels/image1.txt"]))
+	assert.Equal(t, "1 0.500000 0.500000 1.000000 1.000000\n", string(files["labels/image2.txt"]))
+	assert.Equal(t, "cat\ndog\n", string(files[models.YOLOClassesFile]))
+	assert.Contains(t, string(files[YOLODataFile]), "nc: 2")
+
+	// YOLO only describes bounding boxes of images with known dimensions
+	_, _, err = DatasetFiles(models.ExportFormatYOLO, testBoxItems(), testClasses, nil, models.ProjectAnnotationTypeClassification.String())
+	assert.Error(t, err)
+
+	_, _, err = DatasetFiles(models.ExportFormatYOLO, []DatasetItem{testItem("image3", 0, 0)}, testClasses, nil, bbox)
+	assert.Error(t, err)
+}
+
+func TestDatasetFilesManifest(t *testing.T) {
+	files, skipped, err := DatasetFiles(models.ExportFormatManifest, testBoxItems(), testClasses, nil, bbox)
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+
+	lines := strings.Split(strings.TrimSpace(string(files[ManifestFile])), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"source-ref":"s3://bucket/user/content/image1.jpeg"`)
+	assert.Contains(t, lines[0], `"annotations":[{"class_id":0,"left":10,"top":20,"width":40,"height":20}]`)
+
+	// Classification manifests hold a multi-hot label of the known tags
+	files, skipped, err = DatasetFiles(models.ExportFormatManifest, testBoxItems(), testClasses, nil, models.ProjectAnnotationTypeClassification.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+	assert.Contains(t, string(files[ManifestFile]), `"[1,0]"`)
+
+	_, _, err = DatasetFiles(models.ExportFormatManifest, testBoxItems(), testClasses, nil, models.ProjectAnnotationTypeSegmentation.String())
+	assert.Error(t, err)
+}
+
+func TestDatasetFilesUnsupported(t *testing.T) {
+	_, _, err := DatasetFiles("TFRECORD", testBoxItems(), testClasses, nil, bbox)
+	assert.Error(t, err)
+}
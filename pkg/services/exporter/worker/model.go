@@ -0,0 +1,118 @@
+/*
+ * File: model.go
+ * Project: worker
+ * File Created: Friday, 9th February 2024 10:41:08 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 9th February 2024 10:41:08 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package worker
+
+import (
+	"encoding/json"
+	"log"
+	"path"
+
+	"github.com/pkg/errors"
+
+	zipwriter "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/archive"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+const (
+	// Model export archive name
+	ModelArchive = "model.zip"
+	// Model description file name
+	ModelFile = "model.json"
+	// Label to integer mapping file name
+	LabelMapFile = "label_map.json"
+)
+
+// ModelDescription is everything needed to run a trained model outside of Emerald
+type ModelDescription struct {
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	ProjectID       string                 `json:"projectid"`
+	DatasetID       string                 `json:"datasetid"`
+	AnnotationType  string                 `json:"annotation_type"`
+	Artifact        string                 `json:"artifact"`
+	LabelMap        map[string]int         `json:"label_map"`
+	Preprocessing   models.Preprocessors   `json:"preprocessing"`
+	Augmentation    models.Augmentations   `json:"augmentation"`
+	HyperParameters map[string]string      `json:"hyperparameters"`
+	Metrics         map[string]interface{} `json:"metrics"`
+}
+
+// generateModelExport packages the trained model artifact along with its label map and training configuration
+func (e *Export) generateModelExport(
+	plat *platform.Platform,
+	export *models.Export) error {
+
+	if export.Model == nil {
+		return errors.New("unable to locate metadata for model export")
+	}
+
+	// Retrieve the model as stored; internal fields are not part of the export metadata
+	model, err := plat.ModelDB.View(e.Db, export.UserID, export.Model.ID.Hex())
+	if err != nil {
+		log.Printf("error retrieving model from database; export=%s err=%s", export.ID.Hex(), err.Error())
+		return err
+	}
+
+	if model.State != models.ModelStateTrained.String() || model.TrainingJobName == "" {
+		return errors.Errorf("model has not been trained; model=%s state=%s", model.ID.Hex(), model.State)
+	}
+
+	project, err := plat.ProjectDB.View(e.Db, export.UserID, model.ProjectID)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving project from database; project=%s", model.ProjectID)
+	}
+
+	artifactBucket, artifactKey := model.Artifact()
+
+	description, err := json.MarshalIndent(ModelDescription{
+		ID:              model.ID.Hex(),
+		Name:            model.Name,
+		ProjectID:       model.ProjectID,
+		DatasetID:       model.DatasetID,
+		AnnotationType:  project.AnnotationType,
+		Artifact:        path.Base(artifactKey),
+		LabelMap:        model.IntegerMapping,
+		Preprocessing:   model.Preprocessing,
+		Augmentation:    model.Augmentation,
+		HyperParameters: model.HyperParameters,
+		Metrics:         model.Metrics,
+	}, "", "  ")
+	if err != nil {
+		log.Printf("error creating model file; err=%s", err.Error())
+		return err
+	}
+
+	labelMap, err := json.Marshal(model.IntegerMapping)
+	if err != nil {
+		log.Printf("error creating label map file; err=%s", err.Error())
+		return err
+	}
+
+	// Upload archive
+	inChan := make(chan *zipwriter.ObjectInput, 10)
+	doneChan := make(chan error, 1)
+	archiveName := path.Join(export.Path, ModelArchive)
+
+	go func() {
+		err := e.Zipw.ZipS3Files(inChan, &zipwriter.ObjectOutput{Bucket: &e.Bucket, Key: &archiveName})
+		doneChan <- err
+	}()
+
+	// Upload files
+	inChan <- &zipwriter.ObjectInput{Bucket: &artifactBucket, Key: &artifactKey}
+	inChan <- &zipwriter.ObjectInput{RawBytes: map[string][]byte{ModelFile: description, LabelMapFile: labelMap}}
+
+	close(inChan)
+
+	export.ContentKeys = append(export.ContentKeys, archiveName)
+
+	return <-doneChan
+}
@@ -18,6 +18,7 @@ import (
 	stats "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
 )
 
@@ -41,8 +42,9 @@ func (m *Model) RealtimeInference(ctx echo.Context, req realtimeInferenceReq) ([
 	if model.State != models.ModelStateTrained.String() {
 		return nil, ErrModelNotTrained
 	}
-	// Model must have a deployment
-	if model.Deployment.EndpointName == "" {
+	// Model must have a deployment, unless served locally
+	servedBy := backend.ServedBy(&model)
+	if servedBy == backend.SageMaker && model.Deployment.EndpointName == "" {
 		return nil, ErrModelDeploymentNotFound
 	}
 
@@ -58,7 +60,7 @@ func (m *Model) RealtimeInference(ctx echo.Context, req realtimeInferenceReq) ([
 			}
 
 			start := time.Now()
-			result, err := realtime.New(filebytes, model.Deployment.EndpointName, model.Metadata["type"].(string), m.sagemakerRuntimeClient)
+			result, err := m.inference.Infer(&model, filebytes)
 			if err != nil {
 				return nil, err
 			}
@@ -79,7 +81,7 @@ func (m *Model) RealtimeInference(ctx echo.Context, req realtimeInferenceReq) ([
 		}
 
 		start := time.Now()
-		result, err := realtime.New(req.octetStream, model.Deployment.EndpointName, model.Metadata["type"].(string), m.sagemakerRuntimeClient)
+		result, err := m.inference.Infer(&model, req.octetStream)
 		if err != nil {
 			return nil, err
 		}
@@ -97,7 +99,7 @@ func (m *Model) RealtimeInference(ctx echo.Context, req realtimeInferenceReq) ([
 
 	// Update usage - don't hold up the request for this update
 	go func() {
-		if err := m.updateUsage(req, len(results), totalInferenceTimeSeconds); err != nil {
+		if err := m.updateUsage(req, servedBy, len(results), totalInferenceTimeSeconds); err != nil {
 			log.Errorf("unable to record endpoint usage for modelid=%s userid=%s; err=%s", req.modelID, req.userID, err.Error())
 		}
 	}()
@@ -107,16 +109,21 @@ func (m *Model) RealtimeInference(ctx echo.Context, req realtimeInferenceReq) ([
 	return results, nil
 }
 
-func (m *Model) updateUsage(req realtimeInferenceReq, count int, inferenceTimeSeconds float64) error {
+func (m *Model) updateUsage(req realtimeInferenceReq, servedBy string, count int, inferenceTimeSeconds float64) error {
+	metadata := map[string]interface{}{
+		"modelid":         req.modelID,
+		"inference_count": count,
+		"backend":         servedBy,
+	}
+	if servedBy == backend.SageMaker {
+		metadata["memory_size_mb"] = m.cfg.ModelService.EndpointConfig.MemorySizeInMB
+	}
+
 	return m.platform.UserDB.AddUsage(m.db, req.userID, models.Usage{
 		Time:          time.Now().UTC().Format(time.RFC3339),
 		Type:          models.UsageTypeEndpoint,
 		BillingMetric: models.BillingMetricSecond,
 		BillableValue: inferenceTimeSeconds,
-		Metadata: map[string]interface{}{
-			"modelid":         req.modelID,
-			"memory_size_mb":  m.cfg.ModelService.EndpointConfig.MemorySizeInMB,
-			"inference_count": count,
-		},
+		Metadata:      metadata,
 	})
 }
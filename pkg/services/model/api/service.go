@@ -17,9 +17,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sagemakerruntime"
 	"github.com/labstack/echo/v4"
 
+	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
 	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 	config "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/config"
+	localRealtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/realtime"
 	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
 )
 
@@ -35,24 +38,27 @@ type Service interface {
 }
 
 // Initialize initializes User application service with defaults
-func Initialize(db *db.DB, platform *platform.Platform, cfg *config.Configuration) (*Model, error) {
+func Initialize(db *db.DB, platform *platform.Platform, blob *blob.Blob, cfg *config.Configuration) (*Model, error) {
 	awsConfig, err := awsconfig.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		return nil, err
 	}
 
 	return &Model{
-		db:                     db,
-		platform:               platform,
-		cfg:                    cfg,
-		sagemakerRuntimeClient: sagemakerruntime.NewFromConfig(awsConfig),
+		db:       db,
+		platform: platform,
+		cfg:      cfg,
+		inference: &backend.Inference{
+			SageMaker: realtime.NewBackend(sagemakerruntime.NewFromConfig(awsConfig)),
+			Local:     localRealtime.NewBackend(&cfg.ModelService.LocalInferenceConfig, blob),
+		},
 	}, nil
 }
 
 // Model represents user application service
 type Model struct {
-	db                     *db.DB
-	cfg                    *config.Configuration
-	platform               *platform.Platform
-	sagemakerRuntimeClient *sagemakerruntime.Client
+	db        *db.DB
+	cfg       *config.Configuration
+	platform  *platform.Platform
+	inference *backend.Inference
 }
@@ -0,0 +1,147 @@
+/*
+ * File: backend.go
+ * Project: backend
+ * File Created: Thursday, 22nd February 2024 10:12:08 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 10:12:08 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package backend
+
+import (
+	"math"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
+)
+
+// Backends models are trained on and served by
+const (
+	SageMaker = "sagemaker"
+	Local     = "local"
+)
+
+// TrainingJob describes the training of a model
+type TrainingJob struct {
+	Algorithm  models.ProjectAnnotationType
+	Parameters models.TrainParameters
+	// Location of the train and validation manifests; model artifacts are written below it
+	OutputDataPath         string
+	NumClasses             int32
+	NumTrainingSamples     int32
+	NumValidationSamples   int32
+	ForcePaddingLabelWidth int32
+}
+
+// TrainingState is the state of a submitted training job
+type TrainingState string
+
+const (
+	TrainingStateInProgress TrainingState = "InProgress"
+	TrainingStateCompleted  TrainingState = "Completed"
+	TrainingStateFailed     TrainingState = "Failed"
+	TrainingStateStopped    TrainingState = "Stopped"
+)
+
+// TrainingStatus is the status of a submitted training job
+type TrainingStatus struct {
+	State TrainingState
+	// Name of the training job whose model is kept, set once completed
+	TrainingJobName string
+	// Reason of the failure, if failed
+	FailureReason string
+	// Runtime billed for the job, set once stopped
+	BillableTimeInSeconds int32
+}
+
+// TrainingBackend trains models
+type TrainingBackend interface {
+	// Submit starts the training job and returns its name along with the train parameters it runs with
+	Submit(job TrainingJob) (string, models.TrainParameters, error)
+	// Status returns the current status of a submitted job without blocking
+	Status(name string) (TrainingStatus, error)
+	// Metrics returns the final metrics of a completed training job, including "BillableTimeInSeconds"
+	Metrics(trainingJobName string) (map[string]interface{}, error)
+	// Artifacts returns the location of the model artifact and the hyperparameters of a completed training job
+	Artifacts(trainingJobName string) (string, map[string]string, error)
+	// Stop requests a submitted job to stop without blocking; its status reports Stopped once it has
+	Stop(name string) error
+}
+
+// InferenceBackend runs images through trained models
+type InferenceBackend interface {
+	// Infer returns the raw predictions of the model for the image
+	Infer(model *models.Model, image []byte) (realtime.ModelReturn, error)
+}
+
+// ServedBy returns the backend serving a model. Models trained locally are run on the host of the model service
+// and need no deployment; others are invoked through their SageMaker endpoint.
+func ServedBy(model *models.Model) string {
+	if model.TrainingBackend == Local {
+		return Local
+	}
+	return SageMaker
+}
+
+// Inference runs models on the backend serving them
+type Inference struct {
+	SageMaker InferenceBackend
+	Local     InferenceBackend
+}
+
+// Infer runs the image through the model on the backend serving it. The image is preprocessed as the images the
+// model was trained on were, and predicted boxes are mapped back onto the image.
+func (i *Inference) Infer(model *models.Model, img []byte) (realtime.ModelReturn, error) {
+	transforms := Preprocessors(model.Preprocessing)
+	if len(transforms) == 0 {
+		return i.infer(model, img)
+	}
+
+	preprocessed, mapping, err := image.PreprocessImage(img, transforms)
+	if err != nil {
+		return nil, err
+	}
+	result, err := i.infer(model, preprocessed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detections are relative to the size of the preprocessed image
+	if detection, ok := result.(*realtime.ObjectDetectionResult); ok {
+		for _, d := range detection.Return["prediction"] {
+			if len(d) < 6 {
+				continue
+			}
+			d[2], d[3] = mapping.Invert(d[2], d[3])
+			d[4], d[5] = mapping.Invert(d[4], d[5])
+			for j := 2; j < 6; j++ {
+				d[j] = math.Min(math.Max(d[j], 0), 1)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (i *Inference) infer(model *models.Model, img []byte) (realtime.ModelReturn, error) {
+	if ServedBy(model) == Local {
+		return i.Local.Infer(model, img)
+	}
+	return i.SageMaker.Infer(model, img)
+}
+
+// Preprocessors returns the transforms of the preprocessors, in the order they are applied
+func Preprocessors(p models.Preprocessors) []image.Transform {
+	transforms := []image.Transform{}
+	if p.PercentCrop != nil {
+		transforms = append(transforms, image.PercentCrop{Width: p.PercentCrop.Width, Height: p.PercentCrop.Height})
+	}
+	if p.Resize != nil {
+		transforms = append(transforms, image.Resize{Mode: p.Resize.Mode, Width: p.Resize.Size.Width, Height: p.Resize.Size.Height})
+	}
+	if p.Greyscale != nil {
+		transforms = append(transforms, image.Greyscale{})
+	}
+	return transforms
+}
@@ -0,0 +1,87 @@
+/*
+ * File: backend_test.go
+ * Project: backend
+ * File Created: Monday, 26th February 2024 9:42:10 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 26th February 2024 9:42:10 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package backend
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
+)
+
+// detector returns a single detection, relative to the size of the image it is given
+type detector struct {
+	box    image.BoundingBox
+	width  int
+	height int
+}
+
+func (d *detector) Infer(model *models.Model, img []byte) (realtime.ModelReturn, error) {
+	stats, err := image.GetStats(img)
+	if err != nil {
+		return nil, err
+	}
+	d.width, d.height = stats.Width, stats.Height
+	w, h := float64(stats.Width), float64(stats.Height)
+	return &realtime.ObjectDetectionResult{Return: map[string][][]float64{"prediction": {
+		{0, 0.9, float64(d.box.Xmin) / w, float64(d.box.Ymin) / h, float64(d.box.Xmax) / w, float64(d.box.Ymax) / h},
+	}}}, nil
+}
+
+func TestInferencePreprocessing(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, imaging.New(200, 120, color.White)))
+	img := buf.Bytes()
+	stats := &image.Stats{Width: 200, Height: 120}
+
+	resize := &models.Resize{Mode: image.ResizeFill}
+	resize.Size.Width, resize.Size.Height = 64, 64
+	model := &models.Model{Preprocessing: models.Preprocessors{
+		PercentCrop: &models.PercentCrop{Width: 0.8, Height: 0.9},
+		Resize:      resize,
+		Greyscale:   &models.Greyscale{},
+	}}
+	truth := image.BoundingBox{Xmin: 60, Ymin: 30, Xmax: 120, Ymax: 90, ClassName: "cat"}
+
+	// The model predicts the box where training moved it
+	_, width, height, moved, err := image.TransformImage(img, []image.BoundingBox{truth}, Preprocessors(model.Preprocessing), nil)
+	require.NoError(t, err)
+	require.Len(t, moved, 1)
+	d := &detector{box: moved[0]}
+	inference := &Inference{SageMaker: d}
+
+	result, err := inference.Infer(model, img)
+	require.NoError(t, err)
+	assert.Equal(t, []int{width, height}, []int{d.width, d.height})
+
+	// and the prediction is mapped back onto the image
+	predictions := result.ToFormattedResult(map[string]int{"cat": 0}, 0, stats).Predictions
+	require.Len(t, predictions, 1)
+	for key, want := range map[string]int{"xmin": truth.Xmin, "ymin": truth.Ymin, "xmax": truth.Xmax, "ymax": truth.Ymax} {
+		assert.InDelta(t, want, predictions[0].BoundingBox[key], 3, key)
+	}
+
+	// Without preprocessing images are passed as is
+	d = &detector{box: truth}
+	inference = &Inference{SageMaker: d}
+	result, err = inference.Infer(&models.Model{}, img)
+	require.NoError(t, err)
+	assert.Equal(t, []int{200, 120}, []int{d.width, d.height})
+	predictions = result.ToFormattedResult(map[string]int{"cat": 0}, 0, stats).Predictions
+	assert.Equal(t, map[string]interface{}{"xmin": 60, "ymin": 30, "xmax": 120, "ymax": 90}, predictions[0].BoundingBox)
+}
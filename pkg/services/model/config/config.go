@@ -12,6 +12,8 @@ package config
 import (
 	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	localRealtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/realtime"
+	localTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/train"
 	endpoint "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/endpoint"
 	garbage "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/garbage"
 	train "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
@@ -38,9 +40,14 @@ type ModelService struct {
 	BatchWorkerConfig    worker.Config `yaml:"batch_worker_config,omitempty"`
 	GarbageWorkerConfig  worker.Config `yaml:"garbage_worker_config,omitempty"`
 
-	TrainConfig    train.Config    `yaml:"train_config,omitempty"`
-	EndpointConfig endpoint.Config `yaml:"endpoint_config,omitempty"`
-	GarbageConfig  garbage.Config  `yaml:"garbage_config,omitempty"`
+	// Backend models are trained on i.e. "sagemaker" (default) or "local"
+	TrainBackend string `yaml:"train_backend,omitempty"`
+
+	TrainConfig          train.Config         `yaml:"train_config,omitempty"`
+	LocalTrainConfig     localTrain.Config    `yaml:"local_train_config,omitempty"`
+	EndpointConfig       endpoint.Config      `yaml:"endpoint_config,omitempty"`
+	LocalInferenceConfig localRealtime.Config `yaml:"local_inference_config,omitempty"`
+	GarbageConfig        garbage.Config       `yaml:"garbage_config,omitempty"`
 }
 
 // Server holds data necessary for server configuration
@@ -0,0 +1,414 @@
+/*
+ * File: agreement.go
+ * Project: evaluation
+ * File Created: Friday, 16th February 2024 2:05:33 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 16th February 2024 2:05:33 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package evaluation
+
+import (
+	"sort"
+)
+
+// Rating is a single rater's labelling of an item. Objects without a box are classification labels.
+type Rating struct {
+	Item    string
+	Rater   string
+	Objects []Object
+}
+
+// AgreementMetrics measure how well raters agree with each other
+type AgreementMetrics struct {
+	// Chance corrected agreement on classification labels in [-1, 1]. Fleiss' kappa overall and per class,
+	// Cohen's kappa between two raters and the mean Cohen's kappa with every other rater for a single rater.
+	Kappa *float64 `json:"kappa,omitempty" bson:"kappa,omitempty"`
+	// Fraction of boxes matched by a box of the same class from the other rater at the IoU threshold
+	BoxAgreement *float64 `json:"box_agreement,omitempty" bson:"box_agreement,omitempty"`
+	// Mean IoU of matched boxes
+	MeanIoU *float64 `json:"mean_iou,omitempty" bson:"mean_iou,omitempty"`
+}
+
+// RaterAgreement is the agreement of a single rater with the other raters
+type RaterAgreement struct {
+	AgreementMetrics `bson:",inline"`
+	// Items rated by the rater and at least one other rater
+	Items int `json:"items" bson:"items"`
+	// Agreement with each other rater
+	Pairs map[string]AgreementMetrics `json:"pairs" bson:"pairs"`
+}
+
+// Agreement holds the overall, per class and per rater agreement of ratings
+type Agreement struct {
+	AgreementMetrics `bson:",inline"`
+	// Items rated by at least two raters; other items are ignored
+	Items   int                         `json:"items" bson:"items"`
+	Classes map[string]AgreementMetrics `json:"classes" bson:"classes"`
+	Raters  map[string]RaterAgreement   `json:"raters" bson:"raters"`
+}
+
+// fleiss accumulates the binary decisions of Fleiss' kappa, allowing a varying number of raters per subject
+type fleiss struct {
+	agreement float64
+	subjects  int
+	ratings   int
+	positive  int
+}
+
+func (f *fleiss) add(positive, raters int) {
+	negative := raters - positive
+	f.agreement += float64(positive*positive+negative*negative-raters) / float64(raters*(raters-1))
+	f.subjects++
+	f.ratings += raters
+	f.positive += positive
+}
+
+func (f fleiss) kappa() *float64 {
+	if f.subjects == 0 {
+		return nil
+	}
+	observed := f.agreement / float64(f.subjects)
+	p := ratio(f.positive, f.ratings)
+	expected := p*p + (1-p)*(1-p)
+	if expected == 1 {
+		return nil
+	}
+	kappa := (observed - expected) / (1 - expected)
+	return &kappa
+}
+
+// cohen accumulates the binary decisions of two raters for Cohen's kappa
+type cohen struct {
+	both, first, second, neither int
+}
+
+func (c *cohen) add(first, second bool) {
+	switch {
+	case first && second:
+		c.both++
+	case first:
+		c.first++
+	case second:
+		c.second++
+	default:
+		c.neither++
+	}
+}
+
+func (c cohen) kappa() *float64 {
+	n := c.both + c.first + c.second + c.neither
+	if n == 0 {
+		return nil
+	}
+	observed := ratio(c.both+c.neither, n)
+	p1, p2 := ratio(c.both+c.first, n), ratio(c.both+c.second, n)
+	expected := p1*p2 + (1-p1)*(1-p2)
+	if expected == 1 {
+		return nil
+	}
+	kappa := (observed - expected) / (1 - expected)
+	return &kappa
+}
+
+// boxes accumulates box matches between pairs of raters
+type boxes struct {
+	boxes   int
+	matched int
+	iou     float64
+}
+
+func (b *boxes) add(o boxes) {
+	b.boxes += o.boxes
+	b.matched += o.matched
+	b.iou += o.iou
+}
+
+func (b boxes) metrics(m *AgreementMetrics) {
+	if b.boxes == 0 {
+		return
+	}
+	agreement := ratio(2*b.matched, b.boxes)
+	m.BoxAgreement = &agreement
+	if b.matched > 0 {
+		iou := b.iou / float64(b.matched)
+		m.MeanIoU = &iou
+	}
+}
+
+// matchBoxes greedily pairs the boxes of two raters, highest IoU first, at or above iouThreshold
+func matchBoxes(a, b []Box, iouThreshold float64) boxes {
+	type candidate struct {
+		i, j int
+		iou  float64
+	}
+
+	candidates := []candidate{}
+	for i := range a {
+		for j := range b {
+			if iou := IoU(a[i], b[j]); iou >= iouThreshold && iou > 0 {
+				candidates = append(candidates, candidate{i, j, iou})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(x, y int) bool { return candidates[x].iou > candidates[y].iou })
+
+	result := boxes{boxes: len(a) + len(b)}
+	usedA, usedB := make([]bool, len(a)), make([]bool, len(b))
+	for _, c := range candidates {
+		if usedA[c.i] || usedB[c.j] {
+			continue
+		}
+		usedA[c.i], usedB[c.j] = true, true
+		result.matched++
+		result.iou += c.iou
+	}
+	return result
+}
+
+// labels returns the classification labels and the boxes per class of a rating
+func (r Rating) labels() (map[string]bool, map[string][]Box) {
+	labels, classBoxes := make(map[string]bool), make(map[string][]Box)
+	for _, object := range r.Objects {
+		if object.Box == nil {
+			labels[object.Class] = true
+		} else {
+			classBoxes[object.Class] = append(classBoxes[object.Class], *object.Box)
+		}
+	}
+	return labels, classBoxes
+}
+
+// groupRatings groups ratings by item, ordered by rater, keeping items rated by at least two raters
+func groupRatings(ratings []Rating) map[string][]Rating {
+	items := make(map[string][]Rating)
+	for _, rating := range ratings {
+		items[rating.Item] = append(items[rating.Item], rating)
+	}
+	for item, rated := range items {
+		if len(rated) < 2 {
+			delete(items, item)
+			continue
+		}
+		sort.SliceStable(rated, func(i, j int) bool { return rated[i].Rater < rated[j].Rater })
+	}
+	return items
+}
+
+// InterRaterAgreement measures the agreement of raters who labelled the same items. Classification labels are
+// compared with Fleiss' kappa overall and per class and with Cohen's kappa between raters, treating every class
+// seen in any rating as a yes/no decision. Boxes are matched between every two raters of an item by class and
+// IoU at or above iouThreshold.
+func InterRaterAgreement(ratings []Rating, iouThreshold float64) Agreement {
+	items := groupRatings(ratings)
+
+	classSet := make(map[string]bool)
+	for _, rated := range items {
+		for _, rating := range rated {
+			for _, object := range rating.Objects {
+				if object.Box == nil {
+					classSet[object.Class] = true
+				}
+			}
+		}
+	}
+
+	overallFleiss := fleiss{}
+	classFleiss := make(map[string]*fleiss)
+	overallBoxes := boxes{}
+	classBoxes := make(map[string]*boxes)
+	pairCohen := make(map[[2]string]*cohen)
+	pairBoxes := make(map[[2]string]*boxes)
+	raterItems := make(map[string]int)
+
+	for _, rated := range items {
+		labels := make([]map[string]bool, len(rated))
+		objects := make([]map[string][]Box, len(rated))
+		for i, rating := range rated {
+			labels[i], objects[i] = rating.labels()
+			raterItems[rating.Rater]++
+		}
+
+		for class := range classSet {
+			positive := 0
+			for i := range rated {
+				if labels[i][class] {
+					positive++
+				}
+			}
+			if classFleiss[class] == nil {
+				classFleiss[class] = &fleiss{}
+			}
+			classFleiss[class].add(positive, len(rated))
+			overallFleiss.add(positive, len(rated))
+		}
+
+		for i := range rated {
+			for j := i + 1; j < len(rated); j++ {
+				pair := [2]string{rated[i].Rater, rated[j].Rater}
+				if pairCohen[pair] == nil {
+					pairCohen[pair], pairBoxes[pair] = &cohen{}, &boxes{}
+				}
+				for class := range classSet {
+					pairCohen[pair].add(labels[i][class], labels[j][class])
+				}
+
+				classes := make(map[string]bool)
+				for class := range objects[i] {
+					classes[class] = true
+				}
+				for class := range objects[j] {
+					classes[class] = true
+				}
+				for class := range classes {
+					matched := matchBoxes(objects[i][class], objects[j][class], iouThreshold)
+					if classBoxes[class] == nil {
+						classBoxes[class] = &boxes{}
+					}
+					classBoxes[class].add(matched)
+					pairBoxes[pair].add(matched)
+					overallBoxes.add(matched)
+				}
+			}
+		}
+	}
+
+	result := Agreement{Items: len(items), Classes: make(map[string]AgreementMetrics), Raters: make(map[string]RaterAgreement)}
+	result.Kappa = overallFleiss.kappa()
+	overallBoxes.metrics(&result.AgreementMetrics)
+
+	for class, f := range classFleiss {
+		metrics := result.Classes[class]
+		metrics.Kappa = f.kappa()
+		result.Classes[class] = metrics
+	}
+	for class, b := range classBoxes {
+		metrics := result.Classes[class]
+		b.metrics(&metrics)
+		result.Classes[class] = metrics
+	}
+
+	raterBoxes := make(map[string]*boxes)
+	raterKappas := make(map[string][]float64)
+	for rater, n := range raterItems {
+		result.Raters[rater] = RaterAgreement{Items: n, Pairs: make(map[string]AgreementMetrics)}
+		raterBoxes[rater] = &boxes{}
+	}
+	for pair, c := range pairCohen {
+		metrics := AgreementMetrics{Kappa: c.kappa()}
+		pairBoxes[pair].metrics(&metrics)
+
+		for i, rater := range pair {
+			other := pair[1-i]
+			result.Raters[rater].Pairs[other] = metrics
+			raterBoxes[rater].add(*pairBoxes[pair])
+			if metrics.Kappa != nil {
+				raterKappas[rater] = append(raterKappas[rater], *metrics.Kappa)
+			}
+		}
+	}
+	for rater, agreement := range result.Raters {
+		if kappas := raterKappas[rater]; len(kappas) > 0 {
+			kappa := mean(kappas)
+			agreement.Kappa = &kappa
+		}
+		raterBoxes[rater].metrics(&agreement.AgreementMetrics)
+		result.Raters[rater] = agreement
+	}
+
+	return result
+}
+
+// cluster is a group of boxes of the same class from different raters
+type cluster struct {
+	class  string
+	raters map[string]bool
+	boxes  []Box
+}
+
+func (c cluster) mean() Box {
+	m := Box{}
+	for _, b := range c.boxes {
+		m.Xmin += b.Xmin
+		m.Ymin += b.Ymin
+		m.Xmax += b.Xmax
+		m.Ymax += b.Ymax
+	}
+	n := float64(len(c.boxes))
+	return Box{Xmin: m.Xmin / n, Ymin: m.Ymin / n, Xmax: m.Xmax / n, Ymax: m.Ymax / n}
+}
+
+// Consensus merges the ratings of a single item. A classification label is kept when at least minVotes raters
+// applied it. Boxes of the same class are grouped across raters, each box joining the group whose mean box it
+// overlaps most at or above iouThreshold and which has no box from the same rater yet; a group with boxes from at
+// least minVotes raters yields its mean box. minVotes defaults to a strict majority of the raters. The
+// confidence of a merged object is the fraction of raters who agreed on it.
+func Consensus(ratings []Rating, iouThreshold float64, minVotes int) []Object {
+	if len(ratings) == 0 {
+		return []Object{}
+	}
+	if minVotes <= 0 {
+		minVotes = len(ratings)/2 + 1
+	}
+
+	rated := append([]Rating{}, ratings...)
+	sort.SliceStable(rated, func(i, j int) bool { return rated[i].Rater < rated[j].Rater })
+
+	votes := make(map[string]int)
+	clusters := []*cluster{}
+	for _, rating := range rated {
+		labels, classBoxes := rating.labels()
+		for class := range labels {
+			votes[class]++
+		}
+
+		classes := make([]string, 0, len(classBoxes))
+		for class := range classBoxes {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+
+		for _, class := range classes {
+			for _, box := range classBoxes[class] {
+				var best *cluster
+				bestIoU := iouThreshold
+				for _, c := range clusters {
+					if c.class != class || c.raters[rating.Rater] {
+						continue
+					}
+					if iou := IoU(box, c.mean()); iou >= bestIoU && iou > 0 {
+						best, bestIoU = c, iou
+					}
+				}
+				if best == nil {
+					best = &cluster{class: class, raters: make(map[string]bool)}
+					clusters = append(clusters, best)
+				}
+				best.raters[rating.Rater] = true
+				best.boxes = append(best.boxes, box)
+			}
+		}
+	}
+
+	classes := make([]string, 0, len(votes))
+	for class := range votes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	objects := []Object{}
+	for _, class := range classes {
+		if votes[class] >= minVotes {
+			objects = append(objects, Object{Class: class, Confidence: ratio(votes[class], len(rated))})
+		}
+	}
+	for _, c := range clusters {
+		if len(c.raters) >= minVotes {
+			box := c.mean()
+			objects = append(objects, Object{Class: c.class, Confidence: ratio(len(c.raters), len(rated)), Box: &box})
+		}
+	}
+
+	return objects
+}
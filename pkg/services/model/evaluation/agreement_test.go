@@ -0,0 +1,71 @@
+/*
+ * File: agreement_test.go
+ * Project: evaluation
+ * File Created: Friday, 16th February 2024 3:18:40 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 16th February 2024 3:18:40 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterRaterAgreement(t *testing.T) {
+	cat := Object{Class: "cat"}
+	ratings := []Rating{
+		{Item: "1", Rater: "a", Objects: []Object{cat, {Class: "dog", Box: &Box{0, 0, 10, 10}}}},
+		{Item: "1", Rater: "b", Objects: []Object{cat, {Class: "dog", Box: &Box{0, 0, 10, 10}}}},
+		{Item: "2", Rater: "a", Objects: []Object{cat, {Class: "dog", Box: &Box{0, 0, 10, 10}}}},
+		{Item: "2", Rater: "b", Objects: []Object{{Class: "dog", Box: &Box{50, 50, 60, 60}}}},
+		{Item: "3", Rater: "a"},
+		{Item: "3", Rater: "b"},
+		{Item: "4", Rater: "a"},
+		{Item: "4", Rater: "b"},
+		{Item: "5", Rater: "a", Objects: []Object{cat}}, // single rater, ignored
+	}
+
+	result := InterRaterAgreement(ratings, DefaultIoUThreshold)
+	assert.Equal(t, 4, result.Items)
+
+	// Observed agreement 3/4 against 34/64 expected by chance
+	assert.InDelta(t, (0.75-34.0/64.0)/(1-34.0/64.0), *result.Classes["cat"].Kappa, 1e-9)
+	assert.InDelta(t, *result.Classes["cat"].Kappa, *result.Kappa, 1e-9)
+
+	// One of two box pairs matched
+	assert.Equal(t, 0.5, *result.Classes["dog"].BoxAgreement)
+	assert.Equal(t, 1.0, *result.Classes["dog"].MeanIoU)
+	assert.Nil(t, result.Classes["dog"].Kappa)
+
+	a := result.Raters["a"]
+	assert.Equal(t, 4, a.Items)
+	assert.InDelta(t, 0.5, *a.Pairs["b"].Kappa, 1e-9)
+	assert.InDelta(t, 0.5, *a.Kappa, 1e-9)
+	assert.Equal(t, 0.5, *a.BoxAgreement)
+	assert.Equal(t, a.Pairs["b"], result.Raters["b"].Pairs["a"])
+}
+
+func TestConsensus(t *testing.T) {
+	ratings := []Rating{
+		{Rater: "a", Objects: []Object{{Class: "cat"}, {Class: "person", Box: &Box{0, 0, 10, 10}}}},
+		{Rater: "b", Objects: []Object{{Class: "cat"}, {Class: "dog"}, {Class: "person", Box: &Box{0, 0, 10, 12}}}},
+		{Rater: "c", Objects: []Object{{Class: "person", Box: &Box{40, 40, 50, 50}}}},
+	}
+
+	objects := Consensus(ratings, DefaultIoUThreshold, 0)
+	assert.Len(t, objects, 2)
+
+	assert.Equal(t, "cat", objects[0].Class)
+	assert.Nil(t, objects[0].Box)
+	assert.InDelta(t, 2.0/3.0, objects[0].Confidence, 1e-9)
+
+	assert.Equal(t, "person", objects[1].Class)
+	assert.Equal(t, Box{0, 0, 10, 11}, *objects[1].Box)
+
+	// Every label and box is kept when a single vote is enough
+	assert.Len(t, Consensus(ratings, DefaultIoUThreshold, 1), 4)
+}
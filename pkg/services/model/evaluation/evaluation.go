@@ -0,0 +1,303 @@
+/*
+ * File: evaluation.go
+ * Project: evaluation
+ * File Created: Monday, 12th February 2024 9:02:51 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 12th February 2024 9:02:51 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// Confidence at or above which a classification prediction counts as a predicted label
+	DefaultClassificationThreshold = 0.5
+	// Confidence at or above which a detection counts towards precision and recall
+	DefaultDetectionThreshold = 0.5
+	// IoU at or above which a detection matches a ground truth box i.e. mAP@0.5
+	DefaultIoUThreshold = 0.5
+)
+
+// Box is a bounding box in absolute pixels
+type Box struct {
+	Xmin float64
+	Ymin float64
+	Xmax float64
+	Ymax float64
+}
+
+// Area of the box; empty boxes have no area
+func (b Box) Area() float64 {
+	return math.Max(0, b.Xmax-b.Xmin) * math.Max(0, b.Ymax-b.Ymin)
+}
+
+// IoU is the intersection over union of two boxes
+func IoU(a, b Box) float64 {
+	intersection := Box{
+		Xmin: math.Max(a.Xmin, b.Xmin),
+		Ymin: math.Max(a.Ymin, b.Ymin),
+		Xmax: math.Min(a.Xmax, b.Xmax),
+		Ymax: math.Min(a.Ymax, b.Ymax),
+	}.Area()
+
+	union := a.Area() + b.Area() - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// Object is a ground truth or predicted label. Box is nil for classification.
+type Object struct {
+	Class      string
+	Confidence float64
+	Box        *Box
+}
+
+// Sample is the ground truth and predictions for a single image
+type Sample struct {
+	Truth       []Object
+	Predictions []Object
+}
+
+// ClassMetrics are the metrics of a single class
+type ClassMetrics struct {
+	Precision float64 `json:"precision" bson:"precision"`
+	Recall    float64 `json:"recall" bson:"recall"`
+	F1        float64 `json:"f1" bson:"f1"`
+	// Average precision, detection only
+	AP *float64 `json:"ap,omitempty" bson:"ap,omitempty"`
+	// Number of ground truth labels
+	Support        int `json:"support" bson:"support"`
+	TruePositives  int `json:"true_positives" bson:"true_positives"`
+	FalsePositives int `json:"false_positives" bson:"false_positives"`
+	FalseNegatives int `json:"false_negatives" bson:"false_negatives"`
+}
+
+func newClassMetrics(tp, fp, fn int) ClassMetrics {
+	m := ClassMetrics{Support: tp + fn, TruePositives: tp, FalsePositives: fp, FalseNegatives: fn}
+	m.Precision = ratio(tp, tp+fp)
+	m.Recall = ratio(tp, tp+fn)
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	return m
+}
+
+// Result holds the overall and per class metrics of an evaluation. Overall precision and recall are
+// macro averages over the classes present in the ground truth or predictions.
+type Result struct {
+	Samples   int                     `json:"samples" bson:"samples"`
+	Accuracy  *float64                `json:"accuracy,omitempty" bson:"accuracy,omitempty"`
+	MAP       *float64                `json:"map,omitempty" bson:"map,omitempty"`
+	Precision float64                 `json:"precision" bson:"precision"`
+	Recall    float64                 `json:"recall" bson:"recall"`
+	F1        float64                 `json:"f1" bson:"f1"`
+	Classes   map[string]ClassMetrics `json:"classes" bson:"classes"`
+}
+
+func (r *Result) average() {
+	var precision, recall, f1 []float64
+	for _, m := range r.Classes {
+		if m.TruePositives+m.FalsePositives+m.FalseNegatives == 0 {
+			continue
+		}
+		precision = append(precision, m.Precision)
+		recall = append(recall, m.Recall)
+		f1 = append(f1, m.F1)
+	}
+	r.Precision, r.Recall, r.F1 = mean(precision), mean(recall), mean(f1)
+}
+
+// Classification evaluates multi-label classification. A class is predicted when its confidence is at or
+// above threshold; accuracy is the fraction of samples whose predicted labels exactly match the ground truth.
+func Classification(samples []Sample, classes []string, threshold float64) Result {
+	tp, fp, fn := make(map[string]int), make(map[string]int), make(map[string]int)
+	correct := 0
+
+	for _, sample := range samples {
+		truth := make(map[string]bool)
+		for _, object := range sample.Truth {
+			truth[object.Class] = true
+		}
+		predicted := make(map[string]bool)
+		for _, object := range sample.Predictions {
+			if object.Confidence >= threshold {
+				predicted[object.Class] = true
+			}
+		}
+
+		match := len(truth) == len(predicted)
+		for class := range predicted {
+			if truth[class] {
+				tp[class]++
+			} else {
+				fp[class]++
+				match = false
+			}
+		}
+		for class := range truth {
+			if !predicted[class] {
+				fn[class]++
+			}
+		}
+		if match {
+			correct++
+		}
+	}
+
+	result := Result{Samples: len(samples), Classes: make(map[string]ClassMetrics, len(classes))}
+	for _, class := range classes {
+		result.Classes[class] = newClassMetrics(tp[class], fp[class], fn[class])
+	}
+	accuracy := ratio(correct, len(samples))
+	result.Accuracy = &accuracy
+	result.average()
+
+	return result
+}
+
+// detection is a single prediction of a class during matching
+type detection struct {
+	sample     int
+	confidence float64
+	box        Box
+}
+
+// Detection evaluates object detection. Detections are matched greedily, highest confidence first, to the
+// unmatched ground truth box of the same class with the highest IoU at or above iouThreshold. Average precision
+// uses all detections; precision and recall count only detections at or above threshold.
+func Detection(samples []Sample, classes []string, iouThreshold, threshold float64) Result {
+	result := Result{Samples: len(samples), Classes: make(map[string]ClassMetrics, len(classes))}
+
+	var aps []float64
+	for _, class := range classes {
+		truth := make(map[int][]Box)
+		support := 0
+		detections := []detection{}
+		for idx, sample := range samples {
+			for _, object := range sample.Truth {
+				if object.Class == class && object.Box != nil {
+					truth[idx] = append(truth[idx], *object.Box)
+					support++
+				}
+			}
+			for _, object := range sample.Predictions {
+				if object.Class == class && object.Box != nil {
+					detections = append(detections, detection{sample: idx, confidence: object.Confidence, box: *object.Box})
+				}
+			}
+		}
+
+		matches := match(detections, truth, iouThreshold)
+
+		// Precision and recall at the confidence threshold
+		tp, fp := 0, 0
+		for i, d := range detections {
+			if d.confidence < threshold {
+				continue
+			}
+			if matches[i] {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		metrics := newClassMetrics(tp, fp, support-tp)
+
+		// Classes without ground truth have no defined average precision
+		if support > 0 {
+			ap := averagePrecision(matches, support)
+			metrics.AP = &ap
+			aps = append(aps, ap)
+		}
+		result.Classes[class] = metrics
+	}
+
+	mAP := mean(aps)
+	result.MAP = &mAP
+	result.average()
+
+	return result
+}
+
+// match sorts detections by descending confidence and reports whether each one matches a ground truth box
+func match(detections []detection, truth map[int][]Box, iouThreshold float64) []bool {
+	sort.SliceStable(detections, func(i, j int) bool { return detections[i].confidence > detections[j].confidence })
+
+	matched := make(map[int][]bool, len(truth))
+	for sample, boxes := range truth {
+		matched[sample] = make([]bool, len(boxes))
+	}
+
+	matches := make([]bool, len(detections))
+	for i, d := range detections {
+		best, bestIoU := -1, iouThreshold
+		for j, box := range truth[d.sample] {
+			if matched[d.sample][j] {
+				continue
+			}
+			if iou := IoU(d.box, box); iou >= bestIoU {
+				best, bestIoU = j, iou
+			}
+		}
+		if best >= 0 {
+			matched[d.sample][best] = true
+			matches[i] = true
+		}
+	}
+	return matches
+}
+
+// averagePrecision is the area under the interpolated precision recall curve of matches sorted by confidence
+func averagePrecision(matches []bool, support int) float64 {
+	if support == 0 {
+		return 0
+	}
+
+	precision := make([]float64, len(matches))
+	recall := make([]float64, len(matches))
+	tp := 0
+	for i, m := range matches {
+		if m {
+			tp++
+		}
+		precision[i] = float64(tp) / float64(i+1)
+		recall[i] = float64(tp) / float64(support)
+	}
+
+	// Make precision monotonically decreasing
+	for i := len(precision) - 2; i >= 0; i-- {
+		precision[i] = math.Max(precision[i], precision[i+1])
+	}
+
+	ap, previousRecall := 0.0, 0.0
+	for i := range matches {
+		ap += (recall[i] - previousRecall) * precision[i]
+		previousRecall = recall[i]
+	}
+	return ap
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
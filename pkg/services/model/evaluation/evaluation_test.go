@@ -0,0 +1,123 @@
+/*
+ * File: evaluation_test.go
+ * Project: evaluation
+ * File Created: Monday, 12th February 2024 10:37:19 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 12th February 2024 10:37:19 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIoU(t *testing.T) {
+	a := Box{0, 0, 10, 10}
+	assert.Equal(t, 1.0, IoU(a, a))
+	assert.Equal(t, 0.0, IoU(a, Box{20, 20, 30, 30}))
+	assert.InDelta(t, 25.0/175.0, IoU(a, Box{5, 5, 15, 15}), 1e-9)
+}
+
+func TestClassification(t *testing.T) {
+	samples := []Sample{
+		{Truth: []Object{{Class: "cat"}}, Predictions: []Object{{Class: "cat", Confidence: .9}, {Class: "dog", Confidence: .1}}},
+		{Truth: []Object{{Class: "dog"}}, Predictions: []Object{{Class: "cat", Confidence: .7}, {Class: "dog", Confidence: .3}}},
+		{Truth: []Object{{Class: "dog"}}, Predictions: []Object{{Class: "dog", Confidence: .8}}},
+	}
+
+	result := Classification(samples, []string{"cat", "dog"}, DefaultClassificationThreshold)
+	assert.Equal(t, 3, result.Samples)
+	assert.InDelta(t, 2.0/3.0, *result.Accuracy, 1e-9)
+
+	cat := result.Classes["cat"]
+	assert.Equal(t, 1, cat.TruePositives)
+	assert.Equal(t, 1, cat.FalsePositives)
+	assert.Equal(t, 0.5, cat.Precision)
+	assert.Equal(t, 1.0, cat.Recall)
+
+	dog := result.Classes["dog"]
+	assert.Equal(t, 2, dog.Support)
+	assert.Equal(t, 1.0, dog.Precision)
+	assert.Equal(t, 0.5, dog.Recall)
+}
+
+func TestDetection(t *testing.T) {
+	samples := []Sample{
+		{
+			Truth: []Object{
+				{Class: "cat", Box: &Box{0, 0, 10, 10}},
+				{Class: "cat", Box: &Box{20, 20, 30, 30}},
+			},
+			Predictions: []Object{
+				{Class: "cat", Confidence: .9, Box: &Box{0, 0, 10, 10}},
+				{Class: "cat", Confidence: .8, Box: &Box{0, 0, 10, 9}},    // duplicate of a matched box
+				{Class: "cat", Confidence: .6, Box: &Box{20, 20, 30, 30}}, // matched after a false positive
+				{Class: "dog", Confidence: .7, Box: &Box{20, 20, 30, 30}}, // wrong class
+			},
+		},
+	}
+
+	result := Detection(samples, []string{"cat", "dog"}, DefaultIoUThreshold, DefaultDetectionThreshold)
+
+	cat := result.Classes["cat"]
+	assert.Equal(t, 2, cat.TruePositives)
+	assert.Equal(t, 1, cat.FalsePositives)
+	assert.Equal(t, 0, cat.FalseNegatives)
+	// precision 1 up to recall .5, then 2/3 up to recall 1
+	assert.InDelta(t, 0.5+0.5*2.0/3.0, *cat.AP, 1e-9)
+
+	dog := result.Classes["dog"]
+	assert.Nil(t, dog.AP)
+	assert.Equal(t, 1, dog.FalsePositives)
+
+	assert.InDelta(t, *cat.AP, *result.MAP, 1e-9)
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	samples := []Sample{
+		{
+			Truth: []Object{
+				{Class: "cat", Box: &Box{0, 0, 10, 10}},
+				{Class: "dog", Box: &Box{20, 20, 30, 30}},
+			},
+			Predictions: []Object{
+				{Class: "cat", Confidence: .9, Box: &Box{0, 0, 10, 10}},
+				{Class: "cat", Confidence: .8, Box: &Box{20, 20, 30, 30}},
+				{Class: "dog", Confidence: .7, Box: &Box{50, 50, 60, 60}},
+				{Class: "dog", Confidence: .2, Box: &Box{0, 0, 10, 10}}, // below threshold
+			},
+		},
+	}
+
+	c := DetectionConfusion(samples, []string{"cat", "dog"}, DefaultIoUThreshold, DefaultDetectionThreshold)
+	assert.Equal(t, []string{"cat", "dog", Background}, c.Classes)
+	assert.Equal(t, [][]int{
+		{1, 0, 0},
+		{1, 0, 0},
+		{0, 1, 0},
+	}, c.Matrix)
+}
+
+func TestDetectionCurves(t *testing.T) {
+	samples := []Sample{
+		{
+			Truth:       []Object{{Class: "cat", Box: &Box{0, 0, 10, 10}}, {Class: "cat", Box: &Box{20, 20, 30, 30}}},
+			Predictions: []Object{{Class: "cat", Confidence: .9, Box: &Box{0, 0, 10, 10}}, {Class: "cat", Confidence: .8, Box: &Box{20, 20, 30, 26}}},
+		},
+	}
+
+	// The second box only overlaps its ground truth at IoU .6
+	loose := DetectionCurves(samples, []string{"cat"}, 0.5)
+	strict := DetectionCurves(samples, []string{"cat"}, 0.75)
+	assert.Equal(t, 1.0, loose.MAP)
+	assert.Equal(t, 0.5, strict.MAP)
+
+	points := strict.Classes["cat"].Points
+	assert.Len(t, points, CurvePoints)
+	assert.Equal(t, 1.0, points[50].Precision)
+	assert.Equal(t, 0.0, points[51].Precision)
+}
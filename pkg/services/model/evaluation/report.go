@@ -0,0 +1,275 @@
+/*
+ * File: report.go
+ * Project: evaluation
+ * File Created: Tuesday, 13th February 2024 9:48:06 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 13th February 2024 9:48:06 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package evaluation
+
+import (
+	"sort"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+const (
+	// Row and column of the confusion matrix for missed labels and unmatched predictions
+	Background = "background"
+	// Number of recall levels precision is interpolated at for precision recall curves
+	CurvePoints = 101
+)
+
+// ConfusionMatrix counts ground truth labels (rows) against predicted labels (columns). The last row and
+// column is the background class.
+type ConfusionMatrix struct {
+	Classes []string `json:"classes"`
+	Matrix  [][]int  `json:"matrix"`
+
+	index map[string]int
+}
+
+func newConfusionMatrix(classes []string) ConfusionMatrix {
+	c := ConfusionMatrix{
+		Classes: append(append([]string{}, classes...), Background),
+		index:   make(map[string]int, len(classes)+1),
+	}
+	for idx, class := range c.Classes {
+		c.index[class] = idx
+	}
+	c.Matrix = make([][]int, len(c.Classes))
+	for i := range c.Matrix {
+		c.Matrix[i] = make([]int, len(c.Classes))
+	}
+	return c
+}
+
+func (c *ConfusionMatrix) add(truth, predicted string) {
+	t, ok := c.index[truth]
+	if !ok {
+		t = c.index[Background]
+	}
+	p, ok := c.index[predicted]
+	if !ok {
+		p = c.index[Background]
+	}
+	c.Matrix[t][p]++
+}
+
+// ClassificationConfusion builds a confusion matrix for classification. Ground truth labels that were predicted are
+// counted on the diagonal; missed labels are counted against the most confident wrongly predicted label, or background.
+func ClassificationConfusion(samples []Sample, classes []string, threshold float64) ConfusionMatrix {
+	c := newConfusionMatrix(classes)
+
+	for _, sample := range samples {
+		truth := make(map[string]bool)
+		for _, object := range sample.Truth {
+			truth[object.Class] = true
+		}
+
+		predictions := append([]Object{}, sample.Predictions...)
+		sort.SliceStable(predictions, func(i, j int) bool { return predictions[i].Confidence > predictions[j].Confidence })
+
+		predicted := make(map[string]bool)
+		wrong := []string{}
+		for _, object := range predictions {
+			if object.Confidence < threshold || predicted[object.Class] {
+				continue
+			}
+			predicted[object.Class] = true
+			if !truth[object.Class] {
+				wrong = append(wrong, object.Class)
+			}
+		}
+
+		for _, object := range sample.Truth {
+			switch {
+			case predicted[object.Class]:
+				c.add(object.Class, object.Class)
+			case len(wrong) > 0:
+				c.add(object.Class, wrong[0])
+				wrong = wrong[1:]
+			default:
+				c.add(object.Class, Background)
+			}
+		}
+		for _, class := range wrong {
+			c.add(Background, class)
+		}
+	}
+
+	return c
+}
+
+// DetectionConfusion builds a confusion matrix for object detection. Detections at or above threshold are
+// matched, highest confidence first, to the unmatched ground truth box of any class with the highest IoU at
+// or above iouThreshold. Unmatched ground truth and detections are counted against background.
+func DetectionConfusion(samples []Sample, classes []string, iouThreshold, threshold float64) ConfusionMatrix {
+	c := newConfusionMatrix(classes)
+
+	for _, sample := range samples {
+		predictions := []Object{}
+		for _, object := range sample.Predictions {
+			if object.Box != nil && object.Confidence >= threshold {
+				predictions = append(predictions, object)
+			}
+		}
+		sort.SliceStable(predictions, func(i, j int) bool { return predictions[i].Confidence > predictions[j].Confidence })
+
+		matched := make([]bool, len(sample.Truth))
+		for _, prediction := range predictions {
+			best, bestIoU := -1, iouThreshold
+			for j, truth := range sample.Truth {
+				if matched[j] || truth.Box == nil {
+					continue
+				}
+				if iou := IoU(*prediction.Box, *truth.Box); iou >= bestIoU {
+					best, bestIoU = j, iou
+				}
+			}
+			if best < 0 {
+				c.add(Background, prediction.Class)
+				continue
+			}
+			matched[best] = true
+			c.add(sample.Truth[best].Class, prediction.Class)
+		}
+
+		for j, truth := range sample.Truth {
+			if !matched[j] && truth.Box != nil {
+				c.add(truth.Class, Background)
+			}
+		}
+	}
+
+	return c
+}
+
+// PRPoint is a point of a precision recall curve
+type PRPoint struct {
+	Recall    float64 `json:"recall"`
+	Precision float64 `json:"precision"`
+}
+
+// ClassCurve is the precision recall curve of a class
+type ClassCurve struct {
+	AP     float64   `json:"ap"`
+	Points []PRPoint `json:"points"`
+}
+
+// PRCurves are the precision recall curves of all classes at an IoU threshold
+type PRCurves struct {
+	IoUThreshold float64               `json:"iou_threshold"`
+	MAP          float64               `json:"map"`
+	Classes      map[string]ClassCurve `json:"classes"`
+}
+
+// DetectionCurves computes the precision recall curve of each class at the given IoU threshold. Precision is
+// interpolated at CurvePoints evenly spaced recall levels.
+func DetectionCurves(samples []Sample, classes []string, iouThreshold float64) PRCurves {
+	curves := PRCurves{IoUThreshold: iouThreshold, Classes: make(map[string]ClassCurve, len(classes))}
+
+	var aps []float64
+	for _, class := range classes {
+		truth := make(map[int][]Box)
+		support := 0
+		detections := []detection{}
+		for idx, sample := range samples {
+			for _, object := range sample.Truth {
+				if object.Class == class && object.Box != nil {
+					truth[idx] = append(truth[idx], *object.Box)
+					support++
+				}
+			}
+			for _, object := range sample.Predictions {
+				if object.Class == class && object.Box != nil {
+					detections = append(detections, detection{sample: idx, confidence: object.Confidence, box: *object.Box})
+				}
+			}
+		}
+		if support == 0 {
+			continue
+		}
+
+		matches := match(detections, truth, iouThreshold)
+		ap := averagePrecision(matches, support)
+		aps = append(aps, ap)
+		curves.Classes[class] = ClassCurve{AP: ap, Points: interpolatedCurve(matches, support)}
+	}
+	curves.MAP = mean(aps)
+
+	return curves
+}
+
+// interpolatedCurve samples the interpolated precision of matches sorted by confidence at evenly spaced recall levels
+func interpolatedCurve(matches []bool, support int) []PRPoint {
+	precision := make([]float64, len(matches))
+	recall := make([]float64, len(matches))
+	tp := 0
+	for i, m := range matches {
+		if m {
+			tp++
+		}
+		precision[i] = float64(tp) / float64(i+1)
+		recall[i] = float64(tp) / float64(support)
+	}
+
+	points := make([]PRPoint, CurvePoints)
+	for i := range points {
+		level := float64(i) / float64(CurvePoints-1)
+		points[i].Recall = level
+		// Interpolated precision is the best precision at any recall at or above the level
+		for j := range matches {
+			if recall[j] >= level && precision[j] > points[i].Precision {
+				points[i].Precision = precision[j]
+			}
+		}
+	}
+	return points
+}
+
+// Report is the evaluation of a model against the ground truth of its dataset
+//
+// swagger:model Evaluation
+type Report struct {
+	// ID of the model
+	ModelID string `json:"modelid"`
+	// ID of the locked dataset version the model was trained on
+	DatasetID string `json:"datasetid"`
+	// Annotation type of the model
+	Type string `json:"type"`
+	// Split of the dataset evaluated; empty for the whole dataset
+	Split string `json:"split,omitempty"`
+	// Confidence at or above which a prediction is counted
+	Threshold float64 `json:"threshold"`
+	// Overall and per class metrics
+	Result
+	// Confusion matrix of ground truth against predicted labels
+	ConfusionMatrix ConfusionMatrix `json:"confusion_matrix"`
+	// Precision recall curves per IoU threshold, detection only
+	Curves []PRCurves `json:"curves,omitempty"`
+}
+
+// NewReport evaluates samples of a classification or bounding box model. Detection metrics and the confusion
+// matrix use the first IoU threshold; precision recall curves are computed for each IoU threshold.
+func NewReport(annotationType string, samples []Sample, classes []string, threshold float64, iouThresholds []float64) Report {
+	report := Report{Type: annotationType, Threshold: threshold}
+
+	if annotationType == models.ProjectAnnotationTypeClassification.String() {
+		report.Result = Classification(samples, classes, threshold)
+		report.ConfusionMatrix = ClassificationConfusion(samples, classes, threshold)
+		return report
+	}
+
+	if len(iouThresholds) == 0 {
+		iouThresholds = []float64{DefaultIoUThreshold}
+	}
+	report.Result = Detection(samples, classes, iouThresholds[0], threshold)
+	report.ConfusionMatrix = DetectionConfusion(samples, classes, iouThresholds[0], threshold)
+	for _, iouThreshold := range iouThresholds {
+		report.Curves = append(report.Curves, DetectionCurves(samples, classes, iouThreshold))
+	}
+	return report
+}
@@ -0,0 +1,54 @@
+/*
+ * File: artifact.go
+ * Project: local
+ * File Created: Friday, 23rd February 2024 10:05:19 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 23rd February 2024 10:05:19 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package local
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedArtifact is returned for model artifacts without a baseline model i.e. not written by the local
+// training backend
+var ErrUnsupportedArtifact = errors.New("model artifact cannot be run locally; it holds no baseline model")
+
+// Load reads the model from a model artifact i.e. model.tar.gz
+func Load(artifact []byte) (*Model, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(artifact))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading model artifact")
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrUnsupportedArtifact
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading model artifact")
+		}
+		if path.Clean(header.Name) != ModelFile {
+			continue
+		}
+
+		var m Model
+		if err := json.NewDecoder(tr).Decode(&m); err != nil {
+			return nil, errors.Wrap(err, "error decoding model")
+		}
+		return &m, nil
+	}
+}
@@ -0,0 +1,198 @@
+// Package local holds the baseline models trained by the local training backend. They exist to run training and
+// inference end to end in CI and tests without SageMaker and are not meant for production use.
+/*
+ * File: model.go
+ * Project: local
+ * File Created: Thursday, 22nd February 2024 11:05:37 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 11:05:37 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package local
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+const (
+	// File of the model inside the model artifact
+	ModelFile = "model.json"
+	// Images are described by their colours downsampled to FeatureSize x FeatureSize
+	FeatureSize = 16
+)
+
+// Box is a bounding box relative to the image size i.e. coordinates are in [0, 1]
+type Box struct {
+	Class int     `json:"class"`
+	Xmin  float64 `json:"xmin"`
+	Ymin  float64 `json:"ymin"`
+	Xmax  float64 `json:"xmax"`
+	Ymax  float64 `json:"ymax"`
+}
+
+// Prediction is a predicted class; Box is nil for classification
+type Prediction struct {
+	Class      int
+	Confidence float64
+	Box        *Box
+}
+
+// Model is a baseline model trained on the CPU without external dependencies. Classification predicts the
+// classes whose mean image is nearest; detection predicts the mean box of each class, as confident as the
+// class is frequent.
+type Model struct {
+	// Project annotation type i.e. "classification" or "bounding_box"
+	Algorithm  string `json:"algorithm"`
+	NumClasses int    `json:"num_classes"`
+
+	// Mean features of the images of each class; nil for classes without images
+	Centroids [][]float64 `json:"centroids,omitempty"`
+	// Mean squared distance of the images to the centroids of their classes
+	Scale float64 `json:"scale,omitempty"`
+
+	// Mean box of each class
+	Priors []Box `json:"priors,omitempty"`
+	// Fraction of the images each class appears in
+	Frequencies []float64 `json:"frequencies,omitempty"`
+}
+
+// Features describes an image by its downsampled RGB values in [0, 1]
+func Features(img image.Image) []float64 {
+	small := imaging.Resize(img, FeatureSize, FeatureSize, imaging.Box)
+	features := make([]float64, 0, 3*FeatureSize*FeatureSize)
+	for i := 0; i < len(small.Pix); i += 4 {
+		features = append(features, float64(small.Pix[i])/255, float64(small.Pix[i+1])/255, float64(small.Pix[i+2])/255)
+	}
+	return features
+}
+
+// FitClassification fits a classification model to the features of the images and the classes each is labelled with
+func FitClassification(numClasses int, features [][]float64, labels [][]int) *Model {
+	m := &Model{Algorithm: models.ProjectAnnotationTypeClassification.String(), NumClasses: numClasses, Centroids: make([][]float64, numClasses)}
+
+	counts := make([]int, numClasses)
+	for i, classes := range labels {
+		for _, class := range classes {
+			if m.Centroids[class] == nil {
+				m.Centroids[class] = make([]float64, len(features[i]))
+			}
+			for j, v := range features[i] {
+				m.Centroids[class][j] += v
+			}
+			counts[class]++
+		}
+	}
+	for class, centroid := range m.Centroids {
+		for j := range centroid {
+			centroid[j] /= float64(counts[class])
+		}
+	}
+
+	var total float64
+	var n int
+	for i, classes := range labels {
+		for _, class := range classes {
+			total += distance(features[i], m.Centroids[class])
+			n++
+		}
+	}
+	if n > 0 {
+		m.Scale = total / float64(n)
+	}
+	return m
+}
+
+// FitDetection fits a detection model to the boxes of each image
+func FitDetection(numClasses int, boxes [][]Box) *Model {
+	m := &Model{Algorithm: models.ProjectAnnotationTypeBoundingBox.String(), NumClasses: numClasses, Priors: make([]Box, numClasses), Frequencies: make([]float64, numClasses)}
+	if len(boxes) == 0 {
+		return m
+	}
+
+	counts := make([]int, numClasses)
+	for _, imageBoxes := range boxes {
+		seen := make(map[int]bool)
+		for _, box := range imageBoxes {
+			prior := &m.Priors[box.Class]
+			prior.Xmin += box.Xmin
+			prior.Ymin += box.Ymin
+			prior.Xmax += box.Xmax
+			prior.Ymax += box.Ymax
+			counts[box.Class]++
+			if !seen[box.Class] {
+				m.Frequencies[box.Class]++
+				seen[box.Class] = true
+			}
+		}
+	}
+	for class := range m.Priors {
+		m.Priors[class].Class = class
+		m.Frequencies[class] /= float64(len(boxes))
+		if counts[class] > 0 {
+			n := float64(counts[class])
+			m.Priors[class].Xmin /= n
+			m.Priors[class].Ymin /= n
+			m.Priors[class].Xmax /= n
+			m.Priors[class].Ymax /= n
+		}
+	}
+	return m
+}
+
+// Predict returns the confidence of every class of the model for the image
+func (m *Model) Predict(img image.Image) []Prediction {
+	return m.PredictFeatures(Features(img))
+}
+
+// PredictFeatures returns the confidence of every class of the model for the features of an image
+func (m *Model) PredictFeatures(features []float64) []Prediction {
+	predictions := []Prediction{}
+
+	if m.Algorithm == models.ProjectAnnotationTypeBoundingBox.String() {
+		for class, frequency := range m.Frequencies {
+			if frequency > 0 {
+				box := m.Priors[class]
+				predictions = append(predictions, Prediction{Class: class, Confidence: frequency, Box: &box})
+			}
+		}
+		return predictions
+	}
+
+	// Softmax over the distances to the centroids, relative to the spread of the training images
+	scale := math.Max(m.Scale, 1e-6)
+	distances := make(map[int]float64)
+	nearest := math.Inf(1)
+	for class, centroid := range m.Centroids {
+		if centroid != nil {
+			distances[class] = distance(features, centroid)
+			nearest = math.Min(nearest, distances[class])
+		}
+	}
+
+	var sum float64
+	for class, d := range distances {
+		distances[class] = math.Exp((nearest - d) / scale)
+		sum += distances[class]
+	}
+	for class := range m.Centroids {
+		if e, ok := distances[class]; ok {
+			predictions = append(predictions, Prediction{Class: class, Confidence: e / sum})
+		}
+	}
+	return predictions
+}
+
+// distance is the mean squared distance between two feature vectors
+func distance(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d / float64(len(a))
+}
@@ -0,0 +1,134 @@
+// Package realtime serves the baseline models of the local training backend on the CPU of the model service host.
+// It does not run models trained on SageMaker, which are served by their endpoint.
+/*
+ * File: backend.go
+ * Project: realtime
+ * File Created: Friday, 23rd February 2024 10:31:44 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 23rd February 2024 10:31:44 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package realtime
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+	local "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local"
+	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
+)
+
+// ErrModelNotServable is returned for models not trained by the local training backend
+var ErrModelNotServable = echo.NewHTTPError(http.StatusConflict, "model cannot be served locally; only models trained by the local training backend can be")
+
+// Number of models kept loaded by default
+const DefaultCacheSize = 16
+
+type Config struct {
+	// Number of models kept loaded
+	CacheSize int `yaml:"cache_size,omitempty"`
+}
+
+// Store is the blob store model artifacts are read from
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+}
+
+// Backend runs images through models trained by the local training backend on the CPU of the model service host.
+// Models are loaded from their artifact on first use and kept loaded, evicting the least recently loaded once the cache is full.
+type Backend struct {
+	Config *Config
+	Store  Store
+
+	mu     sync.Mutex
+	models map[string]*local.Model
+	loaded []string
+}
+
+func NewBackend(config *Config, store Store) *Backend {
+	return &Backend{Config: config, Store: store, models: make(map[string]*local.Model)}
+}
+
+// Infer returns the predictions of the model in the shape returned by SageMaker endpoints
+func (b *Backend) Infer(model *models.Model, image []byte) (realtime.ModelReturn, error) {
+	if model.TrainingBackend != backend.Local {
+		return nil, ErrModelNotServable
+	}
+
+	m, err := b.load(model)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(image))
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding image")
+	}
+	predictions := m.Predict(img)
+
+	switch m.Algorithm {
+	case models.ProjectAnnotationTypeClassification.String():
+		output := realtime.ClassificationResult{Return: make([]float64, m.NumClasses)}
+		for _, prediction := range predictions {
+			output.Return[prediction.Class] = prediction.Confidence
+		}
+		return &output, nil
+	case models.ProjectAnnotationTypeBoundingBox.String():
+		sort.SliceStable(predictions, func(i, j int) bool { return predictions[i].Confidence > predictions[j].Confidence })
+		detections := [][]float64{}
+		for _, prediction := range predictions {
+			box := prediction.Box
+			detections = append(detections, []float64{float64(prediction.Class), prediction.Confidence, box.Xmin, box.Ymin, box.Xmax, box.Ymax})
+		}
+		return &realtime.ObjectDetectionResult{Return: map[string][][]float64{"prediction": detections}}, nil
+	default:
+		return nil, errors.Errorf("unrecognized model type; type=%s", m.Algorithm)
+	}
+}
+
+// load returns the model of the artifact, loading it if needed
+func (b *Backend) load(model *models.Model) (*local.Model, error) {
+	bucket, key := model.Artifact()
+	location := bucket + "/" + key
+
+	b.mu.Lock()
+	m, ok := b.models[location]
+	b.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	artifact, err := b.Store.Get(bucket, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving model artifact; model=%s", model.ID.Hex())
+	}
+	if m, err = local.Load(artifact); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.models[location]; !ok {
+		size := b.Config.CacheSize
+		if size <= 0 {
+			size = DefaultCacheSize
+		}
+		for len(b.loaded) >= size {
+			delete(b.models, b.loaded[0])
+			b.loaded = b.loaded[1:]
+		}
+		b.models[location] = m
+		b.loaded = append(b.loaded, location)
+	}
+	return b.models[location], nil
+}
@@ -0,0 +1,108 @@
+/*
+ * File: backend_test.go
+ * Project: realtime
+ * File Created: Friday, 23rd February 2024 11:26:50 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 23rd February 2024 11:26:50 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package realtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	stats "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+	local "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Get(bucket, key string) ([]byte, error) {
+	content, ok := s[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("no such key; key=%s", key)
+	}
+	return content, nil
+}
+
+func artifact(t *testing.T, name string, content interface{}) []byte {
+	body, err := json.Marshal(content)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body))}))
+	_, err = tw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func solid(t *testing.T, c color.NRGBA) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 20))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestBackend(t *testing.T) {
+	red, blue := solid(t, color.NRGBA{R: 255, A: 255}), solid(t, color.NRGBA{B: 255, A: 255})
+	redImg, _ := png.Decode(bytes.NewReader(red))
+	blueImg, _ := png.Decode(bytes.NewReader(blue))
+
+	classifier := local.FitClassification(2, [][]float64{local.Features(redImg), local.Features(blueImg)}, [][]int{{0}, {1}})
+	detector := local.FitDetection(2, [][]local.Box{{{Class: 1, Xmin: 0.1, Ymin: 0.2, Xmax: 0.5, Ymax: 0.6}}, {}})
+	store := memoryStore{
+		"bucket/classifier/model.tar.gz": artifact(t, local.ModelFile, classifier),
+		"bucket/detector/model.tar.gz":   artifact(t, local.ModelFile, detector),
+		"bucket/mxnet/model.tar.gz":      artifact(t, "model-symbol.json", map[string]string{}),
+	}
+	b := NewBackend(&Config{CacheSize: 1}, store)
+	classMap := map[string]int{"red": 0, "blue": 1}
+
+	result, err := b.Infer(&models.Model{TrainingBackend: backend.Local, ArtifactPath: "s3://bucket/classifier/model.tar.gz"}, blue)
+	require.NoError(t, err)
+	predictions := result.ToFormattedResult(classMap, 0.5, nil).Predictions
+	require.Len(t, predictions, 1)
+	assert.Equal(t, "blue", predictions[0].ClassName)
+
+	// Boxes are scaled to the image
+	result, err = b.Infer(&models.Model{TrainingBackend: backend.Local, ArtifactPath: "s3://bucket/detector/model.tar.gz"}, red)
+	require.NoError(t, err)
+	predictions = result.ToFormattedResult(classMap, 0, &stats.Stats{Width: 10, Height: 20}).Predictions
+	require.Len(t, predictions, 1)
+	assert.Equal(t, "blue", predictions[0].ClassName)
+	assert.Equal(t, 0.5, predictions[0].Confidence)
+	assert.Equal(t, map[string]interface{}{"xmin": 1, "ymin": 4, "xmax": 5, "ymax": 12}, predictions[0].BoundingBox)
+	assert.Len(t, b.models, 1)
+
+	_, err = b.Infer(&models.Model{TrainingBackend: backend.Local, ArtifactPath: "s3://bucket/mxnet/model.tar.gz"}, red)
+	assert.ErrorIs(t, err, local.ErrUnsupportedArtifact)
+	_, err = b.Infer(&models.Model{TrainingBackend: backend.Local, ArtifactPath: "s3://bucket/classifier/model.tar.gz"}, []byte("not an image"))
+	assert.Error(t, err)
+
+	// Models trained on SageMaker are not served locally, even with an artifact that could be loaded
+	_, err = b.Infer(&models.Model{TrainingBackend: backend.SageMaker, ArtifactPath: "s3://bucket/classifier/model.tar.gz"}, red)
+	assert.Equal(t, ErrModelNotServable, err)
+	_, err = b.Infer(&models.Model{ArtifactPath: "s3://bucket/classifier/model.tar.gz"}, red)
+	assert.Equal(t, ErrModelNotServable, err)
+}
@@ -0,0 +1,327 @@
+// Package train implements a training backend running on the host of the model service. Without a configured
+// trainer command it trains the baseline models of package local, a nearest-centroid classifier and a per-class
+// mean box detector, which exist to run training end to end in CI and tests; they are not an on-prem training path.
+/*
+ * File: backend.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 11:40:52 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 11:40:52 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+)
+
+// Store is the blob store training data is read from and model artifacts and job states are written to
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, content []byte) error
+}
+
+// Prefix of the keys job states are kept under in the bucket of the backend
+const JobPrefix = "local-training-jobs"
+
+// Backend trains models on the host of the model service, either with the configured command or with the
+// built-in baseline trainer. Trainers run in the process that submitted them; the state of every job is kept in
+// the blob store so that it can be looked up once the job ended, and a job left in progress by a restart of the
+// service reports as failed.
+type Backend struct {
+	Config *Config
+	Store  Store
+	// Bucket job states are kept in
+	Bucket string
+
+	mu sync.Mutex
+	// Jobs still running in this process
+	jobs map[string]*job
+}
+
+var _ backend.TrainingBackend = (*Backend)(nil)
+
+// job is a training job running in this process
+type job struct {
+	spec            backend.TrainingJob
+	hyperParameters map[string]string
+	cancel          context.CancelFunc
+	stopped         bool
+}
+
+// jobState is the persisted state of a job
+type jobState struct {
+	Status          backend.TrainingStatus `json:"status"`
+	HyperParameters map[string]string      `json:"hyperparameters"`
+	// Final metrics reported by the trainer, set once completed
+	Metrics      map[string]float64 `json:"metrics,omitempty"`
+	ArtifactPath string             `json:"artifact_path,omitempty"`
+}
+
+func NewBackend(config *Config, store Store, bucket string) *Backend {
+	return &Backend{Config: config, Store: store, Bucket: bucket, jobs: make(map[string]*job)}
+}
+
+// Submit starts training in the background. Pinned hyperparameters are passed to the trainer as is; ranges are
+// passed as comma separated values as nothing is tuned.
+func (b *Backend) Submit(spec backend.TrainingJob) (string, models.TrainParameters, error) {
+	name := fmt.Sprintf("train-job-%s", common.ShortUUID(17))
+
+	hyperParameters := map[string]string{
+		"num_classes":            fmt.Sprint(spec.NumClasses),
+		"num_training_samples":   fmt.Sprint(spec.NumTrainingSamples),
+		"num_validation_samples": fmt.Sprint(spec.NumValidationSamples),
+	}
+	if spec.Algorithm == models.ProjectAnnotationTypeBoundingBox {
+		hyperParameters["label_width"] = fmt.Sprint(spec.ForcePaddingLabelWidth)
+	}
+	for name, value := range spec.Parameters.HyperParameters {
+		hyperParameters[name] = formatHyperParameter(value)
+	}
+
+	params := models.TrainParameters{HyperParameters: spec.Parameters.HyperParameters}
+	params.Runtime.MaxRuntimeInSeconds = spec.Parameters.Runtime.MaxRuntimeInSeconds
+	if params.Runtime.MaxRuntimeInSeconds == nil && b.Config.Runtime.MaxRuntimeInSeconds > 0 {
+		params.Runtime.MaxRuntimeInSeconds = common.Ptr(b.Config.Runtime.MaxRuntimeInSeconds)
+	}
+
+	if err := b.save(name, jobState{
+		Status:          backend.TrainingStatus{State: backend.TrainingStateInProgress},
+		HyperParameters: hyperParameters,
+	}); err != nil {
+		return "", params, errors.Wrapf(err, "error saving training job; name=%s", name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if params.Runtime.MaxRuntimeInSeconds != nil {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(*params.Runtime.MaxRuntimeInSeconds)*time.Second)
+	}
+
+	j := &job{
+		spec:            spec,
+		hyperParameters: hyperParameters,
+		cancel:          cancel,
+	}
+	b.mu.Lock()
+	b.jobs[name] = j
+	b.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		b.run(ctx, name, j)
+	}()
+	log.Debugf("started local training job; name=%s", name)
+
+	return name, params, nil
+}
+
+// Status returns the status of a job; the job is its own training job once completed. A job in progress that
+// is not running in this process was interrupted by a restart and is failed.
+func (b *Backend) Status(name string) (backend.TrainingStatus, error) {
+	b.mu.Lock()
+	_, running := b.jobs[name]
+	b.mu.Unlock()
+	if running {
+		return backend.TrainingStatus{State: backend.TrainingStateInProgress}, nil
+	}
+
+	state, err := b.load(name)
+	if err != nil {
+		return backend.TrainingStatus{}, err
+	}
+	// Jobs are saved in their final state before they stop running
+	if state.Status.State != backend.TrainingStateInProgress {
+		return state.Status, nil
+	}
+
+	log.Errorf("local training job interrupted; name=%s", name)
+	state.Status = backend.TrainingStatus{State: backend.TrainingStateFailed, FailureReason: "training job interrupted by a restart of the model service"}
+	if err := b.save(name, state); err != nil {
+		return backend.TrainingStatus{}, errors.Wrapf(err, "error saving training job; name=%s", name)
+	}
+	return state.Status, nil
+}
+
+func (b *Backend) Metrics(trainingJobName string) (map[string]interface{}, error) {
+	state, err := b.completed(trainingJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]interface{}, len(state.Metrics)+1)
+	for name, value := range state.Metrics {
+		metrics[name] = value
+	}
+	// Billing related metrics
+	metrics["BillableTimeInSeconds"] = state.Status.BillableTimeInSeconds
+	return metrics, nil
+}
+
+// Artifacts returns the location of the model artifact and the hyperparameters of a completed training job
+func (b *Backend) Artifacts(trainingJobName string) (string, map[string]string, error) {
+	state, err := b.completed(trainingJobName)
+	if err != nil {
+		return "", nil, err
+	}
+	return state.ArtifactPath, state.HyperParameters, nil
+}
+
+// Stop cancels the trainer of a job still in progress
+func (b *Backend) Stop(name string) error {
+	b.mu.Lock()
+	j, running := b.jobs[name]
+	if running {
+		j.stopped = true
+		j.cancel()
+	}
+	b.mu.Unlock()
+	if running {
+		return nil
+	}
+
+	// Jobs that ended need no stopping
+	_, err := b.load(name)
+	return err
+}
+
+func (b *Backend) completed(name string) (jobState, error) {
+	state, err := b.load(name)
+	if err != nil {
+		return state, err
+	}
+	if state.Status.State != backend.TrainingStateCompleted {
+		return state, fmt.Errorf("training job not completed; name=%s state=%s", name, state.Status.State)
+	}
+	return state, nil
+}
+
+// save persists the state of a job
+func (b *Backend) save(name string, state jobState) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return b.Store.Put(b.Bucket, path.Join(JobPrefix, name+".json"), content)
+}
+
+// load returns the persisted state of a job
+func (b *Backend) load(name string) (jobState, error) {
+	var state jobState
+
+	content, err := b.Store.Get(b.Bucket, path.Join(JobPrefix, name+".json"))
+	if err != nil {
+		return state, errors.Wrapf(err, "unknown training job; name=%s", name)
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, errors.Wrapf(err, "error decoding training job; name=%s", name)
+	}
+	return state, nil
+}
+
+// run trains the job in its own directory and uploads the model artifact next to the manifests, where SageMaker
+// would write it. The job is no longer tracked in memory once its final state is saved.
+func (b *Backend) run(ctx context.Context, name string, j *job) {
+	started := time.Now()
+	state := jobState{HyperParameters: j.hyperParameters}
+
+	metrics, artifact, err := b.train(ctx, name, j)
+	if err == nil {
+		state.ArtifactPath = j.spec.OutputDataPath + "/" + path.Join(name, "output", "model.tar.gz")
+		err = b.upload(state.ArtifactPath, artifact)
+	}
+
+	billableTime := int32(math.Ceil(time.Since(started).Seconds()))
+
+	b.mu.Lock()
+	stopped := j.stopped
+	b.mu.Unlock()
+
+	switch {
+	case stopped:
+		log.Debugf("local training job stopped; name=%s", name)
+		state.Status = backend.TrainingStatus{State: backend.TrainingStateStopped, BillableTimeInSeconds: billableTime}
+	case err != nil:
+		log.Errorf("local training job failed; name=%s error=%s", name, err.Error())
+		state.Status = backend.TrainingStatus{State: backend.TrainingStateFailed, FailureReason: err.Error()}
+	default:
+		log.Debugf("local training job complete; name=%s", name)
+		state.Metrics = metrics
+		state.Status = backend.TrainingStatus{State: backend.TrainingStateCompleted, TrainingJobName: name, BillableTimeInSeconds: billableTime}
+	}
+
+	if err := b.save(name, state); err != nil {
+		log.Errorf("error saving local training job; name=%s error=%s", name, err.Error())
+	}
+
+	b.mu.Lock()
+	delete(b.jobs, name)
+	b.mu.Unlock()
+}
+
+// train prepares the job directory, runs the trainer and returns the metrics and archived model
+func (b *Backend) train(ctx context.Context, name string, j *job) (map[string]float64, []byte, error) {
+	dir, err := os.MkdirTemp(b.Config.WorkDir, name+"-")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !b.Config.KeepWorkDir {
+		defer os.RemoveAll(dir)
+	}
+
+	if err := b.prepare(dir, j); err != nil {
+		return nil, nil, err
+	}
+
+	if len(b.Config.Command) > 0 {
+		err = runCommand(ctx, dir, b.Config.Command)
+	} else {
+		err = runBaseline(ctx, dir, j.spec.Algorithm)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, nil, fmt.Errorf("training job exceeded its max runtime; name=%s", name)
+	}
+	if err != nil {
+		return nil, nil, failure(dir, err)
+	}
+
+	metrics, err := readMetrics(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	artifact, err := archive(path.Join(dir, ModelDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, artifact, nil
+}
+
+// formatHyperParameter formats a hyperparameter value, joining ranges and lists with commas
+func formatHyperParameter(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return fmt.Sprint(value)
+	}
+
+	values := make([]string, v.Len())
+	for i := range values {
+		values[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,182 @@
+/*
+ * File: backend_test.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 2:02:33 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 2:02:33 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+	local "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local"
+	sageTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Get(bucket, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.objects[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("no such key; key=%s", key)
+	}
+	return content, nil
+}
+
+func (s *memoryStore) Put(bucket, key string, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[bucket+"/"+key] = content
+	return nil
+}
+
+func await(t *testing.T, b *Backend, name string) backend.TrainingStatus {
+	for i := 0; i < 500; i++ {
+		status, err := b.Status(name)
+		require.NoError(t, err)
+		if status.State != backend.TrainingStateInProgress {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("training job did not finish; name=%s", name)
+	return backend.TrainingStatus{}
+}
+
+func TestBackend(t *testing.T) {
+	require.NoError(t, log.New(log.Configuration{EnableConsole: true, Level: log.Fatal}, log.InstanceZapLogger))
+
+	store := &memoryStore{objects: make(map[string][]byte)}
+	for name, c := range map[string]color.NRGBA{"red": {R: 250, A: 255}, "blue": {B: 250, A: 255}, "darkred": {R: 180, G: 20, A: 255}} {
+		img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+		require.NoError(t, store.Put("bucket", "images/"+name+".png", buf.Bytes()))
+	}
+
+	manifest := func(entries ...[2]string) []byte {
+		lines := []string{}
+		for _, entry := range entries {
+			line, _ := sageTrain.NewClassificationManifest("s3://bucket/images/"+entry[0]+".png", entry[1]).ToJSON()
+			lines = append(lines, string(line))
+		}
+		return []byte(strings.Join(lines, "\n"))
+	}
+	store.Put("bucket", "models/model/train.manifest", manifest([2]string{"red", "[1,0]"}, [2]string{"blue", "[0,1]"}))
+	store.Put("bucket", "models/model/validation.manifest", manifest([2]string{"darkred", "[1,0]"}, [2]string{"blue", "[0,1]"}))
+
+	job := backend.TrainingJob{
+		Algorithm:            models.ProjectAnnotationTypeClassification,
+		Parameters:           models.TrainParameters{HyperParameters: map[string]interface{}{"epochs": 5}},
+		OutputDataPath:       "s3://bucket/models/model",
+		NumClasses:           2,
+		NumTrainingSamples:   2,
+		NumValidationSamples: 2,
+	}
+
+	// Built-in baseline trainer
+	b := NewBackend(&Config{WorkDir: t.TempDir()}, store, "bucket")
+	name, _, err := b.Submit(job)
+	require.NoError(t, err)
+	status := await(t, b, name)
+	require.Equal(t, backend.TrainingStateCompleted, status.State, status.FailureReason)
+
+	metrics, err := b.Metrics(status.TrainingJobName)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["validation:accuracy"])
+	assert.IsType(t, int32(0), metrics["BillableTimeInSeconds"])
+
+	artifactPath, hyperParameters, err := b.Artifacts(status.TrainingJobName)
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/models/model/"+name+"/output/model.tar.gz", artifactPath)
+	assert.Equal(t, "5", hyperParameters["epochs"])
+	assert.Equal(t, "2", hyperParameters["num_classes"])
+
+	// Finished jobs are only kept in the blob store, where they outlive the service
+	assert.Empty(t, b.jobs)
+	restarted := NewBackend(&Config{WorkDir: t.TempDir()}, store, "bucket")
+	assert.Equal(t, status, await(t, restarted, name))
+	restartedMetrics, err := restarted.Metrics(name)
+	require.NoError(t, err)
+	assert.Equal(t, metrics, restartedMetrics)
+	restartedArtifactPath, _, err := restarted.Artifacts(name)
+	require.NoError(t, err)
+	assert.Equal(t, artifactPath, restartedArtifactPath)
+	assert.NoError(t, restarted.Stop(name))
+
+	artifact, err := store.Get("bucket", "models/model/"+name+"/output/model.tar.gz")
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(bytes.NewReader(artifact))
+	require.NoError(t, err)
+	header, err := tar.NewReader(gz).Next()
+	require.NoError(t, err)
+	assert.Equal(t, local.ModelFile, header.Name)
+
+	// Trainer commands report failures through the failure file
+	b = NewBackend(&Config{WorkDir: t.TempDir(), Command: []string{"sh", "-c", "test -s input/data/images/0.png && echo out of memory > output/failure; exit 1"}}, store, "bucket")
+	name, _, err = b.Submit(job)
+	require.NoError(t, err)
+	status = await(t, b, name)
+	assert.Equal(t, backend.TrainingStatus{State: backend.TrainingStateFailed, FailureReason: "out of memory"}, status)
+	_, err = b.Metrics(name)
+	assert.Error(t, err)
+
+	// Jobs are stopped at their max runtime
+	job.Parameters.Runtime.MaxRuntimeInSeconds = common.Ptr(int32(1))
+	b = NewBackend(&Config{WorkDir: t.TempDir(), Command: []string{"sleep", "10"}}, store, "bucket")
+	name, params, err := b.Submit(job)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), *params.Runtime.MaxRuntimeInSeconds)
+	assert.Equal(t, backend.TrainingStateFailed, await(t, b, name).State)
+
+	// Stopped jobs report the time trained so far
+	job.Parameters.Runtime.MaxRuntimeInSeconds = nil
+	name, _, err = b.Submit(job)
+	require.NoError(t, err)
+	require.NoError(t, b.Stop(name))
+	status = await(t, b, name)
+	assert.Equal(t, backend.TrainingStateStopped, status.State)
+	assert.GreaterOrEqual(t, status.BillableTimeInSeconds, int32(0))
+	require.NoError(t, b.Stop(name))
+	assert.Error(t, b.Stop("unknown"))
+	_, err = b.Status("unknown")
+	assert.Error(t, err)
+
+	// Jobs left in progress by a restart are failed
+	name, _, err = b.Submit(job)
+	require.NoError(t, err)
+	restarted = NewBackend(&Config{WorkDir: t.TempDir()}, store, "bucket")
+	status, err = restarted.Status(name)
+	require.NoError(t, err)
+	assert.Equal(t, backend.TrainingStateFailed, status.State)
+	assert.Contains(t, status.FailureReason, "interrupted")
+	require.NoError(t, b.Stop(name))
+}
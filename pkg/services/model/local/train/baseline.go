@@ -0,0 +1,212 @@
+/*
+ * File: baseline.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 1:14:46 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 1:14:46 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+	local "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local"
+	sage "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage"
+	sageTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
+)
+
+// sample is a manifest entry with its image features
+type sample struct {
+	features []float64
+	width    int
+	height   int
+	labels   []int
+	boxes    []local.Box
+}
+
+// runBaseline trains the built-in baseline model on the job directory and reports the validation metric the
+// SageMaker algorithm is tuned on
+func runBaseline(ctx context.Context, dir string, algorithm models.ProjectAnnotationType) error {
+	content, err := os.ReadFile(filepath.Join(dir, HyperParametersFile))
+	if err != nil {
+		return err
+	}
+	var hyperParameters map[string]string
+	if err := json.Unmarshal(content, &hyperParameters); err != nil {
+		return err
+	}
+	numClasses, err := strconv.Atoi(hyperParameters["num_classes"])
+	if err != nil || numClasses <= 0 {
+		return errors.Errorf("invalid number of classes; num_classes=%s", hyperParameters["num_classes"])
+	}
+
+	train, err := readSamples(ctx, dir, TrainManifest, algorithm, numClasses)
+	if err != nil {
+		return err
+	}
+	if len(train) == 0 {
+		return errors.New("no training samples")
+	}
+	validation, err := readSamples(ctx, dir, ValidationManifest, algorithm, numClasses)
+	if err != nil {
+		return err
+	}
+
+	var model *local.Model
+	if algorithm == models.ProjectAnnotationTypeClassification {
+		features, labels := make([][]float64, len(train)), make([][]int, len(train))
+		for i, s := range train {
+			features[i], labels[i] = s.features, s.labels
+		}
+		model = local.FitClassification(numClasses, features, labels)
+	} else {
+		boxes := make([][]local.Box, len(train))
+		for i, s := range train {
+			boxes[i] = s.boxes
+		}
+		model = local.FitDetection(numClasses, boxes)
+	}
+
+	modelBytes, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ModelDir, local.ModelFile), modelBytes, 0o644); err != nil {
+		return err
+	}
+
+	metrics := map[string]float64{}
+	if len(validation) > 0 {
+		metrics[sage.ObjectiveMetricName(algorithm)] = validate(model, validation)
+	}
+	metricsBytes, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MetricsFile), metricsBytes, 0o644)
+}
+
+// validate returns the accuracy or mAP of the model on the validation samples
+func validate(model *local.Model, validation []sample) float64 {
+	classes := make([]string, model.NumClasses)
+	for i := range classes {
+		classes[i] = strconv.Itoa(i)
+	}
+
+	samples := make([]evaluation.Sample, len(validation))
+	for i, s := range validation {
+		for _, label := range s.labels {
+			samples[i].Truth = append(samples[i].Truth, evaluation.Object{Class: strconv.Itoa(label)})
+		}
+		for _, box := range s.boxes {
+			samples[i].Truth = append(samples[i].Truth, evaluation.Object{Class: strconv.Itoa(box.Class), Box: absolute(box, s.width, s.height)})
+		}
+
+		for _, prediction := range model.PredictFeatures(s.features) {
+			object := evaluation.Object{Class: strconv.Itoa(prediction.Class), Confidence: prediction.Confidence}
+			if prediction.Box != nil {
+				object.Box = absolute(*prediction.Box, s.width, s.height)
+			}
+			samples[i].Predictions = append(samples[i].Predictions, object)
+		}
+	}
+
+	if model.Algorithm == models.ProjectAnnotationTypeClassification.String() {
+		return *evaluation.Classification(samples, classes, evaluation.DefaultClassificationThreshold).Accuracy
+	}
+	return *evaluation.Detection(samples, classes, evaluation.DefaultIoUThreshold, evaluation.DefaultDetectionThreshold).MAP
+}
+
+func absolute(box local.Box, width, height int) *evaluation.Box {
+	return &evaluation.Box{
+		Xmin: box.Xmin * float64(width),
+		Ymin: box.Ymin * float64(height),
+		Xmax: box.Xmax * float64(width),
+		Ymax: box.Ymax * float64(height),
+	}
+}
+
+// readSamples reads the entries of a manifest along with their images
+func readSamples(ctx context.Context, dir, manifest string, algorithm models.ProjectAnnotationType, numClasses int) ([]sample, error) {
+	content, err := os.ReadFile(filepath.Join(dir, manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := []sample{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var s sample
+		var source string
+		if algorithm == models.ProjectAnnotationTypeClassification {
+			var entry sageTrain.ClassificationManifest
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return nil, errors.Wrap(err, "error decoding manifest entry")
+			}
+			var hot []int
+			if err := json.Unmarshal([]byte(entry.Class), &hot); err != nil {
+				return nil, errors.Wrapf(err, "error decoding classes; class=%s", entry.Class)
+			}
+			for class, v := range hot {
+				if v == 1 && class < numClasses {
+					s.labels = append(s.labels, class)
+				}
+			}
+			source = entry.SourceRef
+		} else {
+			var entry sageTrain.ObjectDetectionManifest
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return nil, errors.Wrap(err, "error decoding manifest entry")
+			}
+			if len(entry.BoundingBox.ImageSize) != 1 || entry.BoundingBox.ImageSize[0].Width <= 0 || entry.BoundingBox.ImageSize[0].Height <= 0 {
+				return nil, errors.Errorf("invalid image size; source=%s", entry.SourceRef)
+			}
+			s.width, s.height = entry.BoundingBox.ImageSize[0].Width, entry.BoundingBox.ImageSize[0].Height
+			for _, a := range entry.BoundingBox.Annotations {
+				if a.ClassID < 0 || a.ClassID >= numClasses {
+					continue
+				}
+				s.boxes = append(s.boxes, local.Box{
+					Class: a.ClassID,
+					Xmin:  float64(a.Left) / float64(s.width),
+					Ymin:  float64(a.Top) / float64(s.height),
+					Xmax:  float64(a.Left+a.Width) / float64(s.width),
+					Ymax:  float64(a.Top+a.Height) / float64(s.height),
+				})
+			}
+			source = entry.SourceRef
+		}
+
+		img, err := imaging.Open(filepath.Join(dir, source))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decoding image; source=%s", source)
+		}
+		s.features = local.Features(img)
+		if s.width == 0 {
+			s.width, s.height = img.Bounds().Dx(), img.Bounds().Dy()
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
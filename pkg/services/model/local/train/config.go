@@ -0,0 +1,24 @@
+/*
+ * File: config.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 11:32:14 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 11:32:14 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+type Config struct {
+	// Directory jobs are run in; defaults to the system temporary directory
+	WorkDir string `yaml:"work_dir,omitempty"`
+	// Trainer run in the job directory e.g. ["docker", "run", "--rm", "-v", "{dir}:/opt/ml", "trainer:latest"], where
+	// {dir} is replaced by the job directory. Without a command the built-in baseline trainer is used.
+	Command []string `yaml:"command,omitempty"`
+	// Keep job directories once training ends
+	KeepWorkDir bool `yaml:"keep_work_dir,omitempty"`
+
+	Runtime struct {
+		MaxRuntimeInSeconds int32 `yaml:"max_runtime_seconds,omitempty"`
+	} `yaml:"runtime,omitempty"`
+}
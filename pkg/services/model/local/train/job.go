@@ -0,0 +1,251 @@
+/*
+ * File: job.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 12:21:09 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 12:21:09 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A job directory follows the layout SageMaker gives training containers under /opt/ml. Manifests are those
+// uploaded for SageMaker, with each source-ref rewritten to the downloaded image, relative to the job directory.
+// The trainer writes the files of its model to the model directory, its final metrics to the metrics file as a
+// JSON object of numbers and, on failure, the reason to the failure file.
+const (
+	HyperParametersFile = "input/config/hyperparameters.json"
+	TrainManifest       = "input/data/train/train.manifest"
+	ValidationManifest  = "input/data/validation/validation.manifest"
+	ImageDir            = "input/data/images"
+	ModelDir            = "model"
+	MetricsFile         = "output/metrics.json"
+	FailureFile         = "output/failure"
+	LogFile             = "output/train.log"
+)
+
+// prepare writes the hyperparameters, manifests and images of the job to its directory
+func (b *Backend) prepare(dir string, j *job) error {
+	for _, d := range []string{path.Dir(HyperParametersFile), path.Dir(TrainManifest), path.Dir(ValidationManifest), ImageDir, ModelDir, path.Dir(MetricsFile)} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			return err
+		}
+	}
+
+	hyperParameters, err := json.Marshal(j.hyperParameters)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, HyperParametersFile), hyperParameters, 0o644); err != nil {
+		return err
+	}
+
+	images := make(map[string]string)
+	channels := []struct {
+		file     string
+		manifest string
+		samples  int32
+	}{
+		{file: TrainManifest, manifest: "train.manifest", samples: j.spec.NumTrainingSamples},
+		{file: ValidationManifest, manifest: "validation.manifest", samples: j.spec.NumValidationSamples},
+	}
+	for _, channel := range channels {
+		manifest := []byte{}
+		// Empty manifests are not uploaded
+		if channel.samples > 0 {
+			if manifest, err = b.download(j.spec.OutputDataPath + "/" + channel.manifest); err != nil {
+				return errors.Wrapf(err, "error downloading manifest; manifest=%s", channel.manifest)
+			}
+			if manifest, err = b.localize(dir, manifest, images); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, channel.file), manifest, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localize downloads the images of a manifest and rewrites their source-ref. Images are downloaded once.
+func (b *Backend) localize(dir string, manifest []byte, images map[string]string) ([]byte, error) {
+	var localized bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrap(err, "error decoding manifest entry")
+		}
+		var source string
+		if err := json.Unmarshal(entry["source-ref"], &source); err != nil {
+			return nil, errors.Wrap(err, "error decoding manifest source-ref")
+		}
+
+		local, ok := images[source]
+		if !ok {
+			content, err := b.download(source)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error downloading image; source=%s", source)
+			}
+			local = path.Join(ImageDir, fmt.Sprintf("%d%s", len(images), path.Ext(source)))
+			if err := os.WriteFile(filepath.Join(dir, local), content, 0o644); err != nil {
+				return nil, err
+			}
+			images[source] = local
+		}
+
+		entry["source-ref"], _ = json.Marshal(local)
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		localized.Write(line)
+		localized.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return localized.Bytes(), nil
+}
+
+func (b *Backend) download(uri string) ([]byte, error) {
+	bucket, key, err := splitURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return b.Store.Get(bucket, key)
+}
+
+func (b *Backend) upload(uri string, content []byte) error {
+	bucket, key, err := splitURI(uri)
+	if err != nil {
+		return err
+	}
+	return b.Store.Put(bucket, key, content)
+}
+
+// splitURI splits a URI of the form s3://bucket/key
+func splitURI(uri string) (string, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("invalid blob location; location=%s", uri)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// runCommand runs the command in the job directory, logging its output to the log file
+func runCommand(ctx context.Context, dir string, command []string) error {
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = strings.ReplaceAll(arg, "{dir}", dir)
+	}
+
+	logFile, err := os.Create(filepath.Join(dir, LogFile))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	return cmd.Run()
+}
+
+// failure returns the reason the trainer wrote to the failure file, if any, in place of its error
+func failure(dir string, err error) error {
+	reason, readErr := os.ReadFile(filepath.Join(dir, FailureFile))
+	if readErr != nil || len(bytes.TrimSpace(reason)) == 0 {
+		return err
+	}
+	return errors.New(strings.TrimSpace(string(reason)))
+}
+
+// readMetrics reads the metrics file; trainers need not write one
+func readMetrics(dir string) (map[string]float64, error) {
+	metrics := make(map[string]float64)
+	content, err := os.ReadFile(filepath.Join(dir, MetricsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return metrics, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &metrics); err != nil {
+		return nil, errors.Wrap(err, "error decoding training metrics")
+	}
+	return metrics, nil
+}
+
+// archive returns the files below dir as a gzipped tarball i.e. model.tar.gz
+func archive(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		if header.Name, err = filepath.Rel(dir, file); err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
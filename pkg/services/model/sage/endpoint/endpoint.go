@@ -134,3 +134,11 @@ func (e *Endpoint) DeleteEndpoint(endpointName string) error {
 
 	return nil
 }
+
+// DeleteModel deletes the SageMaker model created for the endpoint
+func (e *Endpoint) DeleteModel() error {
+	_, err := e.Client.DeleteModel(context.TODO(), &sagemaker.DeleteModelInput{
+		ModelName: aws.String(e.modelName),
+	})
+	return err
+}
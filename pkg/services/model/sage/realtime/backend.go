@@ -0,0 +1,30 @@
+/*
+ * File: backend.go
+ * Project: realtime
+ * File Created: Friday, 23rd February 2024 9:48:03 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 23rd February 2024 9:48:03 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package realtime
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/sagemakerruntime"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// Backend runs images through the SageMaker endpoint of a deployed model
+type Backend struct {
+	Client *sagemakerruntime.Client
+}
+
+func NewBackend(client *sagemakerruntime.Client) *Backend {
+	return &Backend{Client: client}
+}
+
+func (b *Backend) Infer(model *models.Model, image []byte) (ModelReturn, error) {
+	modelType, _ := model.Metadata["type"].(string)
+	return New(image, model.Deployment.EndpointName, modelType, b.Client)
+}
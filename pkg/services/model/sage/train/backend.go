@@ -0,0 +1,89 @@
+/*
+ * File: backend.go
+ * Project: train
+ * File Created: Thursday, 22nd February 2024 10:40:21 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 22nd February 2024 10:40:21 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/sagemaker"
+
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+)
+
+// Backend trains models with SageMaker hyperparameter tuning jobs
+type Backend struct {
+	Config *Config
+	Client *sagemaker.Client
+}
+
+var _ backend.TrainingBackend = (*Backend)(nil)
+
+func NewBackend(config *Config, client *sagemaker.Client) *Backend {
+	return &Backend{Config: config, Client: client}
+}
+
+// Submit creates the hyperparameter tuning job of the training job
+func (b *Backend) Submit(job backend.TrainingJob) (string, models.TrainParameters, error) {
+	// Update dynamic settings
+	config := *b.Config
+	config.OutputDataPath = job.OutputDataPath
+	config.NumClasses = job.NumClasses
+	config.NumTrainingSamples = job.NumTrainingSamples
+	config.NumValidationSamples = job.NumValidationSamples
+	config.ForcePaddingLabelWidth = job.ForcePaddingLabelWidth
+
+	trainer, err := New(&config, b.Client, job.Algorithm, job.Parameters)
+	if err != nil {
+		return "", models.TrainParameters{}, err
+	}
+	if err := trainer.Train(config.NumClasses, config.NumTrainingSamples, config.NumValidationSamples, config.ForcePaddingLabelWidth); err != nil {
+		return "", models.TrainParameters{}, err
+	}
+	log.Debugf("created hyperparameter tuning job: tunningJobName=%s", *trainer.Input.HyperParameterTuningJobName)
+
+	return *trainer.Input.HyperParameterTuningJobName, trainer.Parameters(), nil
+}
+
+func (b *Backend) Metrics(trainingJobName string) (metrics map[string]interface{}, err error) {
+	output, err := b.Client.DescribeTrainingJob(context.TODO(), &sagemaker.DescribeTrainingJobInput{
+		TrainingJobName: &trainingJobName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics = make(map[string]interface{})
+	for _, metric := range output.FinalMetricDataList {
+		metrics[*metric.MetricName] = metric.Value
+	}
+
+	// Billing related metrics
+	metrics["BillableTimeInSeconds"] = *output.BillableTimeInSeconds * output.ResourceConfig.InstanceCount
+
+	return metrics, nil
+}
+
+// Artifacts returns the location of the model artifact and the hyperparameters of a completed training job
+func (b *Backend) Artifacts(trainingJobName string) (modelPath string, hyperParameters map[string]string, err error) {
+	output, err := b.Client.DescribeTrainingJob(context.TODO(), &sagemaker.DescribeTrainingJobInput{
+		TrainingJobName: &trainingJobName,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if output.ModelArtifacts != nil && output.ModelArtifacts.S3ModelArtifacts != nil {
+		modelPath = *output.ModelArtifacts.S3ModelArtifacts
+	}
+
+	return modelPath, output.HyperParameters, nil
+}
@@ -11,6 +11,7 @@ package train
 
 import (
 	"encoding/json"
+	"strings"
 )
 
 type ClassificationManifest struct {
@@ -26,6 +27,21 @@ func (c ClassificationManifest) ToJSON() ([]byte, error) {
 	return json.Marshal(c)
 }
 
+// ClassLabels is a helper function for creating a multi-hot formatted string of labels
+// If for example, there are 10 classes and the image is labeld for the the first and 5th class -> ClassLabels(10, map[int]struct{}{0: {}, 5: {}}
+func ClassLabels(len int, labels map[int]struct{}) string {
+	a := make([]string, len)
+	for i := 0; i < len; i++ {
+		if _, ok := labels[i]; ok {
+			a[i] = "1"
+		} else {
+			a[i] = "0"
+		}
+	}
+
+	return "[" + strings.Join(a, ",") + "]"
+}
+
 type ObjectDetectionManifest struct {
 	SourceRef   string `json:"source-ref"`
 	BoundingBox struct {
@@ -0,0 +1,397 @@
+/*
+ * File: params.go
+ * Project: train
+ * File Created: Wednesday, 21st February 2024 9:18:05 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 21st February 2024 9:18:05 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sagemaker/types"
+	"github.com/aws/aws-sdk-go/aws"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// HyperParameterType is the type of values a hyperparameter takes
+type HyperParameterType int
+
+const (
+	HyperParameterInteger     HyperParameterType = iota // integer between Min and Max
+	HyperParameterContinuous                            // float between Min and Max
+	HyperParameterCategorical                           // one of Values
+	HyperParameterSteps                                 // comma-delimited list of epochs
+)
+
+// HyperParameter describes a hyperparameter users may set. A value pins the hyperparameter; tunable
+// hyperparameters also take a [min, max] range, or a list of values when categorical, narrowing their tuning range.
+type HyperParameter struct {
+	Type    HyperParameterType
+	Min     float64
+	Max     float64
+	Values  []string
+	Tunable bool
+}
+
+const (
+	BatchSizeHyperParameter = "mini_batch_size"
+
+	// Limits of the runtime parameters users may set
+	MaxRuntimeInSeconds     = 86400
+	MaxRetryAttempts        = 10
+	MaxNumberOfTrainingJobs = 100
+)
+
+var (
+	// Hyperparameters shared by both algorithms
+	commonHyperParameters = map[string]HyperParameter{
+		"optimizer":             {Type: HyperParameterCategorical, Values: []string{"sgd", "adam", "rmsprop"}, Tunable: true},
+		"learning_rate":         {Type: HyperParameterContinuous, Min: 1e-6, Max: 1, Tunable: true},
+		"weight_decay":          {Type: HyperParameterContinuous, Min: 0, Max: 1, Tunable: true},
+		"momentum":              {Type: HyperParameterContinuous, Min: 0, Max: 1, Tunable: true},
+		BatchSizeHyperParameter: {Type: HyperParameterInteger, Min: 1, Max: MaxBatchSize, Tunable: true},
+		"epochs":                {Type: HyperParameterInteger, Min: 1, Max: 1000},
+		"lr_scheduler_step":     {Type: HyperParameterSteps},
+		"lr_scheduler_factor":   {Type: HyperParameterContinuous, Min: 0, Max: 1},
+	}
+
+	// https://docs.aws.amazon.com/sagemaker/latest/dg/IC-Hyperparameter.html
+	ClassificationHyperParameters = withHyperParameters(commonHyperParameters, map[string]HyperParameter{
+		"num_layers": {Type: HyperParameterCategorical, Values: []string{"18", "20", "32", "34", "44", "50", "56", "101", "110", "152", "200"}},
+	})
+
+	// https://docs.aws.amazon.com/sagemaker/latest/dg/object-detection-api-config.html
+	ObjectDetectionHyperParameters = withHyperParameters(commonHyperParameters, map[string]HyperParameter{
+		"overlap_threshold": {Type: HyperParameterContinuous, Min: 0, Max: 1},
+		"nms_threshold":     {Type: HyperParameterContinuous, Min: 0, Max: 1},
+	})
+)
+
+func withHyperParameters(base, extra map[string]HyperParameter) map[string]HyperParameter {
+	merged := make(map[string]HyperParameter, len(base)+len(extra))
+	for name, hyperParameter := range base {
+		merged[name] = hyperParameter
+	}
+	for name, hyperParameter := range extra {
+		merged[name] = hyperParameter
+	}
+	return merged
+}
+
+// AllowedHyperParameters returns the hyperparameters users may set for an algorithm
+func AllowedHyperParameters(algorithm models.ProjectAnnotationType) (map[string]HyperParameter, error) {
+	switch algorithm {
+	case models.ProjectAnnotationTypeClassification:
+		return ClassificationHyperParameters, nil
+	case models.ProjectAnnotationTypeBoundingBox:
+		return ObjectDetectionHyperParameters, nil
+	}
+	return nil, fmt.Errorf("training is not supported for '%s' projects", algorithm.String())
+}
+
+// ValidParameters checks the train parameters of a model of the algorithm
+func ValidParameters(params models.TrainParameters, algorithm models.ProjectAnnotationType) error {
+	if v := params.Runtime.MaxRuntimeInSeconds; v != nil && (*v < 1 || *v > MaxRuntimeInSeconds) {
+		return fmt.Errorf("invalid runtime parameter 'max_runtime_seconds'; must be between 1 and %d", MaxRuntimeInSeconds)
+	}
+	if v := params.Runtime.MaximumRetryAttempts; v != nil && (*v < 1 || *v > MaxRetryAttempts) {
+		return fmt.Errorf("invalid runtime parameter 'maximum_retry_attempts'; must be between 1 and %d", MaxRetryAttempts)
+	}
+	if v := params.Runtime.MaxNumberOfTrainingJobs; v != nil && (*v < 1 || *v > MaxNumberOfTrainingJobs) {
+		return fmt.Errorf("invalid runtime parameter 'max_number_training_jobs'; must be between 1 and %d", MaxNumberOfTrainingJobs)
+	}
+	if v := params.Resource.InstanceType; v != nil && !validInstanceType(*v) {
+		return fmt.Errorf("invalid resource parameter 'instance_type'; unknown instance type '%s'", *v)
+	}
+
+	allowed, err := AllowedHyperParameters(algorithm)
+	if err != nil {
+		return err
+	}
+	for name, value := range params.HyperParameters {
+		hyperParameter, ok := allowed[name]
+		if !ok {
+			return fmt.Errorf("invalid hyperparameter '%s'; must be one of %s", name, strings.Join(hyperParameterNames(allowed), ", "))
+		}
+		values, isRange, err := hyperParameterValues(value)
+		if err != nil {
+			return fmt.Errorf("invalid hyperparameter '%s'; %s", name, err.Error())
+		}
+		if err := hyperParameter.valid(values, isRange); err != nil {
+			return fmt.Errorf("invalid hyperparameter '%s'; %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+func (h HyperParameter) valid(values []string, isRange bool) error {
+	if isRange {
+		if !h.Tunable {
+			return fmt.Errorf("not tunable, a single value is expected")
+		}
+		if h.Type != HyperParameterCategorical && len(values) != 2 {
+			return fmt.Errorf("a range is given as [min, max]")
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("at least one value is expected")
+		}
+	}
+
+	bounds := []float64{}
+	for _, value := range values {
+		switch h.Type {
+		case HyperParameterInteger, HyperParameterContinuous:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(f) {
+				return fmt.Errorf("'%s' is not a number", value)
+			}
+			if h.Type == HyperParameterInteger && f != math.Trunc(f) {
+				return fmt.Errorf("'%s' is not an integer", value)
+			}
+			if f < h.Min || f > h.Max {
+				return fmt.Errorf("%s is not between %s and %s", value, formatFloat(h.Min), formatFloat(h.Max))
+			}
+			bounds = append(bounds, f)
+		case HyperParameterCategorical:
+			if !contains(h.Values, value) {
+				return fmt.Errorf("'%s' is not one of %s", value, strings.Join(h.Values, ", "))
+			}
+		case HyperParameterSteps:
+			for _, step := range strings.Split(value, ",") {
+				if _, err := strconv.Atoi(strings.TrimSpace(step)); err != nil {
+					return fmt.Errorf("'%s' is not a comma-delimited list of epochs", value)
+				}
+			}
+		}
+	}
+	if len(bounds) == 2 && bounds[0] > bounds[1] {
+		return fmt.Errorf("range minimum exceeds its maximum")
+	}
+	return nil
+}
+
+// hyperParameterValues returns the values of a hyperparameter, either a single value or a list of values given
+// as a range
+func hyperParameterValues(value interface{}) (values []string, isRange bool, err error) {
+	// Lists decoded from the database are not []interface{}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			s, err := formatValue(v.Index(i).Interface())
+			if err != nil {
+				return nil, false, err
+			}
+			values = append(values, s)
+		}
+		return values, true, nil
+	}
+
+	s, err := formatValue(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{s}, false, nil
+}
+
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return formatFloat(v), nil
+	case float32:
+		return formatFloat(float64(v)), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.Itoa(int(v)), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+	return "", fmt.Errorf("unsupported value '%v'", value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// tuning holds the static hyperparameters and tuning ranges of a job
+type tuning struct {
+	static map[string]string
+	ranges *types.ParameterRanges
+	// User's mini_batch_size, set once the number of samples is known
+	batchSize []string
+}
+
+// newTuning merges the user's hyperparameters over the static hyperparameters and default tuning ranges of an
+// algorithm. The hyperparameters must be valid.
+func newTuning(static map[string]string, hyperParameters map[string]interface{}) tuning {
+	t := tuning{static: make(map[string]string, len(static)), ranges: defaultParameterRanges()}
+	for name, value := range static {
+		t.static[name] = value
+	}
+
+	for name, value := range hyperParameters {
+		values, isRange, _ := hyperParameterValues(value)
+		if name == BatchSizeHyperParameter {
+			t.batchSize = values
+			continue
+		}
+		t.removeRange(name)
+		if !isRange {
+			t.static[name] = values[0]
+			continue
+		}
+
+		delete(t.static, name)
+		switch commonHyperParameters[name].Type {
+		case HyperParameterCategorical:
+			t.ranges.CategoricalParameterRanges = append(t.ranges.CategoricalParameterRanges, types.CategoricalParameterRange{
+				Name:   aws.String(name),
+				Values: values,
+			})
+		case HyperParameterContinuous:
+			t.ranges.ContinuousParameterRanges = append(t.ranges.ContinuousParameterRanges, types.ContinuousParameterRange{
+				Name:        aws.String(name),
+				MinValue:    aws.String(values[0]),
+				MaxValue:    aws.String(values[1]),
+				ScalingType: types.HyperParameterScalingTypeAuto,
+			})
+		}
+	}
+	return t
+}
+
+// setBatchSize tunes or pins mini_batch_size, never exceeding the number of samples
+func (t *tuning) setBatchSize(numTrainingSamples, numValidationSamples int32) {
+	maxBatchSize := math.Min(float64(numTrainingSamples), float64(numValidationSamples))
+	maxBatchSize = math.Min(maxBatchSize, MaxBatchSize)
+	minBatchSize := math.Min(maxBatchSize, 8) - 1
+
+	t.ranges.IntegerParameterRanges = nil
+	switch len(t.batchSize) {
+	case 1:
+		batchSize, _ := strconv.ParseFloat(t.batchSize[0], 64)
+		t.static[BatchSizeHyperParameter] = formatFloat(math.Min(batchSize, maxBatchSize))
+		return
+	case 2:
+		lo, _ := strconv.ParseFloat(t.batchSize[0], 64)
+		hi, _ := strconv.ParseFloat(t.batchSize[1], 64)
+		minBatchSize, maxBatchSize = math.Min(lo, maxBatchSize), math.Min(hi, maxBatchSize)
+	}
+	t.ranges.IntegerParameterRanges = []types.IntegerParameterRange{
+		{
+			Name:        aws.String(BatchSizeHyperParameter),
+			MinValue:    aws.String(formatFloat(minBatchSize)),
+			MaxValue:    aws.String(formatFloat(maxBatchSize)),
+			ScalingType: types.HyperParameterScalingTypeAuto,
+		},
+	}
+}
+
+func (t *tuning) removeRange(name string) {
+	categorical := t.ranges.CategoricalParameterRanges[:0]
+	for _, r := range t.ranges.CategoricalParameterRanges {
+		if *r.Name != name {
+			categorical = append(categorical, r)
+		}
+	}
+	t.ranges.CategoricalParameterRanges = categorical
+
+	continuous := t.ranges.ContinuousParameterRanges[:0]
+	for _, r := range t.ranges.ContinuousParameterRanges {
+		if *r.Name != name {
+			continuous = append(continuous, r)
+		}
+	}
+	t.ranges.ContinuousParameterRanges = continuous
+}
+
+// hyperParameters returns the allowed hyperparameters of the job as train parameters: static values and tuning
+// ranges as [min, max] or the list of values
+func (t *tuning) hyperParameters(allowed map[string]HyperParameter) map[string]interface{} {
+	hyperParameters := make(map[string]interface{})
+	for name, value := range t.static {
+		if _, ok := allowed[name]; ok {
+			hyperParameters[name] = value
+		}
+	}
+	for _, r := range t.ranges.CategoricalParameterRanges {
+		hyperParameters[*r.Name] = r.Values
+	}
+	for _, r := range t.ranges.ContinuousParameterRanges {
+		hyperParameters[*r.Name] = []string{*r.MinValue, *r.MaxValue}
+	}
+	for _, r := range t.ranges.IntegerParameterRanges {
+		hyperParameters[*r.Name] = []string{*r.MinValue, *r.MaxValue}
+	}
+	return hyperParameters
+}
+
+// defaultParameterRanges returns the tuning ranges used unless narrowed or pinned by the user
+func defaultParameterRanges() *types.ParameterRanges {
+	return &types.ParameterRanges{
+		CategoricalParameterRanges: []types.CategoricalParameterRange{
+			{
+				Name:   aws.String("optimizer"),
+				Values: []string{"sgd", "adam", "rmsprop"},
+			},
+		},
+		ContinuousParameterRanges: []types.ContinuousParameterRange{
+			{
+				Name:        aws.String("learning_rate"),
+				MinValue:    aws.String("0.0001"),
+				MaxValue:    aws.String("0.01"),
+				ScalingType: types.HyperParameterScalingTypeAuto,
+			},
+			{
+				Name:        aws.String("weight_decay"),
+				MinValue:    aws.String("0.0001"),
+				MaxValue:    aws.String("0.1"),
+				ScalingType: types.HyperParameterScalingTypeAuto,
+			},
+			{
+				Name:        aws.String("momentum"),
+				MinValue:    aws.String("0.0001"),
+				MaxValue:    aws.String("0.1"),
+				ScalingType: types.HyperParameterScalingTypeAuto,
+			},
+		},
+	}
+}
+
+func validInstanceType(instanceType string) bool {
+	for _, t := range types.TrainingInstanceType("").Values() {
+		if string(t) == instanceType {
+			return true
+		}
+	}
+	return false
+}
+
+func hyperParameterNames(allowed map[string]HyperParameter) []string {
+	names := make([]string, 0, len(allowed))
+	for name := range allowed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+/*
+ * File: params_test.go
+ * Project: train
+ * File Created: Wednesday, 21st February 2024 11:47:30 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 21st February 2024 11:47:30 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestValidParameters(t *testing.T) {
+	valid := func(hyperParameters map[string]interface{}, algorithm models.ProjectAnnotationType) error {
+		return ValidParameters(models.TrainParameters{HyperParameters: hyperParameters}, algorithm)
+	}
+
+	assert.NoError(t, valid(map[string]interface{}{
+		"epochs":            float64(20),
+		"lr_scheduler_step": "10, 20",
+		"num_layers":        "34",
+		"optimizer":         []interface{}{"sgd", "adam"},
+		"learning_rate":     primitive.A{0.001, 0.005},
+		"mini_batch_size":   int32(16),
+	}, models.ProjectAnnotationTypeClassification))
+	assert.NoError(t, valid(map[string]interface{}{"nms_threshold": 0.3}, models.ProjectAnnotationTypeBoundingBox))
+
+	// Parameters of the other algorithm are not allowed
+	assert.Error(t, valid(map[string]interface{}{"num_layers": "34"}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"image_shape": "512"}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"epochs": 2.5}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"learning_rate": 2}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"learning_rate": []interface{}{0.01, 0.001}}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"epochs": []interface{}{10, 20}}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"optimizer": "adagrad"}, models.ProjectAnnotationTypeBoundingBox))
+	assert.Error(t, valid(map[string]interface{}{"epochs": 10}, models.ProjectAnnotationTypeSegmentation))
+
+	params := models.TrainParameters{}
+	params.Resource.InstanceType = common.Ptr("ml.not.a.type")
+	assert.Error(t, ValidParameters(params, models.ProjectAnnotationTypeBoundingBox))
+	params = models.TrainParameters{}
+	params.Runtime.MaxNumberOfTrainingJobs = common.Ptr(int32(0))
+	assert.Error(t, ValidParameters(params, models.ProjectAnnotationTypeBoundingBox))
+}
+
+func TestNewParameters(t *testing.T) {
+	config := &Config{OutputDataPath: "s3://bucket/models/model"}
+	config.Runtime.MaxRuntimeInSeconds = 3600
+	config.Runtime.MaximumRetryAttempts = 1
+	config.Runtime.MaxNumberOfTrainingJobs = 10
+	config.Resource.InstanceType = "ml.p3.2xlarge"
+
+	params := models.TrainParameters{HyperParameters: map[string]interface{}{
+		"epochs":          float64(20),
+		"optimizer":       "adam",
+		"learning_rate":   []interface{}{0.001, 0.005},
+		"mini_batch_size": []interface{}{16, 64},
+	}}
+	params.Runtime.MaxNumberOfTrainingJobs = common.Ptr(int32(4))
+
+	trainer, err := New(config, nil, models.ProjectAnnotationTypeBoundingBox, params)
+	assert.NoError(t, err)
+	trainer.tuning.setBatchSize(100, 20)
+
+	definition := trainer.Input.TrainingJobDefinition
+	assert.Equal(t, "20", definition.StaticHyperParameters["epochs"])
+	assert.Equal(t, "adam", definition.StaticHyperParameters["optimizer"])
+	assert.Equal(t, "resnet-50", definition.StaticHyperParameters["base_network"])
+	assert.Equal(t, int32(3600), definition.StoppingCondition.MaxRuntimeInSeconds)
+	assert.Equal(t, int32(4), *trainer.Input.HyperParameterTuningJobConfig.ResourceLimits.MaxNumberOfTrainingJobs)
+
+	// Defaults are left untouched
+	assert.Equal(t, "100", ObjectDetectionStaticHyperParameters["epochs"])
+
+	// Pinned optimizer is no longer tuned
+	ranges := trainer.Input.HyperParameterTuningJobConfig.ParameterRanges
+	assert.Empty(t, ranges.CategoricalParameterRanges)
+	assert.Len(t, ranges.ContinuousParameterRanges, 3)
+
+	// The recorded parameters reproduce the job
+	recorded := trainer.Parameters()
+	assert.Equal(t, []string{"0.001", "0.005"}, recorded.HyperParameters["learning_rate"])
+	assert.Equal(t, []string{"16", "20"}, recorded.HyperParameters[BatchSizeHyperParameter])
+	assert.Equal(t, "ml.p3.2xlarge", *recorded.Resource.InstanceType)
+	assert.NoError(t, ValidParameters(recorded, models.ProjectAnnotationTypeBoundingBox))
+
+	again, err := New(config, nil, models.ProjectAnnotationTypeBoundingBox, recorded)
+	assert.NoError(t, err)
+	again.tuning.setBatchSize(100, 20)
+	assert.Equal(t, definition.StaticHyperParameters, again.Input.TrainingJobDefinition.StaticHyperParameters)
+	assert.ElementsMatch(t, ranges.ContinuousParameterRanges, again.Input.HyperParameterTuningJobConfig.ParameterRanges.ContinuousParameterRanges)
+	assert.Equal(t, ranges.IntegerParameterRanges, again.Input.HyperParameterTuningJobConfig.ParameterRanges.IntegerParameterRanges)
+}
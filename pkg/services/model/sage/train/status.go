@@ -12,62 +12,54 @@ package train
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sagemaker"
 	"github.com/aws/aws-sdk-go-v2/service/sagemaker/types"
 	"github.com/aws/aws-sdk-go/aws"
 
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 )
 
-func (t *Trainer) PollForStatus() (*types.HyperParameterTrainingJobSummary, error) {
-	var hyperParametersSummary *types.HyperParameterTrainingJobSummary
+// Status returns the status of a hyperparameter tuning job. A completed job reports its best training job.
+func (b *Backend) Status(name string) (backend.TrainingStatus, error) {
+	output, err := b.Client.DescribeHyperParameterTuningJob(context.TODO(), &sagemaker.DescribeHyperParameterTuningJobInput{
+		HyperParameterTuningJobName: aws.String(name),
+	})
+	if err != nil {
+		return backend.TrainingStatus{}, err
+	}
 
-	for {
-		output, err := t.Client.DescribeHyperParameterTuningJob(context.TODO(), &sagemaker.DescribeHyperParameterTuningJobInput{
-			HyperParameterTuningJobName: aws.String(*t.Input.HyperParameterTuningJobName),
-		})
-		if err != nil {
-			return nil, err
+	switch output.HyperParameterTuningJobStatus {
+	case types.HyperParameterTuningJobStatusCompleted:
+		if output.BestTrainingJob == nil || output.BestTrainingJob.TrainingJobName == nil {
+			return backend.TrainingStatus{State: backend.TrainingStateFailed, FailureReason: "no training job completed"}, nil
 		}
-
-		switch output.HyperParameterTuningJobStatus {
-		case types.HyperParameterTuningJobStatusCompleted, types.HyperParameterTuningJobStatusStopped:
-			hyperParametersSummary = output.BestTrainingJob
-			return hyperParametersSummary, nil
-		case types.HyperParameterTuningJobStatusInProgress:
-			log.Debugf("training hyperparameter job; name=%s", *t.Input.HyperParameterTuningJobName)
-			time.Sleep(5 * time.Second)
-			continue
-		case types.HyperParameterTuningJobStatusFailed:
-			return nil, fmt.Errorf("training job error; error=%s", *output.FailureReason)
-		case types.HyperParameterTuningJobStatusStopping:
-			// Stop was initiated on the AWS console
-			log.Debugf("training hyperparameter job stop was initiated; name=%s", *t.Input.HyperParameterTuningJobName)
-			// Await full stop
-			for {
-				output, err = t.Client.DescribeHyperParameterTuningJob(context.TODO(), &sagemaker.DescribeHyperParameterTuningJobInput{
-					HyperParameterTuningJobName: aws.String(*t.Input.HyperParameterTuningJobName),
-				})
-				if err != nil {
-					return nil, err
-				}
-				switch output.HyperParameterTuningJobStatus {
-				case types.HyperParameterTuningJobStatusStopped:
-					return nil, fmt.Errorf("training job stopped; name=%s", *t.Input.HyperParameterTuningJobName)
-				case types.HyperParameterTuningJobStatusFailed:
-					return nil, fmt.Errorf("training job error; error=%s", *output.FailureReason)
-				case types.HyperParameterTuningJobStatusStopping:
-					log.Debugf("training job stopping; name=%s", *t.Input.HyperParameterTuningJobName)
-					time.Sleep(1 * time.Second)
-					continue
-				default:
-					return nil, fmt.Errorf("unexpected status for training job; status=%s", string(output.HyperParameterTuningJobStatus))
-				}
-			}
-		default:
-			return nil, fmt.Errorf("unexpected status for training job; status=%s", string(output.HyperParameterTuningJobStatus))
+		return backend.TrainingStatus{State: backend.TrainingStateCompleted, TrainingJobName: *output.BestTrainingJob.TrainingJobName}, nil
+	case types.HyperParameterTuningJobStatusInProgress:
+		log.Debugf("training hyperparameter job; name=%s", name)
+		return backend.TrainingStatus{State: backend.TrainingStateInProgress}, nil
+	case types.HyperParameterTuningJobStatusStopping:
+		// Stop was requested; await full stop
+		log.Debugf("training hyperparameter job stopping; name=%s", name)
+		return backend.TrainingStatus{State: backend.TrainingStateInProgress}, nil
+	case types.HyperParameterTuningJobStatusStopped:
+		status := backend.TrainingStatus{State: backend.TrainingStateStopped}
+		if output.ConsumedResources != nil {
+			status.BillableTimeInSeconds = output.ConsumedResources.RuntimeInSeconds
 		}
+		return status, nil
+	case types.HyperParameterTuningJobStatusFailed:
+		return backend.TrainingStatus{State: backend.TrainingStateFailed, FailureReason: aws.StringValue(output.FailureReason)}, nil
+	default:
+		return backend.TrainingStatus{}, fmt.Errorf("unexpected status for training job; status=%s", string(output.HyperParameterTuningJobStatus))
 	}
 }
+
+// Stop stops a hyperparameter tuning job along with its running training jobs
+func (b *Backend) Stop(name string) error {
+	_, err := b.Client.StopHyperParameterTuningJob(context.TODO(), &sagemaker.StopHyperParameterTuningJobInput{
+		HyperParameterTuningJobName: aws.String(name),
+	})
+	return err
+}
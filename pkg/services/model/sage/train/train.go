@@ -12,7 +12,6 @@ package train
 import (
 	"context"
 	"fmt"
-	"math"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/sagemaker"
@@ -101,9 +100,17 @@ type Trainer struct {
 	Client *sagemaker.Client
 
 	Input *sagemaker.CreateHyperParameterTuningJobInput
+
+	allowed map[string]HyperParameter
+	tuning  tuning
 }
 
-func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnotationType) (*Trainer, error) {
+// New creates the tuning job spec of the algorithm. The model's train parameters override the service-wide
+// runtime limits and instance type, and are merged over the static hyperparameters and default tuning ranges.
+func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnotationType, params models.TrainParameters) (*Trainer, error) {
+	if err := ValidParameters(params, algorithm); err != nil {
+		return nil, err
+	}
 
 	trainingJobName := fmt.Sprintf("train-job-%s", common.ShortUUID(17))
 	s3TrainManifestKey := config.OutputDataPath + "/train.manifest"
@@ -128,6 +135,22 @@ func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnot
 		validationMetric = sage.Const_ObjectDetectionObjectiveMetric
 		baseImage = config.TrainImageObjectDetection
 	}
+	allowed, _ := AllowedHyperParameters(algorithm)
+	tuning := newTuning(staticHyperParameters, params.HyperParameters)
+
+	runtime, resource := config.Runtime, config.Resource
+	if params.Runtime.MaxRuntimeInSeconds != nil {
+		runtime.MaxRuntimeInSeconds = *params.Runtime.MaxRuntimeInSeconds
+	}
+	if params.Runtime.MaximumRetryAttempts != nil {
+		runtime.MaximumRetryAttempts = *params.Runtime.MaximumRetryAttempts
+	}
+	if params.Runtime.MaxNumberOfTrainingJobs != nil {
+		runtime.MaxNumberOfTrainingJobs = *params.Runtime.MaxNumberOfTrainingJobs
+	}
+	if params.Resource.InstanceType != nil {
+		resource.InstanceType = types.TrainingInstanceType(*params.Resource.InstanceType)
+	}
 
 	trainJobInput := &sagemaker.CreateHyperParameterTuningJobInput{
 		HyperParameterTuningJobName: aws.String(trainingJobName),
@@ -138,15 +161,15 @@ func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnot
 				TrainingInputMode: types.TrainingInputModePipe,
 			},
 			StoppingCondition: &types.StoppingCondition{
-				MaxRuntimeInSeconds: config.Runtime.MaxRuntimeInSeconds,
+				MaxRuntimeInSeconds: runtime.MaxRuntimeInSeconds,
 			},
 			RetryStrategy: &types.RetryStrategy{
-				MaximumRetryAttempts: config.Runtime.MaximumRetryAttempts,
+				MaximumRetryAttempts: runtime.MaximumRetryAttempts,
 			},
 			ResourceConfig: &types.ResourceConfig{
-				InstanceCount:  config.Resource.InstanceCount,
-				InstanceType:   config.Resource.InstanceType,
-				VolumeSizeInGB: config.Resource.VolumeSizeInGB,
+				InstanceCount:  resource.InstanceCount,
+				InstanceType:   resource.InstanceType,
+				VolumeSizeInGB: resource.VolumeSizeInGB,
 			},
 			// Training data should be inside a subdirectory called "train"
 			// Validation data should be inside a subdirectory called "validation"
@@ -185,11 +208,11 @@ func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnot
 				S3OutputPath: aws.String(config.OutputDataPath),
 			},
 			// Hyperparameter docs: https://docs.aws.amazon.com/sagemaker/latest/dg/IC-Hyperparameter.html
-			StaticHyperParameters: staticHyperParameters,
+			StaticHyperParameters: tuning.static,
 		},
 		HyperParameterTuningJobConfig: &types.HyperParameterTuningJobConfig{
 			ResourceLimits: &types.ResourceLimits{
-				MaxNumberOfTrainingJobs: common.Ptr(config.Runtime.MaxNumberOfTrainingJobs),
+				MaxNumberOfTrainingJobs: common.Ptr(runtime.MaxNumberOfTrainingJobs),
 				// Restrict parallelism when using Baysian tuning strategy
 				MaxParallelTrainingJobs: 1,
 			},
@@ -199,42 +222,17 @@ func New(config *Config, client *sagemaker.Client, algorithm models.ProjectAnnot
 				MetricName: aws.String(validationMetric),
 				Type:       types.HyperParameterTuningJobObjectiveTypeMaximize,
 			},
-			ParameterRanges: &types.ParameterRanges{
-				CategoricalParameterRanges: []types.CategoricalParameterRange{
-					{
-						Name:   aws.String("optimizer"),
-						Values: []string{"sgd", "adam", "rmsprop"},
-					},
-				},
-				ContinuousParameterRanges: []types.ContinuousParameterRange{
-					{
-						Name:        aws.String("learning_rate"),
-						MinValue:    aws.String("0.0001"),
-						MaxValue:    aws.String("0.01"),
-						ScalingType: types.HyperParameterScalingTypeAuto,
-					},
-					{
-						Name:        aws.String("weight_decay"),
-						MinValue:    aws.String("0.0001"),
-						MaxValue:    aws.String("0.1"),
-						ScalingType: types.HyperParameterScalingTypeAuto,
-					},
-					{
-						Name:        aws.String("momentum"),
-						MinValue:    aws.String("0.0001"),
-						MaxValue:    aws.String("0.1"),
-						ScalingType: types.HyperParameterScalingTypeAuto,
-					},
-				},
-			},
+			ParameterRanges:              tuning.ranges,
 			TrainingJobEarlyStoppingType: types.TrainingJobEarlyStoppingTypeAuto,
 		},
 	}
 
 	return &Trainer{
-		Config: config,
-		Client: client,
-		Input:  trainJobInput,
+		Config:  config,
+		Client:  client,
+		Input:   trainJobInput,
+		allowed: allowed,
+		tuning:  tuning,
 	}, nil
 }
 
@@ -247,16 +245,7 @@ func (t *Trainer) Train(numClasses, numTrainingSamples, numValidationSamples, fo
 		t.Input.TrainingJobDefinition.StaticHyperParameters["label_width"] = fmt.Sprint(forcePaddingLabelWidth)
 	}
 
-	maxBatchSize := math.Min(float64(numTrainingSamples), float64(numValidationSamples))
-	maxBatchSize = math.Min(maxBatchSize, MaxBatchSize)
-	t.Input.HyperParameterTuningJobConfig.ParameterRanges.IntegerParameterRanges = []types.IntegerParameterRange{
-		{
-			Name:        aws.String("mini_batch_size"),
-			MinValue:    aws.String(fmt.Sprint(math.Min(maxBatchSize, 8) - 1)),
-			MaxValue:    aws.String(fmt.Sprint(maxBatchSize)),
-			ScalingType: types.HyperParameterScalingTypeAuto,
-		},
-	}
+	t.tuning.setBatchSize(numTrainingSamples, numValidationSamples)
 
 	if _, err := t.Client.CreateHyperParameterTuningJob(context.TODO(), t.Input); err != nil {
 		return err
@@ -264,21 +253,14 @@ func (t *Trainer) Train(numClasses, numTrainingSamples, numValidationSamples, fo
 	return nil
 }
 
-func (t *Trainer) Metrics(trainingJobName string) (metrics map[string]interface{}, err error) {
-	output, err := t.Client.DescribeTrainingJob(context.TODO(), &sagemaker.DescribeTrainingJobInput{
-		TrainingJobName: &trainingJobName,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	metrics = make(map[string]interface{})
-	for _, metric := range output.FinalMetricDataList {
-		metrics[*metric.MetricName] = metric.Value
-	}
-
-	// Billing related metrics
-	metrics["BillableTimeInSeconds"] = *output.BillableTimeInSeconds * output.ResourceConfig.InstanceCount
+// Parameters returns the train parameters of the job; training with them again gives the same job
+func (t *Trainer) Parameters() models.TrainParameters {
+	definition := t.Input.TrainingJobDefinition
 
-	return metrics, nil
+	params := models.TrainParameters{HyperParameters: t.tuning.hyperParameters(t.allowed)}
+	params.Runtime.MaxRuntimeInSeconds = common.Ptr(definition.StoppingCondition.MaxRuntimeInSeconds)
+	params.Runtime.MaximumRetryAttempts = common.Ptr(definition.RetryStrategy.MaximumRetryAttempts)
+	params.Runtime.MaxNumberOfTrainingJobs = t.Input.HyperParameterTuningJobConfig.ResourceLimits.MaxNumberOfTrainingJobs
+	params.Resource.InstanceType = common.Ptr(string(definition.ResourceConfig.InstanceType))
+	return params
 }
@@ -86,7 +86,7 @@ func Start(cfg *config.Configuration) error {
 	})
 	v1 := echoServer.Group("/v1")
 
-	modelSvc, err := api.Initialize(db, platform.NewPlatform(), cfg)
+	modelSvc, err := api.Initialize(db, platform.NewPlatform(), blob, cfg)
 	if err != nil {
 		return err
 	}
@@ -13,13 +13,11 @@ import (
 	"errors"
 	"path"
 
-	"github.com/aws/aws-sdk-go-v2/service/sagemakerruntime"
-
 	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
 	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 	sage "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage"
-	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
 )
 
 const (
@@ -30,11 +28,11 @@ const (
 var ErrEmptyPredictions = errors.New("no predictions found")
 
 type inferArgs struct {
-	content                models.Content
-	model                  models.Model
-	blob                   *blob.Blob
-	sagemakerRuntimeClient *sagemakerruntime.Client
-	thumbnailSize          int
+	content       models.Content
+	model         models.Model
+	blob          *blob.Blob
+	inference     *backend.Inference
+	thumbnailSize int
 }
 
 func (args inferArgs) infer() (models.Prediction, error) {
@@ -54,7 +52,7 @@ func (args inferArgs) infer() (models.Prediction, error) {
 		return models.Prediction{ContentID: args.content.ID}, err
 	}
 
-	result, err := realtime.New(contentBytes, args.model.Deployment.EndpointName, detectionType.String(), args.sagemakerRuntimeClient)
+	result, err := args.inference.Infer(&args.model, contentBytes)
 	if err != nil {
 		return models.Prediction{ContentID: args.content.ID}, err
 	}
@@ -13,6 +13,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
 	"time"
 
@@ -28,8 +29,11 @@ import (
 	worker "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/worker"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 	modelConfig "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/config"
+	localRealtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/realtime"
 	sage "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage"
+	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
 )
 
 const (
@@ -43,8 +47,8 @@ type WorkerPool struct {
 	Blob     *blob.Blob
 	Config   modelConfig.Configuration
 
-	sagemakerRuntimeClient *sagemakerruntime.Client
-	consumer               sqs.Consumer
+	inference *backend.Inference
+	consumer  sqs.Consumer
 }
 
 func New(
@@ -78,8 +82,11 @@ func New(
 		Platform: platform,
 		Config:   cfg,
 
-		sagemakerRuntimeClient: sagemakerruntime.NewFromConfig(awsConfig),
-		consumer:               consumer,
+		inference: &backend.Inference{
+			SageMaker: realtime.NewBackend(sagemakerruntime.NewFromConfig(awsConfig)),
+			Local:     localRealtime.NewBackend(&cfg.ModelService.LocalInferenceConfig, blob),
+		},
+		consumer: consumer,
 	}, nil
 }
 
@@ -107,8 +114,10 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		return nil
 	}
 
-	if model.Deployment.EndpointName == "" || model.Deployment.Status != models.DeploymentStatusInService.String() {
-		log.Errorf("model is not deployed; model=%s", event.ModelID, err.Error())
+	// Models served locally need no deployment
+	servedBy := backend.ServedBy(&model)
+	if servedBy == backend.SageMaker && (model.Deployment.EndpointName == "" || model.Deployment.Status != models.DeploymentStatusInService.String()) {
+		log.Errorf("model is not deployed; model=%s", event.ModelID)
 		return w.updateLastErr(errors.New("model not deployed"), &model)
 	}
 
@@ -137,8 +146,12 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 
 	// Spin up a worker pool for running content through realtime
 	log.Debugf("Starting worker pool...")
+	concurrency := int(w.Config.ModelService.EndpointConfig.MaxConcurrency)
+	if servedBy == backend.Local {
+		concurrency = runtime.NumCPU()
+	}
 	config := worker.Config{
-		Concurrency:      int(w.Config.ModelService.EndpointConfig.MaxConcurrency),
+		Concurrency:      concurrency,
 		RetryAttempts:    w.Config.ModelService.BatchWorkerConfig.RetryAttempts,
 		RetryWaitSeconds: w.Config.ModelService.BatchWorkerConfig.RetryWaitSeconds,
 		RetryBackoff:     w.Config.ModelService.BatchWorkerConfig.RetryBackoff,
@@ -239,11 +252,11 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		}
 		for _, c := range content {
 			args := inferArgs{
-				content:                c,
-				model:                  model,
-				blob:                   w.Blob,
-				sagemakerRuntimeClient: w.sagemakerRuntimeClient,
-				thumbnailSize:          int(thumbnailsize),
+				content:       c,
+				model:         model,
+				blob:          w.Blob,
+				inference:     w.inference,
+				thumbnailSize: int(thumbnailsize),
 			}
 			pool.InChan <- args.infer
 			sent++
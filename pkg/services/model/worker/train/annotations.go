@@ -12,7 +12,7 @@ package train
 import (
 	"context"
 	"math/rand"
-	"time"
+	"sort"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -30,6 +30,8 @@ type Annotation struct {
 	ID        primitive.ObjectID        `bson:"_id"`
 	TagIDs    []string                  `bson:"tagids"`
 	ContentID string                    `bson:"contentid"`
+	Split     string                    `bson:"split"`
+	Status    string                    `bson:"status"`
 	Metadata  models.AnnotationMetadata `bson:"metadata,omitempty"`
 }
 
@@ -39,7 +41,7 @@ func (a *Annotations) Length() int {
 
 // FetchContent is a method for retrieving annotations
 func FetchAnnotations(dataset *models.Dataset, platform *platform.Platform, db *db.DB) (*Annotations, error) {
-	options := options.FindOptions{Projection: bson.M{"_id": 1, "tagids": 1, "contentid": 1, "metadata": 1}, AllowDiskUse: common.Ptr(true)}
+	options := options.FindOptions{Projection: bson.M{"_id": 1, "tagids": 1, "contentid": 1, "split": 1, "status": 1, "metadata": 1}, AllowDiskUse: common.Ptr(true)}
 	cursor, err := platform.AnnotationDB.FindDatasetAnnotations(db, dataset.UserID, dataset.ID.Hex(), &options)
 	if err != nil {
 		return nil, err
@@ -54,10 +56,39 @@ func FetchAnnotations(dataset *models.Dataset, platform *platform.Platform, db *
 	return &a, nil
 }
 
-// Shuffle is a method for randomly shuffling annotations
-func (a *Annotations) Shuffle() {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	rand.Shuffle(len(*a), func(i, j int) { (*a)[i], (*a)[j] = (*a)[j], (*a)[i] })
+// Shuffle is a method for shuffling annotations; the same seed always gives the same order
+func (a *Annotations) Shuffle(seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(*a), func(i, j int) { (*a)[i], (*a)[j] = (*a)[j], (*a)[i] })
+}
+
+// Sort orders annotations by content, which unlike annotation ids is the same in every dataset version
+func (a *Annotations) Sort() {
+	sort.SliceStable(*a, func(i, j int) bool { return (*a)[i].ContentID < (*a)[j].ContentID })
+}
+
+// Filter returns the annotations assigned to the given split
+func (a *Annotations) Filter(split models.Split) Annotations {
+	filtered := Annotations{}
+	for _, annotation := range *a {
+		if annotation.Split == split.String() {
+			filtered = append(filtered, annotation)
+		}
+	}
+	return filtered
+}
+
+// Approved returns the annotations approved in review and those left out
+func (a *Annotations) Approved() (approved Annotations, excluded Annotations) {
+	approved, excluded = Annotations{}, Annotations{}
+	for _, annotation := range *a {
+		if annotation.Status == models.AnnotationStatusApproved.String() {
+			approved = append(approved, annotation)
+		} else {
+			excluded = append(excluded, annotation)
+		}
+	}
+	return approved, excluded
 }
 
 func (a *Annotations) Split(splits SplitCounts) (train Annotations, validation Annotations, test Annotations) {
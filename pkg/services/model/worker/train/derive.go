@@ -0,0 +1,152 @@
+/*
+ * File: derive.go
+ * Project: train
+ * File Created: Tuesday, 20th February 2024 1:36:52 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 1:36:52 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"path"
+
+	"github.com/pkg/errors"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+)
+
+// derivation describes the images of a split derived from the stored content: each image is preprocessed and,
+// for the train split, followed by a number of augmented copies
+type derivation struct {
+	// Blob key prefix of the derived images
+	prefix     string
+	preprocess []image.Transform
+	augment    []image.Transform
+	copies     int
+}
+
+// trainingImage is an image referenced by a manifest; box class names hold tag ids
+type trainingImage struct {
+	Path   string
+	Width  int
+	Height int
+	Boxes  []image.BoundingBox
+}
+
+// newDerivation returns the derivation of a split of the model's dataset. Only the train split is augmented.
+func newDerivation(model *models.Model, split models.Split) derivation {
+	d := derivation{
+		prefix:     fmt.Sprintf("%s/models/%s/images", model.UserID, model.ID.Hex()),
+		preprocess: backend.Preprocessors(model.Preprocessing),
+	}
+	if split != models.SplitTrain {
+		return d
+	}
+
+	d.augment = augmentations(model.Augmentation)
+	if len(d.augment) > 0 {
+		d.copies = models.DefaultAugmentationCopies
+		if model.Augmentation.Copies > 0 {
+			d.copies = model.Augmentation.Copies
+		}
+	}
+	return d
+}
+
+// augmentations returns the transforms of the enabled augmentations; geometric transforms come first
+func augmentations(a models.Augmentations) []image.Transform {
+	transforms := []image.Transform{}
+	if a.Flip != nil && (a.Flip.Horizontal || a.Flip.Vertical) {
+		transforms = append(transforms, image.Flip{Horizontal: a.Flip.Horizontal, Vertical: a.Flip.Vertical})
+	}
+	if a.Rotate != nil && a.Rotate.Degree > 0 {
+		transforms = append(transforms, image.Rotate{Degree: a.Rotate.Degree})
+	}
+	if a.RandomCrop != nil && a.RandomCrop.Enabled {
+		transforms = append(transforms, image.RandomCrop{})
+	}
+	if a.ColorJitter != nil {
+		transforms = append(transforms, image.ColorJitter{
+			Brightness: float64(a.ColorJitter.Brightness),
+			Contrast:   float64(a.ColorJitter.Contrast),
+			Saturation: float64(a.ColorJitter.Saturation),
+			Hue:        float64(a.ColorJitter.Hue),
+		})
+	}
+	if a.Grey != nil && a.Grey.Enabled {
+		transforms = append(transforms, image.Greyscale{})
+	}
+	if a.GaussianBlur != nil && a.GaussianBlur.Intensity > 0 {
+		transforms = append(transforms, image.GaussianBlur{Intensity: float64(a.GaussianBlur.Intensity)})
+	}
+	if a.Noise != nil && a.Noise.Intensity > 0 {
+		transforms = append(transforms, image.Noise{Intensity: float64(a.Noise.Intensity)})
+	}
+	if a.RandomErasing != nil && a.RandomErasing.Enabled {
+		transforms = append(transforms, image.RandomErasing{})
+	}
+	if a.JpegCompression != nil && a.JpegCompression.Enabled {
+		transforms = append(transforms, image.JPEGCompression{})
+	}
+	return transforms
+}
+
+// deriveImages returns the images trained on for a content. Without preprocessing or augmentation this is the
+// stored image itself; otherwise the preprocessed image and its augmented copies are uploaded to the blob store.
+// Augmentations are seeded by the content so retraining derives the same copies.
+func (w *WorkerPool) deriveImages(d derivation, content *models.Content, boxes []image.BoundingBox) ([]trainingImage, error) {
+	if len(d.preprocess) == 0 && d.copies == 0 {
+		return []trainingImage{{
+			Path:   "s3://" + path.Join(content.StoredDir, content.StoredPath),
+			Width:  content.Width,
+			Height: content.Height,
+			Boxes:  boxes,
+		}}, nil
+	}
+
+	contentBytes, err := w.Blob.Get(content.StoredDir, content.StoredPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving content from blob store; content=%s", content.ID)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(content.ID))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	images := make([]trainingImage, 0, d.copies+1)
+	for i := 0; i <= d.copies; i++ {
+		transforms := d.preprocess
+		name := content.ID + path.Ext(content.StoredPath)
+		if i > 0 {
+			transforms = append(append([]image.Transform{}, d.preprocess...), d.augment...)
+			name = fmt.Sprintf("%s_%d%s", content.ID, i, path.Ext(content.StoredPath))
+		}
+
+		derived, width, height, derivedBoxes, err := image.TransformImage(contentBytes, boxes, transforms, r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error transforming content; content=%s", content.ID)
+		}
+
+		key := path.Join(d.prefix, name)
+		if _, err := w.Blob.Uploader.Upload(bytes.NewReader(derived), w.Blob.Bucket, key); err != nil {
+			return nil, errors.Wrapf(err, "error uploading derived image; content=%s", content.ID)
+		}
+
+		images = append(images, trainingImage{
+			Path:   "s3://" + path.Join(w.Blob.Bucket, key),
+			Width:  width,
+			Height: height,
+			Boxes:  derivedBoxes,
+		})
+	}
+
+	return images, nil
+}
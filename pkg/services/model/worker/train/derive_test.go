@@ -0,0 +1,58 @@
+/*
+ * File: derive_test.go
+ * Project: train
+ * File Created: Tuesday, 20th February 2024 3:05:44 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 20th February 2024 3:05:44 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestNewDerivation(t *testing.T) {
+	model := models.NewModel("model", "user", "project", "dataset", "bucket", models.Preprocessors{}, models.Augmentations{})
+
+	// Without preprocessing or augmentation the stored images are trained on
+	d := newDerivation(&model, models.SplitTrain)
+	assert.Empty(t, d.preprocess)
+	assert.Zero(t, d.copies)
+
+	// Disabled augmentations do nothing
+	model.Augmentation = models.Augmentations{Flip: &models.Flip{}, Grey: &models.Grey{}}
+	assert.Zero(t, newDerivation(&model, models.SplitTrain).copies)
+
+	model.Preprocessing = models.Preprocessors{Resize: &models.Resize{Mode: "fit"}, Greyscale: &models.Greyscale{}}
+	model.Augmentation = models.Augmentations{Flip: &models.Flip{Horizontal: true}, Noise: &models.Noise{Intensity: 0.5}}
+	d = newDerivation(&model, models.SplitTrain)
+	assert.Len(t, d.preprocess, 2)
+	assert.Equal(t, []image.Transform{image.Flip{Horizontal: true}, image.Noise{Intensity: 0.5}}, d.augment)
+	assert.Equal(t, models.DefaultAugmentationCopies, d.copies)
+
+	model.Augmentation.Copies = 3
+	assert.Equal(t, 3, newDerivation(&model, models.SplitTrain).copies)
+
+	// Only the train split is augmented
+	d = newDerivation(&model, models.SplitValidation)
+	assert.Len(t, d.preprocess, 2)
+	assert.Zero(t, d.copies)
+}
+
+func TestAnnotationMetadata(t *testing.T) {
+	annotation := &Annotation{Metadata: models.AnnotationMetadata{BoundingBoxes: []models.AnnotationDataBoundingBox{
+		{TagID: "cat", Xmin: 1, Ymin: 2, Xmax: 11, Ymax: 22},
+		{TagID: "dog", Xmin: 5, Ymin: 5, Xmax: 6, Ymax: 6},
+	}}}
+
+	boxes := annotationBoxes(annotation, "cat")
+	assert.Equal(t, []image.BoundingBox{{Xmin: 1, Ymin: 2, Xmax: 11, Ymax: 22, ClassName: "cat"}}, boxes)
+	assert.Equal(t, [][]float32{{4, 1, 2, 10, 20}}, annotationMetadata(boxes, map[string]int{"cat": 4}))
+}
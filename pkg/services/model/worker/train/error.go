@@ -15,3 +15,13 @@ var (
 	ErrNoContent    = errors.New("no content to process; training and validation annotations should be > 1")
 	ErrInvalidState = errors.New("invalid model state")
 )
+
+// cancelledError reports a training job stopped as its model's training was cancelled
+type cancelledError struct {
+	// Runtime billed for the job before it stopped
+	billableTimeInSeconds int32
+}
+
+func (e *cancelledError) Error() string {
+	return "training cancelled"
+}
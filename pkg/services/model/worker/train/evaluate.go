@@ -0,0 +1,192 @@
+/*
+ * File: evaluate.go
+ * Project: train
+ * File Created: Monday, 12th February 2024 11:15:42 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 12th February 2024 11:15:42 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+	endpoint "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/endpoint"
+)
+
+// evaluate runs the test split of the dataset through the trained model and returns the resulting test metrics.
+// Models trained on SageMaker are run by a transient endpoint, others locally. Test images are preprocessed by the
+// inference backend, as at inference. No metrics are returned when the dataset has no test split.
+func (w *WorkerPool) evaluate(dataset *models.Dataset, projectType models.ProjectAnnotationType, trained *trainResult, labelIntegerMap map[string]int, preprocessing models.Preprocessors) (map[string]interface{}, error) {
+	annotations, err := FetchAnnotations(dataset, w.Platform, w.DB)
+	if err != nil {
+		return nil, err
+	}
+	test := annotations.Filter(models.SplitTest)
+	if len(test) == 0 {
+		return nil, nil
+	}
+
+	// Ground truth is compared by tag name
+	tagNames := make(map[string]string)
+	for _, annotation := range test {
+		for _, tagid := range annotation.TagIDs {
+			if _, ok := tagNames[tagid]; ok {
+				continue
+			}
+			tag, err := w.Platform.TagDB.View(w.DB, dataset.UserID, tagid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error retrieving tag from database; tag=%s annotation=%s", tagid, annotation.ID.Hex())
+			}
+			tagNames[tagid] = tag.Name
+		}
+	}
+
+	model := &models.Model{
+		TrainingJobName: trained.TrainingJobName,
+		TrainingBackend: w.backendName,
+		ArtifactPath:    trained.ArtifactPath,
+		Metadata:        map[string]interface{}{"type": projectType.String()},
+		Preprocessing:   preprocessing,
+	}
+	concurrency := runtime.NumCPU()
+
+	if backend.ServedBy(model) == backend.SageMaker {
+		// Deploy a transient endpoint for the trained model
+		e, err := endpoint.New(&w.Config.ModelService.EndpointConfig, w.sagemakerClient, trained.TrainingJobName)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.CreateEndpoint(); err != nil {
+			return nil, err
+		}
+		endpointName, _, _ := e.Describe()
+		defer func() {
+			// Left over resources are removed by the garbage collector
+			if err := e.DeleteEndpoint(endpointName); err != nil {
+				log.Errorf("error deleting evaluation endpoint; endpoint=%s error=%s", endpointName, err.Error())
+			}
+			if err := e.DeleteModel(); err != nil {
+				log.Errorf("error deleting evaluation model; endpoint=%s error=%s", endpointName, err.Error())
+			}
+		}()
+		if err := e.PollForStatus(); err != nil {
+			return nil, err
+		}
+
+		model.Deployment.EndpointName = endpointName
+		concurrency = int(w.Config.ModelService.EndpointConfig.MaxConcurrency)
+	}
+
+	log.Debugf("evaluating %d test annotations; training-job=%s", len(test), trained.TrainingJobName)
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+
+	samples := make([]evaluation.Sample, len(test))
+	for i, annotation := range test {
+		i, annotation := i, annotation
+		g.Go(func() error {
+			sample, err := w.evaluationSample(dataset.UserID, model, projectType, annotation, tagNames, labelIntegerMap)
+			if err != nil {
+				return errors.Wrapf(err, "error evaluating annotation=%s", annotation.ID.Hex())
+			}
+			samples[i] = sample
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	classes := make([]string, len(labelIntegerMap))
+	for name, idx := range labelIntegerMap {
+		classes[idx] = name
+	}
+
+	if projectType == models.ProjectAnnotationTypeClassification {
+		result := evaluation.Classification(samples, classes, evaluation.DefaultClassificationThreshold)
+		return map[string]interface{}{
+			"test:accuracy":  *result.Accuracy,
+			"test:precision": result.Precision,
+			"test:recall":    result.Recall,
+			"test:f1":        result.F1,
+			"test:classes":   result.Classes,
+			"test:samples":   result.Samples,
+		}, nil
+	}
+
+	result := evaluation.Detection(samples, classes, evaluation.DefaultIoUThreshold, evaluation.DefaultDetectionThreshold)
+	return map[string]interface{}{
+		"test:mAP":       *result.MAP,
+		"test:precision": result.Precision,
+		"test:recall":    result.Recall,
+		"test:f1":        result.F1,
+		"test:classes":   result.Classes,
+		"test:samples":   result.Samples,
+	}, nil
+}
+
+// evaluationSample runs a single annotated content item through the trained model
+func (w *WorkerPool) evaluationSample(userid string, model *models.Model, projectType models.ProjectAnnotationType, annotation *Annotation, tagNames map[string]string, labelIntegerMap map[string]int) (evaluation.Sample, error) {
+	sample := evaluation.Sample{}
+
+	// Ground truth is compared in the coordinates of the stored image; predictions are mapped back onto it
+	if projectType == models.ProjectAnnotationTypeClassification {
+		for _, tagid := range annotation.TagIDs {
+			sample.Truth = append(sample.Truth, evaluation.Object{Class: tagNames[tagid]})
+		}
+	} else {
+		for _, box := range annotation.Metadata.BoundingBoxes {
+			sample.Truth = append(sample.Truth, evaluation.Object{
+				Class: tagNames[box.TagID],
+				Box:   &evaluation.Box{Xmin: float64(box.Xmin), Ymin: float64(box.Ymin), Xmax: float64(box.Xmax), Ymax: float64(box.Ymax)},
+			})
+		}
+	}
+
+	content, err := w.Platform.ContentDB.View(w.DB, userid, annotation.ContentID)
+	if err != nil {
+		return sample, err
+	}
+
+	contentBytes, _, err := w.Blob.Downloader.Download(content.StoredDir, content.StoredPath)
+	if err != nil {
+		return sample, err
+	}
+
+	stats, err := image.GetStats(contentBytes)
+	if err != nil {
+		return sample, err
+	}
+
+	result, err := w.inference.Infer(model, contentBytes)
+	if err != nil {
+		return sample, err
+	}
+
+	// All predictions are kept; thresholds are applied during evaluation
+	for _, prediction := range result.ToFormattedResult(labelIntegerMap, 0, stats).Predictions {
+		object := evaluation.Object{Class: prediction.ClassName, Confidence: prediction.Confidence}
+		if prediction.BoundingBox != nil {
+			object.Box = &evaluation.Box{
+				Xmin: float64(prediction.BoundingBox["xmin"].(int)),
+				Ymin: float64(prediction.BoundingBox["ymin"].(int)),
+				Xmax: float64(prediction.BoundingBox["xmax"].(int)),
+				Ymax: float64(prediction.BoundingBox["ymax"].(int)),
+			}
+		}
+		sample.Predictions = append(sample.Predictions, object)
+	}
+
+	return sample, nil
+}
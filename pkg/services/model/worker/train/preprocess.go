@@ -13,12 +13,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"path"
-	"strings"
 
 	"github.com/pkg/errors"
 
 	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	train "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
@@ -26,36 +25,44 @@ import (
 
 func (w *WorkerPool) preprocess(model *models.Model, dataset *models.Dataset, project *models.Project, labelIntegerMap map[string]int) (*SplitCounts, error) {
 
-	// Determine dataset split
-	counts, err := GetSplitCounts(dataset, float64(dataset.Split.Train), float64(dataset.Split.Validation), float64(dataset.Split.Test), *w.Platform.AnnotationDB, w.DB)
+	// Fetch all annotations associated with this dataset (only necessary data)
+	annotations, err := FetchAnnotations(dataset, w.Platform, w.DB)
 	if err != nil {
 		return nil, err
 	}
+
+	// Annotations left out of training are not assigned a split
+	excluded := Annotations{}
+	if model.ApprovedOnly != nil && *model.ApprovedOnly {
+		*annotations, excluded = annotations.Approved()
+		log.Debugf("training on approved annotations; approved=%d excluded=%d", annotations.Length(), excluded.Length())
+	}
+
+	// Determine dataset split
+	counts := NewSplitCounts(annotations.Length(), float64(dataset.Split.Train), float64(dataset.Split.Validation), float64(dataset.Split.Test))
 	log.Debugf("computed train splits; train=%d validation=%d test=%d", counts.TrainCount, counts.ValidationCount, counts.TestCount)
 
-	// Fetch all annotations associated with this dataset (only necessary data)
-	annotations, err := FetchAnnotations(dataset, w.Platform, w.DB)
+	// Split annotations according to the dataset's split strategy
+	train, validation, test, err := SplitAnnotations(dataset, *annotations, counts)
 	if err != nil {
 		return nil, err
 	}
-
-	// Randomly shuffle our data
-	annotations.Shuffle()
-
-	// Split annotation
-	train, validation, test := annotations.Split(counts)
 	if len(train) == 0 || len(validation) == 0 {
 		return nil, ErrNoContent
 	}
+	counts = SplitCounts{TrainCount: len(train), ValidationCount: len(validation), TestCount: len(test)}
 	log.Debugf("split annotations; train=%d validation=%d test=%d", train.Length(), validation.Length(), test.Length())
 
 	// Create manifest files according to split
-	trainManifest, trainErr := w.generateManifest(dataset, project, train, labelIntegerMap)
-	validationManifest, validationErr := w.generateManifest(dataset, project, validation, labelIntegerMap)
-	testManifest, testErr := w.generateManifest(dataset, project, test, labelIntegerMap)
+	// Manifests reference the preprocessed images; the train manifest also references the augmented copies
+	trainManifest, trainCount, trainErr := w.generateManifest(dataset, project, train, labelIntegerMap, newDerivation(model, models.SplitTrain))
+	validationManifest, _, validationErr := w.generateManifest(dataset, project, validation, labelIntegerMap, newDerivation(model, models.SplitValidation))
+	testManifest, _, testErr := w.generateManifest(dataset, project, test, labelIntegerMap, newDerivation(model, models.SplitTest))
 	if err := common.CombineErrors([]error{trainErr, validationErr, testErr}); err != nil {
 		return nil, fmt.Errorf("error occurred during manifest file generation; dataset=%s err=%s", dataset.ID.Hex(), err.Error())
 	}
+	counts.TrainCount = trainCount
+	log.Debugf("generated manifests; train-images=%d", trainCount)
 
 	// Upload manifest files!
 	if len(trainManifest) > 0 {
@@ -86,7 +93,7 @@ func (w *WorkerPool) preprocess(model *models.Model, dataset *models.Dataset, pr
 	// Update annotation splits
 	log.Debugf("updating annotation splits...")
 	updated := 0
-	for split, data := range map[models.Split]interface{}{models.SplitTrain: train, models.SplitValidation: validation, models.SplitTest: test} {
+	for split, data := range map[models.Split]interface{}{models.SplitTrain: train, models.SplitValidation: validation, models.SplitTest: test, models.SplitUndefined: excluded} {
 		data := data.(Annotations)
 		for i := 0; i < len(data); i++ {
 			annotation := data[i]
@@ -107,50 +114,28 @@ func (w *WorkerPool) preprocess(model *models.Model, dataset *models.Dataset, pr
 	return &counts, nil
 }
 
-func (w *WorkerPool) generateManifest(dataset *models.Dataset, project *models.Project, annotations Annotations, labelIntegerMap map[string]int) ([]byte, error) {
+// generateManifest writes a manifest entry for each image derived from the annotated content and returns the
+// manifest with its number of entries
+func (w *WorkerPool) generateManifest(dataset *models.Dataset, project *models.Project, annotations Annotations, labelIntegerMap map[string]int, d derivation) ([]byte, int, error) {
 	tagCache := make(map[string]models.Tag) // maps tagid -> tag
 	writer := bytes.NewBufferString("")
+	entries := 0
 
 	log.Debugf("generating manifest for %d annotations", len(annotations))
 
-loop:
 	for i := 0; i < len(annotations); i++ {
 		var content *models.Content
 
 		// Fetch content associated with annotation
 		content, err := w.Platform.ContentDB.View(w.DB, dataset.UserID, annotations[i].ContentID)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error retrieving content from database; user=%s content=%s", dataset.UserID, annotations[i].ContentID)
-		}
-
-		s3Path := "s3://" + path.Join(content.StoredDir, content.StoredPath)
-
-		// Create an entry for each content-tag pair
-		if len(annotations[i].TagIDs) == 0 { // Nil annotation
-			if project.AnnotationType == models.ProjectAnnotationTypeClassification.String() {
-				labels := classLabels(len(labelIntegerMap), make(map[int]struct{}))
-				entry, err := train.NewClassificationManifest(s3Path, labels).ToJSON()
-				if err != nil {
-					return nil, err
-				}
-				if _, err := writer.WriteString(string(entry) + "\n"); err != nil {
-					return nil, err
-				}
-			} else {
-				imageSize := []int{content.Width, content.Height, 3}
-				entry, err := train.NewObjectDetectionManifest(s3Path, common.ReverseMapStringInt(labelIntegerMap), imageSize, [][]float32{}).ToJSON()
-				if err != nil {
-					return nil, err
-				}
-				if _, err := writer.WriteString(string(entry) + "\n"); err != nil {
-					return nil, err
-				}
-			}
-			continue loop
+			return nil, 0, errors.Wrapf(err, "error retrieving content from database; user=%s content=%s", dataset.UserID, annotations[i].ContentID)
 		}
 
+		// A nil annotation has no tags
 		labelIndices := make(map[int]struct{})
-		boundingBoxes := [][]float32{}
+		tagIndices := make(map[string]int)
+		boxes := []image.BoundingBox{}
 
 		for j := 0; j < len(annotations[i].TagIDs); j++ {
 			tagid := annotations[i].TagIDs[j]
@@ -160,7 +145,7 @@ loop:
 			if !ok {
 				tag, err = w.Platform.TagDB.View(w.DB, dataset.UserID, tagid)
 				if err != nil {
-					return nil, errors.Wrapf(err, "error retrieving tag from database; tag=%s annotation=%s", tagid, annotations[i].ID.Hex())
+					return nil, 0, errors.Wrapf(err, "error retrieving tag from database; tag=%s annotation=%s", tagid, annotations[i].ID.Hex())
 				}
 				tagCache[tagid] = tag
 			}
@@ -168,60 +153,57 @@ loop:
 			idx, ok := labelIntegerMap[tag.Name]
 			if !ok {
 				l, _ := json.Marshal(labelIntegerMap) // intentionally ignored
-				return nil, errors.Wrapf(err, "error looking up content's tag name against current label map; tag=%s, label-map=%s", tagid, string(l))
+				return nil, 0, errors.Wrapf(err, "error looking up content's tag name against current label map; tag=%s, label-map=%s", tagid, string(l))
 			}
 
 			labelIndices[idx] = struct{}{}
-			metadata := annotationMetadata(annotations[i], idx, tagid)
-			boundingBoxes = append(boundingBoxes, metadata...)
+			tagIndices[tagid] = idx
+			boxes = append(boxes, annotationBoxes(annotations[i], tagid)...)
 		}
 
-		if project.AnnotationType == models.ProjectAnnotationTypeClassification.String() {
-			labels := classLabels(len(labelIntegerMap), labelIndices)
-			entry, err := train.NewClassificationManifest(s3Path, labels).ToJSON()
-			if err != nil {
-				return nil, err
-			}
-			if _, err := writer.WriteString(string(entry) + "\n"); err != nil {
-				return nil, err
+		images, err := w.deriveImages(d, content, boxes)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Create an entry for each image
+		for _, img := range images {
+			var entry []byte
+			if project.AnnotationType == models.ProjectAnnotationTypeClassification.String() {
+				labels := train.ClassLabels(len(labelIntegerMap), labelIndices)
+				entry, err = train.NewClassificationManifest(img.Path, labels).ToJSON()
+			} else {
+				imageSize := []int{img.Width, img.Height, 3}
+				entry, err = train.NewObjectDetectionManifest(img.Path, common.ReverseMapStringInt(labelIntegerMap), imageSize, annotationMetadata(img.Boxes, tagIndices)).ToJSON()
 			}
-		} else {
-			imageSize := []int{content.Width, content.Height, 3}
-			entry, err := train.NewObjectDetectionManifest(s3Path, common.ReverseMapStringInt(labelIntegerMap), imageSize, boundingBoxes).ToJSON()
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 			if _, err := writer.WriteString(string(entry) + "\n"); err != nil {
-				return nil, err
+				return nil, 0, err
 			}
+			entries++
 		}
-
 	}
-	return writer.Bytes(), nil
+	return writer.Bytes(), entries, nil
 }
 
-// annotationMetadata is a helper function for retrieving and formatting an annotations bounding box metadata
-func annotationMetadata(annotation *Annotation, classID int, tagid string) (formattedBoundingBoxes [][]float32) {
+// annotationBoxes is a helper function for retrieving an annotation's bounding boxes of a tag
+func annotationBoxes(annotation *Annotation, tagid string) (boxes []image.BoundingBox) {
 	for _, boundingBox := range annotation.Metadata.BoundingBoxes {
 		if boundingBox.TagID == tagid {
-			left, top, width, height := boundingBox.ToTopLeftWidthHeightFormat()
-			formattedBoundingBoxes = append(formattedBoundingBoxes, []float32{float32(classID), float32(left), float32(top), float32(width), float32(height)})
+			boxes = append(boxes, image.BoundingBox{Xmin: boundingBox.Xmin, Ymin: boundingBox.Ymin, Xmax: boundingBox.Xmax, Ymax: boundingBox.Ymax, ClassName: tagid})
 		}
 	}
 	return
 }
 
-// classLabels is a helper function for creating a multi-hot formatted string of labels
-// If for example, there are 10 classes and the image is labeld for the the first and 5th class -> classLabels(10, map[int]struct{}{0: {}, 5: {}}
-func classLabels(len int, labels map[int]struct{}) string {
-	a := make([]string, len)
-	for i := 0; i < len; i++ {
-		if _, ok := labels[i]; ok {
-			a[i] = "1"
-		} else {
-			a[i] = "0"
-		}
+// annotationMetadata is a helper function for formatting bounding boxes as manifest annotations
+func annotationMetadata(boxes []image.BoundingBox, tagIndices map[string]int) [][]float32 {
+	formattedBoundingBoxes := [][]float32{}
+	for _, box := range boxes {
+		left, top, width, height := box.Xmin, box.Ymin, box.Xmax-box.Xmin, box.Ymax-box.Ymin
+		formattedBoundingBoxes = append(formattedBoundingBoxes, []float32{float32(tagIndices[box.ClassName]), float32(left), float32(top), float32(width), float32(height)})
 	}
-
-	return "[" + strings.Join(a, ",") + "]"
+	return formattedBoundingBoxes
 }
@@ -0,0 +1,68 @@
+/*
+ * File: reconcile.go
+ * Project: train
+ * File Created: Wednesday, 14th February 2024 3:27:10 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 14th February 2024 3:27:10 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"time"
+
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+const (
+	// Versions pending, deleting or restoring without a heartbeat for longer than this are assumed to have been
+	// interrupted. Copies still in progress, on this or other replicas, refresh theirs every DatasetCopyHeartbeat.
+	DatasetCopyTimeout = 5 * platform.DatasetCopyHeartbeat
+	// Interval at which interrupted dataset versions are looked for
+	DatasetReconcileInterval = 5 * time.Minute
+)
+
+// reconcile periodically cleans up dataset versions left behind by a crash during a copy, a restore or a removal.
+// A pending copy belongs to a train job or restore that never completed, so no model references it and it is
+// removed; a deleting version is finished off, as is a restore whose labels were copied.
+func (w *WorkerPool) reconcile() {
+	ticker := time.NewTicker(DatasetReconcileInterval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		datasets, err := w.Platform.DatasetDB.FindIncomplete(w.DB, time.Now().Add(-DatasetCopyTimeout))
+		if err != nil {
+			log.Errorf("unable to find incomplete dataset versions; error=%s", err.Error())
+			continue
+		}
+
+		for _, dataset := range datasets {
+			dataset := dataset
+			claimed, err := w.Platform.DatasetDB.ClaimIncomplete(w.DB, dataset)
+			if err != nil {
+				log.Errorf("unable to claim incomplete dataset version; dataset=%s error=%s", dataset.ID.Hex(), err.Error())
+				continue
+			}
+			if !claimed {
+				// Another replica picked up the version, or the copy is still in progress
+				continue
+			}
+
+			switch dataset.CopyState {
+			case models.DatasetCopyStateRestoring.String(), models.DatasetCopyStateSwapping.String():
+				log.Infof("finishing interrupted dataset restore; dataset=%s project=%s restore-to=%s state=%s", dataset.ID.Hex(), dataset.ProjectID, dataset.RestoreTo, dataset.CopyState)
+				if err := w.Platform.DatasetDB.FinishRestore(w.DB, &dataset); err != nil {
+					log.Errorf("unable to finish dataset restore; dataset=%s error=%s", dataset.ID.Hex(), err.Error())
+				}
+			default:
+				log.Infof("removing incomplete dataset version; dataset=%s project=%s version=%d state=%s", dataset.ID.Hex(), dataset.ProjectID, dataset.Version, dataset.CopyState)
+				if err := w.Platform.DatasetDB.DeleteVersion(w.DB, dataset.UserID, dataset.ID); err != nil {
+					log.Errorf("unable to remove incomplete dataset version; dataset=%s error=%s", dataset.ID.Hex(), err.Error())
+				}
+			}
+		}
+	}
+}
@@ -10,6 +10,8 @@
 package train
 
 import (
+	"sort"
+
 	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
@@ -29,10 +31,15 @@ func GetSplitCounts(dataset *models.Dataset, trainSplit, validationSplit, testSp
 		return SplitCounts{}, err
 	}
 
-	trainCount := int(trainSplit * float64(*annotationCount))
-	validationCount := int(validationSplit * float64(*annotationCount))
+	return NewSplitCounts(int(*annotationCount), trainSplit, validationSplit, testSplit), nil
+}
+
+// NewSplitCounts divides a number of annotations into train/validation/test counts
+func NewSplitCounts(annotationCount int, trainSplit, validationSplit, testSplit float64) SplitCounts {
+	trainCount := int(trainSplit * float64(annotationCount))
+	validationCount := int(validationSplit * float64(annotationCount))
 	testCount := 0
-	remaining := int(*annotationCount) - int(trainCount) - int(validationCount)
+	remaining := annotationCount - int(trainCount) - int(validationCount)
 	if testSplit == 0 {
 		trainCount += remaining
 	} else {
@@ -43,5 +50,153 @@ func GetSplitCounts(dataset *models.Dataset, trainSplit, validationSplit, testSp
 		trainCount,
 		validationCount,
 		testCount,
-	}, nil
+	}
+}
+
+// SplitAnnotations assigns annotations to train, validation and test according to the split strategy of the dataset.
+// The assignment only depends on the dataset's seed and split settings and the annotations themselves.
+func SplitAnnotations(dataset *models.Dataset, annotations Annotations, counts SplitCounts) (train, validation, test Annotations, err error) {
+	strategy, err := models.SplitStrategyFromString(dataset.SplitStrategy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	annotations = append(Annotations{}, annotations...)
+	annotations.Sort()
+	annotations.Shuffle(dataset.SplitSeed)
+
+	switch strategy {
+	case models.SplitStrategyStratified:
+		train, validation, test = stratifiedSplit(annotations, [3]float64{dataset.Split.Train, dataset.Split.Validation, dataset.Split.Test})
+	case models.SplitStrategyManual:
+		train, validation, test = manualSplit(annotations, counts)
+	default:
+		train, validation, test = annotations.Split(counts)
+	}
+
+	return train, validation, test, nil
+}
+
+// stratifiedSplit splits the annotations of each tag separately. Annotations with several tags are grouped under
+// their rarest tag so that rare tags are spread across the splits.
+func stratifiedSplit(annotations Annotations, ratios [3]float64) (train, validation, test Annotations) {
+	frequency := make(map[string]int)
+	for _, annotation := range annotations {
+		for tagid := range annotation.tags() {
+			frequency[tagid]++
+		}
+	}
+
+	groups := make(map[string]Annotations)
+	for _, annotation := range annotations {
+		key := "" // null annotations
+		for tagid := range annotation.tags() {
+			if key == "" || frequency[tagid] < frequency[key] || (frequency[tagid] == frequency[key] && tagid < key) {
+				key = tagid
+			}
+		}
+		groups[key] = append(groups[key], annotation)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+		c := allocate(len(group), ratios)
+		train = append(train, group[:c[0]]...)
+		validation = append(validation, group[c[0]:c[0]+c[1]]...)
+		test = append(test, group[c[0]+c[1]:]...)
+	}
+
+	return train, validation, test
+}
+
+// allocate divides n annotations between train, validation and test in proportion to ratios, handing out what is
+// left after rounding down by largest remainder. Train and validation get at least one annotation each when n allows.
+func allocate(n int, ratios [3]float64) [3]int {
+	var counts [3]int
+	var remainders [3]float64
+
+	total := 0
+	for i, ratio := range ratios {
+		exact := float64(n) * ratio
+		counts[i] = int(exact)
+		remainders[i] = exact - float64(counts[i])
+		total += counts[i]
+	}
+	for ; total < n; total++ {
+		best := 0
+		for i := range remainders {
+			if ratios[i] > 0 && remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		counts[best]++
+		remainders[best]--
+	}
+
+	for i := 0; i < 2 && n >= 2; i++ {
+		if counts[i] > 0 || ratios[i] == 0 {
+			continue
+		}
+		// Take from the largest split, preferring test on ties
+		donor := 2
+		for j := 1; j >= 0; j-- {
+			if counts[j] > counts[donor] {
+				donor = j
+			}
+		}
+		counts[donor]--
+		counts[i]++
+	}
+
+	return counts
+}
+
+// manualSplit keeps the splits set on annotations and fills the remainder of each split's count, in order,
+// with the annotations that have none. Annotations left over go to train.
+func manualSplit(annotations Annotations, counts SplitCounts) (train, validation, test Annotations) {
+	unassigned := Annotations{}
+	for _, annotation := range annotations {
+		switch annotation.Split {
+		case models.SplitTrain.String():
+			train = append(train, annotation)
+		case models.SplitValidation.String():
+			validation = append(validation, annotation)
+		case models.SplitTest.String():
+			test = append(test, annotation)
+		default:
+			unassigned = append(unassigned, annotation)
+		}
+	}
+
+	fill := func(split Annotations, count int) Annotations {
+		for len(split) < count && len(unassigned) > 0 {
+			split = append(split, unassigned[0])
+			unassigned = unassigned[1:]
+		}
+		return split
+	}
+	train = fill(train, counts.TrainCount)
+	validation = fill(validation, counts.ValidationCount)
+	test = fill(test, counts.TestCount)
+	train = append(train, unassigned...)
+
+	return train, validation, test
+}
+
+// tags returns the tags of an annotation, including those of its bounding boxes
+func (a *Annotation) tags() map[string]struct{} {
+	tags := make(map[string]struct{})
+	for _, tagid := range a.TagIDs {
+		tags[tagid] = struct{}{}
+	}
+	for _, box := range a.Metadata.BoundingBoxes {
+		tags[box.TagID] = struct{}{}
+	}
+	return tags
 }
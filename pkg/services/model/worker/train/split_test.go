@@ -0,0 +1,104 @@
+/*
+ * File: split_test.go
+ * Project: train
+ * File Created: Thursday, 15th February 2024 11:05:32 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Thursday, 15th February 2024 11:05:32 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func testAnnotations(tags ...string) Annotations {
+	annotations := Annotations{}
+	for i, tag := range tags {
+		annotations = append(annotations, &Annotation{ContentID: fmt.Sprintf("content-%03d", i), TagIDs: []string{tag}, Split: models.SplitUndefined.String()})
+	}
+	return annotations
+}
+
+func contentIDs(annotations Annotations) []string {
+	ids := []string{}
+	for _, annotation := range annotations {
+		ids = append(ids, annotation.ContentID)
+	}
+	return ids
+}
+
+func TestAllocate(t *testing.T) {
+	assert.Equal(t, [3]int{8, 2, 0}, allocate(10, [3]float64{.75, .25, 0}))
+	assert.Equal(t, [3]int{7, 2, 1}, allocate(10, [3]float64{.7, .2, .1}))
+	// Rare tags still reach validation
+	assert.Equal(t, [3]int{1, 1, 0}, allocate(2, [3]float64{.7, .2, .1}))
+	assert.Equal(t, [3]int{1, 0, 0}, allocate(1, [3]float64{.75, .25, 0}))
+}
+
+func TestSplitAnnotationsDeterministic(t *testing.T) {
+	dataset := models.NewDataset("user", "project")
+	counts := SplitCounts{TrainCount: 15, ValidationCount: 5}
+
+	annotations := testAnnotations(make([]string, 20)...)
+	train, validation, _, err := SplitAnnotations(dataset, annotations, counts)
+	assert.NoError(t, err)
+
+	// Order of the annotations as read from the database does not matter
+	reversed := Annotations{}
+	for i := len(annotations) - 1; i >= 0; i-- {
+		reversed = append(reversed, annotations[i])
+	}
+	train2, validation2, _, err := SplitAnnotations(dataset, reversed, counts)
+	assert.NoError(t, err)
+
+	assert.Equal(t, contentIDs(train), contentIDs(train2))
+	assert.Equal(t, contentIDs(validation), contentIDs(validation2))
+}
+
+func TestSplitAnnotationsStratified(t *testing.T) {
+	dataset := models.NewDataset("user", "project")
+	dataset.SplitStrategy = models.SplitStrategyStratified.String()
+
+	tags := []string{}
+	for i := 0; i < 18; i++ {
+		tags = append(tags, "common")
+	}
+	tags = append(tags, "rare", "rare")
+
+	train, validation, test, err := SplitAnnotations(dataset, testAnnotations(tags...), SplitCounts{})
+	assert.NoError(t, err)
+	assert.Len(t, train, 15)
+	assert.Len(t, validation, 5)
+	assert.Len(t, test, 0)
+
+	rare := 0
+	for _, annotation := range validation {
+		if annotation.TagIDs[0] == "rare" {
+			rare++
+		}
+	}
+	assert.Equal(t, 1, rare)
+}
+
+func TestSplitAnnotationsManual(t *testing.T) {
+	dataset := models.NewDataset("user", "project")
+	dataset.SplitStrategy = models.SplitStrategyManual.String()
+
+	annotations := testAnnotations(make([]string, 10)...)
+	annotations[0].Split = models.SplitTest.String()
+	annotations[1].Split = models.SplitValidation.String()
+
+	train, validation, test, err := SplitAnnotations(dataset, annotations, SplitCounts{TrainCount: 7, ValidationCount: 2, TestCount: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"content-000"}, contentIDs(test))
+	assert.Contains(t, contentIDs(validation), "content-001")
+	assert.Len(t, validation, 2)
+	assert.Len(t, train, 7)
+}
@@ -10,33 +10,127 @@
 package train
 
 import (
+	"fmt"
+	"math"
+	"time"
+
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
-	train "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 )
 
-func (w *WorkerPool) train(config *train.Config, algorithm models.ProjectAnnotationType) (trainingJobName string, metrics map[string]interface{}, err error) {
-	// Create job spec
-	trainer, err := train.New(config, w.sagemakerClient, algorithm)
+// Time between checks of the status of a submitted training job
+var pollInterval = 5 * time.Second
+
+// trainResult describes the training job selected by hyperparameter tuning
+type trainResult struct {
+	TrainingJobName string
+	Metrics         map[string]interface{}
+	ArtifactPath    string
+	HyperParameters map[string]string
+	// Runtime billed for the job
+	BillableTimeInSeconds int32
+	// Train parameters the job was submitted with
+	Parameters models.TrainParameters
+}
+
+// train submits the job to the training backend and waits for it to complete. The job is stopped once training
+// of the model is cancelled, returning a cancelledError.
+func (w *WorkerPool) train(model *models.Model, job backend.TrainingJob) (*trainResult, error) {
+	if w.cancelled(model) {
+		return nil, &cancelledError{}
+	}
+
+	name, params, err := w.backend.Submit(job)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+
+	var status backend.TrainingStatus
+	stopping := false
+	for {
+		if !stopping && w.cancelled(model) {
+			log.Debugf("stopping training job of cancelled model; model=%s name=%s", model.ID.Hex(), name)
+			if err := w.backend.Stop(name); err != nil {
+				log.Errorf("error stopping training job; name=%s error=%s", name, err.Error())
+			}
+			stopping = true
+		}
+		if status, err = w.backend.Status(name); err != nil {
+			return nil, err
+		}
+		if status.State != backend.TrainingStateInProgress {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+
+	if stopping {
+		cancelled := &cancelledError{billableTimeInSeconds: status.BillableTimeInSeconds}
+		// The job may have completed before it could be stopped
+		if status.State == backend.TrainingStateCompleted {
+			if metrics, err := w.backend.Metrics(status.TrainingJobName); err == nil {
+				cancelled.billableTimeInSeconds = billableTime(metrics)
+			}
+		}
+		return nil, cancelled
 	}
 
-	if err := trainer.Train(config.NumClasses, config.NumTrainingSamples, config.NumValidationSamples, config.ForcePaddingLabelWidth); err != nil {
-		return "", nil, err
+	switch status.State {
+	case backend.TrainingStateFailed:
+		return nil, fmt.Errorf("training job error; error=%s", status.FailureReason)
+	case backend.TrainingStateStopped:
+		return nil, fmt.Errorf("training job stopped; name=%s", name)
 	}
-	log.Debugf("created hyperparameter tuning job: tunningJobName=%s", *trainer.Input.HyperParameterTuningJobName)
 
-	hyperParameterTrainingJobSummary, err := trainer.PollForStatus()
+	log.Debugf("training job complete: selectedTrainingJobName=%s", status.TrainingJobName)
+
+	trainingJobName := status.TrainingJobName
+	metrics, err := w.backend.Metrics(trainingJobName)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	log.Debugf("hyperparameter tuning job complete: selectedTrainingJobName=%s", *hyperParameterTrainingJobSummary.TrainingJobName)
+	artifactPath, hyperParameters, err := w.backend.Artifacts(trainingJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trainResult{
+		TrainingJobName: trainingJobName,
+		Metrics:         metrics,
+		ArtifactPath:    artifactPath,
+		HyperParameters: hyperParameters,
+		Parameters:      params,
+
+		BillableTimeInSeconds: billableTime(metrics),
+	}, nil
+}
+
+// billableTime returns the runtime billed for a training job, as reported by the "BillableTimeInSeconds" metric of
+// the backend. Jobs without the metric are not billed.
+func billableTime(metrics map[string]interface{}) int32 {
+	switch v := metrics["BillableTimeInSeconds"].(type) {
+	case int32:
+		return v
+	case int:
+		return int32(v)
+	case int64:
+		return int32(v)
+	case float64:
+		return int32(math.Ceil(v))
+	}
+	log.Errorf("training job metrics without billable time; metric=%v", metrics["BillableTimeInSeconds"])
+	return 0
+}
 
-	metrics, err = trainer.Metrics(*hyperParameterTrainingJobSummary.TrainingJobName)
+// cancelled reports whether training of the model was cancelled since it started. A run superseded by training
+// queued again after a cancellation counts as cancelled.
+func (w *WorkerPool) cancelled(model *models.Model) bool {
+	current, err := w.Platform.ModelDB.View(w.DB, model.UserID, model.ID.Hex())
 	if err != nil {
-		return "", nil, err
+		log.Errorf("unable to view model to check for cancellation; model=%s error=%s", model.ID.Hex(), err.Error())
+		return false
 	}
-	return *hyperParameterTrainingJobSummary.TrainingJobName, metrics, nil
+	return current.State == models.ModelStateCancelled.String() || current.TrainRunID != model.TrainRunID
 }
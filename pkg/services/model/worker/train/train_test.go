@@ -0,0 +1,44 @@
+/*
+ * File: train_test.go
+ * Project: train
+ * File Created: Monday, 26th February 2024 9:12:40 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 26th February 2024 9:12:40 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package train
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+)
+
+func TestBillableTime(t *testing.T) {
+	require.NoError(t, log.New(log.Configuration{EnableConsole: true, Level: log.Fatal}, log.InstanceZapLogger))
+
+	for _, tc := range []struct {
+		name  string
+		value interface{}
+		want  int32
+	}{
+		{"int32", int32(42), 42},
+		{"int", 42, 42},
+		{"int64", int64(42), 42},
+		{"float64 rounded up", 41.2, 42},
+		{"missing", nil, 0},
+		{"not a number", "42", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := map[string]interface{}{"validation:accuracy": 0.9}
+			if tc.value != nil {
+				metrics["BillableTimeInSeconds"] = tc.value
+			}
+			assert.Equal(t, tc.want, billableTime(metrics))
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sagemaker"
+	"github.com/aws/aws-sdk-go-v2/service/sagemakerruntime"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -30,8 +31,13 @@ import (
 	runtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/runtime"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
 	modelConfig "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/config"
+	localRealtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/realtime"
+	localTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/local/train"
 	sage "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage"
+	realtime "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/realtime"
+	sageTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
 )
 
 type WorkerPool struct {
@@ -41,8 +47,13 @@ type WorkerPool struct {
 	Config   modelConfig.Configuration
 
 	sagemakerClient *sagemaker.Client
+	inference       *backend.Inference
 	consumer        sqs.Consumer
 	mail            *mail.SGModelMailService
+
+	// Backend models are trained on, and its name
+	backend     backend.TrainingBackend
+	backendName string
 }
 
 func New(
@@ -83,19 +94,43 @@ func New(
 		cfg.ModelService.TrainConfig.SendGrid.EmailSubject,
 	)
 
+	sagemakerClient := sagemaker.NewFromConfig(awsConfig)
+
+	var trainingBackend backend.TrainingBackend
+	backendName := cfg.ModelService.TrainBackend
+	switch backendName {
+	case "", backend.SageMaker:
+		backendName = backend.SageMaker
+		trainingBackend = sageTrain.NewBackend(&cfg.ModelService.TrainConfig, sagemakerClient)
+	case backend.Local:
+		trainingBackend = localTrain.NewBackend(&cfg.ModelService.LocalTrainConfig, blob, blob.Bucket)
+	default:
+		return nil, fmt.Errorf("unknown train backend; backend=%s", backendName)
+	}
+
 	return &WorkerPool{
 		Blob:     blob,
 		DB:       db,
 		Platform: platform,
 		Config:   cfg,
 
-		sagemakerClient: sagemaker.NewFromConfig(awsConfig),
-		consumer:        consumer,
-		mail:            mail,
+		sagemakerClient: sagemakerClient,
+		inference: &backend.Inference{
+			SageMaker: realtime.NewBackend(sagemakerruntime.NewFromConfig(awsConfig)),
+			Local:     localRealtime.NewBackend(&cfg.ModelService.LocalInferenceConfig, blob),
+		},
+		consumer: consumer,
+		mail:     mail,
+
+		backend:     trainingBackend,
+		backendName: backendName,
 	}, nil
 }
 
 func (w *WorkerPool) Start() {
+	// Clean up dataset versions interrupted by a crash, here or on another replica
+	go w.reconcile()
+
 	// Start subscriber
 	log.Infof("starting subscriber loop on queue=%s", w.Config.ModelService.TrainJobQueueName)
 	go w.consumer.Consume(w.callback)
@@ -130,14 +165,18 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		return nil
 	}
 
-	// Move model to 'TRAINING' status
-	if err := w.Platform.ModelDB.Update(w.DB, models.Model{
+	// Move model to 'TRAINING' status, unless cancelled or queued again since
+	if err := w.Platform.ModelDB.Transition(w.DB, models.Model{
 		ID:             model.ID,
 		UserID:         model.UserID,
 		State:          models.ModelStateTraining.String(),
 		TrainStartedAt: time.Now(),
 		LastError:      aws.String(""),
-	}); err != nil {
+	}, model.TrainRunID, models.ModelStateInitialized); err != nil {
+		if err == platform.ErrModelStateChanged {
+			log.Debugf("model no longer queued for training; model=%s", model.ID.Hex())
+			return nil
+		}
 		log.Errorf("error updating model=%s; error=%s", model.ID.Hex(), err.Error())
 		return w.updateOnErrorState(err, &model, nil)
 	}
@@ -179,7 +218,7 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		log.Errorf("error during data preprocessing for train event; model=%s error=%s", event.ModelID, err.Error())
 		return w.updateOnErrorState(err, &model, &versionedDataset.ID)
 	}
-	log.Debugf("Preprocessing complete for dataset=%s; starting training on %d annotations", versionedDataset.ID.Hex(), counts.TrainCount)
+	log.Debugf("Preprocessing complete for dataset=%s; starting training on %d images", versionedDataset.ID.Hex(), counts.TrainCount)
 
 	// Get max number of annotations in a single image for dataset to compute force padding label width
 	var results []struct {
@@ -196,17 +235,6 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		}
 	}
 
-	// Prepare config i.e. update dynamic settings
-	cfg := w.Config.ModelService.TrainConfig
-	cfg.OutputDataPath = model.Path
-	cfg.NumClasses = int32(len(labelIntegerMap))
-	cfg.NumTrainingSamples = int32(counts.TrainCount)
-	cfg.NumValidationSamples = int32(counts.ValidationCount)
-	// See https://docs.aws.amazon.com/sagemaker/latest/dg/object-detection-api-config.html for how ForcePaddingLabelWidth is computed
-	if project.AnnotationType == models.ProjectAnnotationTypeBoundingBox.String() {
-		cfg.ForcePaddingLabelWidth = int32(math.Max(350, float64(results[0].MaxBoundingBoxes)*5+2)) // 350 == default label_width
-	}
-
 	// Determine project type
 	projectType, err := models.ProjectAnnotationTypeFromString(project.AnnotationType)
 	if err != nil {
@@ -214,8 +242,27 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		return w.updateOnErrorState(err, &model, &versionedDataset.ID)
 	}
 
+	// Describe the job i.e. dynamic settings
+	job := backend.TrainingJob{
+		Algorithm:            projectType,
+		Parameters:           model.Parameters,
+		OutputDataPath:       model.Path,
+		NumClasses:           int32(len(labelIntegerMap)),
+		NumTrainingSamples:   int32(counts.TrainCount),
+		NumValidationSamples: int32(counts.ValidationCount),
+	}
+	// See https://docs.aws.amazon.com/sagemaker/latest/dg/object-detection-api-config.html for how ForcePaddingLabelWidth is computed
+	if project.AnnotationType == models.ProjectAnnotationTypeBoundingBox.String() {
+		job.ForcePaddingLabelWidth = int32(math.Max(350, float64(results[0].MaxBoundingBoxes)*5+2)) // 350 == default label_width
+	}
+
 	// Train model
-	trainingJobName, metrics, err := w.train(&cfg, projectType)
+	result, err := w.train(&model, job)
+	var cancelled *cancelledError
+	if errors.As(err, &cancelled) {
+		log.Infof("training cancelled; model=%s", model.ID.Hex())
+		return w.updateOnCancelledState(&model, &versionedDataset.ID, cancelled.billableTimeInSeconds)
+	}
 	if err != nil {
 		log.Errorf("error during train; model=%s error=%s", model.ID.Hex(), err.Error())
 		// Send training failed email notification
@@ -224,17 +271,25 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		}
 		return w.updateOnErrorState(err, &model, &versionedDataset.ID)
 	}
+	metrics := result.Metrics
 	metrics["ObjectiveMetricName"] = sage.ObjectiveMetricName(projectType)
 
-	// Dummy metrics
-	if projectType == models.ProjectAnnotationTypeClassification {
-		metrics["test:accuracy"] = "-"
-		metrics["test:precision"] = "-"
-		metrics["test:recall"] = "-"
-	} else if projectType == models.ProjectAnnotationTypeBoundingBox {
-		metrics["test:mAP"] = "-"
-		metrics["test:precision"] = "-"
-		metrics["test:recall"] = "-"
+	// Evaluate the trained model against the held-out test split
+	if counts.TestCount > 0 {
+		testMetrics, err := w.evaluate(versionedDataset, projectType, result, labelIntegerMap, model.Preprocessing)
+		if err != nil {
+			log.Errorf("error evaluating model on test split; model=%s error=%s", model.ID.Hex(), err.Error())
+			metrics["test:error"] = err.Error()
+		}
+		for name, value := range testMetrics {
+			metrics[name] = value
+		}
+	}
+
+	// Training may have been cancelled during evaluation
+	if w.cancelled(&model) {
+		log.Infof("training cancelled; model=%s", model.ID.Hex())
+		return w.updateOnCancelledState(&model, &versionedDataset.ID, result.BillableTimeInSeconds)
 	}
 
 	// Send training success email notification
@@ -242,33 +297,31 @@ func (w *WorkerPool) callback(msg *sqs.Message) error {
 		log.Errorf("Unable to send training complete email to username=%s", user.Username)
 	}
 
-	// Update success status
-	if err := w.Platform.ModelDB.Update(w.DB, models.Model{
-		ID:              model.ID,
-		UserID:          model.UserID,
-		DatasetID:       versionedDataset.ID.Hex(), // update model with new versioned/locked dataset
-		State:           models.ModelStateTrained.String(),
-		TrainStartedAt:  time.Now(),
-		LastError:       aws.String(""),
-		Metrics:         metrics,
-		IntegerMapping:  labelIntegerMap, // only update on success
-		TrainingJobName: trainingJobName, // only update on success
-	}); err != nil {
+	// Update success status, unless cancelled since
+	if err := w.Platform.ModelDB.Transition(w.DB, models.Model{
+		ID:                model.ID,
+		UserID:            model.UserID,
+		DatasetID:         versionedDataset.ID.Hex(), // update model with new versioned/locked dataset
+		State:             models.ModelStateTrained.String(),
+		TrainStartedAt:    time.Now(),
+		LastError:         aws.String(""),
+		Metrics:           metrics,
+		IntegerMapping:    labelIntegerMap,        // only update on success
+		TrainingJobName:   result.TrainingJobName, // only update on success
+		TrainingBackend:   w.backendName,
+		ArtifactPath:      result.ArtifactPath,
+		HyperParameters:   result.HyperParameters,
+		TrainedParameters: &result.Parameters,
+	}, model.TrainRunID, models.ModelStateTraining); err != nil {
+		if err == platform.ErrModelStateChanged {
+			log.Infof("training cancelled; model=%s", model.ID.Hex())
+			return w.updateOnCancelledState(&model, &versionedDataset.ID, result.BillableTimeInSeconds)
+		}
 		return w.updateOnErrorState(errors.Wrapf(err, "error updating model=%s after train success", model.ID.Hex()), &model, &versionedDataset.ID)
 	}
 
 	// Update at user level
-	var resourceMap map[string]interface{}
-	resourceBytes, _ := json.Marshal(w.Config.ModelService.TrainConfig.Resource)
-	json.Unmarshal(resourceBytes, &resourceMap)
-
-	w.Platform.UserDB.AddUsage(w.DB, model.UserID, models.Usage{
-		Time:          time.Now().UTC().Format(time.RFC3339),
-		Type:          models.UsageTypeTrain,
-		BillingMetric: models.BillingMetricSecond,
-		BillableValue: float64(metrics["BillableTimeInSeconds"].(int32)),
-		Metadata:      resourceMap,
-	})
+	w.addUsage(&model, result.BillableTimeInSeconds)
 
 	return nil
 }
@@ -284,31 +337,70 @@ func (w *WorkerPool) updateOnErrorState(err error, model *models.Model, versione
 		TrainEndedAt: time.Now(),
 		LastError:    aws.String(err.Error()),
 	}
-	if err := w.Platform.ModelDB.Update(w.DB, update); err != nil {
+	// The model is left as is once cancelled or queued again; the dataset version is still this run's to remove
+	if err := w.Platform.ModelDB.Transition(w.DB, update, model.TrainRunID, models.ModelStateInitialized, models.ModelStateTraining); err != nil && err != platform.ErrModelStateChanged {
 		log.Errorf("error updating model=%s after train failure; err=%s", model.ID.Hex(), err.Error())
 		return err
 	}
 
 	if versionedDatasetId != nil {
-		// Remove versioned dataset
-		if err := w.Platform.DatasetDB.Delete(w.DB, *versionedDatasetId); err != nil {
+		// Remove versioned dataset along with its annotations and tags
+		if err := w.Platform.DatasetDB.DeleteVersion(w.DB, model.UserID, *versionedDatasetId); err != nil {
 			log.Errorf("error deleting versioned dataset after train failure; dataset=%s after train error; error=%s", versionedDatasetId.Hex(), err.Error())
 			return err
 		}
-		// Delete versioned annotations
-		if err := w.Platform.AnnotationDB.DeleteDatasetAnnotations(w.DB, model.UserID, model.ProjectID, versionedDatasetId.Hex()); err != nil {
-			log.Errorf("error deleting versioned dataset annotations after train failure; dataset=%s after train error; error=%s", versionedDatasetId.Hex(), err.Error())
-		}
+	}
 
-		// Delete versioned tags.
-		if err := w.Platform.TagDB.DeleteDatasetTags(w.DB, model.UserID, model.ProjectID, versionedDatasetId.Hex()); err != nil {
-			log.Errorf("error deleting versioned dataset tags after train failure; dataset=%s after train error; error=%s", versionedDatasetId.Hex(), err.Error())
+	return nil
+}
+
+// updateOnCancelledState is a helper method for cleaning up after training was cancelled; the time trained so far
+// is billed
+func (w *WorkerPool) updateOnCancelledState(model *models.Model, versionedDatasetId *primitive.ObjectID, billableTimeInSeconds int32) error {
+	// The model is left as is once queued again; the dataset version is still this run's to remove
+	if err := w.Platform.ModelDB.Transition(w.DB, models.Model{
+		ID:           model.ID,
+		UserID:       model.UserID,
+		State:        models.ModelStateCancelled.String(),
+		TrainEndedAt: time.Now(),
+	}, model.TrainRunID, models.ModelStateInitialized, models.ModelStateTraining, models.ModelStateCancelled); err != nil && err != platform.ErrModelStateChanged {
+		log.Errorf("error updating model=%s after train cancellation; err=%s", model.ID.Hex(), err.Error())
+		return err
+	}
+
+	if versionedDatasetId != nil {
+		// Remove versioned dataset along with its annotations and tags
+		if err := w.Platform.DatasetDB.DeleteVersion(w.DB, model.UserID, *versionedDatasetId); err != nil {
+			log.Errorf("error deleting versioned dataset after train cancellation; dataset=%s error=%s", versionedDatasetId.Hex(), err.Error())
+			return err
 		}
 	}
 
+	if billableTimeInSeconds > 0 {
+		w.addUsage(model, billableTimeInSeconds)
+	}
+
 	return nil
 }
 
+// addUsage records the training time of the model at user level
+func (w *WorkerPool) addUsage(model *models.Model, billableTimeInSeconds int32) {
+	resourceMap := make(map[string]interface{})
+	if w.backendName == backend.SageMaker {
+		resourceBytes, _ := json.Marshal(w.Config.ModelService.TrainConfig.Resource)
+		json.Unmarshal(resourceBytes, &resourceMap)
+	}
+	resourceMap["backend"] = w.backendName
+
+	w.Platform.UserDB.AddUsage(w.DB, model.UserID, models.Usage{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		Type:          models.UsageTypeTrain,
+		BillingMetric: models.BillingMetricSecond,
+		BillableValue: float64(billableTimeInSeconds),
+		Metadata:      resourceMap,
+	})
+}
+
 func (w *WorkerPool) sendTrainingResultEmail(username, userEmail, projectName, modelName, status string) error {
 	mailType := mail.TrainingConfirmation
 	mailData := &mail.ModelMailData{
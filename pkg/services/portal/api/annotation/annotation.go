@@ -2,6 +2,8 @@
 package annotation
 
 import (
+	"math"
+	"net/http"
 	"reflect"
 	"strings"
 
@@ -26,7 +28,19 @@ const (
 
 // Create creates a new annotation
 func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize int) (*models.Annotation, error) {
-	var annotation *models.Annotation
+	return a.save(c, req, thumbnailSize, req.UserID, false)
+}
+
+// CreateBy creates a new annotation of the requesting user on behalf of them by another user, e.g. a labeller of
+// one of their labelling tasks. The change is recorded as made by authorid.
+func (a Annotation) CreateBy(c echo.Context, req models.Annotation, thumbnailSize int, authorid string) (*models.Annotation, error) {
+	return a.save(c, req, thumbnailSize, authorid, false)
+}
+
+// save creates or updates the annotation of the requested content and records the change, made by authorid, in
+// the annotation's history. A reverted annotation that no longer exists is recreated with its original ID.
+func (a Annotation) save(c echo.Context, req models.Annotation, thumbnailSize int, authorid string, revert bool) (*models.Annotation, error) {
+	var annotation, previous *models.Annotation
 
 	// Check project exists
 	project, err := a.platform.ProjectDB.View(a.db, req.UserID, req.ProjectID)
@@ -39,6 +53,8 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 		return nil, err
 	} else if dataset.Locked {
 		return nil, echo.NewHTTPError(409, "Dataset Locked")
+	} else if dataset.Restoring != "" {
+		return nil, platform.ErrDatasetRestoring
 	}
 
 	// Check content exists
@@ -53,10 +69,13 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 	}
 
 	// Check tags exists
-	for _, tag := range req.TagIDs {
-		if _, err := a.platform.TagDB.View(a.db, req.UserID, tag); err != nil {
+	tags := make(map[string]models.Tag, len(req.TagIDs))
+	for _, tagid := range req.TagIDs {
+		tag, err := a.platform.TagDB.View(a.db, req.UserID, tagid)
+		if err != nil {
 			return nil, err
 		}
+		tags[tagid] = tag
 	}
 
 	// Check annotation exists
@@ -73,15 +92,26 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 
 	// Add or update annotations
 	if annotation != nil {
+		previous = common.Ptr(*annotation)
 
 		// Update annotation fields
 		annotation.TagIDs = req.TagIDs
 		annotation.Metadata = req.Metadata
+		annotation.Metadata.SetAreas()
+		if req.Split != "" {
+			annotation.Split = req.Split
+		}
+
+		// Relabelled annotations have to be reviewed again
+		annotation.Status = relabelledStatus(previous, annotation, req.Status)
 
 		// Validate
 		if err := annotation.Valid(project.AnnotationType); err != nil {
 			return nil, err
 		}
+		if err := annotation.ValidSkeletons(tags); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 
 		// Update Base64 img.
 		contentBytes, err := a.blob.Get(content.StoredDir, content.StoredPath)
@@ -115,7 +145,12 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 				})
 			}
 		}
-		imgBase64, err := createBase64IMG(contentBytes, boundingBoxes, project.AnnotationType, thumbnailSize)
+		boundingBoxes = append(boundingBoxes, keypointBoxes(req.Metadata, tags)...)
+		polygons, masks, err := a.segments(content.UserID, req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		imgBase64, err := createBase64IMG(contentBytes, boundingBoxes, polygons, masks, project.AnnotationType, thumbnailSize)
 		if err != nil {
 			return nil, err
 		}
@@ -162,7 +197,12 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 				})
 			}
 		}
-		imgBase64, err := createBase64IMG(contentBytes, boundingBoxes, project.AnnotationType, thumbnailSize)
+		boundingBoxes = append(boundingBoxes, keypointBoxes(req.Metadata, tags)...)
+		polygons, masks, err := a.segments(content.UserID, req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		imgBase64, err := createBase64IMG(contentBytes, boundingBoxes, polygons, masks, project.AnnotationType, thumbnailSize)
 		if err != nil {
 			return nil, err
 		}
@@ -177,11 +217,23 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 			req.Metadata,
 			models.ContentMetadata{Size: content.Size, Height: content.Height, Width: content.Width},
 		)
+		if req.Split != "" {
+			annotation.Split = req.Split
+		}
+		if req.Status != "" {
+			annotation.Status = req.Status
+		}
+		if !req.ID.IsZero() {
+			annotation.ID = req.ID
+		}
 
 		// Validate
 		if err := annotation.Valid(project.AnnotationType); err != nil {
 			return nil, err
 		}
+		if err := annotation.ValidSkeletons(tags); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 
 		// Create at DB
 		if _, err := a.platform.AnnotationDB.Create(a.db, *annotation); err != nil {
@@ -189,7 +241,26 @@ func (a Annotation) Create(c echo.Context, req models.Annotation, thumbnailSize
 		}
 	}
 
-	return a.platform.AnnotationDB.View(a.db, req.UserID, annotation.ID.Hex())
+	current, err := a.platform.AnnotationDB.View(a.db, req.UserID, annotation.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	// Record revision
+	action := models.RevisionActionCreate
+	switch {
+	case revert:
+		action = models.RevisionActionRevert
+	case previous != nil && previous.State().Equal(current.State()):
+		return current, nil
+	case previous != nil:
+		action = models.RevisionActionUpdate
+	}
+	if err := a.platform.RevisionDB.Create(a.db, models.NewAnnotationRevision(req.UserID, authorid, action, previous, current)); err != nil {
+		return nil, errors.Wrapf(err, "failed recording revision of annotation=%s, user=%s", current.ID.Hex(), req.UserID)
+	}
+
+	return current, nil
 }
 
 func (a Annotation) Query(ctx echo.Context, userid string, q models.Query) ([]models.Annotation, int64, error) {
@@ -230,6 +301,30 @@ func (a Annotation) Delete(c echo.Context, userid string, annotationids ...strin
 		objectIDs = append(objectIDs, id)
 	}
 
+	// Record revisions before the annotations are gone
+	annotations, err := a.platform.AnnotationDB.FindAnnotations(a.db, userid, objectIDs)
+	if err != nil {
+		return err
+	}
+	checked := make(map[string]struct{})
+	for _, annotation := range annotations {
+		if _, ok := checked[annotation.DatasetID]; ok {
+			continue
+		}
+		checked[annotation.DatasetID] = struct{}{}
+
+		dataset, err := a.platform.DatasetDB.View(a.db, userid, annotation.DatasetID)
+		if err != nil {
+			return err
+		}
+		if dataset.Restoring != "" {
+			return platform.ErrDatasetRestoring
+		}
+	}
+	if err := a.platform.RevisionDB.Create(a.db, models.AnnotationRevisions(userid, annotations, nil)...); err != nil {
+		return err
+	}
+
 	// Delete annotations
 	if err := a.platform.AnnotationDB.DeleteUserAnnotations(a.db, userid, objectIDs); err != nil {
 		return err
@@ -241,7 +336,7 @@ func (a Annotation) Delete(c echo.Context, userid string, annotationids ...strin
 // Statistics returns all annotation level statistics
 func (a Annotation) Statistics(c echo.Context, userid, projectid, datasetid string, statsToReturn *string) (*Statistics, error) {
 	// Check project exists
-	_, err := a.platform.ProjectDB.View(a.db, userid, projectid)
+	project, err := a.platform.ProjectDB.View(a.db, userid, projectid)
 	if err != nil {
 		return nil, err
 	}
@@ -329,6 +424,28 @@ func (a Annotation) Statistics(c echo.Context, userid, projectid, datasetid stri
 			return nil
 		})
 	}
+	// Segmentation regions per class
+	if project.AnnotationType == models.ProjectAnnotationTypeSegmentation.String() &&
+		(len(statsSlice) == 0 || getStat("SegmentsPerClass", statsSlice, &Statistics{})) {
+		g.Go(func() error {
+			var err error
+			if stats.SegmentsPerClass, err = a.segmentsPerClass(userid, projectid, datasetid); err != nil {
+				return err
+			}
+			return nil
+		})
+	}
+	// Keypoints per class
+	if project.AnnotationType == models.ProjectAnnotationTypeKeypoint.String() &&
+		(len(statsSlice) == 0 || getStat("KeypointsPerClass", statsSlice, &Statistics{})) {
+		g.Go(func() error {
+			var err error
+			if stats.KeypointsPerClass, err = a.keypointsPerClass(userid, projectid, datasetid); err != nil {
+				return err
+			}
+			return nil
+		})
+	}
 	// Average image height
 	if len(statsSlice) == 0 || getStat("AverageImageHeightPixels", statsSlice, &Statistics{}) {
 		g.Go(func() error {
@@ -518,6 +635,72 @@ func (a Annotation) annotationsPerClass(userid, projectid, datasetid string) (*m
 	return &resultsFmt, nil
 }
 
+func (a Annotation) segmentsPerClass(userid, projectid, datasetid string) (*map[string]segmentClassData, error) {
+	var results []struct {
+		ID          string  `bson:"_id"`
+		Count       int     `bson:"count"`
+		AverageArea float64 `bson:"average_area"`
+	}
+	if err := a.platform.AnnotationDB.SegmentsPerClass(a.db, userid, projectid, datasetid, &results); err != nil {
+		log.Errorf("segments per class err=%s", err.Error())
+		return nil, err
+	}
+
+	var resultsFmt = map[string]segmentClassData{}
+	for _, r := range results {
+		tag, err := a.platform.TagDB.View(a.db, userid, r.ID)
+		if err != nil {
+			return nil, err
+		}
+		resultsFmt[r.ID] = segmentClassData{Name: tag.Name, Count: r.Count, AverageArea: r.AverageArea}
+	}
+	return &resultsFmt, nil
+}
+
+func (a Annotation) keypointsPerClass(userid, projectid, datasetid string) (*map[string]keypointClassData, error) {
+	var results []struct {
+		ID struct {
+			TagID string `bson:"tagid"`
+			Index *int   `bson:"index"`
+		} `bson:"_id"`
+		Count    int `bson:"count"`
+		Labelled int `bson:"labelled"`
+		Visible  int `bson:"visible"`
+	}
+	if err := a.platform.AnnotationDB.KeypointsPerClass(a.db, userid, projectid, datasetid, &results); err != nil {
+		log.Errorf("keypoints per class err=%s", err.Error())
+		return nil, err
+	}
+
+	var resultsFmt = map[string]keypointClassData{}
+	for _, r := range results {
+		classData, ok := resultsFmt[r.ID.TagID]
+		if !ok {
+			tag, err := a.platform.TagDB.View(a.db, userid, r.ID.TagID)
+			if err != nil {
+				return nil, err
+			}
+			classData = keypointClassData{Name: tag.Name, Keypoints: []keypointData{}}
+			if tag.Skeleton != nil {
+				for _, name := range tag.Skeleton.Keypoints {
+					classData.Keypoints = append(classData.Keypoints, keypointData{Name: name})
+				}
+			}
+		}
+
+		// Every object of a class has each keypoint of its skeleton
+		if r.Count > classData.Count {
+			classData.Count = r.Count
+		}
+		if r.ID.Index != nil && *r.ID.Index < len(classData.Keypoints) {
+			classData.Keypoints[*r.ID.Index].Labelled = r.Labelled
+			classData.Keypoints[*r.ID.Index].Visible = r.Visible
+		}
+		resultsFmt[r.ID.TagID] = classData
+	}
+	return &resultsFmt, nil
+}
+
 func (a Annotation) imageStat(userid, datasetid, stat, field string) (*int64, error) {
 	var results []struct {
 		Stat float64 `bson:"stat"`
@@ -708,7 +891,71 @@ func getStat(fieldName string, tags []string, i any) bool {
 	return common.SliceContains(tags, jsonTag)
 }
 
-func createBase64IMG(contentBytes []byte, boundingBoxes []thumbnail.BoundingBox, projectType string, thumbnailSize int) (string, error) {
+// keypointBoxes returns the keypoint objects of annotation metadata to draw on a thumbnail, named by tag and
+// joined by the edges of the tag's skeleton. Tags are keyed by id.
+func keypointBoxes(metadata models.AnnotationMetadata, tags map[string]models.Tag) []thumbnail.BoundingBox {
+	boxes := []thumbnail.BoundingBox{}
+	for _, object := range metadata.KeypointObjects {
+		tag := tags[object.TagID]
+		box := thumbnail.BoundingBox{
+			Xmin:      object.Xmin,
+			Xmax:      object.Xmax,
+			Ymin:      object.Ymin,
+			Ymax:      object.Ymax,
+			ClassName: tag.Name,
+		}
+		if tag.Skeleton != nil {
+			box.Edges = tag.Skeleton.Edges
+		}
+		for _, keypoint := range object.Keypoints {
+			box.Keypoints = append(box.Keypoints, thumbnail.Keypoint{
+				X:        int(math.Round(keypoint.X)),
+				Y:        int(math.Round(keypoint.Y)),
+				Labelled: keypoint.Visibility != models.KeypointNotLabelled,
+			})
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes
+}
+
+// segments returns the polygons and masks of annotation metadata to draw on a thumbnail, named by tag
+func (a Annotation) segments(userid string, metadata models.AnnotationMetadata) ([]thumbnail.Polygon, []thumbnail.Mask, error) {
+	tagmap := make(map[string]string)
+	tagName := func(tagid string) (string, error) {
+		if name, ok := tagmap[tagid]; ok {
+			return name, nil
+		}
+		tag, err := a.platform.TagDB.View(a.db, userid, tagid)
+		if err != nil {
+			return "", err
+		}
+		tagmap[tagid] = tag.Name
+		return tag.Name, nil
+	}
+
+	polygons := []thumbnail.Polygon{}
+	for _, polygon := range metadata.Polygons {
+		name, err := tagName(polygon.TagID)
+		if err != nil {
+			return nil, nil, err
+		}
+		polygons = append(polygons, thumbnail.Polygon{Points: polygon.Points, ClassName: name})
+	}
+
+	masks := []thumbnail.Mask{}
+	for _, mask := range metadata.Masks {
+		name, err := tagName(mask.TagID)
+		if err != nil {
+			return nil, nil, err
+		}
+		masks = append(masks, thumbnail.Mask{Height: mask.Height, Width: mask.Width, Counts: mask.Counts, ClassName: name})
+	}
+
+	return polygons, masks, nil
+}
+
+func createBase64IMG(contentBytes []byte, boundingBoxes []thumbnail.BoundingBox, polygons []thumbnail.Polygon, masks []thumbnail.Mask, projectType string, thumbnailSize int) (string, error) {
 	if thumbnailSize == 0 {
 		thumbnailSize = thumbnail.BoundingBoxDefaultThumbnailSize
 	}
@@ -718,7 +965,7 @@ func createBase64IMG(contentBytes []byte, boundingBoxes []thumbnail.BoundingBox,
 			return "", err
 		}
 		return thumb, nil
-	} else if projectType == models.ProjectAnnotationTypeBoundingBox.String() {
+	} else if projectType == models.ProjectAnnotationTypeBoundingBox.String() || projectType == models.ProjectAnnotationTypeKeypoint.String() {
 		thumb, _, err := thumbnail.ThumbnailBoundingBox(
 			contentBytes,
 			thumbnail.BoundingBoxDefaultThumbnailSize,
@@ -729,6 +976,12 @@ func createBase64IMG(contentBytes []byte, boundingBoxes []thumbnail.BoundingBox,
 			return "", err
 		}
 		return thumb, nil
+	} else if projectType == models.ProjectAnnotationTypeSegmentation.String() {
+		thumb, _, err := thumbnail.ThumbnailSegmentation(contentBytes, thumbnailSize, thumbnailSize, polygons, masks)
+		if err != nil {
+			return "", err
+		}
+		return thumb, nil
 	}
 
 	return "", nil
@@ -3,6 +3,7 @@ package annotation
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
@@ -272,6 +273,163 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.GET("/statistics", h.stats)
+
+	// swagger:operation GET /v1/annotations/{Id}/history annotations annotationHistoryReq
+	// ---
+	// summary: Returns the history of an annotation.
+	// description: |
+	//  Returns the revisions recorded each time the annotation was created, updated, deleted or reverted, newest first.
+	//  The history of a deleted annotation remains available until its project is deleted.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of annotation
+	//   type: string
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   type: integer
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/annotationHistoryResp"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/history", h.history)
+
+	// swagger:operation POST /v1/annotations/{Id}/revert annotations revertAnnotationReq
+	// ---
+	// summary: Reverts an annotation to a revision.
+	// description: |
+	//  Restores the tags and bounding boxes the annotation had after the specified revision and records the revert in its history.
+	//  A deleted annotation is recreated unless its content has been annotated again. The dataset must not be locked.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of annotation
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Annotation"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/revert", h.revert)
+
+	// swagger:operation GET /v1/annotations/review annotations reviewQueueReq
+	// ---
+	// summary: Returns annotations awaiting review.
+	// description: Returns the submitted annotations of the specified project and dataset.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: project_id
+	//   in: query
+	//   type: string
+	//   required: true
+	// - name: dataset_id
+	//   in: query
+	//   type: string
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: sort_key
+	//   in: query
+	//   type: string
+	//   required: false
+	// - name: sort_val
+	//   in: query
+	//   type: integer
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/listAnnotationsResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/review", h.reviewQueue)
+
+	// swagger:operation POST /v1/annotations/approve annotations approveAnnotationsReq
+	// ---
+	// summary: Approves annotations.
+	// description: |
+	//  Approves submitted annotations with the specified IDs. Annotations that are not awaiting review are skipped.
+	//  Returns the number of annotations approved.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/reviewAnnotationsResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/approve", h.approve)
+
+	// swagger:operation POST /v1/annotations/reject annotations rejectAnnotationsReq
+	// ---
+	// summary: Rejects annotations.
+	// description: |
+	//  Rejects submitted annotations with the specified IDs; a comment explaining the rejection is required.
+	//  Annotations that are not awaiting review are skipped. Returns the number of annotations rejected.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/reviewAnnotationsResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/reject", h.reject)
 }
 
 // Annotation create request
@@ -291,6 +449,11 @@ type CreateAnnotationReq struct {
 		Metadata models.AnnotationMetadata `json:"metadata" query:"metadata"`
 		// ThumbnailSize
 		ThumbnailSize int `json:"thumbnail_size,omitempty" validate:"oneof=0 100 200 640"`
+		// Split to pin the annotation to; only used by datasets with the manual split strategy
+		Split string `json:"split,omitempty" validate:"omitempty,oneof=undefined train validation test"`
+		// Review status; submitted annotations await review. Relabelling a reviewed annotation without a status
+		// returns it to draft.
+		Status string `json:"status,omitempty" validate:"omitempty,oneof=draft submitted"`
 	}
 }
 
@@ -309,6 +472,8 @@ func (h HTTP) create(c echo.Context) error {
 		ContentID: r.ContentID,
 		TagIDs:    r.TagID,
 		Metadata:  r.Metadata,
+		Split:     strings.ToUpper(r.Split),
+		Status:    strings.ToUpper(r.Status),
 	}, r.ThumbnailSize)
 	if err != nil {
 		err := errs.EchoErr(err, 500)
@@ -498,3 +663,152 @@ func (h HTTP) stats(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, resp.Body)
 }
+
+// Annotation history response
+// swagger:response annotationHistoryResp
+type annotationHistoryResp struct {
+	// in: body
+	Body struct {
+		Revisions []models.AnnotationRevision `json:"revisions"`
+		Page      int                         `json:"page"`
+		Count     int64                       `json:"count"`
+	}
+}
+
+func (h HTTP) history(c echo.Context) error {
+	var req models.PaginationReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	revisions, count, err := h.svc.History(c, user.ID.Hex(), c.Param("id"), req.Transform())
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := annotationHistoryResp{}
+	resp.Body.Revisions = revisions
+	resp.Body.Page = req.Page
+	resp.Body.Count = count
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Annotation revert request
+// swagger:parameters revertAnnotationReq
+type revertAnnotationReq struct {
+	// in:body
+	Body struct {
+		// ID of the revision to revert to
+		RevisionID string `json:"revision_id" validate:"required,hexadecimal"`
+	}
+}
+
+func (h HTTP) revert(c echo.Context) error {
+	r := new(revertAnnotationReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	annotation, err := h.svc.Revert(c, user.ID.Hex(), c.Param("id"), r.RevisionID)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, annotation)
+}
+
+func (h HTTP) reviewQueue(c echo.Context) error {
+	var req listAnnotationsReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	result, count, err := h.svc.ReviewQueue(c, user.ID.Hex(), req.ProjectID, req.DatasetID, req.Transform())
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := listAnnotationsResp{}
+	resp.Body.Annotations = result
+	resp.Body.Page = req.Page
+	resp.Body.Count = count
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Annotations approve request
+// swagger:parameters approveAnnotationsReq
+type approveAnnotationsReq struct {
+	// in:body
+	Body struct {
+		// IDs of annotations
+		IDs []string `json:"ids" validate:"gt=0,unique,dive,hexadecimal,required"`
+		// Optional reviewer comment
+		Comment string `json:"comment,omitempty"`
+	}
+}
+
+// Annotations reject request
+// swagger:parameters rejectAnnotationsReq
+type rejectAnnotationsReq struct {
+	// in:body
+	Body struct {
+		// IDs of annotations
+		IDs []string `json:"ids" validate:"gt=0,unique,dive,hexadecimal,required"`
+		// Reason for rejecting the annotations
+		Comment string `json:"comment" validate:"required"`
+	}
+}
+
+// Annotations review response
+// swagger:response reviewAnnotationsResp
+type reviewAnnotationsResp struct {
+	// in: body
+	Body struct {
+		// Number of annotations reviewed
+		Reviewed int64 `json:"reviewed"`
+	}
+}
+
+func (h HTTP) approve(c echo.Context) error {
+	r := new(approveAnnotationsReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	return h.review(c, models.AnnotationStatusApproved, r.Comment, r.IDs)
+}
+
+func (h HTTP) reject(c echo.Context) error {
+	r := new(rejectAnnotationsReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	return h.review(c, models.AnnotationStatusRejected, r.Comment, r.IDs)
+}
+
+func (h HTTP) review(c echo.Context, status models.AnnotationStatus, comment string, ids []string) error {
+	user := c.Get("current_user").(models.User)
+
+	reviewed, err := h.svc.Review(c, user.ID.Hex(), status, comment, ids...)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := reviewAnnotationsResp{}
+	resp.Body.Reviewed = reviewed
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
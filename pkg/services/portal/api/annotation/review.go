@@ -0,0 +1,94 @@
+package annotation
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// Custom errors
+var (
+	ErrReviewCommentRequired = echo.NewHTTPError(http.StatusBadRequest, "A comment is required when rejecting annotations.")
+)
+
+// relabelledStatus returns the status of an annotation updated from previous. A status given with the update is
+// taken as is; otherwise a reviewed annotation whose labels changed has to be reviewed again and is a draft.
+func relabelledStatus(previous, annotation *models.Annotation, status string) string {
+	reviewed := previous.Status == models.AnnotationStatusApproved.String() || previous.Status == models.AnnotationStatusRejected.String()
+	switch {
+	case status != "":
+		return status
+	case reviewed && !previous.State().Equal(annotation.State()):
+		return models.AnnotationStatusDraft.String()
+	default:
+		return previous.Status
+	}
+}
+
+// ReviewQueue returns the annotations of a dataset awaiting review
+func (a Annotation) ReviewQueue(c echo.Context, userid, projectid, datasetid string, p models.Pagination) ([]models.Annotation, int64, error) {
+	// Check project exists
+	if _, err := a.platform.ProjectDB.View(a.db, userid, projectid); err != nil {
+		return []models.Annotation{}, 0, err
+	}
+	// Check dataset exists
+	if _, err := a.platform.DatasetDB.View(a.db, userid, datasetid); err != nil {
+		return []models.Annotation{}, 0, err
+	}
+
+	return a.platform.AnnotationDB.ListStatus(a.db, userid, projectid, datasetid, models.AnnotationStatusSubmitted, p)
+}
+
+// Review approves or rejects submitted annotations and returns the number of annotations reviewed. Annotations
+// that are not awaiting review are skipped.
+func (a Annotation) Review(c echo.Context, userid string, status models.AnnotationStatus, comment string, annotationids ...string) (int64, error) {
+	comment = strings.TrimSpace(comment)
+	if status == models.AnnotationStatusRejected && comment == "" {
+		return 0, ErrReviewCommentRequired
+	}
+
+	var objectIDs []primitive.ObjectID
+	for _, idStr := range annotationids {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return 0, platform.ErrAnnotationDoesNotExist
+		}
+		objectIDs = append(objectIDs, id)
+	}
+
+	// Annotations of locked dataset versions cannot change
+	annotations, err := a.platform.AnnotationDB.FindAnnotations(a.db, userid, objectIDs)
+	if err != nil {
+		return 0, err
+	}
+	checked := make(map[string]struct{})
+	for _, annotation := range annotations {
+		if _, ok := checked[annotation.DatasetID]; ok {
+			continue
+		}
+		checked[annotation.DatasetID] = struct{}{}
+
+		dataset, err := a.platform.DatasetDB.View(a.db, userid, annotation.DatasetID)
+		if err != nil {
+			return 0, err
+		}
+		if dataset.Locked {
+			return 0, platform.ErrDatasetLocked
+		}
+		if dataset.Restoring != "" {
+			return 0, platform.ErrDatasetRestoring
+		}
+	}
+
+	return a.platform.AnnotationDB.Review(a.db, userid, status, models.AnnotationReview{
+		ReviewerID: userid,
+		Comment:    comment,
+		ReviewedAt: time.Now(),
+	}, objectIDs)
+}
@@ -0,0 +1,53 @@
+package annotation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestRelabelledStatus(t *testing.T) {
+	box := models.AnnotationDataBoundingBox{TagID: "tag", Xmin: 1, Ymin: 1, Xmax: 4, Ymax: 4}
+	labelled := func(status string, boxes ...models.AnnotationDataBoundingBox) *models.Annotation {
+		return &models.Annotation{TagIDs: []string{"tag"}, Status: status, Metadata: models.AnnotationMetadata{BoundingBoxes: boxes}}
+	}
+	moved := box
+	moved.Xmax = 5
+
+	draft := models.AnnotationStatusDraft.String()
+	submitted := models.AnnotationStatusSubmitted.String()
+	approved := models.AnnotationStatusApproved.String()
+	rejected := models.AnnotationStatusRejected.String()
+
+	for _, tc := range []struct {
+		name     string
+		previous string
+		moved    bool
+		status   string
+		expected string
+	}{
+		{"approved and relabelled", approved, true, "", draft},
+		{"rejected and relabelled", rejected, true, "", draft},
+		{"approved and unchanged", approved, false, "", approved},
+		{"submitted and relabelled", submitted, true, "", submitted},
+		{"draft and relabelled", draft, true, "", draft},
+		{"relabelled and submitted", approved, true, submitted, submitted},
+		{"rejected and resubmitted", rejected, false, submitted, submitted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			annotation := labelled(tc.previous, box)
+			if tc.moved {
+				annotation = labelled(tc.previous, moved)
+			}
+			assert.Equal(t, tc.expected, relabelledStatus(labelled(tc.previous, box), annotation, tc.status))
+		})
+	}
+}
+
+func TestReviewCommentRequired(t *testing.T) {
+	// Rejections are checked before anything is looked up
+	_, err := Annotation{}.Review(nil, "user", models.AnnotationStatusRejected, " ", "annotation")
+	assert.Equal(t, ErrReviewCommentRequired, err)
+}
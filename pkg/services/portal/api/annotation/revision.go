@@ -0,0 +1,74 @@
+package annotation
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// Custom errors
+var (
+	ErrRevisionAnnotationMismatch = echo.NewHTTPError(http.StatusBadRequest, "Revision does not belong to the annotation.")
+	ErrRevertToDelete             = echo.NewHTTPError(http.StatusBadRequest, "Cannot revert to a deletion; delete the annotation instead.")
+)
+
+// History returns the revisions of an annotation, including annotations that have since been deleted
+func (a Annotation) History(c echo.Context, userid, id string, p models.Pagination) ([]models.AnnotationRevision, int64, error) {
+	revisions, count, err := a.platform.RevisionDB.List(a.db, userid, id, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Annotations without history may predate revisions
+	if count == 0 {
+		if _, err := a.platform.AnnotationDB.View(a.db, userid, id); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return revisions, count, nil
+}
+
+// Revert restores the tags and bounding boxes an annotation had after a revision. A deleted annotation is
+// recreated, provided its content has not been annotated again since.
+func (a Annotation) Revert(c echo.Context, userid, id, revisionid string) (*models.Annotation, error) {
+	revision, err := a.platform.RevisionDB.View(a.db, userid, revisionid)
+	if err != nil {
+		return nil, err
+	}
+	if revision.AnnotationID != id {
+		return nil, ErrRevisionAnnotationMismatch
+	}
+	if revision.Current == nil {
+		return nil, ErrRevertToDelete
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, platform.ErrAnnotationDoesNotExist
+	}
+
+	if _, err := a.platform.AnnotationDB.View(a.db, userid, id); err == platform.ErrAnnotationDoesNotExist {
+		if _, err := a.platform.AnnotationDB.FindContentAnnotation(a.db, userid, revision.ProjectID, revision.DatasetID, revision.ContentID); err == nil {
+			return nil, platform.ErrAnnotationAlreadyExists
+		} else if err != platform.ErrAnnotationDoesNotExist {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return a.save(c, models.Annotation{
+		ID:        objID,
+		UserID:    userid,
+		ProjectID: revision.ProjectID,
+		DatasetID: revision.DatasetID,
+		ContentID: revision.ContentID,
+		TagIDs:    append([]string{}, revision.Current.TagIDs...),
+		Metadata:  revision.Current.Metadata,
+	}, 0, userid, true)
+}
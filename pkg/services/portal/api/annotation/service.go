@@ -17,6 +17,10 @@ type Service interface {
 	Query(echo.Context, string, models.Query) ([]models.Annotation, int64, error)
 	Delete(echo.Context, string, ...string) error
 	Statistics(echo.Context, string, string, string, *string) (*Statistics, error)
+	History(echo.Context, string, string, models.Pagination) ([]models.AnnotationRevision, int64, error)
+	Revert(echo.Context, string, string, string) (*models.Annotation, error)
+	ReviewQueue(echo.Context, string, string, string, models.Pagination) ([]models.Annotation, int64, error)
+	Review(echo.Context, string, models.AnnotationStatus, string, ...string) (int64, error)
 }
 
 // New creates new annotation application service
@@ -45,6 +49,8 @@ type Statistics struct {
 	AverageAnnotationsPerImage *float64                        `json:"average_annotations_per_image,omitempty"` // Average number of tags per each image
 	AnnotatedNullTags          *int64                          `json:"annotated_null_images,omitempty"`         // Annotated images with no tag associations
 	AnnotationsPerClass        *map[string]annotationClassData `json:"annotations_per_class,omitempty"`         // Total content associated with each class -> map[tagid]annotationClassData
+	SegmentsPerClass           *map[string]segmentClassData    `json:"segments_per_class,omitempty"`            // Segmentation regions of each class -> map[tagid]segmentClassData; segmentation projects only
+	KeypointsPerClass          *map[string]keypointClassData   `json:"keypoints_per_class,omitempty"`           // Keypoint objects of each class -> map[tagid]keypointClassData; keypoint projects only
 	AverageImageHeightPixels   *int64                          `json:"average_image_height_pixels,omitempty"`   // Average image height
 	AverageImageWidthPixels    *int64                          `json:"average_image_width_pixels,omitempty"`    // Average image width
 	MinImageHeightPixels       *int64                          `json:"min_image_height_pixels,omitempty"`       // Min image height
@@ -64,6 +70,24 @@ type annotationClassData struct {
 	Zscore  float64 `json:"zscore"`
 }
 
+type segmentClassData struct {
+	Name        string  `json:"name"`
+	Count       int     `json:"count"`
+	AverageArea float64 `json:"average_area"` // Average region area in pixels
+}
+
+type keypointClassData struct {
+	Name      string         `json:"name"`
+	Count     int            `json:"count"`     // Objects of the class
+	Keypoints []keypointData `json:"keypoints"` // In skeleton order
+}
+
+type keypointData struct {
+	Name     string `json:"name"`
+	Labelled int    `json:"labelled"` // Objects with the keypoint labelled, visible or not
+	Visible  int    `json:"visible"`  // Objects with the keypoint visible
+}
+
 type annotationDimensionInsights struct {
 	Dimensions               []annotationDimensions             `json:"dimensions"`
 	SizeDistributions        annotationSizeDistributions        `json:"size_distributions"`
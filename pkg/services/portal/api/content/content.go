@@ -119,6 +119,16 @@ func (c Content) Delete(ctc echo.Context, userid, projectid string, contentIds [
 				objectIDs = append(objectIDs, id)
 			}
 
+			annotations, err := c.platform.AnnotationDB.FindAnnotations(c.db, userid, objectIDs)
+			if err != nil {
+				log.Errorf("unable to remove annotation associated with content=%s for user=%s, project=%s; not updating (err=%s)", contentId, userid, projectid, err.Error())
+				continue
+			}
+			if err := c.platform.RevisionDB.Create(c.db, models.AnnotationRevisions(userid, annotations, nil)...); err != nil {
+				log.Errorf("unable to record revisions of annotation associated with content=%s for user=%s, project=%s; not updating (err=%s)", contentId, userid, projectid, err.Error())
+				continue
+			}
+
 			if err := c.platform.AnnotationDB.DeleteUserAnnotations(c.db, userid, objectIDs); err != nil {
 				log.Errorf("unable to remove annotation associated with content=%s for user=%s, project=%s; not updating (err=%s)", contentId, userid, projectid, err.Error())
 				continue
@@ -47,7 +47,11 @@ func NewHTTP(svc Service, r *echo.Group) {
 	// swagger:operation PATCH /v1/datasets/{Id} datasets updateDatasetReq
 	// ---
 	// summary: Updates a dataset.
-	// description: Updates a dataset, including the following fields - split.
+	// description: |
+	//   Updates a dataset, including the following fields - split, split_strategy and split_seed.
+	//   The split strategy decides how annotations are assigned to splits when a model is trained on the dataset:
+	//   random shuffles annotations with the dataset's seed, stratified splits each tag separately so rare tags are found in every split,
+	//   and manual keeps the split set on each annotation and splits the remaining annotations randomly.
 	// security:
 	// - Bearer: []
 	// consumes:
@@ -64,6 +68,102 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.PATCH("/:id", h.update)
+
+	// swagger:operation GET /v1/datasets/{Id}/versions datasets listDatasetVersionsReq
+	// ---
+	// summary: Lists dataset versions.
+	// description: |
+	//   Lists all versions of the project dataset the dataset belongs to, ordered by version, along with the models associated with each version.
+	//   Version 0 is the editable dataset; a new locked version is created every time a model is trained.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of any version of the dataset
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/datasetVersionsResp"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/versions", h.versions)
+
+	// swagger:operation GET /v1/datasets/{Id}/diff datasets diffDatasetReq
+	// ---
+	// summary: Compares two dataset versions.
+	// description: |
+	//   Lists the changes from the dataset version to another version of the same project dataset.
+	//   Tags are compared by name. Content is reported as added, removed, relabelled or moved to a different split.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of the dataset version to compare from
+	//   type: string
+	//   required: true
+	// - name: to
+	//   in: query
+	//   description: id of the dataset version to compare to
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/DatasetDiff"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/diff", h.diff)
+
+	// swagger:operation POST /v1/datasets/{Id}/restore datasets restoreDatasetReq
+	// ---
+	// summary: Restores a dataset version.
+	// description: |
+	//   Restores the tags, annotations and split of the dataset version into the editable dataset in place:
+	//   tags are matched by name and annotations by content, so their ids are kept.
+	//   The editable dataset is first saved as a new locked version so that the restore can be undone.
+	//   Annotations of content that has since been removed from the project are not restored.
+	//   The editable dataset cannot be edited until the restore is finished.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of the dataset version to restore
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/datasetResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/restore", h.restore)
 }
 
 // swagger:response datasetResp
@@ -111,6 +211,10 @@ type updateDatasetReq struct {
 			Validation float64 `json:"validation" validate:"number,min=0,max=1"`
 			Test       float64 `json:"test" validate:"number,min=0,max=1"`
 		} `json:"split,omitempty"`
+		// Split strategy
+		SplitStrategy string `json:"split_strategy,omitempty" validate:"omitempty,oneof=random stratified manual"`
+		// Seed used to shuffle annotations; must be non-zero
+		SplitSeed int64 `json:"split_seed,omitempty"`
 	}
 }
 
@@ -136,6 +240,8 @@ func (h HTTP) update(c echo.Context) error {
 			Validation float64 "json:\"validation\" bson:\"validation\""
 			Test       float64 "json:\"test\" bson:\"test\""
 		}(req.Split),
+		SplitStrategy: req.SplitStrategy,
+		SplitSeed:     req.SplitSeed,
 	})
 	if err != nil {
 		err := errs.EchoErr(err, 500)
@@ -150,3 +256,66 @@ func (h HTTP) update(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, resp.Body)
 }
+
+// swagger:response datasetVersionsResp
+//
+//lint:ignore U1000 ignore, used for swagger spec
+type datasetVersionsResp struct {
+	// in: body
+	Body struct {
+		Versions []DatasetVersion `json:"versions"`
+	}
+}
+
+func (h HTTP) versions(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	datasetid := c.Param("id")
+
+	versions, err := h.svc.Versions(c, user.ID.Hex(), datasetid)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := datasetVersionsResp{}
+	resp.Body.Versions = versions
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+func (h HTTP) diff(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	datasetid := c.Param("id")
+
+	to := c.QueryParam("to")
+	if to == "" {
+		return c.JSON(400, echo.NewHTTPError(400, "missing dataset version to compare to"))
+	}
+
+	diff, err := h.svc.Diff(c, user.ID.Hex(), datasetid, to)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
+func (h HTTP) restore(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	datasetid := c.Param("id")
+
+	dataset, err := h.svc.Restore(c, user.ID.Hex(), datasetid)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := datasetResp{struct {
+		Dataset models.Dataset "json:\"dataset\""
+	}{
+		*dataset,
+	}}
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
@@ -22,6 +22,10 @@ func Initialize(db *db.DB, platform *platform.Platform) (*Dataset, error) {
 type Service interface {
 	View(echo.Context, string, string) (*models.Dataset, error)
 	Update(echo.Context, models.Dataset) (*models.Dataset, error)
+
+	Versions(echo.Context, string, string) ([]DatasetVersion, error)
+	Diff(echo.Context, string, string, string) (*DatasetDiff, error)
+	Restore(echo.Context, string, string) (*models.Dataset, error)
 }
 
 // Dataset represents dataset application service
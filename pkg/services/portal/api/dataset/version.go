@@ -0,0 +1,425 @@
+/*
+ * File: version.go
+ * Project: dataset
+ * File Created: Wednesday, 14th February 2024 10:12:45 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 14th February 2024 10:12:45 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// Custom errors
+var (
+	ErrDatasetProjectMismatch = echo.NewHTTPError(http.StatusBadRequest, "datasets must be versions of the same project")
+	ErrDatasetRestoreHead     = echo.NewHTTPError(http.StatusBadRequest, "dataset is already the editable version")
+)
+
+// VersionModel is a model trained on a dataset version
+type VersionModel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// DatasetVersion is a version of a project's dataset and the models trained on it
+//
+// swagger:model DatasetVersion
+type DatasetVersion struct {
+	models.Dataset
+	// Models trained on, or in the case of the editable version waiting to be trained on, this version
+	Models []VersionModel `json:"models"`
+}
+
+// Labels are the labels of a single content item in a dataset version
+type Labels struct {
+	Tags            []string                 `json:"tags"`
+	BoundingBoxes   []LabelledBox            `json:"bounding_boxes,omitempty"`
+	Polygons        []LabelledPolygon        `json:"polygons,omitempty"`
+	Masks           []LabelledMask           `json:"masks,omitempty"`
+	KeypointObjects []LabelledKeypointObject `json:"keypoint_objects,omitempty"`
+}
+
+// LabelledBox is a bounding box referencing its tag by name
+type LabelledBox struct {
+	Tag  string `json:"tag"`
+	Xmin int    `json:"xmin"`
+	Ymin int    `json:"ymin"`
+	Xmax int    `json:"xmax"`
+	Ymax int    `json:"ymax"`
+}
+
+// LabelledPolygon is a polygon referencing its tag by name
+type LabelledPolygon struct {
+	Tag    string    `json:"tag"`
+	Points []float64 `json:"points"`
+}
+
+// LabelledMask is a run-length encoded mask referencing its tag by name
+type LabelledMask struct {
+	Tag    string `json:"tag"`
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+	Counts []int  `json:"counts"`
+}
+
+// LabelledKeypointObject is a keypoint object referencing its tag by name
+type LabelledKeypointObject struct {
+	LabelledBox
+	Keypoints []models.AnnotationDataKeypoint `json:"keypoints"`
+}
+
+// Relabel is a content item whose labels differ between versions
+type Relabel struct {
+	ContentID string `json:"contentid"`
+	From      Labels `json:"from"`
+	To        Labels `json:"to"`
+}
+
+// SplitChange is a content item assigned to a different split between versions
+type SplitChange struct {
+	ContentID string `json:"contentid"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// TagChange is a tag whose properties differ between versions
+type TagChange struct {
+	Name string   `json:"name"`
+	From []string `json:"from"`
+	To   []string `json:"to"`
+}
+
+// DatasetDiff describes the changes from one version of a dataset to another. Tags are compared by name and
+// content by id since both versions hold their own copies of tags and annotations.
+//
+// swagger:model DatasetDiff
+type DatasetDiff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Tags struct {
+		Added   []string    `json:"added"`
+		Removed []string    `json:"removed"`
+		Changed []TagChange `json:"changed"`
+	} `json:"tags"`
+	Content struct {
+		Added      []string      `json:"added"`
+		Removed    []string      `json:"removed"`
+		Relabelled []Relabel     `json:"relabelled"`
+		Split      []SplitChange `json:"split"`
+	} `json:"content"`
+}
+
+// Versions lists all versions of the project dataset the given dataset belongs to
+func (d Dataset) Versions(c echo.Context, userid, datasetid string) ([]DatasetVersion, error) {
+	dataset, err := d.platform.DatasetDB.View(d.db, userid, datasetid)
+	if err != nil {
+		return nil, err
+	}
+
+	datasets, err := d.platform.DatasetDB.Versions(d.db, userid, dataset.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := d.platform.ModelDB.FindProjectModels(d.db, userid, dataset.ProjectID, &options.FindOptions{
+		Projection: bson.M{"_id": 1, "name": 1, "state": 1, "datasetid": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	trained := make(map[string][]VersionModel)
+	for cursor.Next(context.TODO()) {
+		var model models.Model
+		if err := cursor.Decode(&model); err != nil {
+			return nil, err
+		}
+		trained[model.DatasetID] = append(trained[model.DatasetID], VersionModel{ID: model.ID.Hex(), Name: model.Name, State: model.State})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	versions := make([]DatasetVersion, len(datasets))
+	for i, dataset := range datasets {
+		versions[i] = DatasetVersion{Dataset: dataset, Models: []VersionModel{}}
+		if m, ok := trained[dataset.ID.Hex()]; ok {
+			versions[i].Models = m
+		}
+	}
+
+	return versions, nil
+}
+
+// versionLabels is the labels and split of each content item of a dataset version along with its tags by name
+type versionLabels struct {
+	tags    map[string][]string
+	labels  map[string]Labels
+	splits  map[string]string
+	dataset *models.Dataset
+}
+
+func (d Dataset) versionLabels(userid, datasetid string) (*versionLabels, error) {
+	dataset, err := d.platform.DatasetDB.View(d.db, userid, datasetid)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := d.platform.TagDB.ListAll(d.db, userid, datasetid)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &versionLabels{
+		tags:    make(map[string][]string, len(tags)),
+		labels:  make(map[string]Labels),
+		splits:  make(map[string]string),
+		dataset: dataset,
+	}
+	tagNames := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagNames[tag.ID.Hex()] = tag.Name
+		properties := append([]string{}, tag.Property...)
+		sort.Strings(properties)
+		v.tags[tag.Name] = properties
+	}
+
+	cursor, err := d.platform.AnnotationDB.FindDatasetAnnotations(d.db, userid, datasetid, &options.FindOptions{
+		Projection: bson.M{"b64_image": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var annotation models.Annotation
+		if err := cursor.Decode(&annotation); err != nil {
+			return nil, errors.Wrapf(err, "error retrieving annotation from database; dataset=%s", datasetid)
+		}
+
+		v.labels[annotation.ContentID] = labelsOf(annotation, tagNames)
+		v.splits[annotation.ContentID] = annotation.Split
+	}
+
+	return v, cursor.Err()
+}
+
+// labelsOf returns the labels of an annotation with its tags by name and its regions sorted by key
+func labelsOf(annotation models.Annotation, tagNames map[string]string) Labels {
+	labels := Labels{Tags: []string{}}
+	for _, tagid := range annotation.TagIDs {
+		labels.Tags = append(labels.Tags, tagNames[tagid])
+	}
+	sort.Strings(labels.Tags)
+	for _, box := range annotation.Metadata.BoundingBoxes {
+		labels.BoundingBoxes = append(labels.BoundingBoxes, LabelledBox{Tag: tagNames[box.TagID], Xmin: box.Xmin, Ymin: box.Ymin, Xmax: box.Xmax, Ymax: box.Ymax})
+	}
+	sort.Slice(labels.BoundingBoxes, func(i, j int) bool {
+		return labels.BoundingBoxes[i].key() < labels.BoundingBoxes[j].key()
+	})
+	for _, polygon := range annotation.Metadata.Polygons {
+		labels.Polygons = append(labels.Polygons, LabelledPolygon{Tag: tagNames[polygon.TagID], Points: polygon.Points})
+	}
+	sort.Slice(labels.Polygons, func(i, j int) bool {
+		return labels.Polygons[i].key() < labels.Polygons[j].key()
+	})
+	for _, mask := range annotation.Metadata.Masks {
+		labels.Masks = append(labels.Masks, LabelledMask{Tag: tagNames[mask.TagID], Height: mask.Height, Width: mask.Width, Counts: mask.Counts})
+	}
+	sort.Slice(labels.Masks, func(i, j int) bool {
+		return labels.Masks[i].key() < labels.Masks[j].key()
+	})
+	for _, object := range annotation.Metadata.KeypointObjects {
+		labels.KeypointObjects = append(labels.KeypointObjects, LabelledKeypointObject{
+			LabelledBox: LabelledBox{Tag: tagNames[object.TagID], Xmin: object.Xmin, Ymin: object.Ymin, Xmax: object.Xmax, Ymax: object.Ymax},
+			Keypoints:   object.Keypoints,
+		})
+	}
+	sort.Slice(labels.KeypointObjects, func(i, j int) bool {
+		return labels.KeypointObjects[i].key() < labels.KeypointObjects[j].key()
+	})
+
+	return labels
+}
+
+func (b LabelledBox) key() string {
+	return fmt.Sprintf("%s/%d/%d/%d/%d", b.Tag, b.Xmin, b.Ymin, b.Xmax, b.Ymax)
+}
+
+func (p LabelledPolygon) key() string {
+	return fmt.Sprintf("%s/%v", p.Tag, p.Points)
+}
+
+func (m LabelledMask) key() string {
+	return fmt.Sprintf("%s/%d/%d/%v", m.Tag, m.Height, m.Width, m.Counts)
+}
+
+func (o LabelledKeypointObject) key() string {
+	return fmt.Sprintf("%s/%v", o.LabelledBox.key(), o.Keypoints)
+}
+
+// equal compares labels of which the regions are sorted by key
+func (l Labels) equal(other Labels) bool {
+	if strings.Join(l.Tags, ",") != strings.Join(other.Tags, ",") ||
+		len(l.BoundingBoxes) != len(other.BoundingBoxes) ||
+		len(l.Polygons) != len(other.Polygons) ||
+		len(l.Masks) != len(other.Masks) ||
+		len(l.KeypointObjects) != len(other.KeypointObjects) {
+		return false
+	}
+	for i := range l.BoundingBoxes {
+		if l.BoundingBoxes[i] != other.BoundingBoxes[i] {
+			return false
+		}
+	}
+	for i := range l.Polygons {
+		if l.Polygons[i].key() != other.Polygons[i].key() {
+			return false
+		}
+	}
+	for i := range l.Masks {
+		if l.Masks[i].key() != other.Masks[i].key() {
+			return false
+		}
+	}
+	for i := range l.KeypointObjects {
+		if l.KeypointObjects[i].key() != other.KeypointObjects[i].key() {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff compares two versions of a project dataset
+func (d Dataset) Diff(c echo.Context, userid, fromid, toid string) (*DatasetDiff, error) {
+	from, err := d.versionLabels(userid, fromid)
+	if err != nil {
+		return nil, err
+	}
+	to, err := d.versionLabels(userid, toid)
+	if err != nil {
+		return nil, err
+	}
+	if from.dataset.ProjectID != to.dataset.ProjectID {
+		return nil, ErrDatasetProjectMismatch
+	}
+
+	return diffVersions(fromid, toid, from, to), nil
+}
+
+// diffVersions compares the labels of two versions
+func diffVersions(fromid, toid string, from, to *versionLabels) *DatasetDiff {
+	diff := &DatasetDiff{From: fromid, To: toid}
+	diff.Tags.Added, diff.Tags.Removed, diff.Tags.Changed = []string{}, []string{}, []TagChange{}
+	diff.Content.Added, diff.Content.Removed = []string{}, []string{}
+	diff.Content.Relabelled, diff.Content.Split = []Relabel{}, []SplitChange{}
+
+	for name, properties := range to.tags {
+		previous, ok := from.tags[name]
+		if !ok {
+			diff.Tags.Added = append(diff.Tags.Added, name)
+		} else if strings.Join(previous, ",") != strings.Join(properties, ",") {
+			diff.Tags.Changed = append(diff.Tags.Changed, TagChange{Name: name, From: previous, To: properties})
+		}
+	}
+	for name := range from.tags {
+		if _, ok := to.tags[name]; !ok {
+			diff.Tags.Removed = append(diff.Tags.Removed, name)
+		}
+	}
+
+	for contentid, labels := range to.labels {
+		previous, ok := from.labels[contentid]
+		if !ok {
+			diff.Content.Added = append(diff.Content.Added, contentid)
+			continue
+		}
+		if !previous.equal(labels) {
+			diff.Content.Relabelled = append(diff.Content.Relabelled, Relabel{ContentID: contentid, From: previous, To: labels})
+		}
+		if from.splits[contentid] != to.splits[contentid] {
+			diff.Content.Split = append(diff.Content.Split, SplitChange{ContentID: contentid, From: from.splits[contentid], To: to.splits[contentid]})
+		}
+	}
+	for contentid := range from.labels {
+		if _, ok := to.labels[contentid]; !ok {
+			diff.Content.Removed = append(diff.Content.Removed, contentid)
+		}
+	}
+
+	// Stable output
+	sort.Strings(diff.Tags.Added)
+	sort.Strings(diff.Tags.Removed)
+	sort.Slice(diff.Tags.Changed, func(i, j int) bool { return diff.Tags.Changed[i].Name < diff.Tags.Changed[j].Name })
+	sort.Strings(diff.Content.Added)
+	sort.Strings(diff.Content.Removed)
+	sort.Slice(diff.Content.Relabelled, func(i, j int) bool {
+		return diff.Content.Relabelled[i].ContentID < diff.Content.Relabelled[j].ContentID
+	})
+	sort.Slice(diff.Content.Split, func(i, j int) bool { return diff.Content.Split[i].ContentID < diff.Content.Split[j].ContentID })
+
+	return diff
+}
+
+// Restore restores the labels of the given version into the editable version of a project dataset. The editable
+// version is first saved as a new locked version so that the restore can itself be undone.
+func (d Dataset) Restore(c echo.Context, userid, datasetid string) (*models.Dataset, error) {
+	version, err := d.platform.DatasetDB.View(d.db, userid, datasetid)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := d.platform.DatasetDB.FindVersion(d.db, userid, version.ProjectID, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	heads := []models.Dataset{}
+	if err := cursor.All(context.TODO(), &heads); err != nil {
+		return nil, err
+	}
+	if len(heads) == 0 {
+		return nil, platform.ErrDatasetDoesNotExist
+	}
+	if len(heads) > 1 {
+		return nil, platform.ErrDatasetCorruptState
+	}
+	head := heads[0]
+
+	if head.ID == version.ID {
+		return nil, ErrDatasetRestoreHead
+	}
+	if head.Restoring != "" {
+		return nil, platform.ErrDatasetRestoring
+	}
+
+	if _, err := d.platform.DatasetDB.Copy(d.db, &head, true, nil); err != nil {
+		return nil, errors.Wrapf(err, "error saving editable dataset before restore; dataset=%s", head.ID.Hex())
+	}
+
+	if err := d.platform.DatasetDB.Restore(d.db, &head, version); err != nil {
+		return nil, errors.Wrapf(err, "error restoring dataset; dataset=%s version=%d", head.ID.Hex(), version.Version)
+	}
+
+	return d.platform.DatasetDB.View(d.db, userid, head.ID.Hex())
+}
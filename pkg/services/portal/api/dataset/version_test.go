@@ -0,0 +1,115 @@
+/*
+ * File: version_test.go
+ * Project: dataset
+ * File Created: Wednesday, 14th February 2024 10:12:45 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Wednesday, 14th February 2024 10:12:45 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func testAnnotation(contentid, split string, tagids []string, boxes ...models.AnnotationDataBoundingBox) models.Annotation {
+	annotation := models.NewAnnotation("user", "project", "dataset", contentid, tagids, "", models.AnnotationMetadata{BoundingBoxes: boxes}, models.ContentMetadata{})
+	annotation.Split = split
+	return *annotation
+}
+
+func TestLabelsOf(t *testing.T) {
+	tagNames := map[string]string{"t1": "dog", "t2": "cat"}
+
+	// Tags and regions are compared by name, whatever the ids and order of either version
+	annotation := testAnnotation("c1", "", []string{"t1", "t2"},
+		models.AnnotationDataBoundingBox{TagID: "t1", Xmin: 5, Ymin: 5, Xmax: 10, Ymax: 10},
+		models.AnnotationDataBoundingBox{TagID: "t2", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10},
+	)
+	labels := labelsOf(annotation, tagNames)
+	assert.Equal(t, []string{"cat", "dog"}, labels.Tags)
+	assert.Equal(t, []LabelledBox{
+		{Tag: "cat", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10},
+		{Tag: "dog", Xmin: 5, Ymin: 5, Xmax: 10, Ymax: 10},
+	}, labels.BoundingBoxes)
+
+	copied := testAnnotation("c1", "", []string{"u2", "u1"},
+		models.AnnotationDataBoundingBox{TagID: "u2", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10},
+		models.AnnotationDataBoundingBox{TagID: "u1", Xmin: 5, Ymin: 5, Xmax: 10, Ymax: 10},
+	)
+	assert.True(t, labels.equal(labelsOf(copied, map[string]string{"u1": "dog", "u2": "cat"})))
+
+	moved := testAnnotation("c1", "", []string{"t1", "t2"},
+		models.AnnotationDataBoundingBox{TagID: "t1", Xmin: 6, Ymin: 5, Xmax: 10, Ymax: 10},
+		models.AnnotationDataBoundingBox{TagID: "t2", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10},
+	)
+	assert.False(t, labels.equal(labelsOf(moved, tagNames)))
+
+	assert.False(t, labels.equal(labelsOf(testAnnotation("c1", "", []string{"t1"}), tagNames)))
+}
+
+func TestDiffVersions(t *testing.T) {
+	box := models.AnnotationDataBoundingBox{TagID: "t1", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10}
+	fromNames := map[string]string{"t1": "dog", "t2": "cat", "t3": "bird"}
+	toNames := map[string]string{"t1": "dog", "t2": "cat", "t4": "fish"}
+
+	from := &versionLabels{
+		tags:   map[string][]string{"dog": {"animal"}, "cat": {}, "bird": {}},
+		labels: map[string]Labels{},
+		splits: map[string]string{},
+	}
+	to := &versionLabels{
+		tags:   map[string][]string{"dog": {"animal", "pet"}, "cat": {}, "fish": {}},
+		labels: map[string]Labels{},
+		splits: map[string]string{},
+	}
+	for _, annotation := range []models.Annotation{
+		testAnnotation("unchanged", "TRAIN", []string{"t1"}, box),
+		testAnnotation("relabelled", "TRAIN", []string{"t1"}, box),
+		testAnnotation("moved", "TRAIN", []string{"t2"}),
+		testAnnotation("removed", "", []string{"t3"}),
+	} {
+		from.labels[annotation.ContentID] = labelsOf(annotation, fromNames)
+		from.splits[annotation.ContentID] = annotation.Split
+	}
+	for _, annotation := range []models.Annotation{
+		testAnnotation("unchanged", "TRAIN", []string{"t1"}, box),
+		testAnnotation("relabelled", "TRAIN", []string{"t2"}, models.AnnotationDataBoundingBox{TagID: "t2", Xmin: 0, Ymin: 0, Xmax: 10, Ymax: 10}),
+		testAnnotation("moved", "TEST", []string{"t2"}),
+		testAnnotation("added", "", []string{"t4"}),
+	} {
+		to.labels[annotation.ContentID] = labelsOf(annotation, toNames)
+		to.splits[annotation.ContentID] = annotation.Split
+	}
+
+	diff := diffVersions("from", "to", from, to)
+	assert.Equal(t, "from", diff.From)
+	assert.Equal(t, "to", diff.To)
+
+	assert.Equal(t, []string{"fish"}, diff.Tags.Added)
+	assert.Equal(t, []string{"bird"}, diff.Tags.Removed)
+	assert.Equal(t, []TagChange{{Name: "dog", From: []string{"animal"}, To: []string{"animal", "pet"}}}, diff.Tags.Changed)
+
+	assert.Equal(t, []string{"added"}, diff.Content.Added)
+	assert.Equal(t, []string{"removed"}, diff.Content.Removed)
+	require.Len(t, diff.Content.Relabelled, 1)
+	assert.Equal(t, "relabelled", diff.Content.Relabelled[0].ContentID)
+	assert.Equal(t, []string{"dog"}, diff.Content.Relabelled[0].From.Tags)
+	assert.Equal(t, []string{"cat"}, diff.Content.Relabelled[0].To.Tags)
+	assert.Equal(t, []SplitChange{{ContentID: "moved", From: "TRAIN", To: "TEST"}}, diff.Content.Split)
+
+	// Identical versions have nothing to report, as empty lists rather than null
+	diff = diffVersions("from", "from", from, from)
+	assert.Empty(t, diff.Tags.Added)
+	assert.NotNil(t, diff.Tags.Added)
+	assert.Empty(t, diff.Tags.Changed)
+	assert.Empty(t, diff.Content.Relabelled)
+	assert.NotNil(t, diff.Content.Relabelled)
+	assert.Empty(t, diff.Content.Split)
+}
@@ -52,6 +52,38 @@ func (e Export) Create(c echo.Context, userid string, req createExportReq) (mode
 			return models.Export{}, echo.NewHTTPError(404, fmt.Sprintf("unable to locate datasetid=%s", req.ID))
 		}
 		export.UpdateMetadata(nil, nil, dataset)
+
+		if req.Split && !dataset.Locked {
+			return models.Export{}, echo.NewHTTPError(http.StatusBadRequest, "split exports require a dataset locked by training a model")
+		}
+		export.Split = req.Split
+
+		export.Format = models.ExportFormatEmerald
+		if req.Format != "" {
+			export.Format = models.ExportFormat(strings.ToUpper(req.Format))
+		}
+		if export.Format == models.ExportFormatYOLO || export.Format == models.ExportFormatManifest {
+			project, err := e.platform.ProjectDB.View(e.db, userid, dataset.ProjectID)
+			if err != nil {
+				return models.Export{}, echo.NewHTTPError(404, fmt.Sprintf("unable to locate projectid=%s", dataset.ProjectID))
+			}
+			if export.Format == models.ExportFormatYOLO && project.AnnotationType != models.ProjectAnnotationTypeBoundingBox.String() {
+				return models.Export{}, echo.NewHTTPError(http.StatusBadRequest, "yolo export is only supported for bounding box projects")
+			}
+			if export.Format == models.ExportFormatManifest && (project.AnnotationType == models.ProjectAnnotationTypeSegmentation.String() ||
+				project.AnnotationType == models.ProjectAnnotationTypeKeypoint.String()) {
+				return models.Export{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("manifest export is not supported for %s projects", project.AnnotationType))
+			}
+		}
+	case models.ExportTypeModel:
+		model, err := e.platform.ModelDB.View(e.db, userid, req.ID)
+		if err != nil {
+			return models.Export{}, echo.NewHTTPError(404, fmt.Sprintf("unable to locate modelid=%s", req.ID))
+		}
+		if model.State != models.ModelStateTrained.String() || model.TrainingJobName == "" {
+			return models.Export{}, echo.NewHTTPError(http.StatusConflict, "model must be trained before it can be exported")
+		}
+		export.UpdateMetadata(nil, &model, nil)
 	default:
 		return models.Export{}, echo.NewHTTPError(http.StatusNotImplemented, "export type not supported")
 	}
@@ -44,7 +44,12 @@ func NewHTTP(svc Service, r *echo.Group) {
 	// swagger:operation POST /v1/exports exports createExportReq
 	// ---
 	// summary: Creates an export.
-	// description: Creates an export for the given user and project.
+	// description: |
+	//   Creates an export for the given user and project.
+	//   Dataset exports can be written in the emerald (labels.json), coco, voc, yolo or manifest (SageMaker augmented manifest) format.
+	//   Exports of a dataset locked by training a model can be split into a single archive with train/, validation/ and test/ folders,
+	//   each holding the label files of the split in the requested format and its content in an images/ folder.
+	//   Model exports package the trained model artifact with its label map, preprocessing, augmentation, hyperparameters and metrics.
 	// security:
 	// - Bearer: []
 	// consumes:
@@ -315,6 +320,15 @@ type createExportReq struct {
 	// in: query
 	// required: true
 	ExportType string `json:"export_type" validate:"required,oneof=project dataset model"`
+	// Annotation format of a dataset export; defaults to emerald
+	// in: query
+	// required: false
+	Format string `json:"format" validate:"omitempty,oneof=emerald coco voc yolo manifest"`
+	// Partition a dataset export, including its content, into train/, validation/ and test/ folders of a single
+	// archive. Only datasets locked by training a model carry a split.
+	// in: query
+	// required: false
+	Split bool `json:"split"`
 	// Entity ID e.g. project, dataset, or model
 	// in: query
 	// required: true
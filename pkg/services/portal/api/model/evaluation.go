@@ -0,0 +1,190 @@
+/*
+ * File: evaluation.go
+ * Project: model
+ * File Created: Tuesday, 13th February 2024 2:16:37 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 13th February 2024 2:16:37 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package model
+
+import (
+	"context"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+)
+
+// Evaluation is a struct which contains the parameters of a model evaluation.
+type Evaluation struct {
+	ModelID string
+	UserID  string
+	// Split of the dataset to evaluate; empty for the whole dataset
+	Split         string
+	Threshold     float64
+	IoUThresholds []float64
+}
+
+// Evaluate compares the stored predictions of a model against the ground truth annotations of the locked
+// dataset version the model was trained on.
+func (m Model) Evaluate(c echo.Context, r Evaluation) (evaluation.Report, error) {
+	model, err := m.platform.ModelDB.View(m.db, r.UserID, r.ModelID)
+	if err != nil {
+		return evaluation.Report{}, err
+	}
+
+	if model.State != models.ModelStateTrained.String() {
+		return evaluation.Report{}, ErrModelNotTrained
+	}
+
+	project, err := m.platform.ProjectDB.View(m.db, r.UserID, model.ProjectID)
+	if err != nil {
+		return evaluation.Report{}, err
+	}
+
+	predictions, err := m.modelPredictions(r.UserID, r.ModelID)
+	if err != nil {
+		return evaluation.Report{}, err
+	}
+	if len(predictions) == 0 {
+		return evaluation.Report{}, ErrNoPredictions
+	}
+
+	cursor, err := m.platform.AnnotationDB.FindDatasetAnnotations(m.db, r.UserID, model.DatasetID, &options.FindOptions{
+		Projection: bson.M{"_id": 1, "tagids": 1, "contentid": 1, "split": 1, "metadata": 1},
+	})
+	if err != nil {
+		return evaluation.Report{}, err
+	}
+	defer cursor.Close(context.TODO())
+
+	// Ground truth is compared by tag name
+	tagNames := make(map[string]string)
+	tagName := func(tagid string) (string, error) {
+		if name, ok := tagNames[tagid]; ok {
+			return name, nil
+		}
+		tag, err := m.platform.TagDB.View(m.db, r.UserID, tagid)
+		if err != nil {
+			return "", errors.Wrapf(err, "error retrieving tag from database; tag=%s", tagid)
+		}
+		tagNames[tagid] = tag.Name
+		return tag.Name, nil
+	}
+
+	samples := []evaluation.Sample{}
+	for cursor.Next(context.TODO()) {
+		var annotation models.Annotation
+		if err := cursor.Decode(&annotation); err != nil {
+			return evaluation.Report{}, err
+		}
+
+		if r.Split != "" && !strings.EqualFold(annotation.Split, r.Split) {
+			continue
+		}
+
+		// Content that was not run through batch inference cannot be evaluated
+		objects, ok := predictions[annotation.ContentID]
+		if !ok {
+			continue
+		}
+
+		sample := evaluation.Sample{Predictions: objects}
+		if project.AnnotationType == models.ProjectAnnotationTypeClassification.String() {
+			for _, tagid := range annotation.TagIDs {
+				name, err := tagName(tagid)
+				if err != nil {
+					return evaluation.Report{}, err
+				}
+				sample.Truth = append(sample.Truth, evaluation.Object{Class: name})
+			}
+		} else {
+			for _, box := range annotation.Metadata.BoundingBoxes {
+				name, err := tagName(box.TagID)
+				if err != nil {
+					return evaluation.Report{}, err
+				}
+				sample.Truth = append(sample.Truth, evaluation.Object{
+					Class: name,
+					Box:   &evaluation.Box{Xmin: float64(box.Xmin), Ymin: float64(box.Ymin), Xmax: float64(box.Xmax), Ymax: float64(box.Ymax)},
+				})
+			}
+		}
+		samples = append(samples, sample)
+	}
+	if err := cursor.Err(); err != nil {
+		return evaluation.Report{}, err
+	}
+
+	classes := make([]string, len(model.IntegerMapping))
+	for name, idx := range model.IntegerMapping {
+		if idx >= 0 && idx < len(classes) {
+			classes[idx] = name
+		}
+	}
+
+	report := evaluation.NewReport(project.AnnotationType, samples, classes, r.Threshold, r.IoUThresholds)
+	report.ModelID = r.ModelID
+	report.DatasetID = model.DatasetID
+	report.Split = strings.ToUpper(r.Split)
+
+	return report, nil
+}
+
+// modelPredictions returns the stored predictions of a model by content id
+func (m Model) modelPredictions(userid, modelid string) (map[string][]evaluation.Object, error) {
+	cursor, err := m.platform.PredictionDB.FindModelPredictions(m.db, userid, modelid, &options.FindOptions{
+		Projection: bson.M{"b64_image": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	predictions := make(map[string][]evaluation.Object)
+	for cursor.Next(context.TODO()) {
+		var prediction models.Prediction
+		if err := cursor.Decode(&prediction); err != nil {
+			return nil, err
+		}
+
+		objects := []evaluation.Object{}
+		for _, p := range prediction.Predictions {
+			object := evaluation.Object{Class: p.ClassName, Confidence: p.Confidence}
+			if p.BoundingBox != nil {
+				object.Box = &evaluation.Box{
+					Xmin: coordinate(p.BoundingBox["xmin"]),
+					Ymin: coordinate(p.BoundingBox["ymin"]),
+					Xmax: coordinate(p.BoundingBox["xmax"]),
+					Ymax: coordinate(p.BoundingBox["ymax"]),
+				}
+			}
+			objects = append(objects, object)
+		}
+		predictions[prediction.ContentID] = objects
+	}
+
+	return predictions, cursor.Err()
+}
+
+// coordinate converts a bounding box value decoded from the database to a float
+func coordinate(v interface{}) float64 {
+	switch n := v.(type) {
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	errs "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/error"
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
 )
 
 const (
@@ -132,15 +134,15 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//
 	//   **Key**: `parameters.resource.max_runtime_seconds`
 	//   **Description**: Specifies a limit to how long a model hyperparameter training job can run.
-	//   **Value**:  Any integer in the range [0, 86400]
+	//   **Value**:  Any integer in the range [1, 86400]
 	//
 	//   **Key**: `parameters.resource.maximum_retry_attempts`
 	//   **Description**: The number of times to retry the job if it fails due to an internal server error.
-	//   **Value**: Any integer in the range [0, 10].
+	//   **Value**: Any integer in the range [1, 10].
 	//
 	//   **Key**: `parameters.resource.max_number_training_jobs`
 	//   **Description**: Max number of train jobs.
-	//   **Value**: Any integer in the range [0, 100].
+	//   **Value**: Any integer in the range [1, 100].
 	//
 	//   **Key**:`parameters.resource.instance_type`
 	//   **Description**: AWS instance type to train on.
@@ -194,7 +196,7 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//       "ml.g5.48xlarge"
 	//
 	//   **Key**: `hyperparameters`
-	//   **Description**: Hyperperameters is a mapping of tunable training parameter to it's value.</br>The acceptable parameters are based on model architecture.
+	//   **Description**: Hyperperameters is a mapping of tunable training parameter to it's value.</br>The acceptable parameters are based on model architecture; unknown parameters are rejected.</br>A value pins the parameter. The tuned parameters `learning_rate`, `weight_decay`, `momentum` and `mini_batch_size` also accept a range `[min, max]`, and `optimizer` a list of values, narrowing the values tried.</br>The parameters a model was last trained with are returned as `trained_parameters`.
 	//   **Value**: See Description of the options below:
 	//
 	//   <h2>Object Detection Parameters</h2>
@@ -224,6 +226,20 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//
 	//   **Key**: `hyperparameters.weight_decay`
 	//   **Description**: The weight decay coefficient for sgd and rmsprop. Ignored for other optimizers.
+	//   **Value**: Any float in the range [0:1]
+	//
+	//   <h2>Tuned Parameters (both architectures)</h2>
+	//   **Key**: `hyperparameters.optimizer`
+	//   **Description**: The optimizer type.
+	//   **Value**: Any of "sgd", "adam", "rmsprop", or a list of them.
+	//
+	//   **Key**: `hyperparameters.learning_rate`, `hyperparameters.weight_decay`, `hyperparameters.momentum`
+	//   **Description**: Tuned between 0.0001 and 0.01 (learning_rate) or 0.1 unless set.
+	//   **Value**: Any float in the range (0:1], or a range [min, max].
+	//
+	//   **Key**: `hyperparameters.mini_batch_size`
+	//   **Description**: The batch size for training; never exceeds the number of training or validation samples.
+	//   **Value**: Any integer in the range [1, 128], or a range [min, max].
 	//
 	//   <h2>Classification Parameters</h2>
 	//   **Key**: `hyperparameters.num_layers`
@@ -283,8 +299,11 @@ func NewHTTP(svc Service, r *echo.Group) {
 	// description: |
 	//   Trains a single model by its associated id. This is an asynchronous request and will return immediately if the request is well formed.
 	//   Status of the train job can be viewed by looking up the id of the passed in model.
+	//   Set `approved_only` to train only on annotations approved in review.
 	// security:
 	// - Bearer: []
+	// consumes:
+	//  - application/json
 	// parameters:
 	// - name: Id
 	//   in: path
@@ -301,12 +320,40 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//     "$ref": "#/responses/err"
 	ur.POST("/:id/train", h.train)
 
+	// swagger:operation DELETE /v1/models/{Id}/train models cancelTrainModelReq
+	// ---
+	// summary: Cancels training of a model.
+	// description: |
+	//   Cancels training of a single model by its associated id. This is an asynchronous request and will return immediately if the request is well formed.
+	//   The model moves to the `CANCELLED` state; the running training job is then stopped and the dataset version locked for it removed.
+	//   Time trained until the job stopped is billed.
+	// security:
+	// - Bearer: []
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of model
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Model"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.DELETE("/:id/train", h.cancelTraining)
+
 	// swagger:operation POST /v1/models/{Id}/deploy models deployModelReq
 	// ---
 	// summary: Deploys a model.
 	// description: |
 	//   Deploys a single model by its associated id. This is an asynchronous request and will return immediately if the request is well formed.
 	//   Status of the deployment job can be viewed by looking up the id of the passed in model.
+	//   Models trained locally are served without a deployment and cannot be deployed.
 	// security:
 	// - Bearer: []
 	// parameters:
@@ -321,6 +368,8 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//      "$ref": "#/definitions/Model"
 	//   "401":
 	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.POST("/:id/deploy", h.deployment)
@@ -431,6 +480,48 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//     "$ref": "#/responses/err"
 	ur.POST("/:id/inference/batch", h.createBatch)
 
+	// swagger:operation GET /v1/models/{Id}/evaluation models evaluationModelReq
+	// ---
+	// summary: Evaluates a trained model.
+	// description: |
+	//   Compares the predictions stored by batch inference against the ground truth annotations of the dataset version the model was trained on.
+	//   Returns a confusion matrix, overall and per-class precision, recall and F1 and, for object detection, precision recall curves at each IoU threshold.
+	//   Content without predictions is not evaluated; run batch inference first.
+	// security:
+	// - Bearer: []
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of model
+	//   type: string
+	//   required: true
+	// - name: threshold
+	//   in: query
+	//   description: confidence at or above which a prediction is counted. Defaults to 0.5.
+	//   type: number
+	//   required: false
+	// - name: iou_thresholds
+	//   in: query
+	//   description: comma separated IoU thresholds for object detection. The first is used for metrics and the confusion matrix. Defaults to 0.5.
+	//   type: string
+	//   required: false
+	// - name: split
+	//   in: query
+	//   description: dataset split to evaluate. oneof train, validation, test. Defaults to the whole dataset.
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Evaluation"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/evaluation", h.evaluation)
+
 	// swagger:operation POST /v1/models/query models queryModelReq
 	// ---
 	// summary: Query models
@@ -783,6 +874,16 @@ func (h HTTP) delete(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"message": fmt.Sprintf("Model %s deleted", modelid)})
 }
 
+// Model train request
+// swagger:parameters trainModelReq
+type trainModelReq struct {
+	// in:body
+	Body struct {
+		// Train only on annotations approved in review
+		ApprovedOnly bool `json:"approved_only"`
+	}
+}
+
 func (h HTTP) train(c echo.Context) error {
 	// Required params
 	userid := c.Request().Header.Get("userid")
@@ -794,7 +895,32 @@ func (h HTTP) train(c echo.Context) error {
 		return c.JSON(400, echo.NewHTTPError(400, "model `id` required"))
 	}
 
-	model, err := h.svc.Train(c, userid, modelid)
+	r := new(trainModelReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	model, err := h.svc.Train(c, userid, modelid, r.ApprovedOnly)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, model)
+}
+
+func (h HTTP) cancelTraining(c echo.Context) error {
+	// Required params
+	userid := c.Request().Header.Get("userid")
+	if userid == "" {
+		return c.JSON(401, echo.ErrUnauthorized)
+	}
+	modelid := c.Param("id")
+	if modelid == "" {
+		return c.JSON(400, echo.NewHTTPError(400, "model `id` required"))
+	}
+
+	model, err := h.svc.CancelTraining(c, userid, modelid)
 	if err != nil {
 		err := errs.EchoErr(err, 500)
 		return c.JSON(err.Code, err)
@@ -877,3 +1003,45 @@ func (h HTTP) createBatch(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Creating"})
 }
+
+type evaluationModelReq struct {
+	Threshold     *float64 `query:"threshold" validate:"omitempty,min=0,max=1"`
+	IoUThresholds string   `query:"iou_thresholds"`
+	Split         string   `query:"split" validate:"omitempty,oneof=train validation test"`
+}
+
+func (h HTTP) evaluation(c echo.Context) error {
+	var req evaluationModelReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	r := Evaluation{
+		ModelID:   c.Param("id"),
+		UserID:    user.ID.Hex(),
+		Split:     req.Split,
+		Threshold: evaluation.DefaultDetectionThreshold,
+	}
+	if req.Threshold != nil {
+		r.Threshold = *req.Threshold
+	}
+	if req.IoUThresholds != "" {
+		for _, v := range strings.Split(req.IoUThresholds, ",") {
+			iou, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || iou <= 0 || iou > 1 {
+				return c.JSON(400, echo.NewHTTPError(400, fmt.Sprintf("invalid iou threshold %q; must be in (0, 1]", v)))
+			}
+			r.IoUThresholds = append(r.IoUThresholds, iou)
+		}
+	}
+
+	report, err := h.svc.Evaluate(c, r)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -11,13 +11,17 @@ package model
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/google/go-cmp/cmp"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	backend "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/backend"
+	sageTrain "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/sage/train"
 	batchBL "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/worker/batch"
 	endpointBL "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/worker/endpoint"
 	garbageBL "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/worker/garbage"
@@ -60,6 +64,11 @@ func (m Model) Create(c echo.Context, userid, projectid, name string, preprocess
 		return models.Model{}, err
 	}
 
+	// Segmentation and keypoint projects can be labelled and exported but not trained
+	if project.AnnotationType == models.ProjectAnnotationTypeSegmentation.String() || project.AnnotationType == models.ProjectAnnotationTypeKeypoint.String() {
+		return models.Model{}, ErrTrainingNotSupported
+	}
+
 	// For classification projects, Check that there are at least 2 classes
 	if project.AnnotationType == models.ProjectAnnotationTypeClassification.String() {
 		if err := m.minimumClassCount(userid, projectid, datasets[0].ID.Hex()); err != nil {
@@ -109,6 +118,13 @@ func (m Model) Update(c echo.Context, r Update) (models.Model, error) {
 		return models.Model{}, platform.ErrModelDoesNotExist
 	}
 
+	// Train parameters are checked against the allow-list of the model's algorithm
+	if !cmp.Equal(r.Parameters, models.TrainParameters{}) {
+		if err := m.validParameters(r.UserID, r.ModelID, r.Parameters); err != nil {
+			return models.Model{}, err
+		}
+	}
+
 	if err := m.platform.ModelDB.Update(m.db, models.Model{
 		ID:            id,
 		UserID:        r.UserID,
@@ -123,6 +139,29 @@ func (m Model) Update(c echo.Context, r Update) (models.Model, error) {
 	return m.platform.ModelDB.View(m.db, r.UserID, r.ModelID)
 }
 
+// validParameters checks train parameters of a model
+func (m Model) validParameters(userid, modelid string, params models.TrainParameters) error {
+	model, err := m.platform.ModelDB.View(m.db, userid, modelid)
+	if err != nil {
+		return err
+	}
+
+	project, err := m.platform.ProjectDB.View(m.db, userid, model.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	projectType, err := models.ProjectAnnotationTypeFromString(project.AnnotationType)
+	if err != nil {
+		return err
+	}
+
+	if err := sageTrain.ValidParameters(params, projectType); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
 func (m Model) Delete(c echo.Context, userid, modelid string) error {
 	// Retrieve model for deployment info.
 	model, err := m.platform.ModelDB.View(m.db, userid, modelid)
@@ -155,7 +194,7 @@ func (m Model) Delete(c echo.Context, userid, modelid string) error {
 	return nil
 }
 
-func (m Model) Train(ctx echo.Context, userid, modelid string) (models.Model, error) {
+func (m Model) Train(ctx echo.Context, userid, modelid string, approvedOnly bool) (models.Model, error) {
 	var (
 		model = models.Model{}
 		err   error
@@ -173,7 +212,12 @@ func (m Model) Train(ctx echo.Context, userid, modelid string) (models.Model, er
 		return model, err
 	}
 
-	count, err := m.platform.AnnotationDB.CountAnnotations(m.db, userid, model.DatasetID)
+	var count *int64
+	if approvedOnly {
+		count, err = m.platform.AnnotationDB.CountStatus(m.db, userid, model.DatasetID, models.AnnotationStatusApproved)
+	} else {
+		count, err = m.platform.AnnotationDB.CountAnnotations(m.db, userid, model.DatasetID)
+	}
 	if err != nil {
 		return model, errors.Wrapf(err, "error locating dataset=%s annotation count", model.DatasetID)
 	}
@@ -187,13 +231,19 @@ func (m Model) Train(ctx echo.Context, userid, modelid string) (models.Model, er
 		return model, ErrModelInvalidState
 	}
 
-	if err := m.platform.ModelDB.Update(m.db, models.Model{
+	// A new run is started so that a cancelled run still winding down does not carry on as this one
+	if err := m.platform.ModelDB.Transition(m.db, models.Model{
 		ID:             model.ID,
 		UserID:         model.UserID,
 		State:          models.ModelStateInitialized.String(), // reset state
-		LastError:      aws.String(""),                        // reset error
+		TrainRunID:     primitive.NewObjectID().Hex(),
+		LastError:      aws.String(""), // reset error
 		TrainStartedAt: time.Now(),
-	}); err != nil {
+		ApprovedOnly:   &approvedOnly,
+	}, model.TrainRunID, models.ModelStateUnknown, models.ModelStateInitialized, models.ModelStateErr, models.ModelStateCancelled); err != nil {
+		if err == platform.ErrModelStateChanged {
+			return model, ErrModelInvalidState
+		}
 		log.Errorf("error updating model=%s; error=%s", model.ID.Hex(), err.Error())
 		return model, fmt.Errorf("error queuing up training job; unable to move model to 'ERR' state")
 	}
@@ -208,6 +258,37 @@ func (m Model) Train(ctx echo.Context, userid, modelid string) (models.Model, er
 	return m.platform.ModelDB.View(m.db, userid, modelid)
 }
 
+// CancelTraining moves the model to the 'Cancelled' state. The train worker stops the training job, removes the
+// locked dataset version and bills the time trained so far once it notices.
+func (m Model) CancelTraining(ctx echo.Context, userid, modelid string) (models.Model, error) {
+
+	// Retrieve model to cancel
+	model, err := m.platform.ModelDB.View(m.db, userid, modelid)
+	if err != nil {
+		return model, err
+	}
+
+	// Model must be queued for or in training
+	if model.State != models.ModelStateInitialized.String() && model.State != models.ModelStateTraining.String() {
+		return model, ErrModelNotTraining
+	}
+
+	// Training may have finished or been restarted since the model was retrieved
+	if err := m.platform.ModelDB.Transition(m.db, models.Model{
+		ID:     model.ID,
+		UserID: model.UserID,
+		State:  models.ModelStateCancelled.String(),
+	}, model.TrainRunID, models.ModelStateInitialized, models.ModelStateTraining); err != nil {
+		if err == platform.ErrModelStateChanged {
+			return model, ErrModelNotTraining
+		}
+		log.Errorf("error updating model=%s; error=%s", model.ID.Hex(), err.Error())
+		return model, err
+	}
+
+	return m.platform.ModelDB.View(m.db, userid, modelid)
+}
+
 func (m Model) Deploy(ctx echo.Context, userid, modelid string) error {
 
 	// Retrieve model to train
@@ -221,6 +302,11 @@ func (m Model) Deploy(ctx echo.Context, userid, modelid string) error {
 		return ErrModelNotTrained
 	}
 
+	// Models trained locally cannot be hosted by SageMaker
+	if backend.ServedBy(&model) == backend.Local {
+		return ErrModelServedLocally
+	}
+
 	// Model deployment can only be in UNKNOWN, ERR, or DELETED state to deploy
 	status := models.DeploymentStatusFromString(model.Deployment.Status)
 	if status > models.DeploymentStatusUnknown && status < models.DeploymentStatusDeleted {
@@ -301,8 +387,8 @@ func (m Model) CreateBatch(ctx echo.Context, userID, modelID string, thumbnailSi
 	if model.State != models.ModelStateTrained.String() {
 		return ErrModelNotTrained
 	}
-	// Model must have a deployment
-	if model.Deployment.EndpointName == "" {
+	// Model must have a deployment, unless served locally
+	if backend.ServedBy(&model) == backend.SageMaker && model.Deployment.EndpointName == "" {
 		return ErrModelDeploymentNotFound
 	}
 	// Check if batch job exist
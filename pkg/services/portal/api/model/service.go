@@ -18,6 +18,7 @@ import (
 	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
 	config "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/config"
 )
 
@@ -29,6 +30,10 @@ var (
 	ErrInvalidAnnotationCount      = echo.NewHTTPError(http.StatusBadRequest, "annotations are less than required minimum of 10")
 	ErrBatchBusy                   = echo.NewHTTPError(http.StatusConflict, "batch job already initialized or running")
 	ErrMinimumClasses              = echo.NewHTTPError(http.StatusBadRequest, "classification project must contain at least 2 classes, each with at least 10 annotations")
+	ErrNoPredictions               = echo.NewHTTPError(http.StatusConflict, "model has no predictions; run batch inference before evaluating")
+	ErrTrainingNotSupported        = echo.NewHTTPError(http.StatusNotImplemented, "training is not supported for segmentation or keypoint projects")
+	ErrModelServedLocally          = echo.NewHTTPError(http.StatusConflict, "model was trained locally and is served without a deployment")
+	ErrModelNotTraining            = echo.NewHTTPError(http.StatusConflict, "model is not training; can only be cancelled in 'Initialized' or 'Training' state")
 )
 
 // Initialize initializes Model application service with defaults
@@ -70,10 +75,12 @@ type Service interface {
 	Update(echo.Context, Update) (models.Model, error)
 	Delete(echo.Context, string, string) error
 
-	Train(echo.Context, string, string) (models.Model, error)
+	Train(echo.Context, string, string, bool) (models.Model, error)
+	CancelTraining(echo.Context, string, string) (models.Model, error)
 	Deploy(echo.Context, string, string) error
 	DeleteDeployment(echo.Context, string, string) error
 	CreateBatch(echo.Context, string, string, int) error
+	Evaluate(echo.Context, Evaluation) (evaluation.Report, error)
 }
 
 // Model represents model application service
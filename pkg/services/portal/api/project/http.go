@@ -14,7 +14,6 @@ import (
 	errs "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/error"
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
-	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/project/upload"
 )
 
 const (
@@ -157,25 +156,25 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//   Uploads content for the given user and project.
 	//   Uploaded files must be `multipart/form-data` data.
 	//   File(s) can be specified with key as `files`.
-	//   This endpoint processes files in realtime. Once the request returns, all files are processed.
+	//   Archives (`.zip`, `.tar`, `.tar.gz`) are expanded server-side and the images they contain are processed as
+	//   individual files. If no `labels_file` is specified, a `labels.json` found inside an archive is used to label the upload.
+	//   Label files included in the upload are also imported: COCO annotation files (e.g. `instances.json`),
+	//   Pascal VOC `.xml` files and YOLO `.txt` files with a `classes.txt`. VOC and YOLO files are matched to the
+	//   image with the same name, e.g. `image1.xml` labels `image1.jpeg`.
+	//   Files are staged and processed in the background. The returned upload `id` can be used with
+	//   `GET /v1/uploads/{Id}` to track progress and per-file failures.
 	//
 	//   Example response:
 	//   ```json
 	//   {
+	//       "id": "6475d4b1c2a4b1e0f1a2b3c4",
+	//       "projectid": "6475d4b1c2a4b1e0f1a2b3c5",
+	//       "state": "RUNNING",
 	//       "labels_file": "labels.json",
-	//        "errors": [
-	//        {
-	//            "Error": "UploadErrInvalidFormat",
-	//            "InternalError": "",
-	//            "Filename": "train.manifest",
-	//            "Message": "The provided file format is not allowed. Please upload a JPEG or PNG image"
-	//        }
-	//        ],
-	//        "total_bytes": 3591,
-	//        "total_files": 1,
-	//        "total_files_failed": 1,
-	//        "total_files_succeeded": 0,
-	//        "total_files_duplicate": 0
+	//       "failed": {},
+	//       "total_bytes": 3591,
+	//       "total_images": 1,
+	//       "total_images_processed": 0
 	//   }
 	//   ```
 	// security:
@@ -195,10 +194,10 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//   in: query
 	//   type: string
 	//   required: false
-	//   description: JSON file containing labels for the associated upload.
+	//   description: JSON file containing labels for the associated upload, in Emerald labels or COCO format.
 	// - name: files
 	//   in: formData
-	//   description: File(s) with data for the project
+	//   description: File(s) with data for the project. Images (JPEG or PNG) or archives of images.
 	//   type: file
 	//   required: false
 	// responses:
@@ -366,7 +365,7 @@ type createProjectReq struct {
 		// Description of project
 		ProjectDescription string `json:"description" query:"description"`
 		// Annotation type of project
-		ProjectAnnotation string `json:"annotation_type" query:"annotation_type" validate:"required,oneof=classification bounding_box"`
+		ProjectAnnotation string `json:"annotation_type" query:"annotation_type" validate:"required,oneof=classification bounding_box segmentation keypoint"`
 		// License type of project
 		ProjectLicense string `json:"license" query:"license"`
 	}
@@ -603,7 +602,7 @@ func (h HTTP) update(c echo.Context) error {
 type uploadProjectResp struct {
 	// in:body
 	Body struct {
-		*upload.Report
+		*models.Upload
 	}
 }
 
@@ -629,11 +628,11 @@ func (h HTTP) upload(c echo.Context) error {
 	}
 
 	req := CreateUploadReq{UserID: user.ID.Hex(), ProjectID: projectid, LabelsFile: labelsFile, Files: form.File["files"]}
-	report, err := h.svc.Upload(c, req)
+	upload, err := h.svc.Upload(c, req)
 	if err != nil {
 		err := errs.EchoErr(err, 500)
 		return c.JSON(err.Code, err)
 	}
 
-	return c.JSON(http.StatusOK, *report)
+	return c.JSON(http.StatusOK, upload)
 }
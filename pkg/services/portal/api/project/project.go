@@ -8,14 +8,15 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/sync/errgroup"
 
+	archive "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/archive"
 	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
 	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
 	worker "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/worker"
@@ -32,6 +33,9 @@ const (
 	UploadRetryWaitSeconds = 2
 	UploadBackoff          = true
 	UploadPoolThreshold    = 10
+	UploadResumeInterval   = 5 * time.Minute
+	UploadStaleAfter       = 15 * time.Minute
+	UploadHeartbeat        = time.Minute
 
 	// 3MB to align with Sagemaker serverless inference size limit (4MB) and provide a buffer for other request content.
 	// https://docs.aws.amazon.com/sagemaker/latest/dg/serverless-endpoints-invoke.html
@@ -160,6 +164,16 @@ func (p Project) Delete(c echo.Context, userid, projectid string) error {
 		return err
 	}
 
+	// Delete the history of the project's annotations
+	if err := p.platform.RevisionDB.DeleteProjectRevisions(p.db, userid, projectid); err != nil {
+		return err
+	}
+
+	// Delete any labelling Tasks associated with the project
+	if err := p.platform.TaskDB.DeleteProjectTasks(p.db, userid, projectid); err != nil {
+		return err
+	}
+
 	// Delete any Exports associated with the project
 	cursor, err = p.platform.ExportDB.FindProjectExports(p.db, userid, projectid)
 	if err != nil {
@@ -311,109 +325,319 @@ type CreateUploadReq struct {
 	Files      []*multipart.FileHeader
 }
 
-// Create creates a new upload entry
-func (p Project) Upload(c echo.Context, req CreateUploadReq) (*upload.Report, error) {
+// Upload stages the uploaded files to the blob store and processes them in the background.
+// The returned upload can be polled for progress and per-file failures.
+func (p Project) Upload(c echo.Context, req CreateUploadReq) (models.Upload, error) {
 	// Check project exists
 	project, err := p.platform.ProjectDB.View(p.db, req.UserID, req.ProjectID)
 	if err != nil {
-		return nil, err
+		return models.Upload{}, err
 	}
 
-	// Check for labels file and create label-map if found
+	uploadModel := models.NewUpload(req.UserID, req.ProjectID, p.blob.Bucket, req.LabelsFile)
+	for _, fileHeader := range req.Files {
+		uploadModel.TotalBytes += fileHeader.Size
+		if fileHeader.Filename != req.LabelsFile && !upload.IsLabelsFile(fileHeader.Filename) {
+			uploadModel.TotalImages++
+		}
+	}
+
+	if _, err := p.platform.UploadDB.Create(p.db, uploadModel); err != nil {
+		return models.Upload{}, err
+	}
+
+	// Stage files to the blob store so processing can resume if interrupted
 	now := time.Now()
-	labelSlice, err := models.ParseLabelsFromFile(req.LabelsFile, req.Files)
+	stop := p.heartbeat(uploadModel.ID)
+	err = p.stage(&uploadModel, req.Files)
+	stop()
 	if err != nil {
-		log.Warnf("unable to parse labels file=%s; skipping labeling. Error=%s", req.LabelsFile, err.Error())
+		log.Errorf("error staging upload; upload=%s project=%s err=%s", uploadModel.ID.Hex(), req.ProjectID, err.Error())
+		if err := p.platform.UploadDB.SetState(p.db, uploadModel.ID, models.UploadStateErr, err.Error()); err != nil {
+			log.Errorf("unable to update upload state; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+		}
+		p.blob.DeleteChan <- uploadModel.Key
+		return models.Upload{}, err
 	}
-	log.Debugf("Parse labels time: %dms", time.Since(now).Milliseconds())
+	log.Debugf("Stage upload time: %dms", time.Since(now).Milliseconds())
 
-	// Validate labels
-	labelMap := labelSlice.Validate(project.AnnotationType)
+	uploadModel.State = models.UploadStateRunning.String()
+	if err := p.platform.UploadDB.Update(p.db, uploadModel); err != nil {
+		return models.Upload{}, err
+	}
 
-	// Upload metadata updates
-	report := upload.NewReport(req.LabelsFile, len(req.Files))
+	go p.process(uploadModel, project)
 
-	defer func() {
-		go func() {
-			// Update Project count
-			if err := p.platform.ProjectDB.UpdateCount(p.db, req.ProjectID, req.UserID, report.TotalImagesSucceeded); err != nil {
-				log.Errorf("unable to update project count; project=%s, err=%s", req.ProjectID, err.Error())
-			}
-			// Update usage
-			if err := p.platform.UserDB.AddUsage(p.db, req.UserID, models.Usage{
-				Time:          time.Now().UTC().Format(time.RFC3339),
-				Type:          models.UsageTypeUpload,
-				BillingMetric: models.BillingMetricGB,
-				BillableValue: float64(report.TotalBytes) / 1_000_000_000,
-				Metadata:      map[string]interface{}{"projectid": req.ProjectID, "object_count": len(req.Files)},
-			}); err != nil {
-				log.Errorf("Unable to update usage for project=%s user=%s; unable to locate user", req.ProjectID, req.UserID)
+	return uploadModel, nil
+}
+
+// stage copies the multipart files of an upload request into the upload's staging area
+func (p Project) stage(uploadModel *models.Upload, files []*multipart.FileHeader) error {
+	var g errgroup.Group
+	g.SetLimit(UploadConcurrency)
+
+	keys := make([]string, len(files))
+	for idx, fileHeader := range files {
+		idx, fileHeader := idx, fileHeader
+
+		key := uploadModel.StagedKey(idx, fileHeader.Filename)
+		if uploadModel.LabelsFile != "" && fileHeader.Filename == uploadModel.LabelsFile {
+			key = uploadModel.LabelsKey()
+		} else {
+			keys[idx] = key
+		}
+
+		g.Go(func() error {
+			file, err := fileHeader.Open()
+			if err != nil {
+				return errors.Wrapf(err, "unable to open file; file=%s", fileHeader.Filename)
 			}
-		}()
-	}()
+			defer file.Close()
 
-	// Don't spin up the pool if less than threshold
-	if len(req.Files) < UploadPoolThreshold {
-		for _, fileHeader := range req.Files {
-			args := upload.UploadArgs{
-				File:                fileHeader,
-				Blob:                p.blob,
-				Platform:            p.platform,
-				DB:                  p.db,
-				Report:              report,
-				Project:             &project,
-				LabelMap:            labelMap,
-				UploadMaxFileSizeMB: UploadMaxFileSizeMB,
-				UploadMaxImageWidth: UploadMaxImageWidth,
-				UploadImageFormat:   UploadImageFormat,
+			if _, err := p.blob.Uploader.Upload(file, p.blob.Bucket, key); err != nil {
+				return errors.Wrapf(err, "unable to stage file; file=%s", fileHeader.Filename)
 			}
-			args.Upload()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key != "" {
+			uploadModel.Files = append(uploadModel.Files, key)
 		}
-		return report, nil
 	}
+	uploadModel.TotalFileUploaded = int64(len(uploadModel.Files))
 
-	pool, sent, received := worker.New[struct{}](worker.Config{
-		Concurrency:      UploadConcurrency,
-		RetryAttempts:    UploadRetries,
-		RetryWaitSeconds: UploadRetryWaitSeconds,
-		RetryBackoff:     UploadBackoff,
-		RetryJitter:      true,
-		Name:             fmt.Sprintf("upload=%s", uuid.New()),
-	}).Start(), 0, 0
+	return nil
+}
 
-	go func() {
-		log.Debugf("starting upload progress routine; project=%s time=%d", req.ProjectID, time.Now().UnixMilli())
-		for range pool.OutChan {
-			received++
+// process runs every pending staged file of an upload through the upload pipeline and finalizes the upload
+func (p Project) process(uploadModel models.Upload, project models.Project) {
+	log.Debugf("processing upload; upload=%s project=%s files=%d", uploadModel.ID.Hex(), uploadModel.ProjectID, len(uploadModel.Files))
+
+	stop := p.heartbeat(uploadModel.ID)
+	defer stop()
+
+	// Expand archives into individually staged files
+	expanded := false
+	for _, key := range uploadModel.Files {
+		if !archive.IsArchive(key) {
+			continue
 		}
-	}()
+		args := upload.UploadArgs{Key: key, Job: &uploadModel, Blob: p.blob, Platform: p.platform, DB: p.db, Project: &project}
+		args.Expand()
+		expanded = true
+	}
 
-	// Feed the pool
-	log.Debugf("starting upload feed loop; project=%s time=%s", req.ProjectID, time.Now().UnixMilli())
-	for _, fileHeader := range req.Files {
-		args := upload.UploadArgs{
-			File:                fileHeader,
+	if expanded {
+		// Reload pending files and any labels file found in an archive
+		result, err := p.platform.UploadDB.View(p.db, uploadModel.UserID, uploadModel.ID.Hex())
+		if err != nil {
+			log.Errorf("unable to retrieve upload; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+			if err := p.platform.UploadDB.SetState(p.db, uploadModel.ID, models.UploadStateErr, err.Error()); err != nil {
+				log.Errorf("unable to update upload state; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+			}
+			return
+		}
+		uploadModel = result
+	}
+
+	// Label files are read up front and only marked processed once all images are done,
+	// so a resumed upload can still label its remaining images.
+	imageKeys, labelKeys := []string{}, []string{}
+	for _, key := range uploadModel.Files {
+		if upload.IsLabelsFile(key) {
+			labelKeys = append(labelKeys, key)
+		} else {
+			imageKeys = append(imageKeys, key)
+		}
+	}
+
+	// Create label-map
+	labelMap := upload.ReadLabels(p.blob, &uploadModel, labelKeys, project.AnnotationType)
+
+	newArgs := func(key string) upload.UploadArgs {
+		return upload.UploadArgs{
+			Key:                 key,
+			Job:                 &uploadModel,
 			Blob:                p.blob,
 			Platform:            p.platform,
 			DB:                  p.db,
-			Report:              report,
 			Project:             &project,
 			LabelMap:            labelMap,
 			UploadMaxFileSizeMB: UploadMaxFileSizeMB,
 			UploadMaxImageWidth: UploadMaxImageWidth,
 			UploadImageFormat:   UploadImageFormat,
 		}
-		pool.InChan <- args.Upload
-		sent++
 	}
 
-	// Wait for completion
-	for received != sent {
-		log.Debugf("waiting for upload to finish; sent=%d received=%d", sent, received)
-		time.Sleep(1 * time.Second)
+	// Don't spin up the pool if less than threshold
+	if len(imageKeys) < UploadPoolThreshold {
+		for _, key := range imageKeys {
+			args := newArgs(key)
+			args.Upload()
+		}
+	} else {
+		pool, sent, received := worker.New[struct{}](worker.Config{
+			Concurrency:      UploadConcurrency,
+			RetryAttempts:    UploadRetries,
+			RetryWaitSeconds: UploadRetryWaitSeconds,
+			RetryBackoff:     UploadBackoff,
+			RetryJitter:      true,
+			Name:             fmt.Sprintf("upload=%s", uploadModel.ID.Hex()),
+		}).Start(), 0, 0
+
+		var mu sync.Mutex
+		go func() {
+			log.Debugf("starting upload progress routine; upload=%s time=%d", uploadModel.ID.Hex(), time.Now().UnixMilli())
+			for range pool.OutChan {
+				mu.Lock()
+				received++
+				mu.Unlock()
+			}
+		}()
+
+		// Feed the pool
+		log.Debugf("starting upload feed loop; upload=%s time=%d", uploadModel.ID.Hex(), time.Now().UnixMilli())
+		for _, key := range imageKeys {
+			args := newArgs(key)
+			pool.InChan <- args.Upload
+			sent++
+		}
+
+		// Wait for completion
+		for {
+			mu.Lock()
+			done := received == sent
+			mu.Unlock()
+			if done {
+				break
+			}
+			log.Debugf("waiting for upload to finish; upload=%s sent=%d", uploadModel.ID.Hex(), sent)
+			time.Sleep(1 * time.Second)
+		}
+
+		pool.Stop()
+	}
+
+	for _, key := range labelKeys {
+		if err := p.platform.UploadDB.Progress(p.db, uploadModel.ID, models.UploadProgress{File: key}); err != nil {
+			log.Errorf("error recording upload progress; upload=%s, file=%s, err=%s", uploadModel.ID.Hex(), key, err.Error())
+		}
+	}
+
+	p.finalize(uploadModel)
+}
+
+// heartbeat refreshes the updated_at of an upload every UploadHeartbeat until the returned function is
+// called, so uploads staging or processing for longer than UploadStaleAfter are not resumed by another instance
+func (p Project) heartbeat(uploadid primitive.ObjectID) func() {
+	ticker := time.NewTicker(UploadHeartbeat)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := p.platform.UploadDB.Heartbeat(p.db, uploadid); err != nil {
+					log.Errorf("unable to refresh upload; upload=%s err=%s", uploadid.Hex(), err.Error())
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// finalize sets the terminal state of an upload, updates project counts and usage, and removes staged files
+func (p Project) finalize(uploadModel models.Upload) {
+	result, err := p.platform.UploadDB.View(p.db, uploadModel.UserID, uploadModel.ID.Hex())
+	if err != nil {
+		log.Errorf("unable to retrieve upload; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+		return
+	}
+
+	state := models.UploadStateComplete
+	if result.TotalImagesFailed > 0 {
+		state = models.UploadStateCompleteWithErr
+	}
+	if err := p.platform.UploadDB.SetState(p.db, result.ID, state, ""); err != nil {
+		log.Errorf("unable to update upload state; upload=%s err=%s", result.ID.Hex(), err.Error())
+	}
+
+	// Update Project count
+	if err := p.platform.ProjectDB.UpdateCount(p.db, result.ProjectID, result.UserID, result.TotalImagesSucceeded); err != nil {
+		log.Errorf("unable to update project count; project=%s, err=%s", result.ProjectID, err.Error())
 	}
 
-	pool.Stop()
+	// Update usage
+	if err := p.platform.UserDB.AddUsage(p.db, result.UserID, models.Usage{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		Type:          models.UsageTypeUpload,
+		BillingMetric: models.BillingMetricGB,
+		BillableValue: float64(result.TotalBytesUploaded) / 1_000_000_000,
+		Metadata:      map[string]interface{}{"projectid": result.ProjectID, "uploadid": result.ID.Hex(), "object_count": result.TotalImagesProcessed},
+	}); err != nil {
+		log.Errorf("Unable to update usage for project=%s user=%s; unable to locate user", result.ProjectID, result.UserID)
+	}
+
+	// Remove staged files
+	if err := p.blob.S3Client.DeleteAllObjects(result.Bucket, result.Key); err != nil {
+		log.Errorf("unable to remove staged upload files; upload=%s err=%s", result.ID.Hex(), err.Error())
+	}
+}
+
+// Resume periodically picks up uploads that were interrupted, e.g. by a service restart, and
+// continues processing their remaining staged files.
+func (p Project) Resume() {
+	ticker := time.NewTicker(UploadResumeInterval)
+	defer ticker.Stop()
 
-	return report, nil
+	for ; true; <-ticker.C {
+		uploads, err := p.platform.UploadDB.FindStale(p.db, time.Now().Add(-UploadStaleAfter))
+		if err != nil {
+			log.Errorf("unable to find stale uploads; err=%s", err.Error())
+			continue
+		}
+
+		for _, uploadModel := range uploads {
+			claimed, err := p.platform.UploadDB.Claim(p.db, uploadModel)
+			if err != nil {
+				log.Errorf("unable to claim upload; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+				continue
+			}
+			if !claimed {
+				// Another instance picked up the upload
+				continue
+			}
+
+			// Uploads interrupted while staging never returned an id to the caller
+			if uploadModel.State == models.UploadStateInitialized.String() {
+				if err := p.platform.UploadDB.SetState(p.db, uploadModel.ID, models.UploadStateErr, "upload interrupted while staging files"); err != nil {
+					log.Errorf("unable to update upload state; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+				}
+				p.blob.DeleteChan <- uploadModel.Key
+				continue
+			}
+
+			project, err := p.platform.ProjectDB.View(p.db, uploadModel.UserID, uploadModel.ProjectID)
+			if err != nil {
+				log.Errorf("unable to resume upload; upload=%s project=%s err=%s", uploadModel.ID.Hex(), uploadModel.ProjectID, err.Error())
+				if err := p.platform.UploadDB.SetState(p.db, uploadModel.ID, models.UploadStateErr, err.Error()); err != nil {
+					log.Errorf("unable to update upload state; upload=%s err=%s", uploadModel.ID.Hex(), err.Error())
+				}
+				continue
+			}
+
+			log.Infof("resuming upload; upload=%s project=%s remaining=%d", uploadModel.ID.Hex(), uploadModel.ProjectID, len(uploadModel.Files))
+			go p.process(uploadModel, project)
+		}
+	}
 }
@@ -8,7 +8,6 @@ import (
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
-	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/project/upload"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/config"
 
 	awssqs "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/aws/sqs"
@@ -44,7 +43,7 @@ type Service interface {
 	Delete(echo.Context, string, string) error
 	Profile(echo.Context, string, string, string, *multipart.File) (string, error)
 	Update(echo.Context, Update) (models.Project, error)
-	Upload(echo.Context, CreateUploadReq) (*upload.Report, error)
+	Upload(echo.Context, CreateUploadReq) (models.Upload, error)
 }
 
 // Project represents project application service
@@ -0,0 +1,153 @@
+/*
+ * File: archive.go
+ * Project: upload
+ * File Created: Wednesday, 4th January 2023 8:12:03 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 2nd February 2024 2:33:11 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package upload
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	archive "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/archive"
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+)
+
+const (
+	// Labels file picked up from an archive when the upload did not specify one
+	DefaultLabelsFile = "labels.json"
+
+	expandConcurrency = 16
+)
+
+// Expand unpacks a staged archive and stages each file it contains as its own upload file.
+// A labels file found inside the archive is staged as the labels file of the upload.
+func (a *UploadArgs) Expand() (_ struct{}, _ error) {
+	filename := path.Base(a.Key)
+
+	fail := func(err error, message string) {
+		log.Errorf("error expanding archive; file=%s, err=%s", a.Key, err.Error())
+		report := NewReport(a.Job.LabelsFile, 1)
+		report.AddErr(err, UploadErrArchive, filename, message)
+		if err := a.Platform.UploadDB.Progress(a.DB, a.Job.ID, report.Progress(a.Key)); err != nil {
+			log.Errorf("error recording upload progress; upload=%s, file=%s, err=%s", a.Job.ID.Hex(), a.Key, err.Error())
+		}
+	}
+
+	archiveBytes, err := a.Blob.Get(a.Blob.Bucket, a.Key)
+	if err != nil {
+		fail(err, "Unable to read file")
+		return
+	}
+
+	members, err := archive.Unarchive(filename, archiveBytes)
+	if err != nil {
+		fail(err, "Unable to extract archive")
+		return
+	}
+
+	// Only pick up a labels file from the archive if one was not staged with the upload
+	findLabels := true
+	if a.Job.LabelsFile != "" {
+		exists, err := a.Blob.S3Client.ObjectExists(a.Blob.Bucket, a.Job.LabelsKey())
+		if err != nil {
+			fail(err, "Unable to extract archive")
+			return
+		}
+		findLabels = !exists
+	}
+
+	expansion := a.planExpansion(members, findLabels)
+
+	var g errgroup.Group
+	g.SetLimit(expandConcurrency)
+
+	for _, staged := range expansion.staged {
+		staged := staged
+		g.Go(func() error {
+			if _, err := a.Blob.Uploader.Upload(bytes.NewReader(staged.member.FileBytes), a.Blob.Bucket, staged.key); err != nil {
+				return errors.Wrapf(err, "unable to stage archive member; file=%s", staged.member.FileName)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		fail(err, "Unable to extract archive")
+		return
+	}
+
+	if err := a.Platform.UploadDB.Expand(a.DB, a.Job.ID, a.Key, expansion.keys, expansion.images, expansion.bytes-int64(len(archiveBytes)), expansion.labelsFile); err != nil {
+		log.Errorf("error recording expanded archive; upload=%s, file=%s, err=%s", a.Job.ID.Hex(), a.Key, err.Error())
+		return
+	}
+
+	log.Debugf("expanded archive; upload=%s, file=%s, files=%d, labels=%s", a.Job.ID.Hex(), a.Key, len(expansion.keys), expansion.labelsFile)
+
+	return
+}
+
+// stagedMember is an archive member and the key it is staged under
+type stagedMember struct {
+	key    string
+	member archive.ArchiveResult
+}
+
+// expansion describes how the members of an archive are staged
+type expansion struct {
+	staged []stagedMember
+	// Keys of the staged files pending processing; a labels file found in the archive is not among them
+	keys       []string
+	images     int
+	labelsFile string
+	bytes      int64
+}
+
+// planExpansion decides which members of an archive are staged and under which keys. When findLabels is set,
+// the first member named like the labels file of the upload, or DefaultLabelsFile if it has none, is staged
+// as the labels file of the upload.
+func (a *UploadArgs) planExpansion(members []archive.ArchiveResult, findLabels bool) expansion {
+	result := expansion{}
+
+	for idx, member := range members {
+		if skipArchiveMember(member.FileName) {
+			continue
+		}
+
+		key := a.Job.StagedArchiveKey(a.Key, idx, member.FileName)
+		base := path.Base(member.FileName)
+		if findLabels && result.labelsFile == "" && (base == a.Job.LabelsFile || (a.Job.LabelsFile == "" && base == DefaultLabelsFile)) {
+			result.labelsFile = base
+			a.Job.LabelsFile = base
+			key = a.Job.LabelsKey()
+		} else {
+			result.keys = append(result.keys, key)
+			if !IsLabelsFile(member.FileName) {
+				result.images++
+			}
+		}
+		result.bytes += int64(len(member.FileBytes))
+		result.staged = append(result.staged, stagedMember{key: key, member: member})
+	}
+
+	return result
+}
+
+// skipArchiveMember filters out hidden files and OS metadata commonly bundled into archives.
+// Names are cleaned first so members of archives created from ".", e.g. "./img.jpg", are kept.
+func skipArchiveMember(name string) bool {
+	for _, part := range strings.Split(path.Clean(name), "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+/*
+ * File: archive_test.go
+ * Project: upload
+ * File Created: Monday, 4th March 2024 11:40:18 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 4th March 2024 11:40:18 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package upload
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	archive "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/archive"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// testMembers are the members of the test archives; directories end in "/"
+var testMembers = []string{
+	"./",
+	"./a.jpg",
+	"./images/",
+	"./images/b.png",
+	"./labels.json",
+	"./.DS_Store",
+	"__MACOSX/._a.jpg",
+	"images/.hidden/c.jpg",
+}
+
+func testZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range testMembers {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		if name[len(name)-1] != '/' {
+			_, err = w.Write([]byte(name))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func testTarGz(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gzipWriter)
+	for _, name := range testMembers {
+		if name[len(name)-1] == '/' {
+			require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}))
+			continue
+		}
+		require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(name))}))
+		_, err := writer.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestSkipArchiveMember(t *testing.T) {
+	cases := map[string]bool{
+		"a.jpg":                false,
+		"./a.jpg":              false,
+		"images/./b.png":       false,
+		"images/b.png":         false,
+		".DS_Store":            true,
+		"./.DS_Store":          true,
+		"__MACOSX/._a.jpg":     true,
+		"images/.hidden/c.jpg": true,
+	}
+
+	for name, skip := range cases {
+		assert.Equal(t, skip, skipArchiveMember(name), name)
+	}
+}
+
+func TestPlanExpansion(t *testing.T) {
+	archives := map[string][]byte{
+		"images.zip":    testZip(t),
+		"images.tar.gz": testTarGz(t),
+	}
+
+	for filename, archiveBytes := range archives {
+		t.Run(filename, func(t *testing.T) {
+			members, err := archive.Unarchive(filename, archiveBytes)
+			require.NoError(t, err)
+
+			job := models.NewUpload("user", "project", "bucket", "")
+			key := job.StagedKey(0, filename)
+			args := UploadArgs{Key: key, Job: &job}
+
+			result := args.planExpansion(members, true)
+
+			// Members of archives created from "." are kept; hidden files and OS metadata are not
+			assert.Equal(t, []string{
+				job.StagedArchiveKey(key, 0, "a.jpg"),
+				job.StagedArchiveKey(key, 1, "b.png"),
+			}, result.keys)
+			assert.Equal(t, 2, result.images)
+
+			// The labels file is staged as the labels file of the upload
+			assert.Equal(t, DefaultLabelsFile, result.labelsFile)
+			assert.Equal(t, DefaultLabelsFile, job.LabelsFile)
+			require.Len(t, result.staged, 3)
+			assert.Equal(t, job.LabelsKey(), result.staged[2].key)
+			assert.Equal(t, "./labels.json", result.staged[2].member.FileName)
+
+			assert.Equal(t, int64(len("./a.jpg")+len("./images/b.png")+len("./labels.json")), result.bytes)
+		})
+	}
+}
+
+func TestPlanExpansionLabelsFile(t *testing.T) {
+	members := []archive.ArchiveResult{
+		{FileName: "a.jpg"},
+		{FileName: "labels.json"},
+		{FileName: "coco.json"},
+	}
+
+	// A labels file staged with the upload takes precedence over the one in the archive
+	job := models.NewUpload("user", "project", "bucket", "coco.json")
+	args := UploadArgs{Key: job.StagedKey(0, "images.zip"), Job: &job}
+
+	result := args.planExpansion(members, false)
+	assert.Empty(t, result.labelsFile)
+	assert.Len(t, result.keys, 3)
+	assert.Equal(t, 1, result.images)
+
+	// Otherwise the archive member named like the labels file of the upload is used
+	result = args.planExpansion(members, true)
+	assert.Equal(t, "coco.json", result.labelsFile)
+	assert.Equal(t, []string{args.Key + ".d/0/a.jpg", args.Key + ".d/1/labels.json"}, result.keys)
+}
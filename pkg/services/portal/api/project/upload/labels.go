@@ -0,0 +1,127 @@
+/*
+ * File: labels.go
+ * Project: upload
+ * File Created: Tuesday, 6th February 2024 11:02:31 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Tuesday, 6th February 2024 11:02:31 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package upload
+
+import (
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+const readLabelsConcurrency = 32
+
+// IsLabelsFile reports whether a staged file holds labels rather than an image
+func IsLabelsFile(key string) bool {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".json", ".xml", ".txt", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// ReadLabels builds the label map for an upload from its labels file and any label files staged
+// alongside the images: COCO files, Pascal VOC xml files and YOLO txt files with an optional classes.txt.
+func ReadLabels(b *blob.Blob, job *models.Upload, keys []string, annotationType string) models.LabelMap {
+	labels := models.Labels{}
+
+	// Check for labels file
+	if job.LabelsFile != "" {
+		labelBytes, err := b.Get(job.Bucket, job.LabelsKey())
+		if err != nil {
+			log.Warnf("unable to read labels file=%s; skipping labeling. Error=%s", job.LabelsFile, err.Error())
+		} else if labelSlice, err := models.ParseLabels(labelBytes, annotationType); err != nil {
+			log.Warnf("unable to parse labels file=%s; skipping labeling. Error=%s", job.LabelsFile, err.Error())
+		} else {
+			labels = append(labels, labelSlice...)
+		}
+	}
+
+	// YOLO class names are needed before any YOLO annotation can be parsed
+	classes := []string{}
+	for _, key := range keys {
+		if path.Base(key) != models.YOLOClassesFile {
+			continue
+		}
+		fileBytes, err := b.Get(job.Bucket, key)
+		if err != nil {
+			log.Warnf("unable to read classes file=%s; skipping. Error=%s", key, err.Error())
+			continue
+		}
+		classes = models.ParseYOLOClasses(fileBytes)
+		break
+	}
+
+	// Check for label files staged with the upload i.e. COCO, Pascal VOC and YOLO
+	var (
+		g  errgroup.Group
+		mu sync.Mutex
+	)
+	g.SetLimit(readLabelsConcurrency)
+
+	for _, key := range keys {
+		key := key
+		if path.Base(key) == models.YOLOClassesFile {
+			continue
+		}
+
+		g.Go(func() error {
+			fileBytes, err := b.Get(job.Bucket, key)
+			if err != nil {
+				log.Warnf("unable to read labels file=%s; skipping. Error=%s", key, err.Error())
+				return nil
+			}
+
+			parsed := parseLabelsFile(key, fileBytes, classes, annotationType)
+
+			mu.Lock()
+			labels = append(labels, parsed...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return labels.Validate(annotationType)
+}
+
+// parseLabelsFile parses a label file staged alongside the images by its extension. Files that cannot be
+// parsed are skipped, as are JSON files that are not COCO files, e.g. items.json from a project export.
+func parseLabelsFile(key string, fileBytes []byte, classes []string, annotationType string) models.Labels {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".json":
+		coco, err := models.ParseCOCO(fileBytes)
+		if err != nil {
+			log.Debugf("skipping non COCO json file=%s; err=%s", key, err.Error())
+			return nil
+		}
+		return coco.Labels(annotationType)
+	case ".xml":
+		label, err := models.ParseVOC(key, fileBytes, annotationType)
+		if err != nil {
+			log.Warnf("unable to parse VOC file=%s; skipping. Error=%s", key, err.Error())
+			return nil
+		}
+		return models.Labels{label}
+	case ".txt":
+		label, err := models.ParseYOLO(key, fileBytes, classes, annotationType)
+		if err != nil {
+			log.Warnf("unable to parse YOLO file=%s; skipping. Error=%s", key, err.Error())
+			return nil
+		}
+		return models.Labels{label}
+	}
+	return nil
+}
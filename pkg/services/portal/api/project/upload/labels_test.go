@@ -0,0 +1,68 @@
+/*
+ * File: labels_test.go
+ * Project: upload
+ * File Created: Monday, 4th March 2024 2:15:44 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 4th March 2024 2:15:44 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package upload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/log"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestIsLabelsFile(t *testing.T) {
+	assert.True(t, IsLabelsFile("staging/0/labels.json"))
+	assert.True(t, IsLabelsFile("annotations/image1.XML"))
+	assert.True(t, IsLabelsFile("labels/image1.txt"))
+	assert.False(t, IsLabelsFile("images/image1.jpeg"))
+	assert.False(t, IsLabelsFile("images.zip"))
+}
+
+func TestParseLabelsFile(t *testing.T) {
+	require.NoError(t, log.New(log.Configuration{EnableConsole: true, Level: log.Fatal}, log.InstanceZapLogger))
+
+	bbox := models.ProjectAnnotationTypeBoundingBox.String()
+
+	coco := parseLabelsFile("staging/0/coco.json", []byte(`{
+		"images": [{"id": 1, "file_name": "image1.jpeg", "width": 640, "height": 480}],
+		"categories": [{"id": 1, "name": "cat"}],
+		"annotations": [{"id": 1, "image_id": 1, "category_id": 1, "bbox": [10, 20, 30, 40]}]
+	}`), nil, bbox)
+	require.Len(t, coco, 1)
+	assert.Equal(t, "image1.jpeg", coco[0].ExternalID)
+	assert.Equal(t, []models.AnnotationDataBoundingBox{{TagID: "cat", Xmin: 10, Ymin: 20, Xmax: 40, Ymax: 60}}, coco[0].Metadata)
+
+	voc := parseLabelsFile("staging/1/image2.xml", []byte(`<annotation>
+		<filename>image2.jpeg</filename>
+		<size><width>640</width><height>480</height><depth>3</depth></size>
+		<object><name>dog</name><bndbox><xmin>1</xmin><ymin>2</ymin><xmax>3</xmax><ymax>4</ymax></bndbox></object>
+	</annotation>`), nil, bbox)
+	require.Len(t, voc, 1)
+	assert.Equal(t, []string{"dog"}, voc[0].Tags)
+
+	yolo := parseLabelsFile("staging/2/image3.txt", []byte("1 0.5 0.5 0.5 0.5\n"), []string{"cat", "dog"}, bbox)
+	require.Len(t, yolo, 1)
+	assert.Equal(t, "image3", yolo[0].ExternalID)
+	assert.Equal(t, []string{"dog"}, yolo[0].Tags)
+
+	// Files that are not label files, or cannot be parsed, are skipped
+	assert.Empty(t, parseLabelsFile("staging/3/items.json", []byte(`[{"id": "content"}]`), nil, bbox))
+	assert.Empty(t, parseLabelsFile("staging/4/image4.xml", []byte("<annotation>"), nil, bbox))
+	assert.Empty(t, parseLabelsFile("staging/5/image5.txt", []byte("0 0.5 0.5\n"), []string{"cat"}, bbox))
+	assert.Empty(t, parseLabelsFile("staging/6/notes.yaml", []byte("cat: dog"), nil, bbox))
+
+	// Labels of every file end up in a single label map
+	labelMap := append(append(coco, voc...), yolo...).Validate(bbox)
+	assert.Contains(t, labelMap, "image1.jpeg")
+	assert.Contains(t, labelMap, "image2.jpeg")
+	assert.Contains(t, labelMap, "image3")
+}
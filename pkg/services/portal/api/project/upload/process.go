@@ -10,7 +10,9 @@
 package upload
 
 import (
+	"math"
 	"path"
+	"strings"
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -29,7 +31,7 @@ type File struct {
 
 // annotateContent is a helper method for annotating content based on provided labelMap
 func AnnotateContent(db *db.DB, plat *platform.Platform, content *models.Content, contentBytes []byte, labelMap models.LabelMap, projectAnnotationType, datasetid, projectid, filename string) error {
-	l, ok := labelMap[path.Base(filename)]
+	l, ok := labelFor(labelMap, content, filename)
 	if !ok {
 		// File does not have tag association
 		log.Debugf("File does not have tag association; filename=%s", filename)
@@ -45,6 +47,7 @@ func AnnotateContent(db *db.DB, plat *platform.Platform, content *models.Content
 	// Create tags
 	tagids := []string{}
 	tagmap := make(map[string]string)
+	tags := make(map[string]models.Tag)
 	for _, name := range l.Tags {
 		tag, err := plat.TagDB.FindByName(db, content.UserID, datasetid, name)
 		if err != nil {
@@ -67,13 +70,14 @@ func AnnotateContent(db *db.DB, plat *platform.Platform, content *models.Content
 
 		tagmap[name] = tag.ID.Hex()
 		tagids = append(tagids, tag.ID.Hex())
+		tags[tag.ID.Hex()] = tag
 	}
 
 	meta := models.AnnotationMetadata{}
 	imgBase64 := ""
 	if projectAnnotationType == models.ProjectAnnotationTypeBoundingBox.String() {
 		boundingBoxes := []image.BoundingBox{}
-		for _, bbox := range l.Metadata {
+		for _, bbox := range l.BoundingBoxes(content.Width, content.Height) {
 			// AnnotationDataBoundingBox.TagID is the tag name in the context of a labels file import
 			meta.BoundingBoxes = append(meta.BoundingBoxes, models.AnnotationDataBoundingBox{
 				TagID: tagmap[bbox.TagID],
@@ -91,6 +95,69 @@ func AnnotateContent(db *db.DB, plat *platform.Platform, content *models.Content
 			})
 		}
 
+		// Update Base64 img.
+		thumb, _, err := image.ThumbnailBoundingBox(contentBytes,
+			100,
+			100,
+			boundingBoxes)
+		if err != nil {
+			return errors.Wrapf(err, "error creating annotation thumbnail for content=%s", content.ID)
+		}
+		imgBase64 = thumb
+	} else if projectAnnotationType == models.ProjectAnnotationTypeSegmentation.String() {
+		polygons := []image.Polygon{}
+		for _, polygon := range l.ScaledPolygons(content.Width, content.Height) {
+			// AnnotationDataPolygon.TagID is the tag name in the context of a labels file import
+			meta.Polygons = append(meta.Polygons, models.AnnotationDataPolygon{TagID: tagmap[polygon.TagID], Points: polygon.Points})
+			polygons = append(polygons, image.Polygon{Points: polygon.Points, ClassName: polygon.TagID})
+		}
+		masks := []image.Mask{}
+		for _, mask := range l.ScaledMasks(content.Width, content.Height) {
+			meta.Masks = append(meta.Masks, models.AnnotationDataMask{
+				TagID:  tagmap[mask.TagID],
+				Height: mask.Height,
+				Width:  mask.Width,
+				Counts: mask.Counts,
+			})
+			masks = append(masks, image.Mask{Height: mask.Height, Width: mask.Width, Counts: mask.Counts, ClassName: mask.TagID})
+		}
+
+		// Update Base64 img.
+		thumb, _, err := image.ThumbnailSegmentation(contentBytes,
+			100,
+			100,
+			polygons,
+			masks)
+		if err != nil {
+			return errors.Wrapf(err, "error creating annotation thumbnail for content=%s", content.ID)
+		}
+		imgBase64 = thumb
+	} else if projectAnnotationType == models.ProjectAnnotationTypeKeypoint.String() {
+		boundingBoxes := []image.BoundingBox{}
+		for _, object := range l.ScaledKeypointObjects(content.Width, content.Height) {
+			box := image.BoundingBox{
+				Xmin:      object.Xmin,
+				Xmax:      object.Xmax,
+				Ymin:      object.Ymin,
+				Ymax:      object.Ymax,
+				ClassName: object.TagID,
+			}
+			for _, keypoint := range object.Keypoints {
+				box.Keypoints = append(box.Keypoints, image.Keypoint{
+					X:        int(math.Round(keypoint.X)),
+					Y:        int(math.Round(keypoint.Y)),
+					Labelled: keypoint.Visibility != models.KeypointNotLabelled,
+				})
+			}
+			// AnnotationDataKeypointObject.TagID is the tag name in the context of a labels file import
+			object.TagID = tagmap[object.TagID]
+			if skeleton := tags[object.TagID].Skeleton; skeleton != nil {
+				box.Edges = skeleton.Edges
+			}
+			meta.KeypointObjects = append(meta.KeypointObjects, object)
+			boundingBoxes = append(boundingBoxes, box)
+		}
+
 		// Update Base64 img.
 		thumb, _, err := image.ThumbnailBoundingBox(contentBytes,
 			100,
@@ -116,16 +183,41 @@ func AnnotateContent(db *db.DB, plat *platform.Platform, content *models.Content
 	if err := annotation.Valid(projectAnnotationType); err != nil {
 		return err
 	}
+	if err := annotation.ValidSkeletons(tags); err != nil {
+		return err
+	}
 	// Create annotation at DB
 	_, err := plat.AnnotationDB.Create(db, *annotation)
-	if err != nil && !errors.Is(err, platform.ErrAnnotationAlreadyExists) {
+	if errors.Is(err, platform.ErrAnnotationAlreadyExists) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
-	return nil
+	return plat.RevisionDB.Create(db, models.NewAnnotationRevision(content.UserID, content.UserID, models.RevisionActionCreate, nil, annotation))
+}
+
+// labelFor returns the label of a file from the label map. Per-image label files (VOC, YOLO) are matched on
+// the filename without its extension. Labels without source dimensions are relative to the image as uploaded,
+// before it was resized for storage.
+func labelFor(labelMap models.LabelMap, content *models.Content, filename string) (models.Label, bool) {
+	l, ok := labelMap[path.Base(filename)]
+	if !ok {
+		l, ok = labelMap[strings.TrimSuffix(path.Base(filename), path.Ext(filename))]
+	}
+	if !ok {
+		return models.Label{}, false
+	}
+
+	if (l.Width == 0 || l.Height == 0) && content.OriginalWidth > 0 && content.OriginalHeight > 0 {
+		l.Width, l.Height = content.OriginalWidth, content.OriginalHeight
+	}
+
+	return l, true
 }
 
 // processImage is a helper function for getting image hash and create thumbnail (these can be done in parallel)
+// f.FileBytes is replaced with the resized and formatted image that should be stored.
 func ProcessImage(f *File, stats *image.Stats, maxImageWidth int, imageFmt string) (imagehash, thumb string, err error) {
 	var g errgroup.Group
 
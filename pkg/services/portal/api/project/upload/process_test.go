@@ -0,0 +1,83 @@
+/*
+ * File: process_test.go
+ * Project: upload
+ * File Created: Monday, 4th March 2024 2:48:09 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 4th March 2024 2:48:09 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package upload
+
+import (
+	"bytes"
+	goimage "image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	image "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/image"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestProcessImage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, goimage.NewNRGBA(goimage.Rect(0, 0, 2560, 1920))))
+
+	stats, err := image.GetStats(buf.Bytes())
+	require.NoError(t, err)
+
+	// Images wider than the maximum width are resized and converted to the storage format
+	f := &File{FileName: "image1.png", FileBytes: buf.Bytes()}
+	imagehash, thumb, err := ProcessImage(f, stats, 1280, "image/jpeg")
+	require.NoError(t, err)
+	assert.NotEmpty(t, imagehash)
+	assert.NotEmpty(t, thumb)
+
+	stored, err := image.GetStats(f.FileBytes)
+	require.NoError(t, err)
+	assert.Equal(t, 1280, stored.Width)
+	assert.Equal(t, 960, stored.Height)
+	assert.Equal(t, "jpeg", stored.ContentType)
+
+	// Images within the maximum width keep their dimensions
+	f = &File{FileName: "image1.jpeg", FileBytes: f.FileBytes}
+	_, _, err = ProcessImage(f, stored, 1280, "image/jpeg")
+	require.NoError(t, err)
+
+	stored, err = image.GetStats(f.FileBytes)
+	require.NoError(t, err)
+	assert.Equal(t, 1280, stored.Width)
+	assert.Equal(t, 960, stored.Height)
+}
+
+func TestLabelFor(t *testing.T) {
+	// Content uploaded at 2560x1920 and stored at 1280x960
+	content := models.NewContent("image/jpeg", "bucket", "key", "hash", "user", "project", "image1.jpeg", "", 0, 960, 1280)
+	content.SetOriginalDimensions(1920, 2560)
+
+	box := models.AnnotationDataBoundingBox{TagID: "cat", Xmin: 100, Ymin: 200, Xmax: 300, Ymax: 400}
+	labelMap := models.LabelMap{
+		"image1.jpeg": {Tags: []string{"cat"}, Metadata: []models.AnnotationDataBoundingBox{box}},
+		"image2":      {Tags: []string{"cat"}, Metadata: []models.AnnotationDataBoundingBox{box}, Width: 640, Height: 480},
+	}
+
+	// Labels without source dimensions are rescaled from the image as uploaded
+	label, ok := labelFor(labelMap, content, "staging/0/image1.jpeg")
+	require.True(t, ok)
+	assert.Equal(t, 2560, label.Width)
+	assert.Equal(t, []models.AnnotationDataBoundingBox{{TagID: "cat", Xmin: 50, Ymin: 100, Xmax: 150, Ymax: 200}}, label.BoundingBoxes(content.Width, content.Height))
+
+	// Per-image label files are matched without the extension and keep their own dimensions
+	label, ok = labelFor(labelMap, content, "staging/1/image2.png")
+	require.True(t, ok)
+	assert.Equal(t, []models.AnnotationDataBoundingBox{{TagID: "cat", Xmin: 200, Ymin: 400, Xmax: 600, Ymax: 800}}, label.BoundingBoxes(content.Width, content.Height))
+
+	_, ok = labelFor(labelMap, content, "staging/2/image3.jpeg")
+	assert.False(t, ok)
+
+	// The label map is left untouched
+	assert.Zero(t, labelMap["image1.jpeg"].Width)
+}
@@ -9,11 +9,13 @@
  */
 package upload
 
+import models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+
 type UploadErr struct {
-	Error         string
-	InternalError string
-	Filename      string
-	Message       string
+	Error         string `bson:"error"`
+	InternalError string `bson:"internal_error"`
+	Filename      string `bson:"filename"`
+	Message       string `bson:"message"`
 }
 
 const (
@@ -24,6 +26,7 @@ const (
 	UploadErrInvalidFormat   = "UploadErrInvalidFormat"
 	UploadErrProcessing      = "UploadErrProcessing"
 	UploadErrAnnotation      = "UploadErrAnnotation"
+	UploadErrArchive         = "UploadErrArchive"
 )
 
 // Report represents report job
@@ -69,3 +72,22 @@ func (r *Report) AddErr(internalErr error, err, filename, message string) {
 	r.Errors = append(r.Errors, UploadErr{Error: err, Filename: filename, Message: message, InternalError: internalErrString})
 	r.TotalFilesFailed++
 }
+
+// Progress converts the report for a single staged file into an upload progress update
+func (r *Report) Progress(key string) models.UploadProgress {
+	progress := models.UploadProgress{
+		File:      key,
+		Bytes:     r.TotalBytes,
+		Succeeded: r.TotalImagesSucceeded,
+		Failed:    r.TotalFilesFailed,
+		Duplicate: r.TotalFilesDuplicate,
+		Errors:    make(map[string]interface{}),
+	}
+	if !IsLabelsFile(key) {
+		progress.Processed = 1
+	}
+	for _, uploadErr := range r.Errors {
+		progress.Errors[uploadErr.Filename] = uploadErr
+	}
+	return progress
+}
@@ -12,11 +12,8 @@ package upload
 import (
 	"bytes"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"path"
-	"strings"
 	"time"
 
 	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
@@ -29,11 +26,11 @@ import (
 )
 
 type UploadArgs struct {
-	File     *multipart.FileHeader
+	Key      string
+	Job      *models.Upload
 	Blob     *blob.Blob
 	Platform *platform.Platform
 	DB       *db.DB
-	Report   *Report
 	Project  *models.Project
 
 	LabelMap models.LabelMap
@@ -43,60 +40,84 @@ type UploadArgs struct {
 	UploadImageFormat   string
 }
 
+// Upload processes a single staged file and records the outcome against the upload.
+// Files that are no longer pending, e.g. already imported before a resume, are skipped.
 func (a *UploadArgs) Upload() (_ struct{}, _ error) {
-	a.Report.TotalBytes += a.File.Size
+	filename := path.Base(a.Key)
+	report := NewReport(a.Job.LabelsFile, 1)
 
-	// Skip labels file
-	if strings.HasSuffix(a.File.Filename, "json") {
-		a.Report.TotalImagesSucceeded++
+	pending, err := a.Platform.UploadDB.Pending(a.DB, a.Job.ID, a.Key)
+	if err != nil {
+		log.Errorf("error checking staged file; upload=%s, file=%s, err=%s", a.Job.ID.Hex(), a.Key, err.Error())
+	} else if !pending {
+		log.Debugf("skipping imported file; upload=%s, file=%s", a.Job.ID.Hex(), a.Key)
 		return
 	}
 
-	// Restrict the size of each uploaded file to 5MB.
-	if int(a.File.Size) > a.UploadMaxFileSizeMB {
-		log.Errorf("max file exceeded; file=%s", a.File.Filename)
-		a.Report.AddErr(nil, UploadErrMaxSizeExceeded, a.File.Filename, fmt.Sprintf("Image must be <= %dMB", a.UploadMaxFileSizeMB/(1024*1024)))
+	defer func() {
+		if err := a.Platform.UploadDB.Progress(a.DB, a.Job.ID, report.Progress(a.Key)); err != nil {
+			log.Errorf("error recording upload progress; upload=%s, file=%s, err=%s", a.Job.ID.Hex(), a.Key, err.Error())
+		}
+	}()
+
+	// Skip labels files
+	if IsLabelsFile(filename) {
 		return
 	}
 
-	// Open the file
-	file, err := a.File.Open()
+	fileBytes, err := a.Blob.Get(a.Blob.Bucket, a.Key)
 	if err != nil {
-		log.Errorf("error opening file; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(err, UploadErrIO, a.File.Filename, "Unable to open file")
+		log.Errorf("error reading staged file; file=%s, err=%s", a.Key, err.Error())
+		report.AddErr(err, UploadErrIO, filename, "Unable to read file")
 		return
 	}
-	defer file.Close()
+	report.TotalBytes += int64(len(fileBytes))
 
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Errorf("error reading file; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(err, UploadErrIO, a.File.Filename, "Unable to read file")
+	a.processImage(report, filename, fileBytes)
+
+	return
+}
+
+// processImage validates, resizes and stores a single image, then annotates it using the label map
+func (a *UploadArgs) processImage(report *Report, filename string, fileBytes []byte) {
+	// Restrict the size of each uploaded file to 5MB.
+	if len(fileBytes) > a.UploadMaxFileSizeMB {
+		log.Errorf("max file exceeded; file=%s", filename)
+		report.AddErr(nil, UploadErrMaxSizeExceeded, filename, fmt.Sprintf("Image must be <= %dMB", a.UploadMaxFileSizeMB/(1024*1024)))
 		return
 	}
-	file.Close()
 
 	// Check file type
 	filetype := http.DetectContentType(fileBytes)
 	if filetype != "image/jpeg" && filetype != "image/png" {
-		log.Errorf("error detecting content type; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(nil, UploadErrInvalidFormat, a.File.Filename, "The provided file format is not allowed. Please upload a JPEG or PNG image")
+		log.Errorf("error detecting content type; file=%s, type=%s", filename, filetype)
+		report.AddErr(nil, UploadErrInvalidFormat, filename, "The provided file format is not allowed. Please upload a JPEG or PNG image")
 		return
 	}
 
 	// Get file stats
 	stats, err := image.GetStats(fileBytes)
 	if err != nil {
-		log.Errorf("error getting image stats; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(err, UploadErrStats, a.File.Filename, "Unable to get file stats")
+		log.Errorf("error getting image stats; file=%s, err=%s", filename, err.Error())
+		report.AddErr(err, UploadErrStats, filename, "Unable to get file stats")
 		return
 	}
 
 	// Hash, create thumbnail, resize, and format
-	imagehash, thumb, err := ProcessImage(&File{FileName: a.File.Filename, FileBytes: fileBytes}, stats, a.UploadMaxImageWidth, a.UploadImageFormat)
+	processed := &File{FileName: filename, FileBytes: fileBytes}
+	imagehash, thumb, err := ProcessImage(processed, stats, a.UploadMaxImageWidth, a.UploadImageFormat)
+	if err != nil {
+		log.Errorf("error processing image; file=%s, err=%s", filename, err.Error())
+		report.AddErr(err, UploadErrProcessing, filename, "Unable to process image")
+		return
+	}
+	fileBytes = processed.FileBytes
+
+	// Stats of the image as stored, which differ from the upload if it was resized
+	storedStats, err := image.GetStats(fileBytes)
 	if err != nil {
-		log.Errorf("error processing image; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(err, UploadErrProcessing, a.File.Filename, "Unable to process image")
+		log.Errorf("error getting processed image stats; file=%s, err=%s", filename, err.Error())
+		report.AddErr(err, UploadErrStats, filename, "Unable to get file stats")
 		return
 	}
 
@@ -104,8 +125,8 @@ func (a *UploadArgs) Upload() (_ struct{}, _ error) {
 	newKey := fmt.Sprintf("%s/content/%s.%s", a.Project.UserID, imagehash, path.Base(a.UploadImageFormat))
 	_, err = a.Blob.Uploader.Upload(bytes.NewBuffer(fileBytes), a.Blob.Bucket, newKey)
 	if err != nil {
-		log.Errorf("error uploading to blob store; file=%s, err=%s", a.File.Filename, err.Error())
-		a.Report.AddErr(err, UploadErrProcessing, a.File.Filename, "Unable to process image")
+		log.Errorf("error uploading to blob store; file=%s, err=%s", filename, err.Error())
+		report.AddErr(err, UploadErrProcessing, filename, "Unable to process image")
 		return
 	}
 
@@ -122,33 +143,34 @@ func (a *UploadArgs) Upload() (_ struct{}, _ error) {
 				imagehash,
 				a.Project.UserID,
 				a.Project.ID.Hex(),
-				path.Base(a.File.Filename),
+				path.Base(filename),
 				thumb,
 				len(fileBytes),
-				stats.Height,
-				stats.Width)
+				storedStats.Height,
+				storedStats.Width)
+			newContent.SetOriginalDimensions(stats.Height, stats.Width)
 
 			if _, err := a.Platform.ContentDB.Create(a.DB, newContent); err != nil {
-				log.Errorf("unable to create content metadata; file=%s, err=%s", a.File.Filename, err.Error())
+				log.Errorf("unable to create content metadata; file=%s, err=%s", filename, err.Error())
 				if err := a.Blob.S3Client.DeleteObject(a.Blob.Bucket, newKey); err != nil {
-					log.Errorf("error removing content blob after metadata creation failure; file=%s, err=%s", a.File.Filename, err.Error())
+					log.Errorf("error removing content blob after metadata creation failure; file=%s, err=%s", filename, err.Error())
 				}
-				a.Report.AddErr(err, UploadErrProcessing, a.File.Filename, "Unable to process image")
+				report.AddErr(err, UploadErrProcessing, filename, "Unable to process image")
 				return
 			}
 
-			a.Report.TotalImagesSucceeded++
+			report.TotalImagesSucceeded++
 
 			// Annotate content if found in included labelmap
-			if err := AnnotateContent(a.DB, a.Platform, newContent, fileBytes, a.LabelMap, a.Project.AnnotationType, a.Project.DatasetID, a.Project.ID.Hex(), a.File.Filename); err != nil {
-				log.Errorf("error annotating content; id=%s, file=%s err=%s", contentID, a.File.Filename, err.Error())
-				a.Report.AddErr(err, UploadErrAnnotation, a.File.Filename, "Unable to annotate image")
+			if err := AnnotateContent(a.DB, a.Platform, newContent, fileBytes, a.LabelMap, a.Project.AnnotationType, a.Project.DatasetID, a.Project.ID.Hex(), filename); err != nil {
+				log.Errorf("error annotating content; id=%s, file=%s err=%s", contentID, filename, err.Error())
+				report.AddErr(err, UploadErrAnnotation, filename, "Unable to annotate image")
 				return
 			}
 
 		} else {
-			log.Errorf("error looking up content; id=%s, file=%s err=%s", contentID, a.File.Filename, err.Error())
-			a.Report.AddErr(err, UploadErrProcessing, a.File.Filename, "Unable to process image")
+			log.Errorf("error looking up content; id=%s, file=%s err=%s", contentID, filename, err.Error())
+			report.AddErr(err, UploadErrProcessing, filename, "Unable to process image")
 			return
 		}
 	} else {
@@ -158,7 +180,7 @@ func (a *UploadArgs) Upload() (_ struct{}, _ error) {
 
 		if common.Contains(content.Projects, a.Project.ID.Hex()) {
 			log.Debugf("content already associated with project; id=%s, project=%s (%s) content=%s", a.Project.UserID, a.Project.ID.Hex(), a.Project.Name, contentID)
-			a.Report.TotalFilesDuplicate++
+			report.TotalFilesDuplicate++
 			return
 		}
 
@@ -167,19 +189,17 @@ func (a *UploadArgs) Upload() (_ struct{}, _ error) {
 		content.UpdatedAt = time.Now()
 		if err := a.Platform.ContentDB.Update(a.DB, content); err != nil {
 			log.Errorf("unable to update content; content=%s, err=%s", content.ID, err.Error())
-			a.Report.AddErr(err, UploadErrProcessing, a.File.Filename, "Unable to process image")
+			report.AddErr(err, UploadErrProcessing, filename, "Unable to process image")
 			return
 		}
 
-		a.Report.TotalImagesSucceeded++
+		report.TotalImagesSucceeded++
 
 		// Label content if found in included labelmap
-		if err := AnnotateContent(a.DB, a.Platform, content, fileBytes, a.LabelMap, a.Project.AnnotationType, a.Project.DatasetID, a.Project.ID.Hex(), a.File.Filename); err != nil {
+		if err := AnnotateContent(a.DB, a.Platform, content, fileBytes, a.LabelMap, a.Project.AnnotationType, a.Project.DatasetID, a.Project.ID.Hex(), filename); err != nil {
 			log.Errorf("failed annotating content; content=%s, err=%s", content.ID, err.Error())
-			a.Report.AddErr(err, UploadErrAnnotation, a.File.Filename, "Unable to annotate image")
+			report.AddErr(err, UploadErrAnnotation, filename, "Unable to annotate image")
 			return
 		}
 	}
-
-	return
 }
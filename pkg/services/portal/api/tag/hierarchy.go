@@ -0,0 +1,284 @@
+package tag
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	common "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+var (
+	ErrTagDatasetMismatch = echo.NewHTTPError(http.StatusBadRequest, "tags must belong to the same dataset")
+	ErrTagParentCycle     = echo.NewHTTPError(http.StatusBadRequest, "tag cannot be its own ancestor")
+	ErrTagMergeSelf       = echo.NewHTTPError(http.StatusBadRequest, "tag cannot be merged into itself")
+	ErrTagSplitEmpty      = echo.NewHTTPError(http.StatusBadRequest, "tag must be split into at least one tag")
+	ErrTagSplitDuplicate  = echo.NewHTTPError(http.StatusBadRequest, "tag names and annotations may only appear once in a split")
+	ErrTagSkeletonMerge   = echo.NewHTTPError(http.StatusBadRequest, "tags with different skeletons cannot be merged")
+)
+
+// unlockedTag returns a tag whose dataset can be modified
+func (t Tag) unlockedTag(userid, tagid string) (models.Tag, error) {
+	tag, err := t.platform.TagDB.View(t.db, userid, tagid)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	dataset, err := t.platform.DatasetDB.View(t.db, userid, tag.DatasetID)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if dataset.Locked {
+		return models.Tag{}, platform.ErrDatasetLocked
+	}
+	if dataset.Restoring != "" {
+		return models.Tag{}, platform.ErrDatasetRestoring
+	}
+
+	return tag, nil
+}
+
+// validParent checks that parentid is a tag of the same dataset which does not descend from the tag
+func (t Tag) validParent(tag models.Tag, parentid string) error {
+	for id := parentid; id != ""; {
+		if id == tag.ID.Hex() {
+			return ErrTagParentCycle
+		}
+		parent, err := t.platform.TagDB.View(t.db, tag.UserID, id)
+		if err != nil {
+			return err
+		}
+		if parent.DatasetID != tag.DatasetID {
+			return ErrTagDatasetMismatch
+		}
+		id = parent.ParentID
+	}
+	return nil
+}
+
+// relabel runs a bulk change to the annotations of a tag and records a revision for each annotation it changed
+func (t Tag) relabel(userid, datasetid, tagid string, change func() error, annotationids ...primitive.ObjectID) error {
+	previous, err := t.platform.AnnotationDB.FindTagAnnotations(t.db, userid, datasetid, tagid, annotationids...)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+	if len(previous) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, len(previous))
+	for i, annotation := range previous {
+		ids[i] = annotation.ID
+	}
+	current, err := t.platform.AnnotationDB.FindAnnotations(t.db, userid, ids)
+	if err != nil {
+		return err
+	}
+
+	return t.platform.RevisionDB.Create(t.db, models.AnnotationRevisions(userid, previous, current)...)
+}
+
+// tagName normalizes a tag name the way new tags are named
+func tagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || !isStringAlphabetic(name) {
+		return "", ErrTagNotAlphaNumeric
+	}
+	return name, nil
+}
+
+// Merge relabels every annotation of a tag with another tag of the same dataset and removes the tag. Children of
+// the merged tag move to the tag it is merged into and properties are combined. A descendant merged into moves up
+// to the merged tag's parent first.
+func (t Tag) Merge(c echo.Context, userid, tagid, intoid string) (models.Tag, error) {
+	if tagid == intoid {
+		return models.Tag{}, ErrTagMergeSelf
+	}
+
+	tag, err := t.unlockedTag(userid, tagid)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	into, err := t.platform.TagDB.View(t.db, userid, intoid)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if into.DatasetID != tag.DatasetID {
+		return models.Tag{}, ErrTagDatasetMismatch
+	}
+	// Keypoint objects of the merged tag have to fit the skeleton of the tag they are merged into
+	if !reflect.DeepEqual(tag.Skeleton, into.Skeleton) {
+		return models.Tag{}, ErrTagSkeletonMerge
+	}
+
+	// A descendant merged into takes the tag's place, so that moving the tag's children to it does not form a cycle
+	switch err := t.validParent(tag, into.ParentID); err {
+	case nil:
+	case ErrTagParentCycle:
+		if err := t.platform.TagDB.SetParent(t.db, userid, into.ID, tag.ParentID); err != nil {
+			return models.Tag{}, err
+		}
+	default:
+		return models.Tag{}, err
+	}
+
+	if err := t.relabel(userid, tag.DatasetID, tagid, func() error {
+		return t.platform.AnnotationDB.ReplaceTagID(t.db, userid, tag.DatasetID, tagid, intoid)
+	}); err != nil {
+		return models.Tag{}, err
+	}
+	if err := t.platform.TagDB.ReplaceParent(t.db, userid, tag.DatasetID, tagid, intoid); err != nil {
+		return models.Tag{}, err
+	}
+	if err := t.platform.TagDB.Delete(t.db, tag.ID); err != nil {
+		return models.Tag{}, err
+	}
+
+	if err := t.platform.TagDB.Update(t.db, models.Tag{
+		ID:       into.ID,
+		UserID:   userid,
+		Property: common.RemoveDuplicateStr(append(into.Property, tag.Property...)),
+	}); err != nil {
+		return models.Tag{}, err
+	}
+
+	return t.platform.TagDB.View(t.db, userid, intoid)
+}
+
+// Rename renames a tag. If another tag of the dataset already has the name the rename fails, unless merge is set
+// in which case the tag is merged into the existing tag.
+func (t Tag) Rename(c echo.Context, userid, tagid, name string, merge bool) (models.Tag, error) {
+	name, err := tagName(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	tag, err := t.unlockedTag(userid, tagid)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	if tag.Name == name {
+		return tag, nil
+	}
+
+	existing, err := t.platform.TagDB.FindByName(t.db, userid, tag.DatasetID, name)
+	switch {
+	case err == nil && merge:
+		return t.Merge(c, userid, tagid, existing.ID.Hex())
+	case err == nil:
+		return models.Tag{}, platform.ErrTagAlreadyExists
+	case err != platform.ErrTagDoesNotExist:
+		return models.Tag{}, err
+	}
+
+	if err := t.platform.TagDB.Update(t.db, models.Tag{ID: tag.ID, UserID: userid, Name: name}); err != nil {
+		return models.Tag{}, err
+	}
+
+	return t.platform.TagDB.View(t.db, userid, tagid)
+}
+
+// Split contains the tags a tag is split into
+type Split struct {
+	Tags []SplitTag
+	// Remove the original tag, including from annotations not moved to a new tag
+	DeleteOriginal bool
+}
+
+// SplitTag is a new tag and the annotations moved to it
+type SplitTag struct {
+	Name          string
+	Property      []string
+	AnnotationIDs []string
+}
+
+// Split creates new tags alongside a tag and moves the given annotations from the tag to them. New tags share the
+// tag's parent, its skeleton and, unless given, its properties.
+func (t Tag) Split(c echo.Context, userid, tagid string, r Split) ([]models.Tag, error) {
+	if len(r.Tags) == 0 {
+		return nil, ErrTagSplitEmpty
+	}
+
+	tag, err := t.unlockedTag(userid, tagid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate before making any changes
+	names := make(map[string]struct{})
+	annotations := make(map[string]struct{})
+	annotationIDs := make([][]primitive.ObjectID, len(r.Tags))
+	for i, split := range r.Tags {
+		name, err := tagName(split.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := names[name]; ok {
+			return nil, ErrTagSplitDuplicate
+		}
+		names[name] = struct{}{}
+		r.Tags[i].Name = name
+
+		if _, err := t.platform.TagDB.FindByName(t.db, userid, tag.DatasetID, name); err == nil {
+			return nil, platform.ErrTagAlreadyExists
+		} else if err != platform.ErrTagDoesNotExist {
+			return nil, err
+		}
+
+		for _, annotationid := range split.AnnotationIDs {
+			if _, ok := annotations[annotationid]; ok {
+				return nil, ErrTagSplitDuplicate
+			}
+			annotations[annotationid] = struct{}{}
+			id, err := primitive.ObjectIDFromHex(annotationid)
+			if err != nil {
+				return nil, platform.ErrAnnotationDoesNotExist
+			}
+			annotationIDs[i] = append(annotationIDs[i], id)
+		}
+	}
+
+	tags := []models.Tag{}
+	for i, split := range r.Tags {
+		properties := split.Property
+		if len(properties) == 0 {
+			properties = tag.Property
+		}
+		newTag := models.NewTag(userid, tag.ProjectID, tag.DatasetID, split.Name, properties)
+		newTag.ParentID = tag.ParentID
+		newTag.Skeleton = tag.Skeleton
+
+		newTag, err := t.platform.TagDB.Create(t.db, newTag)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, newTag)
+
+		if len(annotationIDs[i]) == 0 {
+			continue
+		}
+		if err := t.relabel(userid, tag.DatasetID, tagid, func() error {
+			return t.platform.AnnotationDB.ReplaceTagID(t.db, userid, tag.DatasetID, tagid, newTag.ID.Hex(), annotationIDs[i]...)
+		}, annotationIDs[i]...); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.DeleteOriginal {
+		if err := t.Delete(c, userid, tagid); err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
@@ -116,7 +116,11 @@ func NewHTTP(svc Service, r *echo.Group) {
 	// swagger:operation PATCH /v1/tags/{Id} tags updateTagReq
 	// ---
 	// summary: Updates tag information.
-	// description: Updates tag information -> name, property.
+	// description: |
+	//   Updates tag information -> name, property, parent_id, skeleton.
+	//   Renaming to the name of another tag of the dataset fails; use the rename endpoint to merge instead.
+	//   An empty parent_id makes the tag a top level tag.
+	//   The skeleton of a tag of a keypoint project can only change while the tag has no annotations.
 	// security:
 	// - Bearer: []
 	// consumes:
@@ -274,6 +278,87 @@ func NewHTTP(svc Service, r *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	ur.DELETE("/:id", h.delete)
+
+	// swagger:operation POST /v1/tags/{Id}/merge tags mergeTagReq
+	// ---
+	// summary: Merges a tag into another tag.
+	// description: |
+	//   Relabels every annotation and bounding box of the tag with the tag it is merged into, then deletes the tag.
+	//   Children of the tag move to the tag it is merged into and properties are combined.
+	//   Both tags must belong to the same unlocked dataset and have the same skeleton.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Tag"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/merge", h.merge)
+
+	// swagger:operation POST /v1/tags/{Id}/rename tags renameTagReq
+	// ---
+	// summary: Renames a tag.
+	// description: |
+	//   Renames a tag of an unlocked dataset. If another tag of the dataset already has the name, the request fails with a conflict
+	//   unless merge is set, in which case the tag is merged into the existing tag.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Tag"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/rename", h.rename)
+
+	// swagger:operation POST /v1/tags/{Id}/split tags splitTagReq
+	// ---
+	// summary: Splits a tag into new tags.
+	// description: |
+	//   Creates new tags alongside the tag and moves the listed annotations, including their bounding boxes, from the tag to each new tag.
+	//   New tags share the tag's parent and, unless given, its properties.
+	//   If delete_original is set the tag is then deleted, including from annotations that were not moved.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/splitTagResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/split", h.split)
 }
 
 // Tag create request
@@ -289,6 +374,10 @@ type createTagReq struct {
 		ProjectID string `json:"project_id" validate:"required"`
 		// Dataset ID
 		DatasetID string `json:"dataset_id" validate:"required"`
+		// Parent tag ID
+		ParentID string `json:"parent_id,omitempty" validate:"omitempty,hexadecimal"`
+		// Keypoints of objects of the tag; keypoint projects only
+		Skeleton *models.TagSkeleton `json:"skeleton,omitempty" validate:"omitempty"`
 	}
 }
 
@@ -300,6 +389,8 @@ func (h HTTP) create(c echo.Context) error {
 
 	user := c.Get("current_user").(models.User)
 	tagModel := models.NewTag(user.ID.Hex(), r.ProjectID, r.DatasetID, r.TagName, r.Properties)
+	tagModel.ParentID = r.ParentID
+	tagModel.Skeleton = r.Skeleton
 
 	tag, err := h.svc.Create(c, tagModel)
 	if err != nil {
@@ -375,6 +466,9 @@ type updateTagReq struct {
 	Body struct {
 		Name     string   `json:"name,omitempty" validate:"omitempty"`
 		Property []string `json:"property,omitempty" validate:"omitempty,unique"`
+		ParentID *string  `json:"parent_id,omitempty" validate:"omitempty"`
+		// Keypoints of objects of the tag; can only change while the tag has no annotations
+		Skeleton *models.TagSkeleton `json:"skeleton,omitempty" validate:"omitempty"`
 	}
 }
 
@@ -392,6 +486,8 @@ func (h HTTP) update(c echo.Context) error {
 		TagID:    tagid,
 		Name:     req.Name,
 		Property: req.Property,
+		ParentID: req.ParentID,
+		Skeleton: req.Skeleton,
 	})
 
 	if err != nil {
@@ -427,6 +523,130 @@ func (h HTTP) delete(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"message": fmt.Sprintf("Tag %s deleted", req.Id)})
 }
 
+// Tag merge request
+// swagger:parameters mergeTagReq
+type mergeTagReq struct {
+	// ID of tag to merge
+	// in: path
+	// required: true
+	Id string `param:"id" validate:"required"`
+	// in: body
+	Body struct {
+		// ID of tag to merge into
+		Into string `json:"into" validate:"required,hexadecimal"`
+	}
+}
+
+func (h HTTP) merge(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	tagid := c.Param("id")
+
+	req := new(mergeTagReq).Body
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	tag, err := h.svc.Merge(c, user.ID.Hex(), tagid, req.Into)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Tag rename request
+// swagger:parameters renameTagReq
+type renameTagReq struct {
+	// ID of tag
+	// in: path
+	// required: true
+	Id string `param:"id" validate:"required"`
+	// in: body
+	Body struct {
+		// New name of tag
+		Name string `json:"name" validate:"required"`
+		// Merge into the tag that already has the name, if any
+		Merge bool `json:"merge"`
+	}
+}
+
+func (h HTTP) rename(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	tagid := c.Param("id")
+
+	req := new(renameTagReq).Body
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	tag, err := h.svc.Rename(c, user.ID.Hex(), tagid, req.Name, req.Merge)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Tag split request
+// swagger:parameters splitTagReq
+type splitTagReq struct {
+	// ID of tag to split
+	// in: path
+	// required: true
+	Id string `param:"id" validate:"required"`
+	// in: body
+	Body struct {
+		// New tags
+		Tags []struct {
+			// Tag name
+			Name string `json:"name" validate:"required"`
+			// Tag properties
+			Properties []string `json:"properties,omitempty"`
+			// IDs of annotations moved to the tag
+			AnnotationIDs []string `json:"annotation_ids" validate:"dive,hexadecimal"`
+		} `json:"tags" validate:"gt=0,dive"`
+		// Delete the original tag
+		DeleteOriginal bool `json:"delete_original"`
+	}
+}
+
+// Tag split response
+// swagger:response splitTagResp
+type splitTagResp struct {
+	// in:body
+	Body struct {
+		Tags []models.Tag `json:"tags"`
+	}
+}
+
+func (h HTTP) split(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	tagid := c.Param("id")
+
+	req := new(splitTagReq).Body
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	split := Split{DeleteOriginal: req.DeleteOriginal}
+	for _, tag := range req.Tags {
+		split.Tags = append(split.Tags, SplitTag{Name: tag.Name, Property: tag.Properties, AnnotationIDs: tag.AnnotationIDs})
+	}
+
+	tags, err := h.svc.Split(c, user.ID.Hex(), tagid, split)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := splitTagResp{}
+	resp.Body.Tags = tags
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
 // Tag query request
 // swagger:parameters queryTagReq
 //
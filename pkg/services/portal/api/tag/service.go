@@ -27,6 +27,10 @@ type Service interface {
 	Query(echo.Context, string, models.Query) ([]models.Tag, int64, error)
 	Properties(echo.Context, string, string) ([]string, error)
 	Delete(echo.Context, string, string) error
+
+	Merge(echo.Context, string, string, string) (models.Tag, error)
+	Rename(echo.Context, string, string, string, bool) (models.Tag, error)
+	Split(echo.Context, string, string, Split) ([]models.Tag, error)
 }
 
 // Tag represents tag application service
@@ -16,10 +16,31 @@ import (
 )
 
 var (
-	isStringAlphabetic    = regexp.MustCompile(`^[a-zA-Z0-9_-]*$`).MatchString
-	ErrTagNotAlphaNumeric = echo.NewHTTPError(http.StatusBadRequest, "Tag must be match the regex: `^[a-zA-Z0-9_-]*$`")
+	isStringAlphabetic      = regexp.MustCompile(`^[a-zA-Z0-9_-]*$`).MatchString
+	ErrTagNotAlphaNumeric   = echo.NewHTTPError(http.StatusBadRequest, "Tag must be match the regex: `^[a-zA-Z0-9_-]*$`")
+	ErrTagSkeletonType      = echo.NewHTTPError(http.StatusBadRequest, "Only tags of keypoint projects have a skeleton.")
+	ErrTagSkeletonAnnotated = echo.NewHTTPError(http.StatusConflict, "Skeleton of a tag cannot change once the tag is annotated.")
 )
 
+// validSkeleton checks a skeleton is well formed and its tag belongs to a keypoint project
+func (t Tag) validSkeleton(userid, projectid string, skeleton *models.TagSkeleton) error {
+	if skeleton == nil {
+		return nil
+	}
+	if err := skeleton.Valid(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	project, err := t.platform.ProjectDB.View(t.db, userid, projectid)
+	if err != nil {
+		return err
+	}
+	if project.AnnotationType != models.ProjectAnnotationTypeKeypoint.String() {
+		return ErrTagSkeletonType
+	}
+	return nil
+}
+
 // Create creates a new tag entry
 func (t Tag) Create(c echo.Context, req models.Tag) (models.Tag, error) {
 
@@ -28,8 +49,10 @@ func (t Tag) Create(c echo.Context, req models.Tag) (models.Tag, error) {
 	}
 
 	// Check dataset exists
-	if _, err := t.platform.DatasetDB.View(t.db, req.UserID, req.DatasetID); err != nil {
+	if dataset, err := t.platform.DatasetDB.View(t.db, req.UserID, req.DatasetID); err != nil {
 		return models.Tag{}, err
+	} else if dataset.Restoring != "" {
+		return models.Tag{}, platform.ErrDatasetRestoring
 	}
 
 	// Check project exists
@@ -37,6 +60,15 @@ func (t Tag) Create(c echo.Context, req models.Tag) (models.Tag, error) {
 		return models.Tag{}, err
 	}
 
+	// Check parent belongs to the same dataset
+	if err := t.validParent(req, req.ParentID); err != nil {
+		return models.Tag{}, err
+	}
+
+	if err := t.validSkeleton(req.UserID, req.ProjectID, req.Skeleton); err != nil {
+		return models.Tag{}, err
+	}
+
 	// Database operation
 	tag, err := t.platform.TagDB.Create(t.db, req)
 	if err != nil {
@@ -69,6 +101,10 @@ type Update struct {
 	UserID   string
 	Name     string
 	Property []string
+	// New parent of the tag; empty to make it a top level tag, nil to leave it unchanged
+	ParentID *string
+	// New skeleton of the tag; nil to leave it unchanged. Only tags without annotations can change skeleton.
+	Skeleton *models.TagSkeleton
 }
 
 // Update updates tag information
@@ -79,13 +115,50 @@ func (t Tag) Update(c echo.Context, r Update) (models.Tag, error) {
 		return models.Tag{}, err
 	}
 
+	// Renames are checked for collisions with other tags of the dataset
+	if r.Name != "" {
+		if _, err := t.Rename(c, r.UserID, r.TagID, r.Name, false); err != nil {
+			return models.Tag{}, err
+		}
+	}
+
+	if r.ParentID != nil {
+		tag, err := t.unlockedTag(r.UserID, r.TagID)
+		if err != nil {
+			return models.Tag{}, err
+		}
+		if err := t.validParent(tag, *r.ParentID); err != nil {
+			return models.Tag{}, err
+		}
+		if err := t.platform.TagDB.SetParent(t.db, r.UserID, id, *r.ParentID); err != nil {
+			return models.Tag{}, err
+		}
+	}
+
+	if r.Skeleton != nil {
+		tag, err := t.unlockedTag(r.UserID, r.TagID)
+		if err != nil {
+			return models.Tag{}, err
+		}
+		if err := t.validSkeleton(r.UserID, tag.ProjectID, r.Skeleton); err != nil {
+			return models.Tag{}, err
+		}
+		annotations, err := t.platform.AnnotationDB.FindTagAnnotations(t.db, r.UserID, tag.DatasetID, r.TagID)
+		if err != nil {
+			return models.Tag{}, err
+		}
+		if len(annotations) > 0 {
+			return models.Tag{}, ErrTagSkeletonAnnotated
+		}
+	}
+
 	properties := common.RemoveDuplicateStr(common.StringSliceToLower(r.Property))
 
 	err = t.platform.TagDB.Update(t.db, models.Tag{
 		ID:       id,
 		UserID:   r.UserID,
-		Name:     r.Name,
-		Property: properties})
+		Property: properties,
+		Skeleton: r.Skeleton})
 	if err != nil {
 		return models.Tag{}, err
 	}
@@ -108,6 +181,9 @@ func (t Tag) Delete(c echo.Context, userid, tagid string) error {
 	if dataset.Locked {
 		return platform.ErrDatasetLocked
 	}
+	if dataset.Restoring != "" {
+		return platform.ErrDatasetRestoring
+	}
 
 	// Delete tag
 	if err := t.platform.TagDB.Delete(t.db, tag.ID); err != nil {
@@ -115,7 +191,14 @@ func (t Tag) Delete(c echo.Context, userid, tagid string) error {
 	}
 
 	// Delete tagid from annotations
-	if err := t.platform.AnnotationDB.DeleteTagID(t.db, userid, tag.ProjectID, tag.DatasetID, tagid); err != nil {
+	if err := t.relabel(userid, tag.DatasetID, tagid, func() error {
+		return t.platform.AnnotationDB.DeleteTagID(t.db, userid, tag.ProjectID, tag.DatasetID, tagid)
+	}); err != nil {
+		return err
+	}
+
+	// Move children up to the deleted tag's parent
+	if err := t.platform.TagDB.ReplaceParent(t.db, userid, tag.DatasetID, tagid, tag.ParentID); err != nil {
 		return err
 	}
 
@@ -0,0 +1,177 @@
+package task
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+)
+
+// Custom errors
+var (
+	ErrTaskNoOverlap     = echo.NewHTTPError(http.StatusBadRequest, "Task does not send content to more than one labeller.")
+	ErrMergeNotSupported = echo.NewHTTPError(http.StatusNotImplemented, "Merging segmentation and keypoint labels is not supported.")
+)
+
+// Merge is a request to merge the competing labels of a task into annotations
+type Merge struct {
+	UserID string
+	TaskID string
+	// Content to merge; all content labelled by every labeller it was sent to when empty
+	ContentIDs []string
+	// IoU at or above which boxes of different labellers are the same object
+	IoUThreshold float64
+	// Labellers who have to agree on a tag or box; a strict majority of the labels when zero
+	MinVotes int
+	// Review status of the merged annotations
+	Status string
+}
+
+// MergeResult is the outcome of merging the labels of a task
+type MergeResult struct {
+	Annotations []models.Annotation
+	// Content left unmerged because not every labeller has labelled it yet
+	Skipped int
+}
+
+// ratings converts the labels of a task into ratings of their content. Bounding box labels are rated on their
+// boxes, whose tags they are derived from, and other labels on their tags.
+func ratings(labels []models.TaskLabel, annotationType string) map[string][]evaluation.Rating {
+	boundingBox := annotationType == models.ProjectAnnotationTypeBoundingBox.String()
+
+	contents := make(map[string][]evaluation.Rating)
+	for _, label := range labels {
+		rating := evaluation.Rating{Item: label.ContentID, Rater: label.LabellerID, Objects: []evaluation.Object{}}
+		if boundingBox {
+			for _, box := range label.Metadata.BoundingBoxes {
+				rating.Objects = append(rating.Objects, evaluation.Object{
+					Class: box.TagID,
+					Box: &evaluation.Box{
+						Xmin: float64(box.Xmin),
+						Ymin: float64(box.Ymin),
+						Xmax: float64(box.Xmax),
+						Ymax: float64(box.Ymax),
+					},
+				})
+			}
+		} else {
+			for _, tagid := range label.TagIDs {
+				rating.Objects = append(rating.Objects, evaluation.Object{Class: tagid})
+			}
+		}
+		contents[label.ContentID] = append(contents[label.ContentID], rating)
+	}
+
+	return contents
+}
+
+// labelled returns the task and the project annotation type of a task with overlap
+func (t Task) labelled(userid, id string) (*models.Task, string, error) {
+	task, err := t.platform.TaskDB.View(t.db, userid, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if task.Overlap < 2 {
+		return nil, "", ErrTaskNoOverlap
+	}
+
+	project, err := t.platform.ProjectDB.View(t.db, userid, task.ProjectID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &task, project.AnnotationType, nil
+}
+
+// Agreement measures how well the labellers of a task with overlap agree, overall, per tag and per labeller
+func (t Task) Agreement(c echo.Context, userid, id string, iouThreshold float64) (*evaluation.Agreement, error) {
+	_, annotationType, err := t.labelled(userid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := t.platform.TaskDB.ListLabels(t.db, userid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	all := []evaluation.Rating{}
+	for _, rated := range ratings(labels, annotationType) {
+		all = append(all, rated...)
+	}
+
+	agreement := evaluation.InterRaterAgreement(all, iouThreshold)
+	return &agreement, nil
+}
+
+// Merge merges the competing labels of content into the dataset's annotation of the content, keeping the tags
+// and boxes enough labellers agree on. Content is only merged once every labeller it was sent to has labelled it,
+// unless it is requested explicitly. Revisions record the user merging the labels as the author.
+func (t Task) Merge(c echo.Context, req Merge) (*MergeResult, error) {
+	task, annotationType, err := t.labelled(req.UserID, req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if annotationType == models.ProjectAnnotationTypeSegmentation.String() || annotationType == models.ProjectAnnotationTypeKeypoint.String() {
+		return nil, ErrMergeNotSupported
+	}
+
+	labels, err := t.platform.TaskDB.ListLabels(t.db, req.UserID, req.TaskID, req.ContentIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := ratings(labels, annotationType)
+	contentids := make([]string, 0, len(contents))
+	for contentid := range contents {
+		contentids = append(contentids, contentid)
+	}
+	sort.Strings(contentids)
+
+	result := &MergeResult{Annotations: []models.Annotation{}}
+	for _, contentid := range contentids {
+		rated := contents[contentid]
+		if len(req.ContentIDs) == 0 && len(rated) < task.Overlap {
+			result.Skipped++
+			continue
+		}
+
+		annotation := models.Annotation{
+			UserID:    task.UserID,
+			ProjectID: task.ProjectID,
+			DatasetID: task.DatasetID,
+			ContentID: contentid,
+			TagIDs:    []string{},
+			Status:    req.Status,
+		}
+
+		tags := make(map[string]struct{})
+		for _, object := range evaluation.Consensus(rated, req.IoUThreshold, req.MinVotes) {
+			if _, ok := tags[object.Class]; !ok {
+				tags[object.Class] = struct{}{}
+				annotation.TagIDs = append(annotation.TagIDs, object.Class)
+			}
+			if object.Box != nil {
+				annotation.Metadata.BoundingBoxes = append(annotation.Metadata.BoundingBoxes, models.AnnotationDataBoundingBox{
+					TagID: object.Class,
+					Xmin:  int(math.Round(object.Box.Xmin)),
+					Ymin:  int(math.Round(object.Box.Ymin)),
+					Xmax:  int(math.Round(object.Box.Xmax)),
+					Ymax:  int(math.Round(object.Box.Ymax)),
+				})
+			}
+		}
+
+		merged, err := t.annotation.CreateBy(c, annotation, 0, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result.Annotations = append(result.Annotations, *merged)
+	}
+
+	return result, nil
+}
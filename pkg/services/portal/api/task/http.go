@@ -0,0 +1,845 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	errs "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/error"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+)
+
+// HTTP represents labelling task http service
+type HTTP struct {
+	svc Service
+}
+
+// NewHTTP creates new labelling task http service
+func NewHTTP(svc Service, r *echo.Group) {
+	h := HTTP{svc}
+	ur := r.Group("/tasks")
+
+	// swagger:operation POST /v1/tasks tasks createTaskReq
+	// ---
+	// summary: Creates a labelling task.
+	// description: |
+	//  Creates a labelling task over the unannotated content of a project's dataset. Content can be narrowed down with
+	//  the same filters as a content query; content already pending in another task of the dataset is left out.
+	//  The content is split into batches of `batch_size` items, assigned to the labellers in turn.
+	//  With an `overlap` above one, each batch is sent to as many different labellers. Their labels are kept apart
+	//  from the dataset's annotations so their agreement can be measured before they are merged.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Task"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("", h.create)
+
+	// swagger:operation GET /v1/tasks tasks listTasksReq
+	// ---
+	// summary: Returns list of labelling tasks.
+	// description: Returns list of labelling tasks for the specified project.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: project_id
+	//   in: query
+	//   type: string
+	//   required: true
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: sort_key
+	//   in: query
+	//   type: string
+	//   required: false
+	// - name: sort_val
+	//   in: query
+	//   type: integer
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/listTasksResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("", h.list)
+
+	// swagger:operation GET /v1/tasks/assigned tasks listAssignedTasksReq
+	// ---
+	// summary: Returns the labelling tasks assigned to the current user.
+	// description: Returns list of labelling tasks with a batch assigned to the current user.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   required: false
+	// - name: page
+	//   in: query
+	//   type: integer
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/listTasksResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/assigned", h.assigned)
+
+	// swagger:operation GET /v1/tasks/{Id} tasks getTaskReq
+	// ---
+	// summary: Returns a single labelling task.
+	// description: Returns a single labelling task by its ID.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Task"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id", h.view)
+
+	// swagger:operation DELETE /v1/tasks/{Id} tasks deleteTaskReq
+	// ---
+	// summary: Deletes a labelling task.
+	// description: Deletes a labelling task, its items and labels. Annotations created through the task are kept.
+	// security:
+	// - Bearer: []
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.DELETE("/:id", h.delete)
+
+	// swagger:operation PATCH /v1/tasks/{Id}/batches/{BatchId} tasks assignTaskBatchReq
+	// ---
+	// summary: Reassigns a batch of a labelling task.
+	// description: |
+	//  Assigns a batch to another labeller. Items of the batch that have not been labelled or skipped move with it;
+	//  items held by the previous labeller are released. In a task with overlap, a labeller cannot be assigned two
+	//  batches of the same content.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "schema":
+	//      "$ref": "#/definitions/Task"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.PATCH("/:id/batches/:batchid", h.assign)
+
+	// swagger:operation GET /v1/tasks/{Id}/throughput tasks taskThroughputReq
+	// ---
+	// summary: Returns the throughput of the labellers of a task.
+	// description: |
+	//  Returns, for each labeller, the number of items assigned, labelled and skipped, and the labelled items per
+	//  hour between their first and last labelled item.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/taskThroughputResp"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/throughput", h.throughput)
+
+	// swagger:operation GET /v1/tasks/{Id}/agreement tasks taskAgreementReq
+	// ---
+	// summary: Returns the inter-annotator agreement of a labelling task.
+	// description: |
+	//  Measures how well the labellers of a task with overlap agree on the content they labelled in common.
+	//  Classification tags are compared with Fleiss' kappa overall and per tag, and with Cohen's kappa between
+	//  every two labellers. Bounding boxes of the same tag are matched between every two labellers at the IoU
+	//  threshold; box agreement is the fraction of boxes matched and mean IoU the overlap of matched boxes.
+	//  Per labeller metrics are averaged over the other labellers, with the agreement with each one under `pairs`.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// - name: iou_threshold
+	//   in: query
+	//   type: number
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/taskAgreementResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/agreement", h.agreement)
+
+	// swagger:operation POST /v1/tasks/{Id}/merge tasks mergeTaskLabelsReq
+	// ---
+	// summary: Merges the competing labels of a labelling task.
+	// description: |
+	//  Creates or updates the dataset's annotation of each content from the labels of a task with overlap. Tags and
+	//  boxes are kept when at least `min_votes` labellers agree on them, a strict majority by default; boxes agree
+	//  when they overlap at the IoU threshold and are averaged. Without `content_ids`, only content labelled by
+	//  every labeller it was sent to is merged.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/mergeTaskLabelsResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/merge", h.merge)
+
+	// swagger:operation POST /v1/tasks/{Id}/next tasks nextTaskItemReq
+	// ---
+	// summary: Serves the next item of a labelling task.
+	// description: |
+	//  Locks the next item of the current user's batches and returns it with its content. An item the user already
+	//  holds is served again. The item is held until it is submitted, skipped or released, or for 30 minutes.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/taskItemResp"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/next", h.next)
+
+	// swagger:operation GET /v1/tasks/{Id}/items/{ItemId} tasks getTaskItemReq
+	// ---
+	// summary: Returns an item of a labelling task.
+	// description: Returns an item assigned to the current user with its content, or the content image.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	//  - image/jpeg
+	//  - image/png
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/taskItemResp"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id/items/:itemid", h.item)
+
+	// swagger:operation POST /v1/tasks/{Id}/items/{ItemId}/submit tasks submitTaskItemReq
+	// ---
+	// summary: Annotates an item of a labelling task.
+	// description: |
+	//  Annotates the content of an item held by the current user in the task's dataset and completes the item.
+	//  Set `submit` to send the annotation for review. In a task with overlap, the current user's label is saved
+	//  instead and returned in place of the annotation.
+	// security:
+	// - Bearer: []
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/submitTaskItemResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/items/:itemid/submit", h.submit)
+
+	// swagger:operation POST /v1/tasks/{Id}/items/{ItemId}/skip tasks skipTaskItemReq
+	// ---
+	// summary: Skips an item of a labelling task.
+	// description: Completes an item held by the current user without annotating it.
+	// security:
+	// - Bearer: []
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// - name: ItemId
+	//   in: path
+	//   description: id of item
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/items/:itemid/skip", h.skip)
+
+	// swagger:operation POST /v1/tasks/{Id}/items/{ItemId}/release tasks releaseTaskItemReq
+	// ---
+	// summary: Releases an item of a labelling task.
+	// description: Gives up the current user's hold on an item so it is served again.
+	// security:
+	// - Bearer: []
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of task
+	//   type: string
+	//   required: true
+	// - name: ItemId
+	//   in: path
+	//   description: id of item
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/:id/items/:itemid/release", h.release)
+}
+
+// Labelling task create request
+// swagger:parameters createTaskReq
+type createTaskReq struct {
+	// in:body
+	Body struct {
+		// Project ID
+		ProjectID string `json:"project_id" validate:"required"`
+		// Dataset annotations are created in
+		DatasetID string `json:"dataset_id" validate:"required"`
+		// Name of the task
+		Name string `json:"name" validate:"required"`
+		// Content filters, as for a content query
+		Filters []models.Filter `json:"filters" validate:"omitempty"`
+		// Logical operator of the filters. One of "and" || "or"
+		Operator string `json:"operator" validate:"omitempty,oneof=and or"`
+		// Labellers the batches are assigned to in turn
+		AssigneeIDs []string `json:"assignee_ids" validate:"gt=0,unique,dive,hexadecimal,required"`
+		// Number of items per batch; defaults to 100
+		BatchSize int `json:"batch_size" validate:"omitempty,min=1,max=10000"`
+		// Number of labellers each content is sent to; defaults to 1
+		Overlap int `json:"overlap" validate:"omitempty,min=1"`
+	}
+}
+
+func (h HTTP) create(c echo.Context) error {
+	r := new(createTaskReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	task, err := h.svc.Create(c, Create{
+		UserID:      user.ID.Hex(),
+		ProjectID:   r.ProjectID,
+		DatasetID:   r.DatasetID,
+		Name:        r.Name,
+		Filters:     r.Filters,
+		Operator:    r.Operator,
+		AssigneeIDs: r.AssigneeIDs,
+		BatchSize:   r.BatchSize,
+		Overlap:     r.Overlap,
+	})
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+// Labelling tasks list response
+// swagger:response listTasksResp
+type listTasksResp struct {
+	// in:body
+	Body struct {
+		Tasks []models.Task `json:"tasks"`
+		Page  int           `json:"page"`
+		Count int64         `json:"count"`
+	}
+}
+
+type listTasksReq struct {
+	models.PaginationReq
+	ProjectID string `json:"project_id" query:"project_id" validate:"required"`
+}
+
+func (h HTTP) list(c echo.Context) error {
+	var req listTasksReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	tasks, count, err := h.svc.List(c, user.ID.Hex(), req.ProjectID, req.Transform())
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := listTasksResp{}
+	resp.Body.Tasks = tasks
+	resp.Body.Page = req.Page
+	resp.Body.Count = count
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+func (h HTTP) assigned(c echo.Context) error {
+	var req models.PaginationReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	tasks, count, err := h.svc.Assigned(c, user.ID.Hex(), req.Transform())
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := listTasksResp{}
+	resp.Body.Tasks = tasks
+	resp.Body.Page = req.Page
+	resp.Body.Count = count
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+func (h HTTP) view(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	task, err := h.svc.View(c, user.ID.Hex(), c.Param("id"))
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+func (h HTTP) delete(c echo.Context) error {
+	id := c.Param("id")
+	user := c.Get("current_user").(models.User)
+
+	if err := h.svc.Delete(c, user.ID.Hex(), id); err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": fmt.Sprintf("Task %s deleted", id)})
+}
+
+// Labelling task batch assign request
+// swagger:parameters assignTaskBatchReq
+type assignTaskBatchReq struct {
+	// ID of task
+	//
+	// in: path
+	// required: true
+	ID string `json:"-" param:"id"`
+	// ID of batch
+	//
+	// in: path
+	// required: true
+	BatchID string `json:"-" param:"batchid"`
+	// in:body
+	Body struct {
+		// Labeller the batch is assigned to
+		AssigneeID string `json:"assignee_id" validate:"required,hexadecimal"`
+	}
+}
+
+func (h HTTP) assign(c echo.Context) error {
+	r := new(assignTaskBatchReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	task, err := h.svc.Assign(c, user.ID.Hex(), c.Param("id"), c.Param("batchid"), r.AssigneeID)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+// Labelling task throughput response
+// swagger:response taskThroughputResp
+type taskThroughputResp struct {
+	// in:body
+	Body struct {
+		Throughput []models.Throughput `json:"throughput"`
+	}
+}
+
+func (h HTTP) throughput(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	throughput, err := h.svc.Throughput(c, user.ID.Hex(), c.Param("id"))
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := taskThroughputResp{}
+	resp.Body.Throughput = throughput
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Labelling task item response
+// swagger:response taskItemResp
+type taskItemResp struct {
+	// in:body
+	Body struct {
+		Item    *models.TaskItem `json:"item"`
+		Content *models.Content  `json:"content"`
+	}
+}
+
+func (h HTTP) next(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	item, content, err := h.svc.Next(c, user.ID.Hex(), c.Param("id"))
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := taskItemResp{}
+	resp.Body.Item = item
+	resp.Body.Content = content
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Labelling task item request
+// swagger:parameters getTaskItemReq
+type getTaskItemReq struct {
+	//	ID of task
+	//  in: path
+	//  required: true
+	ID string `json:"-" param:"id"`
+	//	ID of item
+	//  in: path
+	//  required: true
+	ItemID string `json:"-" param:"itemid"`
+	//	Include Image - If true, returns image bytes. Otherwise, returns the item and content metadata.
+	//  in: query
+	//  type: boolean
+	//  required: false
+	IncludeImage bool `query:"image" json:"image"`
+}
+
+func (h HTTP) item(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	r := new(getTaskItemReq)
+	if err := c.Bind(r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	item, content, imgBytes, err := h.svc.Item(c, user.ID.Hex(), r.ID, r.ItemID, r.IncludeImage)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	if r.IncludeImage {
+		buf := bytes.NewBuffer(imgBytes)
+
+		c.Response().Header().Set("Content-Type", content.ContentType)
+		c.Response().Header().Set("Content-Length", strconv.Itoa(len(buf.Bytes())))
+
+		io.Copy(c.Response().Writer, buf)
+
+		return c.NoContent(200)
+	}
+
+	resp := taskItemResp{}
+	resp.Body.Item = item
+	resp.Body.Content = content
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Labelling task item submit request
+// swagger:parameters submitTaskItemReq
+type submitTaskItemReq struct {
+	// in:body
+	Body struct {
+		//	Tag ID
+		TagID []string `json:"tag_id"`
+		// Metadata
+		Metadata models.AnnotationMetadata `json:"metadata"`
+		// Submit the annotation for review; otherwise it is kept as a draft
+		Submit bool `json:"submit"`
+	}
+}
+
+func (h HTTP) submit(c echo.Context) error {
+	r := new(submitTaskItemReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	annotation, label, err := h.svc.Submit(c, user.ID.Hex(), c.Param("id"), c.Param("itemid"), Submit{
+		TagIDs:   r.TagID,
+		Metadata: r.Metadata,
+		Review:   r.Submit,
+	})
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	if label != nil {
+		return c.JSON(http.StatusOK, label)
+	}
+	return c.JSON(http.StatusOK, annotation)
+}
+
+// Labelling task item submit response; a TaskLabel in tasks with overlap
+// swagger:response submitTaskItemResp
+//
+//lint:ignore U1000 ignore, used for swagger spec
+type submitTaskItemResp struct {
+	// in:body
+	Body models.Annotation
+}
+
+// Labelling task agreement request
+// swagger:parameters taskAgreementReq
+type taskAgreementReq struct {
+	// IoU at or above which boxes of two labellers match; defaults to 0.5
+	//
+	// in: query
+	// required: false
+	IoUThreshold float64 `json:"iou_threshold" query:"iou_threshold" validate:"omitempty,gt=0,lte=1"`
+}
+
+// Labelling task agreement response
+// swagger:response taskAgreementResp
+type taskAgreementResp struct {
+	// in:body
+	Body struct {
+		Agreement *evaluation.Agreement `json:"agreement"`
+	}
+}
+
+func (h HTTP) agreement(c echo.Context) error {
+	var req taskAgreementReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+	if req.IoUThreshold == 0 {
+		req.IoUThreshold = evaluation.DefaultIoUThreshold
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	agreement, err := h.svc.Agreement(c, user.ID.Hex(), c.Param("id"), req.IoUThreshold)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := taskAgreementResp{}
+	resp.Body.Agreement = agreement
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+// Labelling task merge request
+// swagger:parameters mergeTaskLabelsReq
+type mergeTaskLabelsReq struct {
+	// in:body
+	Body struct {
+		// Content to merge; by default all content labelled by every labeller it was sent to
+		ContentIDs []string `json:"content_ids" validate:"omitempty,unique,dive,hexadecimal,required"`
+		// IoU at or above which boxes of different labellers are the same object; defaults to 0.5
+		IoUThreshold float64 `json:"iou_threshold" validate:"omitempty,gt=0,lte=1"`
+		// Labellers who have to agree on a tag or box; defaults to a strict majority
+		MinVotes int `json:"min_votes" validate:"omitempty,min=1"`
+		// Review status of the merged annotations
+		Status string `json:"status,omitempty" validate:"omitempty,oneof=draft submitted"`
+	}
+}
+
+// Labelling task merge response
+// swagger:response mergeTaskLabelsResp
+type mergeTaskLabelsResp struct {
+	// in:body
+	Body struct {
+		Annotations []models.Annotation `json:"annotations"`
+		// Content not yet labelled by every labeller it was sent to
+		Skipped int `json:"skipped"`
+	}
+}
+
+func (h HTTP) merge(c echo.Context) error {
+	r := new(mergeTaskLabelsReq).Body
+	if err := c.Bind(&r); err != nil {
+		return c.JSON(400, echo.NewHTTPError(400, err.Error()))
+	}
+	if r.IoUThreshold == 0 {
+		r.IoUThreshold = evaluation.DefaultIoUThreshold
+	}
+
+	user := c.Get("current_user").(models.User)
+
+	result, err := h.svc.Merge(c, Merge{
+		UserID:       user.ID.Hex(),
+		TaskID:       c.Param("id"),
+		ContentIDs:   r.ContentIDs,
+		IoUThreshold: r.IoUThreshold,
+		MinVotes:     r.MinVotes,
+		Status:       strings.ToUpper(r.Status),
+	})
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	resp := mergeTaskLabelsResp{}
+	resp.Body.Annotations = result.Annotations
+	resp.Body.Skipped = result.Skipped
+
+	return c.JSON(http.StatusOK, resp.Body)
+}
+
+func (h HTTP) skip(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	if err := h.svc.Skip(c, user.ID.Hex(), c.Param("id"), c.Param("itemid")); err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": fmt.Sprintf("Item %s skipped", c.Param("itemid"))})
+}
+
+func (h HTTP) release(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+
+	if err := h.svc.Release(c, user.ID.Hex(), c.Param("id"), c.Param("itemid")); err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": fmt.Sprintf("Item %s released", c.Param("itemid"))})
+}
@@ -0,0 +1,198 @@
+package task
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// Submit is a labeller's annotation of a task item
+type Submit struct {
+	TagIDs   []string
+	Metadata models.AnnotationMetadata
+	// Submit the annotation for review rather than leaving it as a draft
+	Review bool
+}
+
+// Assigned returns the labelling tasks with a batch assigned to the labeller
+func (t Task) Assigned(c echo.Context, assigneeid string, p models.Pagination) ([]models.Task, int64, error) {
+	return t.platform.TaskDB.ListAssigned(t.db, assigneeid, p)
+}
+
+// Next locks the next item of a task for the labeller and returns it with its content. The item is held for the
+// labeller until it is submitted, skipped or released, or the lock times out.
+func (t Task) Next(c echo.Context, assigneeid, id string) (*models.TaskItem, *models.Content, error) {
+	if _, err := t.platform.TaskDB.ViewAssigned(t.db, assigneeid, id); err != nil {
+		return nil, nil, err
+	}
+
+	item, err := t.platform.TaskDB.Next(t.db, assigneeid, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := t.platform.ContentDB.View(t.db, item.UserID, item.ContentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return item, content, nil
+}
+
+// Item returns an item assigned to the labeller with its content and, optionally, the content image
+func (t Task) Item(c echo.Context, assigneeid, id, itemid string, returnImage bool) (*models.TaskItem, *models.Content, []byte, error) {
+	item, err := t.item(assigneeid, id, itemid)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	content, err := t.platform.ContentDB.View(t.db, item.UserID, item.ContentID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if returnImage {
+		contentBytes, err := t.blob.Get(content.StoredDir, content.StoredPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return item, content, contentBytes, nil
+	}
+
+	return item, content, nil, nil
+}
+
+// Submit annotates the content of an item held by the labeller and completes the item. The annotation belongs
+// to the task owner's dataset and goes through the same checks as any other annotation; its revision records the
+// labeller as the author. In a task with overlap
+// the labeller's label is kept apart instead, competing with the other labellers' until they are merged.
+func (t Task) Submit(c echo.Context, assigneeid, id, itemid string, req Submit) (*models.Annotation, *models.TaskLabel, error) {
+	item, err := t.held(assigneeid, id, itemid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := t.platform.TaskDB.View(t.db, item.UserID, item.TaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if task.Overlap > 1 {
+		label, err := t.label(task, *item, assigneeid, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, label, nil
+	}
+
+	status := models.AnnotationStatusDraft
+	if req.Review {
+		status = models.AnnotationStatusSubmitted
+	}
+
+	annotation, err := t.annotation.CreateBy(c, models.Annotation{
+		UserID:    task.UserID,
+		ProjectID: task.ProjectID,
+		DatasetID: task.DatasetID,
+		ContentID: item.ContentID,
+		TagIDs:    req.TagIDs,
+		Metadata:  req.Metadata,
+		Status:    status.String(),
+	}, 0, assigneeid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := t.platform.TaskDB.Complete(t.db, assigneeid, itemid, models.TaskItemStateDone, annotation.ID.Hex(), ""); err != nil {
+		return nil, nil, err
+	}
+
+	return annotation, nil, nil
+}
+
+// label saves a labeller's competing label of an item and completes the item. The label is checked like an
+// annotation of the task's dataset would be.
+func (t Task) label(task models.Task, item models.TaskItem, assigneeid string, req Submit) (*models.TaskLabel, error) {
+	project, err := t.platform.ProjectDB.View(t.db, task.UserID, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check tags exists
+	tags := make(map[string]models.Tag, len(req.TagIDs))
+	for _, tagid := range req.TagIDs {
+		tag, err := t.platform.TagDB.View(t.db, task.UserID, tagid)
+		if err != nil {
+			return nil, err
+		}
+		tags[tagid] = tag
+	}
+
+	annotation := models.Annotation{TagIDs: req.TagIDs, Metadata: req.Metadata}
+	if err := annotation.Valid(project.AnnotationType); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := annotation.ValidSkeletons(tags); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	label, err := t.platform.TaskDB.SaveLabel(t.db, models.NewTaskLabel(item, assigneeid, req.TagIDs, req.Metadata))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.platform.TaskDB.Complete(t.db, assigneeid, item.ID.Hex(), models.TaskItemStateDone, "", label.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	return label, nil
+}
+
+// Skip completes an item held by the labeller without annotating it
+func (t Task) Skip(c echo.Context, assigneeid, id, itemid string) error {
+	if _, err := t.held(assigneeid, id, itemid); err != nil {
+		return err
+	}
+
+	return t.platform.TaskDB.Complete(t.db, assigneeid, itemid, models.TaskItemStateSkipped, "", "")
+}
+
+// Release returns an item held by the labeller to the queue
+func (t Task) Release(c echo.Context, assigneeid, id, itemid string) error {
+	if _, err := t.item(assigneeid, id, itemid); err != nil {
+		return err
+	}
+
+	return t.platform.TaskDB.Release(t.db, assigneeid, itemid)
+}
+
+// item returns an item of a task assigned to the labeller
+func (t Task) item(assigneeid, id, itemid string) (*models.TaskItem, error) {
+	item, err := t.platform.TaskDB.ViewItem(t.db, assigneeid, itemid)
+	if err != nil {
+		return nil, err
+	}
+	if item.TaskID != id {
+		return nil, platform.ErrTaskItemDoesNotExist
+	}
+
+	return item, nil
+}
+
+// held returns a pending item of a task the labeller holds an unexpired lock on
+func (t Task) held(assigneeid, id, itemid string) (*models.TaskItem, error) {
+	item, err := t.item(assigneeid, id, itemid)
+	if err != nil {
+		return nil, err
+	}
+	if !item.Held(assigneeid, time.Now()) {
+		return nil, platform.ErrTaskItemNotLocked
+	}
+
+	return item, nil
+}
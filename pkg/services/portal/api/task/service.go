@@ -0,0 +1,49 @@
+package task
+
+import (
+	"github.com/labstack/echo/v4"
+
+	blob "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/blob"
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+	evaluation "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/model/evaluation"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/annotation"
+)
+
+// New creates new labelling task application service
+func New(db *db.DB, platform *platform.Platform, blob *blob.Blob) *Task {
+	return &Task{db: db, platform: platform, blob: blob, annotation: annotation.New(db, platform, blob)}
+}
+
+// Initialize initializes Task application service with defaults
+func Initialize(db *db.DB, platform *platform.Platform, blob *blob.Blob) *Task {
+	return New(db, platform, blob)
+}
+
+// Service represents labelling task application interface
+type Service interface {
+	Create(echo.Context, Create) (*models.Task, error)
+	List(echo.Context, string, string, models.Pagination) ([]models.Task, int64, error)
+	View(echo.Context, string, string) (*models.Task, error)
+	Assign(echo.Context, string, string, string, string) (*models.Task, error)
+	Throughput(echo.Context, string, string) ([]models.Throughput, error)
+	Agreement(echo.Context, string, string, float64) (*evaluation.Agreement, error)
+	Merge(echo.Context, Merge) (*MergeResult, error)
+	Delete(echo.Context, string, string) error
+
+	Assigned(echo.Context, string, models.Pagination) ([]models.Task, int64, error)
+	Next(echo.Context, string, string) (*models.TaskItem, *models.Content, error)
+	Item(echo.Context, string, string, string, bool) (*models.TaskItem, *models.Content, []byte, error)
+	Submit(echo.Context, string, string, string, Submit) (*models.Annotation, *models.TaskLabel, error)
+	Skip(echo.Context, string, string, string) error
+	Release(echo.Context, string, string, string) error
+}
+
+// Task represents labelling task application service
+type Task struct {
+	db         *db.DB
+	platform   *platform.Platform
+	blob       *blob.Blob
+	annotation *annotation.Annotation
+}
@@ -0,0 +1,222 @@
+package task
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	platform "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// Custom errors
+var (
+	ErrNoContentToLabel = echo.NewHTTPError(http.StatusConflict, "No unannotated content matches the task filters.")
+	ErrOverlapTooLarge  = echo.NewHTTPError(http.StatusBadRequest, "Overlap cannot exceed the number of labellers.")
+	ErrBatchOverlap     = echo.NewHTTPError(http.StatusConflict, "Labeller is already assigned the content of this batch.")
+)
+
+// Create is a request to create a labelling task
+type Create struct {
+	UserID    string
+	ProjectID string
+	DatasetID string
+	Name      string
+	Filters   []models.Filter
+	Operator  string
+	// Labellers the batches are assigned to in turn
+	AssigneeIDs []string
+	// Number of items per batch; defaults to models.DefaultTaskBatchSize
+	BatchSize int
+	// Number of labellers each content is sent to; defaults to one
+	Overlap int
+}
+
+// Create creates a labelling task over the unannotated content of a dataset matching the filters. Content already
+// pending in another task of the dataset is left out. The content is split into batches assigned to the labellers
+// in turn; with overlap, each batch is repeated for as many different labellers.
+func (t Task) Create(c echo.Context, req Create) (*models.Task, error) {
+	if req.Overlap > len(req.AssigneeIDs) {
+		return nil, ErrOverlapTooLarge
+	}
+
+	// Check project exists
+	if _, err := t.platform.ProjectDB.View(t.db, req.UserID, req.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check dataset exists
+	if dataset, err := t.platform.DatasetDB.View(t.db, req.UserID, req.DatasetID); err != nil {
+		return nil, err
+	} else if dataset.Locked {
+		return nil, platform.ErrDatasetLocked
+	} else if dataset.Restoring != "" {
+		return nil, platform.ErrDatasetRestoring
+	}
+
+	// Check labellers exist
+	for _, assigneeid := range req.AssigneeIDs {
+		if _, err := t.platform.UserDB.View(t.db, assigneeid); err != nil {
+			return nil, err
+		}
+	}
+
+	task := models.NewTask(req.UserID, req.ProjectID, req.DatasetID, req.Name)
+	if req.Overlap > 1 {
+		task.Overlap = req.Overlap
+	}
+
+	var filter interface{}
+	if len(req.Filters) > 0 {
+		query := models.Query{Filters: req.Filters, Operator: strings.ToLower(req.Operator)}
+		if query.Operator == "" {
+			query.Operator = "and"
+		}
+
+		var err error
+		if filter, err = query.NewQueryFilter(req.UserID); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		task.Filters = query.Filters
+		task.Operator = query.Operator
+	}
+
+	contentids, err := t.platform.ContentDB.FindUnannotatedIDs(t.db, req.UserID, req.ProjectID, req.DatasetID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := t.platform.TaskDB.PendingContent(t.db, req.UserID, req.DatasetID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		exclude := make(map[string]struct{}, len(pending))
+		for _, contentid := range pending {
+			exclude[contentid] = struct{}{}
+		}
+
+		remaining := contentids[:0]
+		for _, contentid := range contentids {
+			if _, ok := exclude[contentid]; !ok {
+				remaining = append(remaining, contentid)
+			}
+		}
+		contentids = remaining
+	}
+
+	if len(contentids) == 0 {
+		return nil, ErrNoContentToLabel
+	}
+
+	items := batchItems(&task, contentids, req.AssigneeIDs, req.BatchSize)
+
+	if err := t.platform.TaskDB.Create(t.db, task, items); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// batchItems splits content into batches of the task assigned to the labellers in turn and returns the items of the
+// batches. With overlap, each group of content is repeated in as many batches. A batch size of zero or less
+// defaults to models.DefaultTaskBatchSize.
+func batchItems(task *models.Task, contentids, assigneeids []string, batchSize int) []models.TaskItem {
+	if batchSize <= 0 {
+		batchSize = models.DefaultTaskBatchSize
+	}
+
+	items := make([]models.TaskItem, 0, len(contentids)*task.Overlap)
+	for group, start := 0, 0; start < len(contentids); group, start = group+1, start+batchSize {
+		end := start + batchSize
+		if end > len(contentids) {
+			end = len(contentids)
+		}
+
+		// Consecutive labellers of a group are distinct as the overlap does not exceed the labellers
+		for i := 0; i < task.Overlap; i++ {
+			batch := models.TaskBatch{
+				ID:         primitive.NewObjectID(),
+				AssigneeID: assigneeids[len(task.Batches)%len(assigneeids)],
+				Size:       end - start,
+				Group:      group,
+			}
+			task.Batches = append(task.Batches, batch)
+
+			for _, contentid := range contentids[start:end] {
+				items = append(items, models.NewTaskItem(*task, batch, contentid))
+			}
+		}
+	}
+	task.Size = len(items)
+
+	return items
+}
+
+// List returns the labelling tasks of a project
+func (t Task) List(c echo.Context, userid, projectid string, p models.Pagination) ([]models.Task, int64, error) {
+	// Check project exists
+	if _, err := t.platform.ProjectDB.View(t.db, userid, projectid); err != nil {
+		return []models.Task{}, 0, err
+	}
+
+	return t.platform.TaskDB.List(t.db, userid, projectid, p)
+}
+
+// View returns a single labelling task
+func (t Task) View(c echo.Context, userid, id string) (*models.Task, error) {
+	task, err := t.platform.TaskDB.View(t.db, userid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// Assign reassigns a batch of a task to another labeller. A labeller cannot be assigned two batches of the same
+// content.
+func (t Task) Assign(c echo.Context, userid, id, batchid, assigneeid string) (*models.Task, error) {
+	// Check labeller exists
+	if _, err := t.platform.UserDB.View(t.db, assigneeid); err != nil {
+		return nil, err
+	}
+
+	task, err := t.platform.TaskDB.View(t.db, userid, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range task.Batches {
+		if task.Overlap < 2 || batch.ID.Hex() != batchid {
+			continue
+		}
+		for _, other := range task.Batches {
+			if other.Group == batch.Group && other.ID != batch.ID && other.AssigneeID == assigneeid {
+				return nil, ErrBatchOverlap
+			}
+		}
+	}
+
+	if err := t.platform.TaskDB.Assign(t.db, userid, id, batchid, assigneeid); err != nil {
+		return nil, err
+	}
+
+	return t.View(c, userid, id)
+}
+
+// Throughput returns the progress of each labeller of a task
+func (t Task) Throughput(c echo.Context, userid, id string) ([]models.Throughput, error) {
+	// Check task exists
+	if _, err := t.platform.TaskDB.View(t.db, userid, id); err != nil {
+		return nil, err
+	}
+
+	return t.platform.TaskDB.Throughput(t.db, userid, id)
+}
+
+// Delete deletes a labelling task and its competing labels. Annotations already created through the task are kept.
+func (t Task) Delete(c echo.Context, userid, id string) error {
+	return t.platform.TaskDB.Delete(t.db, userid, id)
+}
@@ -0,0 +1,88 @@
+/*
+ * File: task_test.go
+ * Project: task
+ * File Created: Monday, 19th February 2024 10:12:41 am
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Monday, 19th February 2024 10:12:41 am
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+func TestBatchItems(t *testing.T) {
+	contentids := []string{"c1", "c2", "c3", "c4", "c5"}
+	assigneeids := []string{"a", "b", "c"}
+
+	t.Run("batches are assigned in turn", func(t *testing.T) {
+		task := models.NewTask("user", "project", "dataset", "task")
+		items := batchItems(&task, contentids, assigneeids, 2)
+
+		require.Len(t, task.Batches, 3)
+		assert.Equal(t, 5, task.Size)
+		assert.Len(t, items, 5)
+		for i, want := range []struct {
+			assignee string
+			size     int
+		}{{"a", 2}, {"b", 2}, {"c", 1}} {
+			assert.Equal(t, want.assignee, task.Batches[i].AssigneeID)
+			assert.Equal(t, want.size, task.Batches[i].Size)
+			assert.Equal(t, i, task.Batches[i].Group)
+		}
+
+		// Items follow the content order and belong to the batch of their content
+		for i, item := range items {
+			batch := task.Batches[i/2]
+			assert.Equal(t, contentids[i], item.ContentID)
+			assert.Equal(t, batch.ID.Hex(), item.BatchID)
+			assert.Equal(t, batch.AssigneeID, item.AssigneeID)
+			assert.Equal(t, task.ID.Hex(), item.TaskID)
+			assert.Equal(t, models.TaskItemStatePending.String(), item.State)
+		}
+	})
+
+	t.Run("overlapping batches go to different labellers", func(t *testing.T) {
+		task := models.NewTask("user", "project", "dataset", "task")
+		task.Overlap = 2
+		items := batchItems(&task, contentids, assigneeids, 2)
+
+		require.Len(t, task.Batches, 6)
+		assert.Equal(t, 10, task.Size)
+		assert.Len(t, items, 10)
+
+		labellers := make(map[int][]string)
+		for _, batch := range task.Batches {
+			labellers[batch.Group] = append(labellers[batch.Group], batch.AssigneeID)
+		}
+		assert.Equal(t, map[int][]string{0: {"a", "b"}, 1: {"c", "a"}, 2: {"b", "c"}}, labellers)
+
+		// Every content is sent to as many labellers as the overlap
+		sent := make(map[string]map[string]struct{})
+		for _, item := range items {
+			if sent[item.ContentID] == nil {
+				sent[item.ContentID] = make(map[string]struct{})
+			}
+			sent[item.ContentID][item.AssigneeID] = struct{}{}
+		}
+		for _, contentid := range contentids {
+			assert.Len(t, sent[contentid], 2, contentid)
+		}
+	})
+
+	t.Run("default batch size", func(t *testing.T) {
+		task := models.NewTask("user", "project", "dataset", "task")
+		items := batchItems(&task, contentids, assigneeids, 0)
+
+		require.Len(t, task.Batches, 1)
+		assert.Equal(t, len(contentids), task.Batches[0].Size)
+		assert.Len(t, items, len(contentids))
+	})
+}
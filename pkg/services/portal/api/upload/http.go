@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	errs "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/error"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// HTTP represents upload http service
+type HTTP struct {
+	svc Service
+}
+
+func NewHTTP(svc Service, r *echo.Group) {
+	h := HTTP{svc}
+	ur := r.Group("/uploads")
+
+	// swagger:operation GET /v1/uploads/{Id} uploads getUploadReq
+	// ---
+	// summary: Returns a single upload.
+	// description: |
+	//   Returns the progress of an upload started via `POST /v1/projects/{Id}/upload`.
+	//   `state` is one of INITIALIZED, RUNNING, COMPLETE, COMPLETE_WITH_ERR or ERR.
+	//   `failed` maps each file that could not be processed to the reason it failed.
+	// security:
+	// - Bearer: []
+	// produces:
+	//  - application/json
+	// parameters:
+	// - name: Id
+	//   in: path
+	//   description: id of upload
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/uploadResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/:id", h.view)
+}
+
+// swagger:response uploadResp
+// forces generation of swagger model
+//
+//lint:ignore U1000 ignore, used for swagger spec
+type uploadResp struct {
+	// in: body
+	Body struct {
+		*models.Upload
+	}
+}
+
+func (h HTTP) view(c echo.Context) error {
+	user := c.Get("current_user").(models.User)
+	uploadid := c.Param("id")
+
+	upload, err := h.svc.View(c, user.ID.Hex(), uploadid)
+	if err != nil {
+		err := errs.EchoErr(err, 500)
+		return c.JSON(err.Code, err)
+	}
+
+	return c.JSON(http.StatusOK, upload)
+}
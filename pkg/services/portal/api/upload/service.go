@@ -0,0 +1,30 @@
+package upload
+
+import (
+	"github.com/labstack/echo/v4"
+
+	db "gitlab.com/krydus/emeraldai/go-emerald-app/pkg/common/db/mongo"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/platform"
+)
+
+// New creates new upload application service
+func New(db *db.DB, platform *platform.Platform) *Upload {
+	return &Upload{db: db, platform: platform}
+}
+
+// Initialize initializes Upload application service with defaults
+func Initialize(db *db.DB, platform *platform.Platform) (*Upload, error) {
+	return New(db, platform), nil
+}
+
+// Service represents Upload application interface
+type Service interface {
+	View(echo.Context, string, string) (models.Upload, error)
+}
+
+// Upload represents upload application service
+type Upload struct {
+	db       *db.DB
+	platform *platform.Platform
+}
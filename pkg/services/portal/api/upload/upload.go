@@ -0,0 +1,22 @@
+/*
+ * File: upload.go
+ * Project: upload
+ * File Created: Monday, 16th May 2022 3:02:11 pm
+ * Author: Anonymous (anonymous@gmail.com)
+ * -----
+ * Last Modified: Friday, 2nd February 2024 2:33:11 pm
+ * Modified By: Anonymous (anonymous@gmail.com>)
+ */
+// Package upload contains upload application services
+package upload
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/models"
+)
+
+// View returns the progress of a single upload
+func (u Upload) View(c echo.Context, userid, uploadid string) (models.Upload, error) {
+	return u.platform.UploadDB.View(u.db, userid, uploadid)
+}
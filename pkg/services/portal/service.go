@@ -34,6 +34,8 @@ import (
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/prediction"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/project"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/tag"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/task"
+	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/upload"
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/api/user"
 
 	"gitlab.com/krydus/emeraldai/go-emerald-app/pkg/services/portal/swaggerui"
@@ -135,6 +137,7 @@ func Start(cfg *config.Configuration) error {
 	annotationSvc := annotation.Initialize(db, platform, blob)
 	predictionSvc := prediction.Initialize(db, platform, blob)
 	experimentalSvc := experimental.Initialize(db, platform)
+	taskSvc := task.Initialize(db, platform, blob)
 
 	projectSvc, err := project.Initialize(db, platform, blob, *cfg.App)
 	if err != nil {
@@ -164,6 +167,13 @@ func Start(cfg *config.Configuration) error {
 	if err != nil {
 		return errors.Wrap(err, "could not instantiate dataset service")
 	}
+	uploadSvc, err := upload.Initialize(db, platform)
+	if err != nil {
+		return errors.Wrap(err, "could not instantiate upload service")
+	}
+
+	// Resume uploads interrupted by a restart
+	go projectSvc.Resume()
 
 	user.NewHTTP(userSvc, v1)
 	annotation.NewHTTP(annotationSvc, v1)
@@ -173,9 +183,11 @@ func Start(cfg *config.Configuration) error {
 	tag.NewHTTP(tagSvc, v1)
 	export.NewHTTP(exportSvc, v1)
 	dataset.NewHTTP(datasetSvc, v1)
+	upload.NewHTTP(uploadSvc, v1)
 	model.NewHTTP(modelSvc, v1)
 	prediction.NewHTTP(predictionSvc, v1)
 	experimental.NewHTTP(experimentalSvc, v1)
+	task.NewHTTP(taskSvc, v1)
 
 	// API Docs
 	echoServer.GET("/*", echo.WrapHandler(swaggerui.Handler()))